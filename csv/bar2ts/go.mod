@@ -1,3 +1,15 @@
 module bar2ts
 
 go 1.18
+
+require compressed v0.0.0
+
+require (
+	github.com/klauspost/compress v1.17.4 // indirect
+	github.com/larzconwell/bzip2 v0.0.0-20160405040150-ecf7a0ddeda1 // indirect
+	github.com/pierrec/lz4/v4 v4.1.18 // indirect
+	github.com/ulikunitz/xz v0.5.11 // indirect
+	golang.org/x/sys v0.15.0 // indirect
+)
+
+replace compressed => ../compressed
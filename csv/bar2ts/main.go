@@ -1,14 +1,12 @@
 package main
 
 import (
-	"encoding/csv"
 	"flag"
 	"fmt"
-	"io"
 	"os"
-	"strconv"
-	"strings"
 	"time"
+
+	"compressed/dialect"
 )
 
 const header = `import { TimeGranularity } from 'projects/mb/src/public-api';
@@ -34,24 +32,34 @@ func main() {
 
 	flag.Parse()
 
-	var fin *os.File
+	columns := []dialect.Column{
+		{Name: "time", Type: dialect.Time, Layout: *tformatPtr},
+		{Name: "opening price", Type: dialect.Float},
+		{Name: "highest price", Type: dialect.Float},
+		{Name: "lowest price", Type: dialect.Float},
+		{Name: "closing price", Type: dialect.Float},
+		{Name: "volume", Type: dialect.Float, Optional: true, Default: fmt.Sprint(*volumePtr)},
+	}
+
+	var csvReader *dialect.Reader
 	var fout *os.File
 	var err error
 
 	if filename := flag.Arg(0); filename == "" {
 		fail("expecting CSV file name as the positional argument")
 	} else {
-		if !strings.HasSuffix(filename, ".csv") {
-			fail(fmt.Sprintf("expecting CSV file name to end with '.csv': %s", filename))
+		if !dialect.HasExt(filename, ".csv") {
+			fail(fmt.Sprintf("expecting CSV file name to end with '.csv', optionally compressed: %s", filename))
 		}
 
-		fin, err = os.Open(filename)
+		d := dialect.Dialect{Comma: ';', Comment: '#', Header: *headerPtr, Quote: '"'}
+		csvReader, err = dialect.Open(filename, d, columns)
 		if err != nil {
 			fail(fmt.Sprintf("error opening file: %s", err))
 		}
-		defer fin.Close()
+		defer csvReader.Close()
 
-		fout, err = os.Create(filename + ".ts")
+		fout, err = os.Create(dialect.TrimExt(filename) + ".ts")
 		if err != nil {
 			fail(fmt.Sprintf("error creating file: %s", err))
 		}
@@ -60,88 +68,42 @@ func main() {
 
 	writeString(fout, header)
 
-	csvReader := csv.NewReader(fin)
-	csvReader.Comment = '#'
-	csvReader.Comma = ';'
-
 	t0 := time.Date(0, 0, 0, 0, 0, 0, 0, time.Local)
-	lineNo := 0
 
-	for {
-		rec, err := csvReader.Read()
-		if err != nil {
-			if err == io.EOF {
-				break
-			}
-
-			fail(fmt.Sprintf("error reading line %d: %s", lineNo, err))
-		}
-
-		if lineNo == 0 && *headerPtr {
-			lineNo++
-			continue
-		}
-
-		if len(rec) < 5 {
-			fail(fmt.Sprintf("line %d: expected at least 5 parts, got %d", lineNo, len(rec)))
-		}
-
-		t, err := time.Parse(*tformatPtr, rec[0])
-		if err != nil {
-			fail(fmt.Sprintf("line %d: failed to parse time part '%s' using format '%s': %s", lineNo, rec[0], *tformatPtr, err))
-		}
+	for csvReader.Next() {
+		row := csvReader.Row()
+		lineNo := row.Line()
 
+		t := row.Time(0)
 		if t0.After(t) {
-			fail(fmt.Sprintf("line %d: time part '%s' time '%v' is before previous line time '%v'", lineNo, rec[0], t, t0))
+			fail(fmt.Sprintf("line %d: time part '%s' time '%v' is before previous line time '%v'", lineNo, row.Bytes(0), t, t0))
 		}
 
 		t0 = t
 
-		op, err := strconv.ParseFloat(rec[1], 64)
-		if err != nil {
-			fail(fmt.Sprintf("line %d: failed to parse opening price part '%s': %s", lineNo, rec[1], err))
-		}
-
-		hp, err := strconv.ParseFloat(rec[2], 64)
-		if err != nil {
-			fail(fmt.Sprintf("line %d: failed to parse highest price part '%s': %s", lineNo, rec[2], err))
-		}
-
-		lp, err := strconv.ParseFloat(rec[3], 64)
-		if err != nil {
-			fail(fmt.Sprintf("line %d: failed to parse lowest price part '%s': %s", lineNo, rec[3], err))
-		}
-
-		cp, err := strconv.ParseFloat(rec[4], 64)
-		if err != nil {
-			fail(fmt.Sprintf("line %d: failed to parse closing price part '%s': %s", lineNo, rec[4], err))
-		}
+		op, hp, lp, cp := row.Float(1), row.Float(2), row.Float(3), row.Float(4)
 
 		if op > hp || lp > hp || cp > hp {
-			fail(fmt.Sprintf("line %d: high price '%v' is not the highest: %+v", lineNo, hp, rec))
+			fail(fmt.Sprintf("line %d: high price '%v' is not the highest: %v %v %v %v", lineNo, hp, op, hp, lp, cp))
 		}
 
 		if op < lp || hp < lp || cp < lp {
-			fail(fmt.Sprintf("line %d: low price '%v' is not the lowest: %+v", lineNo, lp, rec))
+			fail(fmt.Sprintf("line %d: low price '%v' is not the lowest: %v %v %v %v", lineNo, lp, op, hp, lp, cp))
 		}
 
 		if op <= 0 || lp <= 0 || hp <= 0 || cp <= 0 {
-			fail(fmt.Sprintf("line %d: price should be positive: %+v", lineNo, rec))
+			fail(fmt.Sprintf("line %d: price should be positive: %v %v %v %v", lineNo, op, hp, lp, cp))
 		}
 
-		var v float64 = float64(*volumePtr)
-
-		if len(rec) > 5 {
-			v, err = strconv.ParseFloat(rec[5], 64)
-			if err != nil {
-				fail(fmt.Sprintf("line %d: failed to parse volume part '%s': %s", lineNo, rec[5], err))
-			}
-		}
+		v := row.Float(5)
 
 		writeString(fout, fmt.Sprintf(
 			"    { time: %s, open: %v, high: %v, low: %v, close: %v, volume: %.f },\n",
 			time2Ts(t, *tgranPtr), op, hp, lp, cp, v))
-		lineNo++
+	}
+
+	if err := csvReader.Err(); err != nil {
+		fail(fmt.Sprintf("error reading file: %s", err))
 	}
 
 	writeString(fout, footer)
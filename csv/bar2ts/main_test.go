@@ -0,0 +1,49 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"compressed/writer"
+)
+
+func TestMainQuotedCompressedInput(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "bars.csv.gz")
+	w, err := writer.Create(fileName, writer.Options{})
+	if err != nil {
+		t.Fatalf("cannot create writer: %v", err)
+	}
+
+	content := "time;open;high;low;close;volume\n" +
+		"# comment\n" +
+		"\"2024/01/02 00:00:00\";\"1.5\";2;1;1.75;\"100\"\n" +
+		"2024/01/03 00:00:00;1.75;2.5;1.5;2.25;\n"
+	if err := w.WriteString(content); err != nil {
+		t.Fatalf("cannot write: %v", err)
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatalf("cannot close writer: %v", err)
+	}
+
+	args := os.Args
+	defer func() { os.Args = args }()
+	os.Args = []string{"bar2ts", "-volume", "7", fileName}
+	main()
+
+	b, err := os.ReadFile(strings.TrimSuffix(fileName, ".gz") + ".ts")
+	if err != nil {
+		t.Fatalf("cannot read output: %v", err)
+	}
+
+	for _, expected := range []string{
+		"    { time: new Date(2024, 0, 2), open: 1.5, high: 2, low: 1, close: 1.75, volume: 100 },\n",
+		"    { time: new Date(2024, 0, 3), open: 1.75, high: 2.5, low: 1.5, close: 2.25, volume: 7 },\n",
+	} {
+		if !strings.Contains(string(b), expected) {
+			t.Errorf("expected output to contain %q, got\n%s", expected, b)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+// Package calendar implements the trading calendars of the exchanges:
+// holidays, early closes and the session open and close times.
+//
+// Session dates are calendar dates represented as midnight UTC,
+// the open and close times are in the location of the exchange.
+package calendar
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	_ "time/tzdata" // the exchange locations are available without the system time zone database
+)
+
+// Clock is a time of the day in minutes after midnight.
+type Clock int
+
+// At returns the clock of the hour and the minute.
+func At(hour, minute int) Clock {
+	return Clock(hour*60 + minute)
+}
+
+// String returns the clock as hh:mm.
+func (c Clock) String() string {
+	return fmt.Sprintf("%02d:%02d", int(c)/60, int(c)%60)
+}
+
+// Calendar is the trading calendar of an exchange.
+type Calendar struct {
+	// Name is the name of the calendar, e.g. "euronext".
+	Name string
+
+	// Location is the time zone of the exchange.
+	Location *time.Location
+
+	// Open and Close are the regular session times.
+	Open, Close Clock
+
+	// EarlyClose is the close time of the early close sessions.
+	EarlyClose Clock
+
+	// Holidays are the rules of the days without a session.
+	Holidays []Rule
+
+	// EarlyCloses are the rules of the sessions closing at the EarlyClose time.
+	EarlyCloses []Rule
+
+	// Closures are the one-off days without a session.
+	Closures []time.Time
+}
+
+func matches(rules []Rule, date time.Time) bool {
+	for _, r := range rules {
+		// An observed rule of the next year may fall on the 31st of December.
+		for _, y := range []int{date.Year(), date.Year() + 1} {
+			if d, ok := r(y); ok && d.Equal(date) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// IsHoliday reports if the weekday date has no session.
+func (c *Calendar) IsHoliday(date time.Time) bool {
+	date = Date(date)
+	for _, d := range c.Closures {
+		if Date(d).Equal(date) {
+			return true
+		}
+	}
+
+	return matches(c.Holidays, date)
+}
+
+// IsSession reports if there is a trading session on the date.
+func (c *Calendar) IsSession(date time.Time) bool {
+	switch date.Weekday() {
+	case time.Saturday, time.Sunday:
+		return false
+	}
+
+	return !c.IsHoliday(date)
+}
+
+// IsEarlyClose reports if the session of the date closes early.
+func (c *Calendar) IsEarlyClose(date time.Time) bool {
+	return c.IsSession(date) && matches(c.EarlyCloses, Date(date))
+}
+
+// Hours returns the open and close times of the session on the date in the location of the exchange.
+// The times follow the daylight saving changes of the location. The ok is false if there is no session.
+func (c *Calendar) Hours(date time.Time) (open, close time.Time, ok bool) {
+	if !c.IsSession(date) {
+		return open, close, false
+	}
+
+	closing := c.Close
+	if matches(c.EarlyCloses, Date(date)) {
+		closing = c.EarlyClose
+	}
+
+	y, m, d := date.Date()
+	open = time.Date(y, m, d, int(c.Open)/60, int(c.Open)%60, 0, 0, c.Location)
+	close = time.Date(y, m, d, int(closing)/60, int(closing)%60, 0, 0, c.Location)
+	return open, close, true
+}
+
+// NextSession returns the first session date after the date.
+func (c *Calendar) NextSession(date time.Time) time.Time {
+	d := Date(date).AddDate(0, 0, 1)
+	for !c.IsSession(d) {
+		d = d.AddDate(0, 0, 1)
+	}
+
+	return d
+}
+
+// PrevSession returns the last session date before the date.
+func (c *Calendar) PrevSession(date time.Time) time.Time {
+	d := Date(date).AddDate(0, 0, -1)
+	for !c.IsSession(d) {
+		d = d.AddDate(0, 0, -1)
+	}
+
+	return d
+}
+
+// AddSessions moves the date by n sessions, forwards if n is positive and backwards if negative.
+// A date which is not a session counts as the session before it when moving forwards
+// and as the session after it when moving backwards.
+func (c *Calendar) AddSessions(date time.Time, n int) time.Time {
+	d := Date(date)
+	for ; n > 0; n-- {
+		d = c.NextSession(d)
+	}
+
+	for ; n < 0; n++ {
+		d = c.PrevSession(d)
+	}
+
+	return d
+}
+
+// SessionsBetween returns the session dates from the first to the last date inclusive.
+func (c *Calendar) SessionsBetween(from, to time.Time) []time.Time {
+	sessions := []time.Time{}
+	last := Date(to)
+	for d := Date(from); !d.After(last); d = d.AddDate(0, 0, 1) {
+		if c.IsSession(d) {
+			sessions = append(sessions, d)
+		}
+	}
+
+	return sessions
+}
+
+// LastSession returns the latest session date on or before the date of the instant in the location of the exchange.
+// The session may be still open or not opened yet.
+func (c *Calendar) LastSession(now time.Time) time.Time {
+	d := Date(now.In(c.Location))
+	if c.IsSession(d) {
+		return d
+	}
+
+	return c.PrevSession(d)
+}
+
+// LastClosedSession returns the latest session date which closed at least the delay before the instant.
+func (c *Calendar) LastClosedSession(now time.Time, delay time.Duration) time.Time {
+	d := c.LastSession(now)
+	if _, closing, _ := c.Hours(d); now.Before(closing.Add(delay)) {
+		return c.PrevSession(d)
+	}
+
+	return d
+}
+
+// ForMic returns the calendar of the market identifier code, the code is case insensitive.
+func ForMic(mic string) (*Calendar, error) {
+	c, ok := mics[strings.ToUpper(mic)]
+	if !ok {
+		return nil, fmt.Errorf("unknown mic '%s'", mic)
+	}
+
+	return c, nil
+}
+
+// Mics returns the sorted known market identifier codes.
+func Mics() []string {
+	codes := make([]string, 0, len(mics))
+	for m := range mics {
+		codes = append(codes, m)
+	}
+
+	sort.Strings(codes)
+	return codes
+}
@@ -0,0 +1,180 @@
+package calendar
+
+import (
+	"testing"
+	"time"
+)
+
+func date(s string) time.Time {
+	d, _ := time.Parse("2006-01-02", s)
+	return d
+}
+
+func TestIsSession(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		mic     string
+		date    string
+		session bool
+	}{
+		{"XPAR", "2024-03-28", true},  // Thursday before Easter
+		{"XPAR", "2024-03-29", false}, // Good Friday
+		{"XPAR", "2024-04-01", false}, // Easter Monday
+		{"XPAR", "2024-04-02", true},
+		{"XPAR", "2024-05-01", false},
+		{"XPAR", "2024-05-09", true}, // Ascension Day
+		{"XPAR", "2024-12-24", true},
+		{"XPAR", "2024-12-25", false},
+		{"XPAR", "2024-12-26", false},
+		{"XPAR", "2025-01-01", false},
+		{"XPAR", "2025-04-18", false}, // Good Friday
+		{"XPAR", "2024-06-08", false}, // Saturday
+		{"xams", "2024-04-01", false},
+		{"XOSL", "2024-03-28", false}, // Maundy Thursday
+		{"XOSL", "2024-05-09", false}, // Ascension Day
+		{"XOSL", "2024-05-17", false},
+		{"XOSL", "2024-05-20", false}, // Whit Monday
+		{"XOSL", "2024-12-31", false},
+		{"MTAA", "2024-08-15", false},
+		{"XNAS", "2024-03-29", false}, // Good Friday
+		{"XNAS", "2024-04-01", true},
+		{"XNAS", "2024-01-15", false}, // Martin Luther King Jr. Day
+		{"XNAS", "2024-05-27", false}, // Memorial Day
+		{"XNAS", "2024-06-19", false}, // Juneteenth
+		{"XNAS", "2021-06-18", true},  // Juneteenth before 2022
+		{"XNYS", "2021-07-05", false}, // Independence Day observed on Monday
+		{"XNYS", "2020-07-03", false}, // Independence Day observed on Friday
+		{"XNYS", "2024-11-28", false}, // Thanksgiving Day
+		{"XNYS", "2022-12-26", false}, // Christmas observed on Monday
+		{"XNYS", "2021-12-31", true},  // New Year on Saturday is not observed
+		{"XNYS", "2023-01-02", false}, // New Year observed on Monday
+		{"XNYS", "2025-01-09", false}, // one-off closure
+	}
+
+	for _, tt := range tests {
+		c, err := ForMic(tt.mic)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if s := c.IsSession(date(tt.date)); s != tt.session {
+			t.Errorf("%s %s: expected %v, got %v", tt.mic, tt.date, tt.session, s)
+		}
+	}
+
+	if _, err := ForMic("XXXX"); err == nil {
+		t.Error("expected an error for an unknown mic")
+	}
+}
+
+func TestHours(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		cal         *Calendar
+		date        string
+		open, close string
+	}{
+		{Euronext, "2024-01-15", "08:00", "16:30"}, // winter, UTC+1
+		{Euronext, "2024-06-14", "07:00", "15:30"}, // summer, UTC+2
+		{Euronext, "2024-12-24", "08:00", "13:05"}, // early close
+		{EuronextLisbon, "2024-06-14", "07:00", "15:30"},
+		{Us, "2024-03-08", "14:30", "21:00"}, // before the US daylight saving change
+		{Us, "2024-03-11", "13:30", "20:00"}, // after the US daylight saving change
+		{Us, "2024-11-29", "14:30", "18:00"}, // the day after Thanksgiving
+		{Us, "2024-07-03", "13:30", "17:00"},
+	}
+
+	for _, tt := range tests {
+		open, close, ok := tt.cal.Hours(date(tt.date))
+		if !ok {
+			t.Errorf("%s %s: expected a session", tt.cal.Name, tt.date)
+			continue
+		}
+
+		if o, c := open.UTC().Format("15:04"), close.UTC().Format("15:04"); o != tt.open || c != tt.close {
+			t.Errorf("%s %s: expected %s-%s UTC, got %s-%s", tt.cal.Name, tt.date, tt.open, tt.close, o, c)
+		}
+	}
+
+	if _, _, ok := Us.Hours(date("2024-12-25")); ok {
+		t.Error("expected no session on Christmas")
+	}
+
+	if Us.IsEarlyClose(date("2021-12-24")) {
+		t.Error("expected no early close on the observed Christmas")
+	}
+}
+
+func TestSessions(t *testing.T) {
+	t.Parallel()
+
+	if d := Euronext.NextSession(date("2024-03-28")); !d.Equal(date("2024-04-02")) {
+		t.Errorf("unexpected next session %s", d.Format("2006-01-02"))
+	}
+
+	if d := Euronext.PrevSession(date("2024-04-02")); !d.Equal(date("2024-03-28")) {
+		t.Errorf("unexpected previous session %s", d.Format("2006-01-02"))
+	}
+
+	if d := Euronext.AddSessions(date("2024-04-02"), -2); !d.Equal(date("2024-03-27")) {
+		t.Errorf("unexpected session %s", d.Format("2006-01-02"))
+	}
+
+	sessions := Euronext.SessionsBetween(date("2024-12-20"), date("2025-01-03"))
+	expected := []string{"2024-12-20", "2024-12-23", "2024-12-24", "2024-12-27", "2024-12-30", "2024-12-31", "2025-01-02", "2025-01-03"}
+	if len(sessions) != len(expected) {
+		t.Fatalf("expected %d sessions, got %v", len(expected), sessions)
+	}
+
+	for i, e := range expected {
+		if s := sessions[i].Format("2006-01-02"); s != e {
+			t.Errorf("session %d: expected %s, got %s", i, e, s)
+		}
+	}
+}
+
+func TestLastClosedSession(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		now      time.Time
+		last     string
+		lastDone string
+	}{
+		// Monday after Easter Monday, before and after the close plus the delay.
+		{time.Date(2024, 4, 2, 10, 0, 0, 0, time.UTC), "2024-04-02", "2024-03-28"},
+		{time.Date(2024, 4, 2, 17, 0, 0, 0, time.UTC), "2024-04-02", "2024-04-02"},
+		// Sunday.
+		{time.Date(2024, 6, 9, 12, 0, 0, 0, time.UTC), "2024-06-07", "2024-06-07"},
+		// Late Friday UTC is already Saturday in Paris.
+		{time.Date(2024, 6, 7, 22, 30, 0, 0, time.UTC), "2024-06-07", "2024-06-07"},
+	}
+
+	for _, tt := range tests {
+		if d := Euronext.LastSession(tt.now).Format("2006-01-02"); d != tt.last {
+			t.Errorf("%v: expected last session %s, got %s", tt.now, tt.last, d)
+		}
+
+		if d := Euronext.LastClosedSession(tt.now, 90*time.Minute).Format("2006-01-02"); d != tt.lastDone {
+			t.Errorf("%v: expected last closed session %s, got %s", tt.now, tt.lastDone, d)
+		}
+	}
+}
+
+func TestNthWeekday(t *testing.T) {
+	t.Parallel()
+
+	if d, _ := NthWeekday(time.May, time.Monday, -1)(2021); !d.Equal(date("2021-05-31")) {
+		t.Errorf("unexpected last Monday of May %s", d.Format("2006-01-02"))
+	}
+
+	if d, _ := NthWeekday(time.September, time.Monday, 1)(2025); !d.Equal(date("2025-09-01")) {
+		t.Errorf("unexpected first Monday of September %s", d.Format("2006-01-02"))
+	}
+
+	if d := EasterSunday(2025); !d.Equal(date("2025-04-20")) {
+		t.Errorf("unexpected Easter Sunday %s", d.Format("2006-01-02"))
+	}
+}
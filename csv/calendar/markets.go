@@ -0,0 +1,159 @@
+package calendar
+
+import "time"
+
+func location(name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		panic("cannot load location " + name + ": " + err.Error())
+	}
+
+	return loc
+}
+
+// euronextHolidays are the days when all Euronext cash markets are closed.
+var euronextHolidays = []Rule{
+	Fixed(time.January, 1),
+	Easter(-2), // Good Friday
+	Easter(1),  // Easter Monday
+	Fixed(time.May, 1),
+	Fixed(time.December, 25),
+	Fixed(time.December, 26),
+}
+
+// euronextEarlyCloses are the Christmas and New Year eves closing at 14:05 CET.
+var euronextEarlyCloses = []Rule{
+	Fixed(time.December, 24),
+	Fixed(time.December, 31),
+}
+
+// Euronext is the calendar of the Paris, Amsterdam and Brussels markets.
+var Euronext = &Calendar{
+	Name:        "euronext",
+	Location:    location("Europe/Paris"),
+	Open:        At(9, 0),
+	Close:       At(17, 30),
+	EarlyClose:  At(14, 5),
+	Holidays:    euronextHolidays,
+	EarlyCloses: euronextEarlyCloses,
+}
+
+// EuronextLisbon is the calendar of the Lisbon markets, trading the Euronext hours in the local time.
+var EuronextLisbon = &Calendar{
+	Name:        "euronext lisbon",
+	Location:    location("Europe/Lisbon"),
+	Open:        At(8, 0),
+	Close:       At(16, 30),
+	EarlyClose:  At(13, 5),
+	Holidays:    euronextHolidays,
+	EarlyCloses: euronextEarlyCloses,
+}
+
+// EuronextDublin is the calendar of the Dublin markets, trading the Euronext hours in the local time.
+var EuronextDublin = &Calendar{
+	Name:        "euronext dublin",
+	Location:    location("Europe/Dublin"),
+	Open:        At(8, 0),
+	Close:       At(16, 30),
+	EarlyClose:  At(13, 5),
+	Holidays:    euronextHolidays,
+	EarlyCloses: euronextEarlyCloses,
+}
+
+// EuronextOslo is the calendar of the Oslo markets, closed on the Norwegian public holidays.
+var EuronextOslo = &Calendar{
+	Name:       "euronext oslo",
+	Location:   location("Europe/Oslo"),
+	Open:       At(9, 0),
+	Close:      At(16, 20),
+	EarlyClose: At(16, 20),
+	Holidays: []Rule{
+		Fixed(time.January, 1),
+		Easter(-3), // Maundy Thursday
+		Easter(-2), // Good Friday
+		Easter(1),  // Easter Monday
+		Fixed(time.May, 1),
+		Fixed(time.May, 17), // Constitution Day
+		Easter(39),          // Ascension Day
+		Easter(50),          // Whit Monday
+		Fixed(time.December, 24),
+		Fixed(time.December, 25),
+		Fixed(time.December, 26),
+		Fixed(time.December, 31),
+	},
+}
+
+// EuronextMilan is the calendar of the Milan markets.
+var EuronextMilan = &Calendar{
+	Name:       "euronext milan",
+	Location:   location("Europe/Rome"),
+	Open:       At(9, 0),
+	Close:      At(17, 30),
+	EarlyClose: At(17, 30),
+	Holidays: []Rule{
+		Fixed(time.January, 1),
+		Easter(-2), // Good Friday
+		Easter(1),  // Easter Monday
+		Fixed(time.May, 1),
+		Fixed(time.August, 15),
+		Fixed(time.December, 24),
+		Fixed(time.December, 25),
+		Fixed(time.December, 26),
+		Fixed(time.December, 31),
+	},
+}
+
+// independenceDay is the 4th of July observed on the Friday before or the Monday after.
+var independenceDay = Observed(Fixed(time.July, 4))
+
+// Us is the calendar of the NYSE and Nasdaq markets.
+var Us = &Calendar{
+	Name:       "us",
+	Location:   location("America/New_York"),
+	Open:       At(9, 30),
+	Close:      At(16, 0),
+	EarlyClose: At(13, 0),
+	Holidays: []Rule{
+		ObservedSunday(Fixed(time.January, 1)),
+		Since(1998, NthWeekday(time.January, time.Monday, 3)), // Martin Luther King Jr. Day
+		NthWeekday(time.February, time.Monday, 3),             // Washington's Birthday
+		Easter(-2),                                  // Good Friday
+		NthWeekday(time.May, time.Monday, -1),       // Memorial Day
+		Since(2022, Observed(Fixed(time.June, 19))), // Juneteenth
+		independenceDay,
+		NthWeekday(time.September, time.Monday, 1),  // Labor Day
+		NthWeekday(time.November, time.Thursday, 4), // Thanksgiving Day
+		Observed(Fixed(time.December, 25)),
+	},
+	EarlyCloses: []Rule{
+		// The 3rd of July, unless the Independence Day is observed on it or on the Monday after.
+		Weekdays(Fixed(time.July, 3), time.Monday, time.Tuesday, time.Wednesday, time.Thursday),
+		Offset(NthWeekday(time.November, time.Thursday, 4), 1), // the day after Thanksgiving
+		Fixed(time.December, 24),
+	},
+	Closures: []time.Time{
+		time.Date(2001, time.September, 11, 0, 0, 0, 0, time.UTC),
+		time.Date(2001, time.September, 12, 0, 0, 0, 0, time.UTC),
+		time.Date(2001, time.September, 13, 0, 0, 0, 0, time.UTC),
+		time.Date(2001, time.September, 14, 0, 0, 0, 0, time.UTC),
+		time.Date(2004, time.June, 11, 0, 0, 0, 0, time.UTC),   // Reagan's funeral
+		time.Date(2007, time.January, 2, 0, 0, 0, 0, time.UTC), // Ford's funeral
+		time.Date(2012, time.October, 29, 0, 0, 0, 0, time.UTC),
+		time.Date(2012, time.October, 30, 0, 0, 0, 0, time.UTC),
+		time.Date(2018, time.December, 5, 0, 0, 0, 0, time.UTC), // Bush's funeral
+		time.Date(2025, time.January, 9, 0, 0, 0, 0, time.UTC),  // Carter's funeral
+	},
+}
+
+// mics maps the market identifier codes to the calendars.
+var mics = map[string]*Calendar{
+	"XPAR": Euronext, "ALXP": Euronext, "XMLI": Euronext, "XPMC": Euronext, "XMAT": Euronext, "XMON": Euronext,
+	"XAMS": Euronext, "ALXA": Euronext, "TNLA": Euronext, "XAMC": Euronext,
+	"XBRU": Euronext, "ALXB": Euronext, "ENXB": Euronext, "MLXB": Euronext, "TNLB": Euronext, "XBRD": Euronext,
+	"XLIS": EuronextLisbon, "ALXL": EuronextLisbon, "ENXL": EuronextLisbon,
+	"XDUB": EuronextDublin, "XMSM": EuronextDublin, "XESM": EuronextDublin, "XACD": EuronextDublin,
+	"XOSL": EuronextOslo, "XOAS": EuronextOslo, "MERK": EuronextOslo, "VPXB": EuronextOslo,
+	"MTAA": EuronextMilan, "MTAH": EuronextMilan, "EXGM": EuronextMilan, "BGEM": EuronextMilan,
+	"ETLX": EuronextMilan, "ETFP": EuronextMilan, "ATFX": EuronextMilan, "MIVX": EuronextMilan,
+	"XNYS": Us, "XNAS": Us, "ARCX": Us, "XASE": Us, "BATS": Us, "IEXG": Us,
+}
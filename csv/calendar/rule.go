@@ -0,0 +1,136 @@
+package calendar
+
+import "time"
+
+// Rule returns the date of a holiday or an early close in the year, if any.
+// The dates are midnight UTC.
+type Rule func(year int) (time.Time, bool)
+
+// Date returns the calendar date of the time as midnight UTC, ignoring the location.
+func Date(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// Fixed is a rule for the same day every year.
+func Fixed(month time.Month, day int) Rule {
+	return func(year int) (time.Time, bool) {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC), true
+	}
+}
+
+// Easter is a rule for the day at the offset in days from the Easter Sunday,
+// e.g. -2 for the Good Friday, 1 for the Easter Monday, 39 for the Ascension Day.
+func Easter(offset int) Rule {
+	return func(year int) (time.Time, bool) {
+		return EasterSunday(year).AddDate(0, 0, offset), true
+	}
+}
+
+// NthWeekday is a rule for the n-th weekday of the month, negative n counts from the end of the month.
+func NthWeekday(month time.Month, weekday time.Weekday, n int) Rule {
+	return func(year int) (time.Time, bool) {
+		if n > 0 {
+			d := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+			d = d.AddDate(0, 0, (int(weekday)-int(d.Weekday())+7)%7)
+			return d.AddDate(0, 0, 7*(n-1)), true
+		}
+
+		d := time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC)
+		d = d.AddDate(0, 0, -((int(d.Weekday()) - int(weekday) + 7) % 7))
+		return d.AddDate(0, 0, 7*(n+1)), true
+	}
+}
+
+// Offset shifts the date of the rule by the number of days.
+func Offset(rule Rule, days int) Rule {
+	return func(year int) (time.Time, bool) {
+		d, ok := rule(year)
+		return d.AddDate(0, 0, days), ok
+	}
+}
+
+// Observed moves a holiday falling on a Saturday to the Friday before
+// and a holiday falling on a Sunday to the Monday after.
+func Observed(rule Rule) Rule {
+	return func(year int) (time.Time, bool) {
+		d, ok := rule(year)
+		switch d.Weekday() {
+		case time.Saturday:
+			return d.AddDate(0, 0, -1), ok
+		case time.Sunday:
+			return d.AddDate(0, 0, 1), ok
+		}
+
+		return d, ok
+	}
+}
+
+// ObservedSunday moves a holiday falling on a Sunday to the Monday after,
+// a holiday falling on a Saturday is not observed.
+func ObservedSunday(rule Rule) Rule {
+	return func(year int) (time.Time, bool) {
+		d, ok := rule(year)
+		switch d.Weekday() {
+		case time.Saturday:
+			return d, false
+		case time.Sunday:
+			return d.AddDate(0, 0, 1), ok
+		}
+
+		return d, ok
+	}
+}
+
+// Weekdays restricts the rule to the dates falling on the weekdays.
+func Weekdays(rule Rule, weekdays ...time.Weekday) Rule {
+	return func(year int) (time.Time, bool) {
+		d, ok := rule(year)
+		if !ok {
+			return d, false
+		}
+
+		for _, w := range weekdays {
+			if d.Weekday() == w {
+				return d, true
+			}
+		}
+
+		return d, false
+	}
+}
+
+// Since restricts the rule to the years starting from the first one.
+func Since(first int, rule Rule) Rule {
+	return func(year int) (time.Time, bool) {
+		d, ok := rule(year)
+		return d, ok && year >= first
+	}
+}
+
+// Until restricts the rule to the years up to and including the last one.
+func Until(last int, rule Rule) Rule {
+	return func(year int) (time.Time, bool) {
+		d, ok := rule(year)
+		return d, ok && year <= last
+	}
+}
+
+// EasterSunday returns the date of the Easter Sunday of the year, using the anonymous Gregorian algorithm.
+func EasterSunday(year int) time.Time {
+	a := year % 19
+	b := year / 100
+	c := year % 100
+	d := b / 4
+	e := b % 4
+	f := (b + 8) / 25
+	g := (b - f + 1) / 3
+	h := (19*a + b - d - g + 15) % 30
+	i := c / 4
+	k := c % 4
+	l := (32 + 2*e + 2*i - h - k) % 7
+	m := (a + 11*h + 22*l) / 451
+	month := (h + l - 7*m + 114) / 31
+	day := (h+l-7*m+114)%31 + 1
+	return time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"nq/cme"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type symbols struct {
+	Symbols []cme.FutureSymbol `json:"symbols"`
+}
+
+func main() {
+	symbolsPtr := flag.String("symbols", "cme-archive.json", "symbols json file name")
+	repositoryPtr := flag.String("repository", "./cme-downloads/", "repository folder of the archived trades")
+	futurePtr := flag.String("future", "", "comma-separated futures, e.g. ES,NQ, default is all futures of the symbols")
+	adjustPtr := flag.String("adjust", "none,difference,ratio", "comma-separated adjustments: [none, difference, ratio]")
+	quarterlyPtr := flag.Bool("quarterly", true, "use the quarterly contracts only")
+	outPtr := flag.String("out", ".", "output folder of the continuous series")
+	flag.Parse()
+
+	sym, err := readSymbols(*symbolsPtr)
+	if err != nil {
+		panic(fmt.Sprintf("cannot read symbols: %s", err))
+	}
+
+	adjustments := make([]cme.Adjustment, 0)
+	for _, a := range strings.Split(*adjustPtr, ",") {
+		adj, err := cme.ParseAdjustment(a)
+		if err != nil {
+			panic(err.Error())
+		}
+
+		adjustments = append(adjustments, adj)
+	}
+
+	futures := strings.Split(*futurePtr, ",")
+	if *futurePtr == "" {
+		futures = futuresOf(sym.Symbols)
+	}
+
+	if err := os.MkdirAll(*outPtr, os.ModePerm); err != nil {
+		panic(fmt.Sprintf("cannot create directory '%s': %s", *outPtr, err))
+	}
+
+	for _, future := range futures {
+		if err := continuous(sym.Symbols, strings.TrimSpace(future), *repositoryPtr, *outPtr, *quarterlyPtr, adjustments); err != nil {
+			fmt.Printf("%s: %s\n", future, err)
+		}
+	}
+}
+
+func readSymbols(fileName string) (*symbols, error) {
+	var s symbols
+
+	f, err := os.Open(fileName)
+	if err != nil {
+		return &s, fmt.Errorf("cannot open '%s' file: %w", fileName, err)
+	}
+	defer f.Close()
+
+	decoder := json.NewDecoder(f)
+
+	err = decoder.Decode(&s)
+	if err != nil {
+		return &s, fmt.Errorf("cannot decode '%s' file: %w", fileName, err)
+	}
+
+	return &s, nil
+}
+
+// futuresOf returns the distinct futures of the symbols in the order of appearance.
+func futuresOf(symbols []cme.FutureSymbol) []string {
+	futures := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, s := range symbols {
+		f := strings.ToUpper(s.Future)
+		if !seen[f] {
+			seen[f] = true
+			futures = append(futures, f)
+		}
+	}
+
+	return futures
+}
+
+// continuous writes the "es_continuous_ratio.csv" series of the future for every adjustment.
+func continuous(symbols []cme.FutureSymbol, future, repository, out string, quarterly bool, adjustments []cme.Adjustment) error {
+	cal, err := cme.NewRollCalendar(symbols, future, quarterly)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s: %d contracts\n", cal.Future, len(cal.Contracts))
+	for _, r := range cal.Rolls {
+		fmt.Printf("%s roll %s -> %s\n", r.Date.Format("2006-01-02"), r.From, r.To)
+	}
+
+	trades := make(map[string][]cme.RealtimeEntryParsed)
+	for i := range cal.Contracts {
+		c := &cal.Contracts[i]
+		folder := cme.ContractFolder(repository, c)
+		t, err := cme.ReadContractTrades(folder)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("%s: %d trades in '%s'\n", c.Mnemonic, len(t), folder)
+		trades[c.Mnemonic] = t
+	}
+
+	for _, adj := range adjustments {
+		series, err := cal.Continuous(trades, adj)
+		if err != nil {
+			fmt.Printf("%s %s: %s\n", cal.Future, adj, err)
+			continue
+		}
+
+		file := filepath.Join(out, fmt.Sprintf("%s_continuous_%s.csv", strings.ToLower(cal.Future), adj))
+		if err := os.WriteFile(file, []byte(strings.Join(cme.ContinuousCSV(series), "")), 0644); err != nil {
+			return fmt.Errorf("cannot write '%s': %w", file, err)
+		}
+
+		fmt.Printf("%s %s: %d trades written to '%s'\n", cal.Future, adj, len(series), file)
+	}
+
+	return nil
+}
@@ -1,13 +1,11 @@
 package main
 
 import (
-	"archive/zip"
 	"encoding/json"
 	"flag"
 	"fmt"
 	"nq/cme"
 	"os"
-	"strconv"
 	"strings"
 	"time"
 )
@@ -134,14 +132,9 @@ func ensureDirectoryExists(directory string) error {
 }
 
 func archive(sym cme.FutureSymbol, repository, prefix string, retryDelayMins []int, daysback int) error {
-	path := repository + sym.Mic + "/" + sym.Future + "/" + sym.Mnemonic // xcme/es/esm23
+	path := cme.ContractFolder(repository, &sym) // xcme/es/esm23
 	fmt.Printf("%s '%s' to '%s' ...\n", prefix, sym.Mnemonic, path)
 
-	err := ensureDirectoryExists(path)
-	if err != nil {
-		return fmt.Errorf("cannot create symbol repository directory '%s': %s", path, err)
-	}
-
 	t, err := sessionDate()
 	if err != nil {
 		return err
@@ -156,12 +149,16 @@ func archive(sym cme.FutureSymbol, repository, prefix string, retryDelayMins []i
 	// var parsed []cme.RealtimeEntryParsed
 	var json cme.RealtimeJSON
 	var csv []string
+	var report *cme.PagingReport
 
-	path += "/"
+	// At least one attempt without the retry delays.
 	retriesMax := len(retryDelayMins)
+	if retriesMax < 1 {
+		retriesMax = 1
+	}
 	retries := 0
 	for retries < retriesMax {
-		csv, _, json, err = cme.RetrieveCode(sym, t)
+		csv, _, json, report, err = cme.RetrieveCode(sym, t)
 		if err != nil {
 			retries += 1
 			err := fmt.Errorf("failed to retrieve trades, retries (%d of %d): %w", retries, retriesMax, err)
@@ -179,54 +176,12 @@ func archive(sym cme.FutureSymbol, repository, prefix string, retryDelayMins []i
 		}
 	}
 
-	file := path + t.Format("2006-01-02") // _15-04-05
-	fz := file + "_trade.zip"
-
-	a := 0
-again:
-	_, err = os.Stat(fz)
-	if err == nil {
-		a++
-		fz = file + fmt.Sprintf("_trade(%d).zip", a)
-		goto again
-	}
-
-	z, err := os.Create(fz)
-	if err != nil {
-		return fmt.Errorf("cannot create '%s': %w", fz, err)
-	}
-	defer z.Close()
-
-	w := zip.NewWriter(z)
-	defer w.Close()
-
-	td := t.Format("2006-01-02") + "_trade"
-	nam := td + ".csv"
-	f, err := w.Create(nam)
-	if err != nil {
-		return fmt.Errorf("cannot create zip entry '%s': %w", nam, err)
-	}
-
-	joined := strings.Join(csv, "")
-	_, err = f.Write([]byte(joined))
-	if err != nil {
-		return fmt.Errorf("cannot write zip entry '%s': %w", nam, err)
+	if !report.IsComplete() || report.Duplicates > 0 {
+		fmt.Printf("paging: %s\n", report)
 	}
 
-	for _, j := range json.Timeslots {
-		nam = td + "_" + j.Timeslot + "_"
-		for _, i := range j.JSON {
-			n := nam + strconv.Itoa(i.Page) + ".json"
-			f, err = w.Create(n)
-			if err != nil {
-				return fmt.Errorf("cannot create zip entry '%s': %w", n, err)
-			}
-
-			_, err = f.Write(i.JSON)
-			if err != nil {
-				return fmt.Errorf("cannot write zip entry '%s': %w", n, err)
-			}
-		}
+	if _, err = cme.WriteArchive(repository, &sym, t, csv, json, report); err != nil {
+		return err
 	}
 
 	fmt.Println("done")
@@ -0,0 +1,84 @@
+package cme
+
+import (
+	"archive/zip"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ContractFolder returns the "xcme/es/esm23" archive folder of the contract in the repository.
+// The folder is lowercase whatever the case of the symbols file.
+func ContractFolder(repository string, sym *FutureSymbol) string {
+	return filepath.Join(repository, strings.ToLower(sym.Mic), strings.ToLower(sym.Future), strings.ToLower(sym.Mnemonic))
+}
+
+// WriteArchive writes the "2006-01-02_trade.zip" archive of the entry date to the contract folder,
+// or "2006-01-02_trade(1).zip" and so on if it exists, and returns the archive file name.
+//
+// The archive has the "2006-01-02_trade.csv" entry, the "2006-01-02_trade_missing.csv" entry
+// if the paging is incomplete and the "2006-01-02_trade_17_1.json" timeslot page entries.
+func WriteArchive(repository string, sym *FutureSymbol, entryDate time.Time, csv []string, jsons RealtimeJSON, report *PagingReport) (string, error) {
+	folder := ContractFolder(repository, sym)
+	if err := os.MkdirAll(folder, os.ModePerm); err != nil {
+		return empty, fmt.Errorf("cannot create directory '%s': %w", folder, err)
+	}
+
+	td := entryDate.Format("2006-01-02") + "_trade"
+	file := filepath.Join(folder, td)
+	fz := file + ".zip"
+	for a := 1; ; a++ {
+		if _, err := os.Stat(fz); err != nil {
+			break
+		}
+
+		fz = file + fmt.Sprintf("(%d).zip", a)
+	}
+
+	z, err := os.Create(fz)
+	if err != nil {
+		return fz, fmt.Errorf("cannot create '%s': %w", fz, err)
+	}
+	defer z.Close()
+
+	w := zip.NewWriter(z)
+	write := func(name string, b []byte) error {
+		f, err := w.Create(name)
+		if err != nil {
+			return fmt.Errorf("cannot create zip entry '%s': %w", name, err)
+		}
+
+		if _, err = f.Write(b); err != nil {
+			return fmt.Errorf("cannot write zip entry '%s': %w", name, err)
+		}
+
+		return nil
+	}
+
+	if err := write(td+".csv", []byte(strings.Join(csv, ""))); err != nil {
+		return fz, err
+	}
+
+	if report != nil && !report.IsComplete() {
+		if err := write(td+"_missing.csv", []byte(strings.Join(report.MissingCSV(), ""))); err != nil {
+			return fz, err
+		}
+	}
+
+	for _, j := range jsons.Timeslots {
+		for _, i := range j.JSON {
+			if err := write(td+"_"+j.Timeslot+"_"+strconv.Itoa(i.Page)+".json", i.JSON); err != nil {
+				return fz, err
+			}
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		return fz, fmt.Errorf("cannot close '%s': %w", fz, err)
+	}
+
+	return fz, nil
+}
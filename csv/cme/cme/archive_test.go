@@ -0,0 +1,43 @@
+package cme
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWriteArchiveReadBack(t *testing.T) {
+	t.Parallel()
+
+	// The symbols file has the upper case mic, future and mnemonic.
+	repository := t.TempDir()
+	sym := &FutureSymbol{Future: "ES", Mnemonic: "ESM24", Mic: "XCME"}
+	date := time.Date(2024, 6, 11, 0, 0, 0, 0, time.UTC)
+	report := &PagingReport{Missing: []MissingPage{{Timeslot: 9, Page: 2, Reason: "no entries"}}}
+
+	first, err := WriteArchive(repository, sym, date, []string{"2024-06-11 09:00:00;5300;2;1\n"}, RealtimeJSON{}, report)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	second, err := WriteArchive(repository, sym, date, []string{"2024-06-11 10:00:00;5301;1;2\n"}, RealtimeJSON{}, &PagingReport{})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	folder := ContractFolder(repository, sym)
+	if first != filepath.Join(folder, "2024-06-11_trade.zip") || second != filepath.Join(folder, "2024-06-11_trade(1).zip") {
+		t.Errorf("unexpected archives '%s', '%s'", first, second)
+	}
+
+	trades, err := ReadContractTrades(ContractFolder(repository, sym))
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	expected := []string{"2024-06-11 09:00:00;5300;2;1\n", "2024-06-11 10:00:00;5301;1;2\n"}
+	if csv := convertToCSV(trades); fmt.Sprint(csv) != fmt.Sprint(expected) {
+		t.Errorf("expected %v, got %v", expected, csv)
+	}
+}
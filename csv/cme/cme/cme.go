@@ -76,14 +76,74 @@ type RealtimeJSON struct {
 }
 
 type FutureSymbol struct {
-	Future        string `json:"future"`        // ES
-	Mnemonic      string `json:"mnemonic"`      // ESM23 (Globex code)
-	Name          string `json:"name"`          // E-mini S&P 500 Futures
-	Code          string `json:"code"`          // 133
-	ContractMonth string `json:"contractMonth"` // JUN 2023
-	ContractCode  string `json:"contractCode"`  // M3
-	Currency      string `json:"currency"`      // USD
-	Mic           string `json:"mic"`           // CMX
+	Future         string `json:"future"`         // ES
+	Mnemonic       string `json:"mnemonic"`       // ESM23 (Globex code)
+	Name           string `json:"name"`           // E-mini S&P 500 Futures
+	Code           string `json:"code"`           // 133
+	ContractMonth  string `json:"contractMonth"`  // JUN 2023
+	ContractCode   string `json:"contractCode"`   // M3
+	Currency       string `json:"currency"`       // USD
+	Mic            string `json:"mic"`            // CMX
+	FirstTradeDate string `json:"firstTradeDate"` // 2023-03-18
+	LastTradeDate  string `json:"lastTradeDate"`  // 2024-06-21
+	RolloverDate   string `json:"rolloverDate"`   // 2024-06-14
+}
+
+// MissingPage is a time and sales page which cannot be retrieved consistently.
+type MissingPage struct {
+	Timeslot int
+	Page     int
+	Reason   string
+}
+
+// PagingReport tells how complete the paging of the time and sales is.
+type PagingReport struct {
+	// Pages is the number of the retrieved pages.
+	Pages int
+
+	// PageTotal is the total number of the pages of the props.
+	PageTotal int
+
+	// Refetched is the number of the pages retrieved again because they were inconsistent.
+	Refetched int
+
+	// Duplicates is the number of the dropped entries with a repeated key.
+	Duplicates int
+
+	Missing []MissingPage
+}
+
+// IsComplete tells if every page up to the page total is retrieved.
+func (r *PagingReport) IsComplete() bool {
+	return len(r.Missing) == 0
+}
+
+// String implements the fmt.Stringer interface.
+func (r *PagingReport) String() string {
+	s := fmt.Sprintf("%d of %d pages, %d refetched, %d duplicates, %d missing", r.Pages, r.PageTotal, r.Refetched, r.Duplicates, len(r.Missing))
+	for _, m := range r.Missing {
+		s += fmt.Sprintf("\ntimeslot %02d page %d: %s", m.Timeslot, m.Page, m.Reason)
+	}
+
+	return s
+}
+
+// MissingCSV returns the "timeslot;page;reason" lines of the missing pages.
+func (r *PagingReport) MissingCSV() []string {
+	csv := make([]string, 0, len(r.Missing))
+	for _, m := range r.Missing {
+		csv = append(csv, fmt.Sprintf("%02d;%d;%s\n", m.Timeslot, m.Page, m.Reason))
+	}
+
+	return csv
+}
+
+func (r *PagingReport) add(o *PagingReport) {
+	r.Pages += o.Pages
+	r.PageTotal += o.PageTotal
+	r.Refetched += o.Refetched
+	r.Duplicates += o.Duplicates
+	r.Missing = append(r.Missing, o.Missing...)
 }
 
 const userAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/108.0.0.0 Safari/537.36"
@@ -192,11 +252,60 @@ func convertToCSV(series []RealtimeEntryParsed) []string {
 	return csv
 }
 
+// pageRetries is the number of the retrievals of an inconsistent page.
+const pageRetries = 3
+
+// checkPage returns why the page is inconsistent with the requested page number, or an empty string.
+func checkPage(rt *Realtime, pg, pageTotal int) string {
+	if rt.Props.PageNumber != 0 && rt.Props.PageNumber != pg {
+		return fmt.Sprintf("page number %d returned", rt.Props.PageNumber)
+	}
+
+	if pageTotal > 0 && len(rt.Entries) == 0 {
+		return "no entries"
+	}
+
+	if pg < pageTotal && rt.Props.PageSize > 0 && len(rt.Entries) < rt.Props.PageSize {
+		return fmt.Sprintf("%d of %d entries", len(rt.Entries), rt.Props.PageSize)
+	}
+
+	return empty
+}
+
+// DedupeByKey sorts the entries by key and drops the entries with a repeated key,
+// returning the unique entries and the number of the dropped ones.
+func DedupeByKey(entries []RealtimeEntryParsed) ([]RealtimeEntryParsed, int) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Key < entries[j].Key
+	})
+
+	unique := make([]RealtimeEntryParsed, 0, len(entries))
+	for i, e := range entries {
+		if i > 0 && e.Key == entries[i-1].Key {
+			continue
+		}
+
+		unique = append(unique, e)
+	}
+
+	return unique, len(entries) - len(unique)
+}
+
 // RetrieveTimeslot downloads all time and sales pages for the given timeslot.
+//
+// Every page up to the page total is verified: a page with an unexpected page number,
+// without entries or with less entries than the page size before the last page is retrieved again.
+// A page still inconsistent after the retries is reported as missing.
 func RetrieveTimeslot(sym FutureSymbol, entryDate time.Time,
-	timeslot, daydelta int) ([]RealtimePageJSON, []RealtimeEntryParsed, error) {
+	timeslot, daydelta int) ([]RealtimePageJSON, []RealtimeEntryParsed, *PagingReport, error) {
+	return retrieveTimeslot(get, sym, entryDate, timeslot, daydelta)
+}
+
+func retrieveTimeslot(fetch func(string) ([]byte, error), sym FutureSymbol, entryDate time.Time,
+	timeslot, daydelta int) ([]RealtimePageJSON, []RealtimeEntryParsed, *PagingReport, error) {
 	parsed := make([]RealtimeEntryParsed, 0)
 	jsons := make([]RealtimePageJSON, 0)
+	report := &PagingReport{}
 
 	if daydelta != 0 {
 		entryDate = entryDate.AddDate(0, 0, daydelta)
@@ -210,6 +319,7 @@ func RetrieveTimeslot(sym FutureSymbol, entryDate time.Time,
 		"&pageSize=12&pageNumber="
 	pg := 1
 	pg100 := 100
+	pageTotal := 0
 
 nextPage:
 	page := url + strconv.Itoa(pg)
@@ -217,34 +327,55 @@ nextPage:
 		fmt.Printf("getting %s\n", page)
 	}
 
-	b, err := get(page)
-	if err != nil {
-		return jsons, parsed, fmt.Errorf("cannot get url '%s': %w", page, err)
-	}
+	var rt *Realtime
+	reason := empty
+	for attempt := 1; attempt <= pageRetries; attempt++ {
+		if attempt > 1 {
+			report.Refetched++
+		}
 
-	jsons = append(jsons, RealtimePageJSON{
-		Page: pg,
-		JSON: b,
-	})
+		b, err := fetch(page)
+		if err != nil {
+			return jsons, parsed, report, fmt.Errorf("cannot get url '%s': %w", page, err)
+		}
 
-	rt, err := unmarshalRealtime(b)
-	if err != nil {
-		return jsons, parsed, fmt.Errorf("cannot unmarshal url '%s': %w\n%s", page, err, string(b))
+		rt, err = unmarshalRealtime(b)
+		if err != nil {
+			return jsons, parsed, report, fmt.Errorf("cannot unmarshal url '%s': %w\n%s", page, err, string(b))
+		}
+
+		// The page total grows while paging a live timeslot.
+		if rt.Props.PageTotal > pageTotal {
+			pageTotal = rt.Props.PageTotal
+		}
+
+		if reason = checkPage(rt, pg, pageTotal); reason == empty || attempt == pageRetries {
+			jsons = append(jsons, RealtimePageJSON{
+				Page: pg,
+				JSON: b,
+			})
+			break
+		}
+	}
+
+	report.Pages++
+	if reason != empty {
+		report.Missing = append(report.Missing, MissingPage{Timeslot: timeslot, Page: pg, Reason: reason})
 	}
 
 	for i := len(rt.Entries) - 1; i >= 0; i-- {
 		el := rt.Entries[i]
 		cv, err := el.parse(daydelta)
 		if err != nil {
-			return jsons, parsed, fmt.Errorf("cannot convert url '%s': %w", page, err)
+			return jsons, parsed, report, fmt.Errorf("cannot convert url '%s': %w", page, err)
 		}
 
 		parsed = append(parsed, *cv)
 	}
 
-	if pg < rt.Props.PageTotal {
+	if pg < pageTotal {
 		if pg == 1 {
-			fmt.Printf("total pages %d\n", rt.Props.PageTotal)
+			fmt.Printf("total pages %d\n", pageTotal)
 		}
 		pg++
 		if pg == pg100 {
@@ -258,11 +389,17 @@ nextPage:
 		fmt.Printf("\n")
 	}
 
-	return jsons, parsed, nil
+	report.PageTotal = pageTotal
+	return jsons, parsed, report, nil
 }
 
 // RetrieveCode retrievs all timeslots for the given code.
-func RetrieveCode(sym FutureSymbol, entryDate time.Time) ([]string, []RealtimeEntryParsed, RealtimeJSON, error) {
+// The entries are deduplicated by key, the report tells if every page is retrieved.
+func RetrieveCode(sym FutureSymbol, entryDate time.Time) ([]string, []RealtimeEntryParsed, RealtimeJSON, *PagingReport, error) {
+	return retrieveCode(get, sym, entryDate)
+}
+
+func retrieveCode(fetch func(string) ([]byte, error), sym FutureSymbol, entryDate time.Time) ([]string, []RealtimeEntryParsed, RealtimeJSON, *PagingReport, error) {
 	timeSlots := []int{
 		17, // 17:00 - 17:59:59
 		18, // 18:00 - 18:59:59
@@ -290,6 +427,7 @@ func RetrieveCode(sym FutureSymbol, entryDate time.Time) ([]string, []RealtimeEn
 
 	csv := make([]string, 0)
 	series := make([]RealtimeEntryParsed, 0)
+	report := &PagingReport{}
 	jsons := RealtimeJSON{
 		Extension: sym.ContractCode,
 		Timeslots: make([]RealtimeTimeslotJSON, 0),
@@ -301,11 +439,12 @@ func RetrieveCode(sym FutureSymbol, entryDate time.Time) ([]string, []RealtimeEn
 			daydelta = -1
 		}
 
-		pages, entries, err := RetrieveTimeslot(sym, entryDate, p, daydelta)
+		pages, entries, rep, err := retrieveTimeslot(fetch, sym, entryDate, p, daydelta)
 		if err != nil {
-			return csv, series, jsons, fmt.Errorf("cannot retrieve timeslot '%d': %w", p, err)
+			return csv, series, jsons, report, fmt.Errorf("cannot retrieve timeslot '%d': %w", p, err)
 		}
 
+		report.add(rep)
 		ts := empty
 		if p < 10 {
 			ts = "0"
@@ -319,9 +458,6 @@ func RetrieveCode(sym FutureSymbol, entryDate time.Time) ([]string, []RealtimeEn
 		series = append(series, entries...)
 	}
 
-	sort.Slice(series, func(i, j int) bool {
-		return series[i].Key < series[j].Key
-	})
-
-	return convertToCSV(series), series, jsons, nil
+	series, report.Duplicates = DedupeByKey(series)
+	return convertToCSV(series), series, jsons, report, nil
 }
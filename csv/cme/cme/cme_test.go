@@ -0,0 +1,96 @@
+package cme
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// pageJSON returns the time and sales page json of the entry keys, the latest first.
+func pageJSON(number, total, size int, keys ...int64) []byte {
+	rt := Realtime{Props: RealtimeProps{PageNumber: number, PageTotal: total, PageSize: size}, Entries: []RealtimeEntry{}}
+	for _, k := range keys {
+		rt.Entries = append(rt.Entries, RealtimeEntry{
+			Key:   k,
+			Date:  "10 Jun 2024",
+			Time:  fmt.Sprintf("10:00:%02d", k%60),
+			Price: "5,300.25",
+			Size:  "1",
+		})
+	}
+
+	b, _ := json.Marshal(rt)
+	return b
+}
+
+func TestRetrieveTimeslotPaging(t *testing.T) {
+	t.Parallel()
+
+	sym := FutureSymbol{Code: "133", ContractCode: "M4"}
+	url := "https://www.cmegroup.com/CmeWS/mvc/TimeandSales/133/G/M4?timeSlot=10&entryDate=20240610&pageSize=12&pageNumber="
+
+	// Page 2 is short once, page 3 always returns page 2, page 4 is the short last page.
+	fetched := map[string]int{}
+	fetch := func(u string) ([]byte, error) {
+		fetched[u]++
+		switch u {
+		case url + "1":
+			return pageJSON(1, 4, 3, 9, 8, 7), nil
+		case url + "2":
+			if fetched[u] == 1 {
+				return pageJSON(2, 4, 3, 7, 6), nil
+			}
+			return pageJSON(2, 4, 3, 7, 6, 5), nil
+		case url + "3":
+			return pageJSON(2, 4, 3, 7, 6, 5), nil
+		default:
+			return pageJSON(4, 4, 3, 1), nil
+		}
+	}
+
+	pages, entries, report, err := retrieveTimeslot(fetch, sym, time.Date(2024, 6, 10, 0, 0, 0, 0, time.UTC), 10, 0)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if len(pages) != 4 || len(entries) != 10 || fetched[url+"2"] != 2 || fetched[url+"3"] != pageRetries {
+		t.Errorf("unexpected %d pages, %d entries, fetches %v", len(pages), len(entries), fetched)
+	}
+
+	if report.Pages != 4 || report.PageTotal != 4 || report.Refetched != 3 || report.IsComplete() {
+		t.Errorf("unexpected report %s", report)
+	}
+
+	if len(report.Missing) == 1 && (report.Missing[0].Page != 3 || report.Missing[0].Reason != "page number 2 returned") {
+		t.Errorf("unexpected missing page %v", report.Missing[0])
+	}
+
+	unique, duplicates := DedupeByKey(entries)
+	if len(unique) != 6 || duplicates != 4 || unique[0].Key != 1 || unique[5].Key != 9 {
+		t.Errorf("unexpected %v with %d duplicates", unique, duplicates)
+	}
+}
+
+func TestCheckPage(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		rt        Realtime
+		pg        int
+		pageTotal int
+		reason    string
+	}{
+		{Realtime{Props: RealtimeProps{PageNumber: 1, PageSize: 2}, Entries: make([]RealtimeEntry, 2)}, 1, 2, empty},
+		{Realtime{Props: RealtimeProps{PageNumber: 2, PageSize: 2}, Entries: make([]RealtimeEntry, 1)}, 2, 2, empty},
+		{Realtime{Props: RealtimeProps{PageNumber: 1, PageSize: 2}, Entries: make([]RealtimeEntry, 1)}, 1, 2, "1 of 2 entries"},
+		{Realtime{Props: RealtimeProps{PageNumber: 1, PageSize: 2}}, 1, 2, "no entries"},
+		{Realtime{}, 1, 0, empty},
+	}
+
+	for i, tt := range tests {
+		if reason := checkPage(&tt.rt, tt.pg, tt.pageTotal); reason != tt.reason {
+			t.Errorf("%d: expected '%s', got '%s'", i, tt.reason, reason)
+		}
+	}
+}
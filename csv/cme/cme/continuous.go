@@ -0,0 +1,458 @@
+package cme
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MonthCodes are the contract month codes from January (F) to December (Z).
+const MonthCodes = "FGHJKMNQUVXZ"
+
+// QuarterlyMonthCodes are the month codes of the quarterly contracts, e.g. ESH24, ESM24, ESU24, ESZ24.
+const QuarterlyMonthCodes = "HMUZ"
+
+// DefaultRollDays is the number of calendar days before the expiration on the third Friday
+// of the contract month the equity index futures roll, the Thursday of the previous week.
+const DefaultRollDays = 8
+
+const dateLayout = "2006-01-02"
+
+// ContractMonthOf returns the year and month of the "ESM23" mnemonic traded at the trade date.
+//
+// A single year digit is resolved to the first year ending with it not before the year of the trade date,
+// so the "ESM3" traded in 2023 is June 2023 and the "ESM3" traded in 2024 is June 2033.
+func ContractMonthOf(mnemonic string, tradeDate time.Time) (int, time.Month, error) {
+	m := strings.ToUpper(strings.TrimSpace(mnemonic))
+	if len(m) < 3 {
+		return 0, 0, fmt.Errorf("invalid contract mnemonic '%s'", mnemonic)
+	}
+
+	// ESM23 or ESM3
+	i := len(m) - 1
+	for i > 0 && m[i] >= '0' && m[i] <= '9' {
+		i--
+	}
+
+	month := strings.IndexByte(MonthCodes, m[i])
+	digits := m[i+1:]
+	if month < 0 || len(digits) < 1 || len(digits) > 2 || i < 1 {
+		return 0, 0, fmt.Errorf("invalid contract mnemonic '%s'", mnemonic)
+	}
+
+	year, _ := strconv.Atoi(digits)
+	if len(digits) == 2 {
+		return 2000 + year, time.Month(month + 1), nil
+	}
+
+	year += tradeDate.Year() - tradeDate.Year()%10
+	if year < tradeDate.Year() {
+		year += 10
+	}
+
+	return year, time.Month(month + 1), nil
+}
+
+// Expiry returns the year and month of the contract month "JUN 2023", or of the mnemonic.
+// A single year digit of the mnemonic is resolved relative to the first trade date, the last trade date or today.
+func (s *FutureSymbol) Expiry() (int, time.Month, error) {
+	if f := strings.Fields(strings.ToUpper(s.ContractMonth)); len(f) == 2 {
+		year, err := strconv.Atoi(f[1])
+		for m := time.January; m <= time.December && err == nil; m++ {
+			if strings.ToUpper(m.String()[:3]) == f[0] {
+				return year, m, nil
+			}
+		}
+	}
+
+	tradeDate := time.Now()
+	for _, d := range []string{s.FirstTradeDate, s.LastTradeDate} {
+		if t, err := time.Parse(dateLayout, strings.TrimSpace(d)); err == nil {
+			tradeDate = t
+			break
+		}
+	}
+
+	return ContractMonthOf(s.Mnemonic, tradeDate)
+}
+
+// thirdFriday returns the third Friday of the month.
+func thirdFriday(year int, month time.Month) time.Time {
+	t := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+	for t.Weekday() != time.Friday {
+		t = t.AddDate(0, 0, 1)
+	}
+
+	return t.AddDate(0, 0, 14)
+}
+
+// RollDate returns the rollover date, or DefaultRollDays before the third Friday of the contract month.
+func (s *FutureSymbol) RollDate() (time.Time, error) {
+	if d := strings.TrimSpace(s.RolloverDate); d != empty {
+		t, err := time.Parse(dateLayout, d)
+		if err != nil {
+			return t, fmt.Errorf("cannot parse %s rollover date '%s': %w", s.Mnemonic, d, err)
+		}
+
+		return t, nil
+	}
+
+	year, month, err := s.Expiry()
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return thirdFriday(year, month).AddDate(0, 0, -DefaultRollDays), nil
+}
+
+// Roll is a roll from a contract to the next one, the trades from the roll date on are of the next contract.
+type Roll struct {
+	Date time.Time
+	From string
+	To   string
+}
+
+// RollCalendar is the chain of the contracts of a future ordered by the contract month.
+type RollCalendar struct {
+	Future    string
+	Contracts []FutureSymbol
+	Rolls     []Roll
+}
+
+// NewRollCalendar returns the roll calendar of the future, e.g. "ES", from the symbols.
+// Only the quarterly contracts are used if quarterly is set.
+func NewRollCalendar(symbols []FutureSymbol, future string, quarterly bool) (*RollCalendar, error) {
+	type contract struct {
+		sym   FutureSymbol
+		year  int
+		month time.Month
+		roll  time.Time
+	}
+
+	contracts := make([]contract, 0)
+	seen := make(map[string]bool)
+	for _, s := range symbols {
+		if !strings.EqualFold(s.Future, future) || seen[strings.ToUpper(s.Mnemonic)] {
+			continue
+		}
+
+		year, month, err := s.Expiry()
+		if err != nil {
+			return nil, err
+		}
+
+		if quarterly && strings.IndexByte(QuarterlyMonthCodes, MonthCodes[month-1]) < 0 {
+			continue
+		}
+
+		roll, err := s.RollDate()
+		if err != nil {
+			return nil, err
+		}
+
+		seen[strings.ToUpper(s.Mnemonic)] = true
+		contracts = append(contracts, contract{sym: s, year: year, month: month, roll: roll})
+	}
+
+	if len(contracts) == 0 {
+		return nil, fmt.Errorf("no contracts of future '%s'", future)
+	}
+
+	sort.Slice(contracts, func(i, j int) bool {
+		if contracts[i].year != contracts[j].year {
+			return contracts[i].year < contracts[j].year
+		}
+		return contracts[i].month < contracts[j].month
+	})
+
+	cal := &RollCalendar{Future: strings.ToUpper(future), Contracts: make([]FutureSymbol, 0, len(contracts)), Rolls: make([]Roll, 0)}
+	for i, c := range contracts {
+		cal.Contracts = append(cal.Contracts, c.sym)
+		if i > 0 {
+			prev := contracts[i-1]
+			if !prev.roll.Before(c.roll) {
+				return nil, fmt.Errorf("roll date %s of %s is not before the roll date %s of %s",
+					prev.roll.Format(dateLayout), prev.sym.Mnemonic, c.roll.Format(dateLayout), c.sym.Mnemonic)
+			}
+
+			cal.Rolls = append(cal.Rolls, Roll{Date: prev.roll, From: prev.sym.Mnemonic, To: c.sym.Mnemonic})
+		}
+	}
+
+	return cal, nil
+}
+
+// ContractAt returns the mnemonic of the front contract at the time.
+func (c *RollCalendar) ContractAt(t time.Time) string {
+	for _, r := range c.Rolls {
+		if t.Before(r.Date) {
+			return r.From
+		}
+	}
+
+	return c.Contracts[len(c.Contracts)-1].Mnemonic
+}
+
+// Adjustment is the back-adjustment mode of a continuous series.
+type Adjustment int
+
+const (
+	// AdjustNone keeps the prices of the contracts, the series has the roll gaps.
+	AdjustNone Adjustment = iota
+
+	// AdjustDifference adds the price differences of the later rolls to the earlier contracts.
+	AdjustDifference
+
+	// AdjustRatio multiplies the earlier contracts by the price ratios of the later rolls.
+	AdjustRatio
+)
+
+// String implements the fmt.Stringer interface.
+func (a Adjustment) String() string {
+	switch a {
+	case AdjustDifference:
+		return "difference"
+	case AdjustRatio:
+		return "ratio"
+	default:
+		return "none"
+	}
+}
+
+// ParseAdjustment parses the "none", "difference" or "ratio" adjustment.
+func ParseAdjustment(s string) (Adjustment, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "none", "unadjusted":
+		return AdjustNone, nil
+	case "difference", "diff":
+		return AdjustDifference, nil
+	case "ratio":
+		return AdjustRatio, nil
+	default:
+		return AdjustNone, fmt.Errorf("unknown adjustment '%s', expected none, difference or ratio", s)
+	}
+}
+
+// ContinuousTrade is a trade of a continuous series.
+type ContinuousTrade struct {
+	RealtimeEntryParsed
+
+	// Contract is the mnemonic of the contract of the trade.
+	Contract string
+
+	// RawPrice is the unadjusted price, the Price is adjusted.
+	RawPrice float64
+}
+
+// lastBefore returns the price of the last trade before the time.
+func lastBefore(trades []RealtimeEntryParsed, t time.Time) (float64, bool) {
+	i := sort.Search(len(trades), func(i int) bool { return !trades[i].Time.Before(t) })
+	if i == 0 {
+		return 0, false
+	}
+
+	return trades[i-1].Price, true
+}
+
+// Continuous builds the continuous series from the time-ordered trades of the contracts by mnemonic.
+//
+// The trades of a contract are used from the previous roll date until its roll date.
+// The back-adjustment keeps the last contract as is and adjusts the earlier contracts by the roll gaps,
+// the gap of a roll is between the last trades of both contracts before the roll date.
+// The leading contracts without trades are skipped, the size-0 indicator rows are not trades and are dropped.
+func (c *RollCalendar) Continuous(trades map[string][]RealtimeEntryParsed, adj Adjustment) ([]ContinuousTrade, error) {
+	trades = withoutIndicatorRows(trades)
+	first := 0
+	for first < len(c.Contracts)-1 && len(trades[c.Contracts[first].Mnemonic]) == 0 {
+		first++
+	}
+
+	n := len(c.Contracts)
+	offsets := make([]float64, n)
+	factors := make([]float64, n)
+	offsets[n-1], factors[n-1] = 0, 1
+	for i := n - 2; i >= first; i-- {
+		offsets[i], factors[i] = offsets[i+1], factors[i+1]
+		if adj == AdjustNone {
+			continue
+		}
+
+		r := c.Rolls[i]
+		old, ok := lastBefore(trades[r.From], r.Date)
+		if !ok {
+			return nil, fmt.Errorf("no %s trades before the roll to %s on %s", r.From, r.To, r.Date.Format(dateLayout))
+		}
+
+		next, ok := lastBefore(trades[r.To], r.Date)
+		if !ok {
+			return nil, fmt.Errorf("no %s trades before the roll from %s on %s", r.To, r.From, r.Date.Format(dateLayout))
+		}
+
+		if old == 0 {
+			return nil, fmt.Errorf("zero %s price before the roll to %s on %s", r.From, r.To, r.Date.Format(dateLayout))
+		}
+
+		offsets[i] += next - old
+		factors[i] *= next / old
+	}
+
+	series := make([]ContinuousTrade, 0)
+	for i := first; i < n; i++ {
+		mnemonic := c.Contracts[i].Mnemonic
+		for _, t := range trades[mnemonic] {
+			if (i > first && t.Time.Before(c.Rolls[i-1].Date)) || (i < n-1 && !t.Time.Before(c.Rolls[i].Date)) {
+				continue
+			}
+
+			ct := ContinuousTrade{RealtimeEntryParsed: t, Contract: mnemonic, RawPrice: t.Price}
+			switch adj {
+			case AdjustDifference:
+				ct.Price = t.Price + offsets[i]
+			case AdjustRatio:
+				ct.Price = t.Price * factors[i]
+			}
+
+			series = append(series, ct)
+		}
+	}
+
+	return series, nil
+}
+
+// withoutIndicatorRows returns the trades without the size-0 "Open" indicator rows.
+// The archive csv has no indicator, so the rows are told apart by the zero size.
+func withoutIndicatorRows(trades map[string][]RealtimeEntryParsed) map[string][]RealtimeEntryParsed {
+	filtered := make(map[string][]RealtimeEntryParsed, len(trades))
+	for mnemonic, entries := range trades {
+		kept := make([]RealtimeEntryParsed, 0, len(entries))
+		for _, e := range entries {
+			if e.Size != 0 && !strings.EqualFold(e.Indicator, "Open") {
+				kept = append(kept, e)
+			}
+		}
+
+		filtered[mnemonic] = kept
+	}
+
+	return filtered
+}
+
+// ContinuousCSV converts the continuous series to the "2006-01-02 15:04:05;price;size;key;contract;raw price" lines.
+func ContinuousCSV(series []ContinuousTrade) []string {
+	const layout = "2006-01-02 15:04:05"
+
+	csv := make([]string, 0, len(series))
+	for _, p := range series {
+		csv = append(csv, fmt.Sprintf("%s;%v;%v;%v;%s;%v\n", p.Time.Format(layout), p.Price, p.Size, p.Key, p.Contract, p.RawPrice))
+	}
+
+	return csv
+}
+
+// parseCSV parses the "2006-01-02 15:04:05;price;size;key" lines of an archived trade csv.
+func parseCSV(r io.Reader, name string) ([]RealtimeEntryParsed, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read '%s': %w", name, err)
+	}
+
+	entries := make([]RealtimeEntryParsed, 0)
+	for i, line := range strings.Split(string(b), "\n") {
+		line = strings.TrimSpace(line)
+		if line == empty {
+			continue
+		}
+
+		f := strings.Split(line, ";")
+		if len(f) < 4 {
+			return nil, fmt.Errorf("'%s' line %d: expected 4 fields: '%s'", name, i+1, line)
+		}
+
+		t, err := time.Parse("2006-01-02 15:04:05", f[0])
+		if err != nil {
+			return nil, fmt.Errorf("'%s' line %d: cannot parse time '%s': %w", name, i+1, f[0], err)
+		}
+
+		p, err := strconv.ParseFloat(f[1], 64)
+		if err != nil {
+			return nil, fmt.Errorf("'%s' line %d: cannot parse price '%s': %w", name, i+1, f[1], err)
+		}
+
+		v, err := strconv.Atoi(f[2])
+		if err != nil {
+			return nil, fmt.Errorf("'%s' line %d: cannot parse size '%s': %w", name, i+1, f[2], err)
+		}
+
+		k, err := strconv.ParseInt(f[3], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("'%s' line %d: cannot parse key '%s': %w", name, i+1, f[3], err)
+		}
+
+		entries = append(entries, RealtimeEntryParsed{Key: k, Time: t, Price: p, Size: v})
+	}
+
+	return entries, nil
+}
+
+// ReadArchive reads the trades of the "2006-01-02_trade.csv" entry of a "2006-01-02_trade.zip" archive.
+func ReadArchive(fileName string) ([]RealtimeEntryParsed, error) {
+	z, err := zip.OpenReader(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open '%s': %w", fileName, err)
+	}
+	defer z.Close()
+
+	entries := make([]RealtimeEntryParsed, 0)
+	for _, f := range z.File {
+		if !strings.HasSuffix(f.Name, "_trade.csv") {
+			continue
+		}
+
+		r, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("cannot open '%s' entry '%s': %w", fileName, f.Name, err)
+		}
+
+		e, err := parseCSV(r, f.Name)
+		r.Close()
+		if err != nil {
+			return nil, fmt.Errorf("cannot read '%s': %w", fileName, err)
+		}
+
+		entries = append(entries, e...)
+	}
+
+	return entries, nil
+}
+
+// ReadContractTrades reads the trade archives of a contract folder, e.g. "xcme/es/esm23",
+// and returns the trades deduplicated by key in time order.
+func ReadContractTrades(folder string) ([]RealtimeEntryParsed, error) {
+	files, err := filepath.Glob(filepath.Join(folder, "*_trade*.zip"))
+	if err != nil {
+		return nil, fmt.Errorf("cannot list '%s': %w", folder, err)
+	}
+
+	sort.Strings(files)
+	entries := make([]RealtimeEntryParsed, 0)
+	for _, f := range files {
+		e, err := ReadArchive(f)
+		if err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, e...)
+	}
+
+	entries, _ = DedupeByKey(entries)
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Time.Before(entries[j].Time)
+	})
+
+	return entries, nil
+}
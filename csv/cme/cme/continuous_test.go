@@ -0,0 +1,216 @@
+package cme
+
+import (
+	"archive/zip"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestContractMonthOf(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		mnemonic  string
+		tradeDate time.Time
+		year      int
+		month     time.Month
+	}{
+		{"ESM23", date(2023, 1, 5), 2023, time.June},
+		{"nqz4", date(2024, 3, 1), 2024, time.December},
+		{"NQZ4", date(2020, 9, 18), 2024, time.December},
+		{"ESH0", date(2029, 12, 20), 2030, time.March},
+		{"ESM3", date(2024, 1, 2), 2033, time.June},
+		{"MESH25", date(2024, 12, 20), 2025, time.March},
+	}
+
+	for _, tt := range tests {
+		if year, month, err := ContractMonthOf(tt.mnemonic, tt.tradeDate); err != nil || year != tt.year || month != tt.month {
+			t.Errorf("%s: got %d %s, error %v", tt.mnemonic, year, month, err)
+		}
+	}
+
+	for _, m := range []string{"ES", "ESA23", "ES123", "M23"} {
+		if _, _, err := ContractMonthOf(m, date(2024, 1, 2)); err == nil {
+			t.Errorf("%s: expected an error", m)
+		}
+	}
+}
+
+func TestExpiry(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		symbol FutureSymbol
+		year   int
+	}{
+		{FutureSymbol{Mnemonic: "ESM3", ContractMonth: "JUN 2023"}, 2023},
+		{FutureSymbol{Mnemonic: "ESM3", FirstTradeDate: "2022-03-18", LastTradeDate: "2023-06-16"}, 2023},
+		{FutureSymbol{Mnemonic: "ESM3", LastTradeDate: "2033-06-17"}, 2033},
+	}
+
+	for _, tt := range tests {
+		if year, month, err := tt.symbol.Expiry(); err != nil || year != tt.year || month != time.June {
+			t.Errorf("%+v: got %d %s, error %v", tt.symbol, year, month, err)
+		}
+	}
+}
+
+func TestRollDate(t *testing.T) {
+	t.Parallel()
+
+	s := FutureSymbol{Mnemonic: "ESM24", RolloverDate: "2024-06-14"}
+	if d, err := s.RollDate(); err != nil || !d.Equal(date(2024, 6, 14)) {
+		t.Errorf("expected the rollover date, got %v, error %v", d, err)
+	}
+
+	// The third Friday of June 2023 is the 16th.
+	s = FutureSymbol{Mnemonic: "ESM23", ContractMonth: "JUN 2023"}
+	if d, err := s.RollDate(); err != nil || !d.Equal(date(2023, 6, 8)) {
+		t.Errorf("expected 2023-06-08, got %v, error %v", d, err)
+	}
+}
+
+func testCalendar(t *testing.T) *RollCalendar {
+	symbols := []FutureSymbol{
+		{Future: "ES", Mnemonic: "ESU24", RolloverDate: "2024-09-12"},
+		{Future: "NQ", Mnemonic: "NQM24"},
+		{Future: "ES", Mnemonic: "ESM24", RolloverDate: "2024-06-14"},
+		{Future: "ES", Mnemonic: "ESN24"},
+	}
+
+	cal, err := NewRollCalendar(symbols, "es", true)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	return cal
+}
+
+func TestNewRollCalendar(t *testing.T) {
+	t.Parallel()
+
+	cal := testCalendar(t)
+	if len(cal.Contracts) != 2 || len(cal.Rolls) != 1 || cal.Rolls[0].From != "ESM24" || cal.Rolls[0].To != "ESU24" {
+		t.Fatalf("unexpected calendar %v", cal)
+	}
+
+	if c := cal.ContractAt(date(2024, 6, 13).Add(23 * time.Hour)); c != "ESM24" {
+		t.Errorf("expected ESM24 before the roll, got %s", c)
+	}
+
+	if c := cal.ContractAt(date(2024, 6, 14)); c != "ESU24" {
+		t.Errorf("expected ESU24 on the roll date, got %s", c)
+	}
+
+	if _, err := NewRollCalendar(nil, "ES", true); err == nil {
+		t.Errorf("expected an error without contracts")
+	}
+}
+
+func TestContinuous(t *testing.T) {
+	t.Parallel()
+
+	cal := testCalendar(t)
+	// The size-0 "Open" indicator rows, with or without the indicator, would change the roll gap.
+	trades := map[string][]RealtimeEntryParsed{
+		"ESM24": {
+			{Key: 1, Time: date(2024, 6, 12), Price: 80, Size: 1},
+			{Key: 2, Time: date(2024, 6, 13), Price: 100, Size: 2},
+			{Key: 6, Time: date(2024, 6, 13).Add(time.Hour), Price: 90},
+			{Key: 3, Time: date(2024, 6, 14), Price: 101, Size: 1},
+		},
+		"ESU24": {
+			{Key: 4, Time: date(2024, 6, 13), Price: 125, Size: 1},
+			{Key: 7, Time: date(2024, 6, 13).Add(time.Hour), Price: 150, Indicator: "Open"},
+			{Key: 5, Time: date(2024, 6, 14), Price: 130, Size: 3},
+		},
+	}
+
+	tests := []struct {
+		adj    Adjustment
+		prices []float64
+	}{
+		{AdjustNone, []float64{80, 100, 130}},
+		{AdjustDifference, []float64{105, 125, 130}},
+		{AdjustRatio, []float64{100, 125, 130}},
+	}
+
+	for _, tt := range tests {
+		series, err := cal.Continuous(trades, tt.adj)
+		if err != nil {
+			t.Fatalf("%s: unexpected error %v", tt.adj, err)
+		}
+
+		prices := make([]float64, 0, len(series))
+		for _, s := range series {
+			prices = append(prices, s.Price)
+		}
+
+		if fmt.Sprint(prices) != fmt.Sprint(tt.prices) {
+			t.Errorf("%s: expected %v, got %v", tt.adj, tt.prices, prices)
+		}
+
+		if series[1].Contract != "ESM24" || series[1].RawPrice != 100 || series[2].Contract != "ESU24" {
+			t.Errorf("%s: unexpected contracts %v", tt.adj, series)
+		}
+	}
+
+	// The gap cannot be measured without the next contract trades before the roll.
+	trades["ESU24"] = trades["ESU24"][1:]
+	if len(trades["ESM24"]) != 4 {
+		t.Errorf("expected the trades of the caller unchanged, got %v", trades["ESM24"])
+	}
+
+	if _, err := cal.Continuous(trades, AdjustRatio); err == nil {
+		t.Errorf("expected an error without the overlap")
+	}
+
+	if _, err := cal.Continuous(trades, AdjustNone); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestReadContractTrades(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	write := func(name, entry, content string) {
+		f, err := os.Create(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+
+		w := zip.NewWriter(f)
+		e, _ := w.Create(entry)
+		e.Write([]byte(content))
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write("2024-06-11_trade.zip", "2024-06-11_trade.csv", "2024-06-11 10:00:00;5300.25;1;2\n2024-06-11 09:00:00;5300;2;1\n")
+	write("2024-06-11_trade(1).zip", "2024-06-11_trade.csv", "2024-06-11 10:00:00;5300.25;1;2\n2024-06-11 11:00:00;5301;3;3\n")
+
+	trades, err := ReadContractTrades(dir)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	csv := convertToCSV(trades)
+	expected := []string{
+		"2024-06-11 09:00:00;5300;2;1\n",
+		"2024-06-11 10:00:00;5300.25;1;2\n",
+		"2024-06-11 11:00:00;5301;3;3\n",
+	}
+	if fmt.Sprint(csv) != fmt.Sprint(expected) {
+		t.Errorf("expected %v, got %v", expected, csv)
+	}
+}
@@ -0,0 +1,288 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"compressed/internal"
+)
+
+// input is an input file with its path relative to the input folder.
+type input struct {
+	name string
+	rel  string
+}
+
+// result is the outcome of processing a single file.
+type result struct {
+	in       string
+	out      string
+	inSize   int64
+	outSize  int64
+	elapsed  time.Duration
+	verified bool
+	trashed  bool
+	err      error
+}
+
+// rename moves the files, replaced in the tests.
+var rename = os.Rename
+
+type copier interface {
+	CopyTo(dst io.Writer) (int64, error)
+}
+
+// expand turns file names, glob patterns and folders into a list of input files.
+// Folders are searched recursively for text files when compressing
+// and for compressed files when decompressing.
+func (p *packer) expand(args []string) ([]input, error) {
+	var trash string
+	if p.trash != "" {
+		var err error
+		if trash, err = filepath.Abs(p.trash); err != nil {
+			return nil, fmt.Errorf("cannot resolve trash folder %q: %w", p.trash, err)
+		}
+	}
+
+	seen := make(map[string]bool)
+	var files []input
+
+	add := func(name, rel string) {
+		if abs, err := filepath.Abs(name); err == nil {
+			if seen[abs] || (trash != "" && isWithin(abs, trash)) {
+				return
+			}
+
+			seen[abs] = true
+		}
+
+		files = append(files, input{name: name, rel: rel})
+	}
+
+	for _, arg := range args {
+		matches, err := filepath.Glob(arg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid glob pattern %q: %w", arg, err)
+		}
+
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no files match %q", arg)
+		}
+
+		for _, match := range matches {
+			fi, err := os.Stat(match)
+			if err != nil {
+				return nil, fmt.Errorf("cannot stat %q: %w", match, err)
+			}
+
+			if !fi.IsDir() {
+				add(match, filepath.Base(match))
+				continue
+			}
+
+			err = filepath.WalkDir(match, func(path string, d fs.DirEntry, err error) error {
+				if err != nil {
+					return err
+				}
+
+				if d.IsDir() || !d.Type().IsRegular() {
+					return nil
+				}
+
+				compressed := internal.CodecFromExt(path) != internal.CodecNone
+				if compressed != p.decompress {
+					return nil
+				}
+
+				rel, err := filepath.Rel(match, path)
+				if err != nil {
+					return err
+				}
+
+				add(path, rel)
+				return nil
+			})
+			if err != nil {
+				return nil, fmt.Errorf("cannot walk %q folder: %w", match, err)
+			}
+		}
+	}
+
+	return files, nil
+}
+
+// run processes the files on a bounded pool of workers
+// and returns the results in the input order.
+func (p *packer) run(files []input, jobs int) []result {
+	results := make([]result, len(files))
+	indices := make(chan int)
+
+	var wg sync.WaitGroup
+	for i := 0; i < jobs && i < len(files); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range indices {
+				results[j] = p.pack(files[j])
+			}
+		}()
+	}
+
+	for i := range files {
+		indices <- i
+	}
+
+	close(indices)
+	wg.Wait()
+	return results
+}
+
+// verify decompresses the file and compares its checksum with the expected one.
+func verify(fileName string, expected []byte) error {
+	s, err := internal.OpenScanner(fileName)
+	if err != nil {
+		return fmt.Errorf("cannot verify %q: %w", fileName, err)
+	}
+	defer s.Close()
+
+	c, ok := s.(copier)
+	if !ok {
+		return fmt.Errorf("cannot verify %q: scanner cannot copy", fileName)
+	}
+
+	h := sha256.New()
+	if _, err := c.CopyTo(h); err != nil {
+		return fmt.Errorf("cannot verify %q: %w", fileName, err)
+	}
+
+	if actual := h.Sum(nil); !bytes.Equal(actual, expected) {
+		return fmt.Errorf("verification of %q failed: checksum %x, expected %x", fileName, actual, expected)
+	}
+
+	return nil
+}
+
+// moveToTrash moves the input file to the trash folder keeping its relative path.
+func moveToTrash(in input, trash string) error {
+	target := filepath.Join(trash, in.rel)
+	if _, err := os.Stat(target); !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("trash file %q already exists", target)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return fmt.Errorf("cannot create trash folder: %w", err)
+	}
+
+	if err := rename(in.name, target); err == nil {
+		return nil
+	}
+
+	// The trash folder may be on another device.
+	if err := copyFile(in.name, target); err != nil {
+		os.Remove(target)
+		return fmt.Errorf("cannot move %q to trash: %w", in.name, err)
+	}
+
+	if err := os.Remove(in.name); err != nil {
+		return fmt.Errorf("cannot remove %q: %w", in.name, err)
+	}
+
+	return nil
+}
+
+func copyFile(src, dst string) error {
+	r, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	w, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(w, r); err != nil {
+		w.Close()
+		return err
+	}
+
+	return w.Close()
+}
+
+func isWithin(path, folder string) bool {
+	rel, err := filepath.Rel(folder, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+func printSummary(results []result) {
+	width := len("input")
+	for _, r := range results {
+		if len(r.in) > width {
+			width = len(r.in)
+		}
+	}
+
+	fmt.Printf("%-*s %14s %14s %7s %12s  %s\n", width, "input", "input size", "output size", "ratio", "elapsed", "status")
+
+	var inTotal, outTotal int64
+	var elapsedTotal time.Duration
+	failed := 0
+
+	for _, r := range results {
+		status := "ok"
+		switch {
+		case r.err != nil:
+			status = "error: " + r.err.Error()
+			failed++
+		case r.trashed:
+			status = "verified, trashed"
+		case r.verified:
+			status = "verified"
+		}
+
+		fmt.Printf("%-*s %14d %14d %7s %12s  %s\n", width, r.in, r.inSize, r.outSize,
+			ratio(r.inSize, r.outSize), r.elapsed.Round(time.Millisecond), status)
+
+		if r.err == nil {
+			inTotal += r.inSize
+			outTotal += r.outSize
+		}
+
+		elapsedTotal += r.elapsed
+	}
+
+	fmt.Printf("%-*s %14d %14d %7s %12s  %d ok, %d failed\n", width, "total", inTotal, outTotal,
+		ratio(inTotal, outTotal), elapsedTotal.Round(time.Millisecond), len(results)-failed, failed)
+
+	if failed > 0 {
+		failedNames := make([]string, 0, failed)
+		for _, r := range results {
+			if r.err != nil {
+				failedNames = append(failedNames, r.in)
+			}
+		}
+
+		sort.Strings(failedNames)
+		fail(fmt.Sprintf("%d files failed: %v", failed, failedNames))
+	}
+}
+
+// ratio returns the output to input size ratio in percents.
+func ratio(in, out int64) string {
+	if in == 0 {
+		return "-"
+	}
+
+	return fmt.Sprintf("%.1f%%", float64(out)*100/float64(in))
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"crypto/sha256"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"testing"
+
+	"compressed/internal"
+)
+
+func writeTestFile(t *testing.T, name, content string) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := internal.CreateWriter(name, internal.WriterOptions{})
+	if err != nil {
+		t.Fatalf("cannot create writer: %v", err)
+	}
+
+	if err := w.WriteString(content); err != nil {
+		t.Fatalf("cannot write: %v", err)
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatalf("cannot close writer: %v", err)
+	}
+}
+
+func inputNames(files []input) []string {
+	names := make([]string, 0, len(files))
+	for _, f := range files {
+		names = append(names, filepath.ToSlash(f.rel))
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+func TestExpand(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	for _, f := range []string{"a.csv", "b.csv", "c.txt", "d.csv.gz", "sub/e.csv", "sub/f.csv.zst", "trash/g.csv"} {
+		writeTestFile(t, filepath.Join(dir, f), "x\n")
+	}
+
+	p := &packer{}
+	files, err := p.expand([]string{filepath.Join(dir, "*.csv"), filepath.Join(dir, "a.csv")})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if names := strings.Join(inputNames(files), ","); names != "a.csv,b.csv" {
+		t.Errorf("expected the glob matches once, got %s", names)
+	}
+
+	p = &packer{trash: filepath.Join(dir, "trash")}
+	files, err = p.expand([]string{dir})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if names := strings.Join(inputNames(files), ","); names != "a.csv,b.csv,c.txt,sub/e.csv" {
+		t.Errorf("expected the text files outside of the trash, got %s", names)
+	}
+
+	p = &packer{decompress: true}
+	files, err = p.expand([]string{dir})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if names := strings.Join(inputNames(files), ","); names != "d.csv.gz,sub/f.csv.zst" {
+		t.Errorf("expected the compressed files, got %s", names)
+	}
+
+	if _, err := p.expand([]string{filepath.Join(dir, "*.xz")}); err == nil {
+		t.Errorf("expected an error for a glob without matches")
+	}
+
+	if _, err := p.expand([]string{filepath.Join(dir, "[")}); err == nil {
+		t.Errorf("expected an error for an invalid glob")
+	}
+}
+
+func TestVerify(t *testing.T) {
+	t.Parallel()
+
+	name := filepath.Join(t.TempDir(), "a.csv.gz")
+	content := "a;1\nb;2\n"
+	writeTestFile(t, name, content)
+
+	sum := sha256.Sum256([]byte(content))
+	if err := verify(name, sum[:]); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	other := sha256.Sum256([]byte("a;1\n"))
+	if err := verify(name, other[:]); err == nil || !strings.Contains(err.Error(), "verification") {
+		t.Errorf("expected a checksum mismatch, got %v", err)
+	}
+
+	if err := verify(name+".missing", sum[:]); err == nil {
+		t.Errorf("expected an error for a missing file")
+	}
+}
+
+func TestMoveToTrashAcrossDevices(t *testing.T) {
+	// Not parallel, the rename function is replaced.
+	rename = func(oldName, newName string) error {
+		return &os.LinkError{Op: "rename", Old: oldName, New: newName, Err: syscall.EXDEV}
+	}
+	defer func() { rename = os.Rename }()
+
+	dir := t.TempDir()
+	name := filepath.Join(dir, "in", "sub", "a.csv")
+	writeTestFile(t, name, "a;1\n")
+
+	trash := filepath.Join(dir, "trash")
+	if err := moveToTrash(input{name: name, rel: filepath.Join("sub", "a.csv")}, trash); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if b, err := os.ReadFile(filepath.Join(trash, "sub", "a.csv")); err != nil || string(b) != "a;1\n" {
+		t.Errorf("expected the copied file, got %q, error %v", b, err)
+	}
+
+	if _, err := os.Stat(name); !os.IsNotExist(err) {
+		t.Errorf("expected the input file removed, error %v", err)
+	}
+
+	writeTestFile(t, name, "a;2\n")
+	if err := moveToTrash(input{name: name, rel: filepath.Join("sub", "a.csv")}, trash); err == nil {
+		t.Errorf("expected an error for an existing trash file")
+	}
+
+	if _, err := os.Stat(name); err != nil {
+		t.Errorf("expected the input file kept, error %v", err)
+	}
+}
+
+func TestPackOutFail(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	name := filepath.Join(dir, "a.csv")
+	writeTestFile(t, name, "a;1\n")
+	writeTestFile(t, name+".gz", "old\n")
+	before, _ := os.ReadFile(name + ".gz")
+
+	p := &packer{out: "fail", verify: true, opts: internal.WriterOptions{Codec: internal.CodecGz}}
+	r := p.pack(input{name: name, rel: "a.csv"})
+	if r.err == nil || !strings.Contains(r.err.Error(), "already exists") {
+		t.Errorf("expected an existing output error, got %v", r.err)
+	}
+
+	if after, _ := os.ReadFile(name + ".gz"); string(after) != string(before) {
+		t.Errorf("expected the existing output unchanged")
+	}
+
+	writeTestFile(t, filepath.Join(dir, "b.csv"), "b;1\n")
+	if r := p.pack(input{name: filepath.Join(dir, "b.csv"), rel: "b.csv"}); r.err != nil || !r.verified {
+		t.Errorf("expected a verified output, got %+v", r)
+	}
+}
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	var files []input
+	for _, f := range []string{"a.csv", "b.csv", "c.csv", "d.csv"} {
+		name := filepath.Join(dir, f)
+		writeTestFile(t, name, f+"\n")
+		files = append(files, input{name: name, rel: f})
+	}
+
+	p := &packer{out: "overwrite", verify: true, opts: internal.WriterOptions{Codec: internal.CodecZst}}
+	results := p.run(files, 2)
+	for i, r := range results {
+		if r.in != files[i].name || r.out != files[i].name+".zst" || r.err != nil || !r.verified {
+			t.Errorf("unexpected result %d: %+v", i, r)
+		}
+	}
+}
@@ -0,0 +1,363 @@
+package main
+
+import (
+	"crypto/sha256"
+	"errors"
+	"flag"
+	"fmt"
+	"hash"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+
+	"compressed/internal"
+	"compressed/internal/mmap"
+)
+
+const extCsv = ".csv"
+
+// packer holds the settings shared by all files of a run.
+type packer struct {
+	decompress bool
+	mmap       string
+	out        string
+	verify     bool
+	trash      string
+	opts       internal.WriterOptions
+}
+
+func main() {
+	decompressPtr := flag.Bool("d", false, "decompress instead of compress")
+	codecPtr := flag.String("codec", "gz", "compression codec: [gz, bz2, xz, zst, lz4]")
+	levelPtr := flag.Int("level", 0, "codec-specific compression level, 0 means the codec default, -1 means no gz compression")
+	workersPtr := flag.Int("workers", 1, "number of parallel compression workers per file")
+	jobsPtr := flag.Int("jobs", runtime.NumCPU(), "number of files processed in parallel")
+	mmapPtr := flag.String("mmap", "none", "use memory mapping: [none, direct, scanner]")
+	outPtr := flag.String("out", "overwrite", "what to do if output file already exists: [overwrite, append, fail]")
+	verifyPtr := flag.Bool("verify", true, "verify every output file by comparing checksums")
+	trashPtr := flag.String("trash", "", "folder to move verified input files to, empty means keep them")
+	timeColumnPtr := flag.Int("timecol", -1, "zero-based time column to write a seekable file, -1 means not seekable")
+	timeLayoutPtr := flag.String("timelayout", "2006-01-02", "time column layout of a seekable file")
+	separatorPtr := flag.String("sep", ",", "column separator of a seekable file")
+	frameSizePtr := flag.Int("framesize", internal.DefaultFrameSize, "uncompressed frame size of a seekable file")
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		usage()
+		fail("expecting input file names, globs or folders as the positional arguments")
+		return
+	}
+
+	if *workersPtr < 1 || *jobsPtr < 1 {
+		fail("number of workers and jobs should be positive")
+		return
+	}
+
+	p := &packer{
+		decompress: *decompressPtr,
+		mmap:       *mmapPtr,
+		out:        *outPtr,
+		verify:     *verifyPtr,
+		trash:      *trashPtr,
+		opts: internal.WriterOptions{
+			Append:  *outPtr == "append",
+			Level:   *levelPtr,
+			Workers: *workersPtr,
+		},
+	}
+
+	switch p.mmap {
+	case "none", "scanner":
+	case "direct":
+		if p.decompress {
+			fail("direct memory mapping is not supported when decompressing")
+			return
+		}
+	default:
+		fail(fmt.Sprintf("unknown mmap value %q", p.mmap))
+		return
+	}
+
+	if p.trash != "" && (!p.verify || p.opts.Append) {
+		fail("moving input files to the trash folder requires verification and no appending")
+		return
+	}
+
+	if *timeColumnPtr >= 0 {
+		if p.decompress {
+			fail("seekable file options are not used when decompressing")
+			return
+		}
+
+		if len(*separatorPtr) != 1 {
+			fail("column separator should be a single character")
+			return
+		}
+
+		p.opts.Time = internal.TimeColumn(*timeColumnPtr, (*separatorPtr)[0], *timeLayoutPtr)
+		p.opts.FrameSize = *frameSizePtr
+	}
+
+	if !p.decompress {
+		var err error
+		if p.opts.Codec, err = internal.ParseCodec(*codecPtr); err != nil {
+			fail(err.Error())
+			return
+		}
+
+		if p.opts.Codec == internal.CodecNone {
+			fail("expecting a compression codec")
+			return
+		}
+	}
+
+	files, err := p.expand(flag.Args())
+	if err != nil {
+		fail(err.Error())
+		return
+	}
+
+	if len(files) == 0 {
+		fail("no input files found")
+		return
+	}
+
+	fmt.Printf("d=%t, codec=%s, level=%d, workers=%d, jobs=%d, mmap=%s, out=%s, verify=%t, trash=%q, files=%d\n",
+		p.decompress, *codecPtr, *levelPtr, *workersPtr, *jobsPtr, p.mmap, p.out, p.verify, p.trash, len(files))
+
+	start := time.Now()
+	results := p.run(files, *jobsPtr)
+	elapsed := time.Since(start)
+
+	printSummary(results)
+	fmt.Printf("elapsed %s\n", elapsed)
+}
+
+func usage() {
+	fmt.Println("usage:")
+	fmt.Println("csvpack {-d} {-codec=[gz, bz2, xz, zst, lz4]} {-level=N} {-workers=N} {-jobs=N}")
+	fmt.Println("        {-mmap=[none, direct, scanner]} {-out=[overwrite, append, fail]}")
+	fmt.Println("        {-verify=[true, false]} {-trash=folder}")
+	fmt.Println("        {-timecol=N {-timelayout=layout} {-sep=c} {-framesize=N}} input...")
+	fmt.Println("-d        - decompress the input files instead of compressing them")
+	fmt.Println("            the codec is detected from the file contents and extension")
+	fmt.Println("-codec    - compression codec, possible values are")
+	fmt.Println("            gz  - gzip, default")
+	fmt.Println("            bz2 - bzip2")
+	fmt.Println("            xz  - xz")
+	fmt.Println("            zst - zstandard")
+	fmt.Println("            lz4 - lz4")
+	fmt.Println("-level    - codec-specific compression level, 0 means the codec default")
+	fmt.Println("            gz 1-9 (default 9, -1 no compression), bz2 1-9 (default 1), zst 1-22 (default 3),")
+	fmt.Println("            lz4 1-9 (default fast), xz has no levels")
+	fmt.Println("-workers  - number of parallel compression workers per file, default is 1")
+	fmt.Println("            only gz supports parallel compression, the input is split")
+	fmt.Println("            into blocks compressed in parallel into a multi-member gzip file")
+	fmt.Println("-jobs     - number of files processed in parallel, default is the number of CPUs")
+	fmt.Println("-mmap     - whether to use memory mapping, possible values are")
+	fmt.Println("            none    - use ordinary file scanner")
+	fmt.Println("                      line terminations will be replaced with LF")
+	fmt.Println("            direct  - pass the memory mapped byte array to the writer directly")
+	fmt.Println("                      line terminations will not be changed")
+	fmt.Println("                      only when compressing")
+	fmt.Println("            scanner - use a scanner on the memory mapped byte array")
+	fmt.Println("                      line terminations will be replaced with LF")
+	fmt.Println("-out      - what to do if output file already exists, possible values are")
+	fmt.Println("            overwrite - overwrite existing file")
+	fmt.Println("            append    - append to existing file, output is not verified")
+	fmt.Println("            fail      - do nothing for this file")
+	fmt.Println("-verify   - decompress every output file and compare its checksum")
+	fmt.Println("            with the checksum of the written data, default is true")
+	fmt.Println("-trash    - folder to move input files to after successful verification")
+	fmt.Println("            the relative paths of files found in input folders are kept")
+	fmt.Println("            empty means input files are kept in place, default is empty")
+	fmt.Println("-timecol  - zero-based time column, if given, a seekable file is written")
+	fmt.Println("            of independently compressed frames with a time index")
+	fmt.Println("            the input lines should be time-ordered, only gz and zst are supported")
+	fmt.Println("            lines with unparsable time column, like headers, are not indexed")
+	fmt.Println("-timelayout - Go layout of the time column, default is '2006-01-02'")
+	fmt.Println("-sep      - column separator, default is ','")
+	fmt.Println("-framesize - uncompressed size of a frame in bytes, default is 4 MiB")
+	fmt.Println("input     - input file names, glob patterns or folders")
+	fmt.Println("            folders are searched recursively for text files when compressing")
+	fmt.Println("            and for compressed files when decompressing")
+	fmt.Println("            when compressing, an output file will have codec extension appended")
+	fmt.Println("            when decompressing, the codec extension will be removed from the output file,")
+	fmt.Println("            or '.csv' extension appended if there is no codec extension")
+	fmt.Println("")
+}
+
+func fail(s string) {
+	fmt.Println("panic: " + s)
+}
+
+// pack compresses or decompresses a single file, verifies
+// the output and moves the input file to the trash folder.
+func (p *packer) pack(in input) result {
+	r := result{in: in.name}
+	start := time.Now()
+	defer func() { r.elapsed = time.Since(start) }()
+
+	if fi, err := os.Stat(in.name); err != nil {
+		r.err = err
+		return r
+	} else {
+		r.inSize = fi.Size()
+	}
+
+	if p.decompress {
+		if r.out, r.err = decompressedName(in.name); r.err != nil {
+			return r
+		}
+	} else {
+		if c := internal.CodecFromExt(in.name); c != internal.CodecNone {
+			r.err = fmt.Errorf("input text file name shouldn't have '%s' extension", c.Ext())
+			return r
+		}
+
+		r.out = in.name + p.opts.Codec.Ext()
+	}
+
+	if p.out == "fail" {
+		if _, err := os.Stat(r.out); !errors.Is(err, os.ErrNotExist) {
+			r.err = fmt.Errorf("output file %q already exists", r.out)
+			return r
+		}
+	}
+
+	h := sha256.New()
+	if p.mmap == "direct" {
+		r.err = mmap2Writer(in.name, r.out, p.opts, h)
+	} else {
+		r.err = scanner2Writer(in.name, r.out, p.decompress, p.mmap == "scanner", p.opts, h)
+	}
+
+	if r.err != nil {
+		return r
+	}
+
+	if fi, err := os.Stat(r.out); err == nil {
+		r.outSize = fi.Size()
+	}
+
+	if !p.verify || p.opts.Append {
+		return r
+	}
+
+	if r.err = verify(r.out, h.Sum(nil)); r.err != nil {
+		return r
+	}
+
+	r.verified = true
+	if p.trash != "" {
+		if r.err = moveToTrash(in, p.trash); r.err == nil {
+			r.trashed = true
+		}
+	}
+
+	return r
+}
+
+func decompressedName(fileName string) (string, error) {
+	c, err := internal.DetectCodec(fileName)
+	if err != nil {
+		return "", err
+	}
+
+	if c == internal.CodecNone {
+		return "", fmt.Errorf("input file %q is not compressed", fileName)
+	}
+
+	if ext := c.Ext(); strings.HasSuffix(strings.ToLower(fileName), ext) {
+		return fileName[:len(fileName)-len(ext)], nil
+	}
+
+	return fileName + extCsv, nil
+}
+
+func mmap2Writer(fileName, outName string, opts internal.WriterOptions, h hash.Hash) error {
+	m, err := mmap.OpenFile(fileName, mmap.ReadOnly)
+	if err != nil {
+		return fmt.Errorf("cannot mmap %q file: %w", fileName, err)
+	}
+	defer m.Close()
+
+	if err := m.Advise(mmap.AdviceSequential); err != nil {
+		return fmt.Errorf("cannot advise %q file: %w", fileName, err)
+	}
+
+	w, err := internal.CreateWriter(outName, opts)
+	if err != nil {
+		return fmt.Errorf("cannot create file writer: %w", err)
+	}
+
+	h.Write(m.Data())
+	if err := w.WriteBytes(m.Data()); err != nil {
+		w.Close()
+		return fmt.Errorf("file writer: %w", err)
+	}
+
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("file writer: %w", err)
+	}
+
+	return nil
+}
+
+func scanner2Writer(fileName, outName string, decompress, useMmap bool, opts internal.WriterOptions, h hash.Hash) error {
+	var (
+		s   internal.LineScanner
+		err error
+	)
+
+	switch {
+	case decompress && useMmap:
+		s, err = internal.OpenScannerMmap(fileName)
+	case decompress:
+		s, err = internal.OpenScanner(fileName)
+	case useMmap:
+		s, err = internal.NewTextFileScannerMmap(fileName)
+	default:
+		s, err = internal.NewTextFileScanner(fileName)
+	}
+
+	if err != nil {
+		return fmt.Errorf("cannot create file scanner for the %q file: %w", fileName, err)
+	}
+	defer s.Close()
+
+	w, err := internal.CreateWriter(outName, opts)
+	if err != nil {
+		return fmt.Errorf("cannot create file writer: %w", err)
+	}
+
+	newLine := []byte{'\n'}
+
+	for s.Scan() {
+		bs := s.Bytes()
+		h.Write(bs)
+		h.Write(newLine)
+
+		if err := w.WriteBytes(bs); err != nil {
+			w.Close()
+			return fmt.Errorf("file writer: %w", err)
+		}
+
+		if err := w.WriteBytes(newLine); err != nil {
+			w.Close()
+			return fmt.Errorf("file writer: %w", err)
+		}
+	}
+
+	if err := s.Err(); err != nil {
+		w.Close()
+		return fmt.Errorf("cannot scan: %w", err)
+	}
+
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("file writer: %w", err)
+	}
+
+	return nil
+}
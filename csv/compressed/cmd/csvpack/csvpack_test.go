@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"compressed/internal"
+)
+
+func TestPackRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	var sb strings.Builder
+	for i := 0; i < 20000; i++ {
+		fmt.Fprintf(&sb, "2023-06-22 15:30:%02d;%d.%03d;%d\n", i%60, 100+i%7, i%1000, i)
+	}
+	content := sb.String()
+
+	for _, codec := range []internal.Codec{internal.CodecZst, internal.CodecLz4} {
+		for _, mmap := range []string{"none", "direct", "scanner"} {
+			codec, mmap := codec, mmap
+			t.Run(codec.String()+" "+mmap, func(t *testing.T) {
+				t.Parallel()
+
+				dir := t.TempDir()
+				name := filepath.Join(dir, "a.csv")
+				if err := os.WriteFile(name, []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+
+				p := &packer{mmap: mmap, out: "overwrite", verify: true, opts: internal.WriterOptions{Codec: codec}}
+				r := p.pack(input{name: name, rel: "a.csv"})
+				if r.err != nil || !r.verified || r.out != name+codec.Ext() {
+					t.Fatalf("unexpected compression result %+v", r)
+				}
+
+				if c, err := internal.DetectCodec(r.out); err != nil || c != codec {
+					t.Errorf("expected codec %v, got %v, error %v", codec, c, err)
+				}
+
+				// The direct memory mapping is compressing only.
+				for _, dmmap := range []string{"none", "scanner"} {
+					if err := os.Remove(name); err != nil {
+						t.Fatal(err)
+					}
+
+					d := &packer{decompress: true, mmap: dmmap, out: "fail", verify: true}
+					dr := d.pack(input{name: r.out, rel: "a.csv" + codec.Ext()})
+					if dr.err != nil || !dr.verified || dr.out != name {
+						t.Fatalf("mmap %s: unexpected decompression result %+v", dmmap, dr)
+					}
+
+					if b, err := os.ReadFile(name); err != nil || string(b) != content {
+						t.Errorf("mmap %s: expected the original content, got %d bytes, error %v", dmmap, len(b), err)
+					}
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,503 @@
+// Package dialect decodes delimited text files line by line into typed rows.
+//
+// It sits on top of the compressed line scanners, so the input file
+// may be plain text or compressed with any supported codec.
+// The fields of a line are not copied into strings, a Reader reuses
+// the same Row for every line.
+package dialect
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unsafe"
+
+	"compressed/internal"
+)
+
+// Type is a type of a column.
+type Type int
+
+const (
+	String Type = iota // String is a text column.
+	Float              // Float is a 64-bit floating point column.
+	Int                // Int is a 64-bit integer column.
+	Time               // Time is a time column parsed with the column layout.
+)
+
+// String implements the fmt.Stringer interface.
+func (t Type) String() string {
+	switch t {
+	case String:
+		return "string"
+	case Float:
+		return "float"
+	case Int:
+		return "int"
+	case Time:
+		return "time"
+	default:
+		return fmt.Sprintf("Type(%d)", int(t))
+	}
+}
+
+// Column describes a single column of a row.
+type Column struct {
+	// Name is used in error messages and to look the column up.
+	Name string
+
+	// Type is the type of the column values.
+	Type Type
+
+	// Layout is a Go time layout of a Time column.
+	Layout string
+
+	// Location is the location of a Time column without a time zone,
+	// nil means UTC.
+	Location *time.Location
+
+	// Optional columns may be missing or empty.
+	Optional bool
+
+	// Default is the textual value of an optional column
+	// when it is missing or empty.
+	Default string
+}
+
+// Dialect describes the format of a delimited text file.
+type Dialect struct {
+	// Comma is the field delimiter, zero means ';'.
+	Comma byte
+
+	// Comment, if not zero, is the first character of comment lines.
+	Comment byte
+
+	// Header skips the very first non-comment line.
+	Header bool
+
+	// Quote, if not zero, is the character which may enclose a field
+	// containing delimiters. Two quotes inside a quoted field mean one quote.
+	Quote byte
+}
+
+// Default is the dialect used by most of the converters.
+var Default = Dialect{Comma: ';', Comment: '#', Header: true, Quote: '"'}
+
+// ParseError is an error of a specific line and column.
+type ParseError struct {
+	Line   int    // Line is a one-based line number.
+	Column string // Column is the column name, empty if not column-specific.
+	Field  string // Field is the offending field value.
+	Err    error
+}
+
+// Error implements the error interface.
+func (e *ParseError) Error() string {
+	if e.Column == "" {
+		return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+	}
+
+	return fmt.Sprintf("line %d: column '%s': cannot parse '%s': %v", e.Line, e.Column, e.Field, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+var errMissing = errors.New("missing required value")
+var errUnterminatedQuote = errors.New("unterminated quoted field")
+
+type value struct {
+	present bool
+	f       float64
+	i       int64
+	t       time.Time
+	b       []byte
+}
+
+// Row is a decoded line. It is valid until the next Reader.Next call.
+type Row struct {
+	line   int
+	values []value
+}
+
+// Line returns the one-based line number of the row.
+func (r *Row) Line() int {
+	return r.line
+}
+
+// Len returns the number of columns.
+func (r *Row) Len() int {
+	return len(r.values)
+}
+
+// Present tells if the i-th column has a non-empty field in the line.
+func (r *Row) Present(i int) bool {
+	return r.values[i].present
+}
+
+// Float returns the value of the i-th Float column.
+func (r *Row) Float(i int) float64 {
+	return r.values[i].f
+}
+
+// Int returns the value of the i-th Int column.
+func (r *Row) Int(i int) int64 {
+	return r.values[i].i
+}
+
+// Time returns the value of the i-th Time column.
+func (r *Row) Time(i int) time.Time {
+	return r.values[i].t
+}
+
+// Bytes returns the raw field of the i-th column.
+// The slice is only valid until the next Reader.Next call.
+func (r *Row) Bytes(i int) []byte {
+	return r.values[i].b
+}
+
+// String returns a copy of the raw field of the i-th column.
+func (r *Row) String(i int) string {
+	return string(r.values[i].b)
+}
+
+// Reader decodes the lines of a delimited text file into rows.
+type Reader struct {
+	s        internal.LineScanner
+	d        Dialect
+	cols     []Column
+	defaults []value
+	fields   [][]byte
+	unquoted []byte
+	row      Row
+	line     int
+	header   bool
+	err      error
+}
+
+// HasExt tells if the file name ends with the extension, possibly followed
+// by the extension of a compression codec, e.g. "a.csv.gz" has the ".csv" extension.
+func HasExt(fileName, ext string) bool {
+	return strings.HasSuffix(internal.TrimExt(fileName), ext)
+}
+
+// TrimExt returns the file name without the extension of a compression codec,
+// e.g. "a.csv" for "a.csv.gz".
+func TrimExt(fileName string) string {
+	return internal.TrimExt(fileName)
+}
+
+// Open opens a plain or compressed file detecting its codec.
+func Open(fileName string, d Dialect, cols []Column) (*Reader, error) {
+	s, err := internal.OpenScanner(fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := NewReader(s, d, cols)
+	if err != nil {
+		s.Close()
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// NewReader creates a reader on top of a line scanner.
+// It validates the schema and parses the default values.
+func NewReader(s internal.LineScanner, d Dialect, cols []Column) (*Reader, error) {
+	if len(cols) == 0 {
+		return nil, errors.New("schema has no columns")
+	}
+
+	if d.Comma == 0 {
+		d.Comma = ';'
+	}
+
+	if d.Comma == d.Quote || d.Comma == d.Comment || (d.Quote != 0 && d.Quote == d.Comment) {
+		return nil, errors.New("delimiter, quote and comment characters should differ")
+	}
+
+	r := &Reader{
+		s:        s,
+		d:        d,
+		cols:     cols,
+		defaults: make([]value, len(cols)),
+		fields:   make([][]byte, 0, len(cols)),
+		row:      Row{values: make([]value, len(cols))},
+	}
+
+	for i, c := range cols {
+		if c.Type < String || c.Type > Time {
+			return nil, fmt.Errorf("column '%s': unknown type %v", c.Name, c.Type)
+		}
+
+		if c.Type == Time && c.Layout == "" {
+			return nil, fmt.Errorf("column '%s': time layout is empty", c.Name)
+		}
+
+		if !c.Optional || c.Default == "" {
+			continue
+		}
+
+		if err := r.decode(&r.defaults[i], i, []byte(c.Default)); err != nil {
+			return nil, fmt.Errorf("column '%s': invalid default value '%s': %w", c.Name, c.Default, err)
+		}
+
+		// The default field should not alias the scanner buffer.
+		r.defaults[i].b = []byte(c.Default)
+		r.defaults[i].present = false
+	}
+
+	return r, nil
+}
+
+// Columns returns the schema.
+func (r *Reader) Columns() []Column {
+	return r.cols
+}
+
+// Index returns the index of the named column or -1.
+func (r *Reader) Index(name string) int {
+	for i, c := range r.cols {
+		if c.Name == name {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// Next advances to the next row skipping comment and empty lines and the header.
+// It returns false at the end of the file or on the first error.
+func (r *Reader) Next() bool {
+	if r.err != nil {
+		return false
+	}
+
+	for r.s.Scan() {
+		r.line++
+
+		line := r.s.Bytes()
+		if len(line) == 0 || (r.d.Comment != 0 && line[0] == r.d.Comment) {
+			continue
+		}
+
+		if r.d.Header && !r.header {
+			r.header = true
+			continue
+		}
+
+		if err := r.parse(line); err != nil {
+			r.err = err
+			return false
+		}
+
+		return true
+	}
+
+	if err := r.s.Err(); err != nil {
+		r.err = &ParseError{Line: r.line + 1, Err: err}
+	}
+
+	return false
+}
+
+// Row returns the current row. The same row is reused by every Next call.
+func (r *Reader) Row() *Row {
+	return &r.row
+}
+
+// Line returns the one-based number of the last scanned line.
+func (r *Reader) Line() int {
+	return r.line
+}
+
+// Err returns the first error, it is a *ParseError.
+func (r *Reader) Err() error {
+	return r.err
+}
+
+// Close closes the underlying scanner.
+func (r *Reader) Close() error {
+	return r.s.Close()
+}
+
+func (r *Reader) parse(line []byte) error {
+	if err := r.split(line); err != nil {
+		return &ParseError{Line: r.line, Err: err}
+	}
+
+	r.row.line = r.line
+	for i, c := range r.cols {
+		v := &r.row.values[i]
+
+		if i >= len(r.fields) || len(r.fields[i]) == 0 {
+			if !c.Optional {
+				if i >= len(r.fields) {
+					return &ParseError{Line: r.line, Err: fmt.Errorf("expected at least %d fields, got %d", r.required(), len(r.fields))}
+				}
+
+				return &ParseError{Line: r.line, Column: c.Name, Err: errMissing}
+			}
+
+			*v = r.defaults[i]
+			continue
+		}
+
+		if err := r.decode(v, i, r.fields[i]); err != nil {
+			return &ParseError{Line: r.line, Column: c.Name, Field: string(r.fields[i]), Err: err}
+		}
+	}
+
+	return nil
+}
+
+// required returns the number of fields up to the last required column.
+func (r *Reader) required() int {
+	n := 0
+	for i, c := range r.cols {
+		if !c.Optional {
+			n = i + 1
+		}
+	}
+
+	return n
+}
+
+// split splits the line into the reused fields slice.
+// Unquoted fields alias the line, quoted ones alias the reused unquoted buffer.
+func (r *Reader) split(line []byte) error {
+	r.fields = r.fields[:0]
+
+	// Unquoted fields are never longer than the line,
+	// so the buffer is not reallocated while fields alias it.
+	if cap(r.unquoted) < len(line) {
+		r.unquoted = make([]byte, 0, len(line))
+	}
+	r.unquoted = r.unquoted[:0]
+
+	for start := 0; ; {
+		if r.d.Quote != 0 && start < len(line) && line[start] == r.d.Quote {
+			from := len(r.unquoted)
+			i := start + 1
+			for {
+				if i >= len(line) {
+					return errUnterminatedQuote
+				}
+
+				if line[i] == r.d.Quote {
+					if i+1 < len(line) && line[i+1] == r.d.Quote {
+						r.unquoted = append(r.unquoted, r.d.Quote)
+						i += 2
+						continue
+					}
+
+					break
+				}
+
+				r.unquoted = append(r.unquoted, line[i])
+				i++
+			}
+
+			// Skip the closing quote.
+			i++
+			if i < len(line) && line[i] != r.d.Comma {
+				return fmt.Errorf("unexpected character '%c' after a quoted field", line[i])
+			}
+
+			r.fields = append(r.fields, r.unquoted[from:len(r.unquoted)])
+			if i >= len(line) {
+				break
+			}
+
+			start = i + 1
+			continue
+		}
+
+		end := start
+		for end < len(line) && line[end] != r.d.Comma {
+			end++
+		}
+
+		r.fields = append(r.fields, line[start:end])
+		if end >= len(line) {
+			break
+		}
+
+		start = end + 1
+	}
+
+	return nil
+}
+
+func (r *Reader) decode(v *value, i int, b []byte) error {
+	c := &r.cols[i]
+	v.present = true
+	v.b = b
+
+	switch c.Type {
+	case Float:
+		f, err := strconv.ParseFloat(unsafeString(b), 64)
+		if err != nil {
+			return numError(err)
+		}
+
+		v.f = f
+	case Int:
+		n, err := strconv.ParseInt(unsafeString(b), 10, 64)
+		if err != nil {
+			return numError(err)
+		}
+
+		v.i = n
+	case Time:
+		loc := c.Location
+		if loc == nil {
+			loc = time.UTC
+		}
+
+		// A parsed zone abbreviation is kept by the time location,
+		// so the field cannot alias the reused buffer then.
+		s := unsafeString(b)
+		if strings.Contains(c.Layout, "MST") {
+			s = string(b)
+		}
+
+		t, err := time.ParseInLocation(c.Layout, s, loc)
+		if err != nil {
+			// Parse once again to keep the error from referencing the reused buffer.
+			_, err = time.ParseInLocation(c.Layout, string(b), loc)
+			return err
+		}
+
+		v.t = t
+	}
+
+	return nil
+}
+
+// numError strips the value from a strconv error,
+// the value is reported by the ParseError.
+func numError(err error) error {
+	var ne *strconv.NumError
+	if errors.As(err, &ne) {
+		return ne.Err
+	}
+
+	return err
+}
+
+// unsafeString returns a string sharing the memory of the byte slice.
+// The string should not outlive the next line scan.
+func unsafeString(b []byte) string {
+	if len(b) == 0 {
+		return ""
+	}
+
+	return *(*string)(unsafe.Pointer(&b))
+}
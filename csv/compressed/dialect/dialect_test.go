@@ -0,0 +1,296 @@
+package dialect
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"compressed/internal"
+)
+
+var barColumns = []Column{
+	{Name: "time", Type: Time, Layout: "2006/01/02"},
+	{Name: "open", Type: Float},
+	{Name: "high", Type: Float},
+	{Name: "low", Type: Float},
+	{Name: "close", Type: Float},
+	{Name: "volume", Type: Float, Optional: true, Default: "42"},
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+
+	fileName := filepath.Join(t.TempDir(), name)
+	w, err := internal.CreateWriter(fileName, internal.WriterOptions{})
+	if err != nil {
+		t.Fatalf("cannot create writer: %v", err)
+	}
+
+	if err := w.WriteString(content); err != nil {
+		t.Fatalf("cannot write: %v", err)
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatalf("cannot close writer: %v", err)
+	}
+
+	return fileName
+}
+
+func TestReaderRows(t *testing.T) {
+	t.Parallel()
+
+	const content = "time;open;high;low;close;volume\n" +
+		"# a comment\n" +
+		"2023/06/21;1.5;2;1;1.75;100\n" +
+		"\n" +
+		"2023/06/22;2;3;1.5;2.5\n" +
+		"2023/06/23;2.5;3;2;3;\n"
+
+	for _, name := range []string{"bars.csv", "bars.csv.gz", "bars.csv.zst", "bars.csv.lz4"} {
+		name := name
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			r, err := Open(writeFile(t, name, content), Default, barColumns)
+			if err != nil {
+				t.Fatalf("cannot open: %v", err)
+			}
+			defer r.Close()
+
+			expected := []struct {
+				line    int
+				time    time.Time
+				close   float64
+				volume  float64
+				present bool
+			}{
+				{3, time.Date(2023, 6, 21, 0, 0, 0, 0, time.UTC), 1.75, 100, true},
+				{5, time.Date(2023, 6, 22, 0, 0, 0, 0, time.UTC), 2.5, 42, false},
+				{6, time.Date(2023, 6, 23, 0, 0, 0, 0, time.UTC), 3, 42, false},
+			}
+
+			n := 0
+			for r.Next() {
+				row := r.Row()
+				if n >= len(expected) {
+					t.Fatalf("unexpected row at line %d", row.Line())
+				}
+
+				e := expected[n]
+				if row.Line() != e.line {
+					t.Errorf("row %d: expected line %d, got %d", n, e.line, row.Line())
+				}
+
+				if !row.Time(0).Equal(e.time) {
+					t.Errorf("row %d: expected time %v, got %v", n, e.time, row.Time(0))
+				}
+
+				if row.Float(4) != e.close {
+					t.Errorf("row %d: expected close %v, got %v", n, e.close, row.Float(4))
+				}
+
+				if row.Float(5) != e.volume || row.Present(5) != e.present {
+					t.Errorf("row %d: expected volume %v (%t), got %v (%t)",
+						n, e.volume, e.present, row.Float(5), row.Present(5))
+				}
+
+				n++
+			}
+
+			if err := r.Err(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if n != len(expected) {
+				t.Errorf("expected %d rows, got %d", len(expected), n)
+			}
+		})
+	}
+}
+
+func TestReaderErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		content string
+		line    int
+		column  string
+		err     error
+	}{
+		{"bad float", "h\n2023/06/21;1;2;x;1\n", 2, "low", strconv.ErrSyntax},
+		{"bad time", "h\n#\n2023-06-21;1;2;1;1\n", 3, "time", nil},
+		{"too few fields", "h\n2023/06/21;1;2;1\n", 2, "", nil},
+		{"empty required", "h\n2023/06/21;1;;1;1\n", 2, "high", errMissing},
+		{"bad optional", "h\n2023/06/21;1;2;1;1;1e999\n", 2, "volume", strconv.ErrRange},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r, err := Open(writeFile(t, "bars.csv", tt.content), Default, barColumns)
+			if err != nil {
+				t.Fatalf("cannot open: %v", err)
+			}
+			defer r.Close()
+
+			for r.Next() {
+			}
+
+			var pe *ParseError
+			if !errors.As(r.Err(), &pe) {
+				t.Fatalf("expected a parse error, got %v", r.Err())
+			}
+
+			if pe.Line != tt.line || pe.Column != tt.column {
+				t.Errorf("expected line %d column '%s', got line %d column '%s': %v",
+					tt.line, tt.column, pe.Line, pe.Column, pe)
+			}
+
+			if tt.err != nil && !errors.Is(pe, tt.err) {
+				t.Errorf("expected error %v, got %v", tt.err, pe.Err)
+			}
+
+			if r.Next() {
+				t.Errorf("expected no rows after an error")
+			}
+		})
+	}
+}
+
+func TestReaderQuotes(t *testing.T) {
+	t.Parallel()
+
+	cols := []Column{
+		{Name: "name", Type: String},
+		{Name: "count", Type: Int},
+		{Name: "note", Type: String, Optional: true, Default: "none"},
+	}
+
+	d := Dialect{Comma: ',', Quote: '"'}
+	fileName := writeFile(t, "quotes.csv", "\"a,b\",1,\"say \"\"hi\"\"\"\nplain,-2\n\"\",3,\"\"\n")
+
+	r, err := Open(fileName, d, cols)
+	if err != nil {
+		t.Fatalf("cannot open: %v", err)
+	}
+	defer r.Close()
+
+	expected := []struct {
+		name  string
+		count int64
+		note  string
+	}{
+		{"a,b", 1, "say \"hi\""},
+		{"plain", -2, "none"},
+	}
+
+	for _, e := range expected {
+		if !r.Next() {
+			t.Fatalf("expected a row, got error %v", r.Err())
+		}
+
+		row := r.Row()
+		if row.String(0) != e.name || row.Int(1) != e.count || row.String(2) != e.note {
+			t.Errorf("expected %q %d %q, got %q %d %q", e.name, e.count, e.note,
+				row.String(0), row.Int(1), row.String(2))
+		}
+	}
+
+	// An empty quoted required field is missing.
+	if r.Next() {
+		t.Fatalf("expected an error")
+	}
+
+	if !errors.Is(r.Err(), errMissing) {
+		t.Errorf("expected missing value error, got %v", r.Err())
+	}
+}
+
+func TestNewReaderSchema(t *testing.T) {
+	t.Parallel()
+
+	fileName := filepath.Join(t.TempDir(), "empty.csv")
+	if err := os.WriteFile(fileName, nil, 0666); err != nil {
+		t.Fatalf("cannot create file: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		d    Dialect
+		cols []Column
+	}{
+		{"no columns", Default, nil},
+		{"no layout", Default, []Column{{Name: "t", Type: Time}}},
+		{"bad default", Default, []Column{{Name: "v", Type: Float, Optional: true, Default: "x"}}},
+		{"same comma and quote", Dialect{Comma: ',', Quote: ','}, []Column{{Name: "s"}}},
+	}
+
+	for _, tt := range tests {
+		if _, err := Open(fileName, tt.d, tt.cols); err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+	}
+}
+
+func TestReaderAllocations(t *testing.T) {
+	content := "time;open;high;low;close;volume\n"
+	for i := 0; i < 1000; i++ {
+		content += "2023/06/21;1.5;2;1;1.75;100\n"
+	}
+
+	fileName := writeFile(t, "bars.csv", content)
+
+	r, err := Open(fileName, Default, barColumns)
+	if err != nil {
+		t.Fatalf("cannot open: %v", err)
+	}
+	defer r.Close()
+
+	// Warm up the reused buffers.
+	r.Next()
+
+	allocs := testing.AllocsPerRun(500, func() {
+		if !r.Next() {
+			t.Fatalf("unexpected end: %v", r.Err())
+		}
+	})
+
+	if allocs > 0 {
+		t.Errorf("expected no allocations per row, got %v", allocs)
+	}
+}
+
+func TestHasExt(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		fileName string
+		has      bool
+		trimmed  string
+	}{
+		{"bars.csv", true, "bars.csv"},
+		{"bars.csv.gz", true, "bars.csv"},
+		{"bars.csv.ZST", true, "bars.csv"},
+		{"bars.csv.lz4", true, "bars.csv"},
+		{"bars.txt.xz", false, "bars.txt"},
+		{"bars.gz", false, "bars"},
+		{"bars.csv.ts", false, "bars.csv.ts"},
+	}
+
+	for _, tt := range tests {
+		if got := HasExt(tt.fileName, ".csv"); got != tt.has {
+			t.Errorf("%s: expected %v, got %v", tt.fileName, tt.has, got)
+		}
+
+		if got := TrimExt(tt.fileName); got != tt.trimmed {
+			t.Errorf("%s: expected %s, got %s", tt.fileName, tt.trimmed, got)
+		}
+	}
+}
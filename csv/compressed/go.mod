@@ -4,4 +4,9 @@ go 1.19
 
 require github.com/larzconwell/bzip2 v0.0.0-20160405040150-ecf7a0ddeda1
 
-require github.com/ulikunitz/xz v0.5.11
+require (
+	github.com/klauspost/compress v1.17.4
+	github.com/pierrec/lz4/v4 v4.1.18
+	github.com/ulikunitz/xz v0.5.11
+	golang.org/x/sys v0.15.0
+)
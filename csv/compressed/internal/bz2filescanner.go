@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"io"
 	"os"
-	"strings"
 )
 
 type Bz2FileScanner struct {
@@ -16,12 +15,6 @@ type Bz2FileScanner struct {
 }
 
 func NewBz2FileScanner(fileName string) (*Bz2FileScanner, error) {
-	const extBz2 = ".bz2"
-
-	if !strings.HasSuffix(fileName, extBz2) {
-		return nil, fmt.Errorf("input bzip2 file %q should have %q extension", fileName, extBz2)
-	}
-
 	f, err := os.Open(fileName)
 	if err != nil {
 		return nil, fmt.Errorf("cannot open %q file: %w", fileName, err)
@@ -5,7 +5,6 @@ import (
 	"compress/bzip2"
 	"fmt"
 	"io"
-	"strings"
 
 	"compressed/internal/mmap"
 )
@@ -17,12 +16,6 @@ type Bz2FileScannerMmap struct {
 }
 
 func NewBz2FileScannerMmap(fileName string) (*Bz2FileScannerMmap, error) {
-	const extBz2 = ".bz2"
-
-	if !strings.HasSuffix(fileName, extBz2) {
-		return nil, fmt.Errorf("input bzip2 file %q should have %q extension", fileName, extBz2)
-	}
-
 	m, err := mmap.OpenFile(fileName, mmap.ReadOnly)
 	if err != nil {
 		return nil, fmt.Errorf("cannot mmap %q file: %w", fileName, err)
@@ -14,16 +14,25 @@ type Bz2FileWriter struct {
 	bw *bufio.Writer
 }
 
-func NewBz2FileWriter(fileName string, append bool) (*Bz2FileWriter, error) {
+// NewBz2FileWriter creates a bzip2 file writer.
+// The level is a bzip2 compression level from 1 to 9,
+// zero means the best speed.
+func NewBz2FileWriter(fileName string, append bool, level int) (*Bz2FileWriter, error) {
+	if level == 0 {
+		level = bzip2.BestSpeed
+	}
+
 	flag := os.O_WRONLY | os.O_CREATE
 	if append {
 		flag |= os.O_APPEND
+	} else {
+		flag |= os.O_TRUNC
 	}
 
 	if f, err := os.OpenFile(fileName, flag, 0666); err != nil {
 		return nil, fmt.Errorf("cannot open '%s' file: %w", fileName, err)
 	} else {
-		if zw, err := bzip2.NewWriterLevel(f, bzip2.BestSpeed); err != nil {
+		if zw, err := bzip2.NewWriterLevel(f, level); err != nil {
 			f.Close()
 			return nil, fmt.Errorf("cannot create bzip2 writer: %w", err)
 		} else {
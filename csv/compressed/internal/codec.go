@@ -0,0 +1,340 @@
+package internal
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// LineScanner reads a possibly compressed text file line by line.
+type LineScanner interface {
+	Scan() bool
+	Text() string
+	Bytes() []byte
+	Err() error
+	Close() error
+}
+
+// LineWriter writes a possibly compressed text file.
+type LineWriter interface {
+	WriteString(s string) error
+	WriteBytes(b []byte) error
+	Flush() error
+	Close() error
+}
+
+// Codec identifies a compression format.
+type Codec int
+
+const (
+	CodecNone Codec = iota // CodecNone is a plain text file.
+	CodecGz                // CodecGz is a gzip file.
+	CodecBz2               // CodecBz2 is a bzip2 file.
+	CodecXz                // CodecXz is a xz file.
+	CodecZst               // CodecZst is a zstd file.
+	CodecLz4               // CodecLz4 is a lz4 file.
+)
+
+// NoCompression is the WriterOptions level storing the data uncompressed,
+// the gzip level zero. The zero level is the codec default.
+const NoCompression = -1
+
+// WriterOptions specifies how CreateWriter creates a file.
+type WriterOptions struct {
+	// Append appends to an existing file instead of overwriting it.
+	Append bool
+
+	// Codec, if not CodecNone, overrides the codec derived from the file extension.
+	Codec Codec
+
+	// Level is a codec-specific compression level, zero means the codec default.
+	// NoCompression stores the data uncompressed, only the gz codec supports it.
+	Level int
+
+	// Workers is the number of goroutines compressing the data
+	// for the codecs supporting parallel compression.
+	Workers int
+
+	// Time, if not nil, makes CreateWriter create a seekable file
+	// of independently compressed frames with a time index.
+	// Only the gz and zst codecs support seekable files.
+	Time TimeFunc
+
+	// FrameSize is the uncompressed size of a seekable file frame,
+	// zero means the DefaultFrameSize.
+	FrameSize int
+}
+
+type codec struct {
+	codec          Codec
+	name           string
+	ext            string
+	magic          []byte
+	newScanner     func(fileName string) (LineScanner, error)
+	newScannerMmap func(fileName string) (LineScanner, error)
+	newWriter      func(fileName string, opts WriterOptions) (LineWriter, error)
+}
+
+// The plain text codec should be the first one.
+var codecs = []codec{
+	{
+		codec: CodecNone,
+		name:  "none",
+		newScanner: func(fileName string) (LineScanner, error) {
+			return NewTextFileScanner(fileName)
+		},
+		newScannerMmap: func(fileName string) (LineScanner, error) {
+			return NewTextFileScannerMmap(fileName)
+		},
+		newWriter: func(fileName string, opts WriterOptions) (LineWriter, error) {
+			return NewTextFileWriter(fileName, opts.Append)
+		},
+	},
+	{
+		codec: CodecGz,
+		name:  "gz",
+		ext:   ".gz",
+		magic: []byte{0x1f, 0x8b},
+		newScanner: func(fileName string) (LineScanner, error) {
+			return NewGzFileScanner(fileName)
+		},
+		newScannerMmap: func(fileName string) (LineScanner, error) {
+			return NewGzFileScannerMmap(fileName)
+		},
+		newWriter: func(fileName string, opts WriterOptions) (LineWriter, error) {
+			return NewGzFileWriter(fileName, opts.Append, gzipLevel(opts.Level), opts.Workers)
+		},
+	},
+	{
+		codec: CodecBz2,
+		name:  "bz2",
+		ext:   ".bz2",
+		magic: []byte{'B', 'Z', 'h'},
+		newScanner: func(fileName string) (LineScanner, error) {
+			return NewBz2FileScanner(fileName)
+		},
+		newScannerMmap: func(fileName string) (LineScanner, error) {
+			return NewBz2FileScannerMmap(fileName)
+		},
+		newWriter: func(fileName string, opts WriterOptions) (LineWriter, error) {
+			return NewBz2FileWriter(fileName, opts.Append, opts.Level)
+		},
+	},
+	{
+		codec: CodecXz,
+		name:  "xz",
+		ext:   ".xz",
+		magic: []byte{0xfd, '7', 'z', 'X', 'Z', 0x00},
+		newScanner: func(fileName string) (LineScanner, error) {
+			return NewXzFileScanner(fileName)
+		},
+		newScannerMmap: func(fileName string) (LineScanner, error) {
+			return NewXzFileScannerMmap(fileName)
+		},
+		newWriter: func(fileName string, opts WriterOptions) (LineWriter, error) {
+			if opts.Level != 0 {
+				return nil, fmt.Errorf("xz writer does not support compression levels")
+			}
+
+			return NewXzFileWriter(fileName, opts.Append)
+		},
+	},
+	{
+		codec: CodecZst,
+		name:  "zst",
+		ext:   ".zst",
+		magic: []byte{0x28, 0xb5, 0x2f, 0xfd},
+		newScanner: func(fileName string) (LineScanner, error) {
+			return NewZstFileScanner(fileName)
+		},
+		newScannerMmap: func(fileName string) (LineScanner, error) {
+			return NewZstFileScannerMmap(fileName)
+		},
+		newWriter: func(fileName string, opts WriterOptions) (LineWriter, error) {
+			return NewZstFileWriter(fileName, opts.Append, opts.Level)
+		},
+	},
+	{
+		codec: CodecLz4,
+		name:  "lz4",
+		ext:   ".lz4",
+		magic: []byte{0x04, 0x22, 0x4d, 0x18},
+		newScanner: func(fileName string) (LineScanner, error) {
+			return NewLz4FileScanner(fileName)
+		},
+		newScannerMmap: func(fileName string) (LineScanner, error) {
+			return NewLz4FileScannerMmap(fileName)
+		},
+		newWriter: func(fileName string, opts WriterOptions) (LineWriter, error) {
+			return NewLz4FileWriter(fileName, opts.Append, opts.Level)
+		},
+	},
+}
+
+// gzipLevel converts the WriterOptions level to the gzip level.
+func gzipLevel(level int) int {
+	switch level {
+	case 0:
+		return gzip.DefaultCompression
+	case NoCompression:
+		return gzip.NoCompression
+	}
+
+	return level
+}
+
+// String returns the short name of the codec.
+func (c Codec) String() string {
+	for i := range codecs {
+		if codecs[i].codec == c {
+			return codecs[i].name
+		}
+	}
+
+	return fmt.Sprintf("codec(%d)", int(c))
+}
+
+// ParseCodec returns the codec with the given short name:
+// none, gz, bz2, xz, zst or lz4.
+func ParseCodec(name string) (Codec, error) {
+	for i := range codecs {
+		if codecs[i].name == name {
+			return codecs[i].codec, nil
+		}
+	}
+
+	return CodecNone, fmt.Errorf("unknown codec %q", name)
+}
+
+// Ext returns the file extension of the codec, including the leading dot.
+// The plain text codec has an empty extension.
+func (c Codec) Ext() string {
+	for i := range codecs {
+		if codecs[i].codec == c {
+			return codecs[i].ext
+		}
+	}
+
+	return ""
+}
+
+// CodecFromExt returns the codec matching the extension of the file name.
+// Files with an unknown extension are considered plain text.
+func CodecFromExt(fileName string) Codec {
+	return codecByExt(fileName).codec
+}
+
+// TrimExt returns the file name without the extension of a compression codec.
+// File names without a known codec extension are returned as is.
+func TrimExt(fileName string) string {
+	return fileName[:len(fileName)-len(codecByExt(fileName).ext)]
+}
+
+// DetectCodec returns the codec of an existing file.
+// The codec is identified by the magic bytes at the start of the file,
+// with the file name extension as a fallback.
+func DetectCodec(fileName string) (Codec, error) {
+	c, err := detect(fileName)
+	if err != nil {
+		return CodecNone, err
+	}
+
+	return c.codec, nil
+}
+
+// OpenScanner opens a line scanner on the file, choosing the codec
+// by the magic bytes with the file name extension as a fallback.
+func OpenScanner(fileName string) (LineScanner, error) {
+	c, err := detect(fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.newScanner(fileName)
+}
+
+// OpenScannerMmap is like OpenScanner but uses a memory mapped file.
+func OpenScannerMmap(fileName string) (LineScanner, error) {
+	c, err := detect(fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.newScannerMmap(fileName)
+}
+
+// CreateWriter creates a line writer on the file, choosing the codec
+// by the file name extension unless the options specify one.
+// If the options have a time function, it creates a seekable file writer.
+func CreateWriter(fileName string, opts WriterOptions) (LineWriter, error) {
+	c := codecByExt(fileName)
+	if opts.Codec != CodecNone {
+		var ok bool
+		if c, ok = codecByCodec(opts.Codec); !ok {
+			return nil, fmt.Errorf("unknown codec %v", opts.Codec)
+		}
+	}
+
+	if opts.Time != nil {
+		opts.Codec = c.codec
+		return NewSeekableFileWriter(fileName, opts)
+	}
+
+	return c.newWriter(fileName, opts)
+}
+
+func codecByCodec(cd Codec) (*codec, bool) {
+	for i := range codecs {
+		if codecs[i].codec == cd {
+			return &codecs[i], true
+		}
+	}
+
+	return nil, false
+}
+
+func codecByExt(fileName string) *codec {
+	lower := strings.ToLower(fileName)
+	for i := range codecs {
+		if codecs[i].ext != "" && strings.HasSuffix(lower, codecs[i].ext) {
+			return &codecs[i]
+		}
+	}
+
+	return &codecs[0]
+}
+
+func codecByMagic(head []byte) (*codec, bool) {
+	for i := range codecs {
+		if len(codecs[i].magic) > 0 && bytes.HasPrefix(head, codecs[i].magic) {
+			return &codecs[i], true
+		}
+	}
+
+	return nil, false
+}
+
+func detect(fileName string) (*codec, error) {
+	f, err := os.Open(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open %q file: %w", fileName, err)
+	}
+	defer f.Close()
+
+	const maxMagicLen = 16
+
+	head := make([]byte, maxMagicLen)
+	n, err := io.ReadFull(f, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, fmt.Errorf("cannot read %q file: %w", fileName, err)
+	}
+
+	if c, ok := codecByMagic(head[:n]); ok {
+		return c, nil
+	}
+
+	return codecByExt(fileName), nil
+}
@@ -0,0 +1,262 @@
+package internal
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCodecRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	// Larger than the bufio and codec buffers, so the scanners refill several times.
+	lines := make([]string, 0, 50000)
+	for i := 0; len(lines) < cap(lines); i++ {
+		lines = append(lines, fmt.Sprintf("2023-06-22 15:30:%02d;%d.%03d;%d", i%60, 100+i%7, i%1000, i))
+	}
+
+	tests := []struct {
+		name  string
+		ext   string
+		level int
+		lines []string
+	}{
+		{"zst default level", ".zst", 0, lines},
+		{"zst best level", ".zst", 22, lines},
+		{"zst small input", ".zst", 0, lines[:10]},
+		{"zst empty input", ".zst", 0, nil},
+		{"lz4 default level", ".lz4", 0, lines},
+		{"lz4 best level", ".lz4", 9, lines},
+		{"lz4 small input", ".lz4", 0, lines[:10]},
+		{"lz4 empty input", ".lz4", 0, nil},
+	}
+
+	open := []struct {
+		name string
+		open func(fileName string) (LineScanner, error)
+	}{
+		{"scanner", OpenScanner},
+		{"mmap scanner", OpenScannerMmap},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			fileName := filepath.Join(t.TempDir(), "test.csv"+tt.ext)
+
+			w, err := CreateWriter(fileName, WriterOptions{Level: tt.level})
+			if err != nil {
+				t.Fatalf("cannot create writer: %v", err)
+			}
+
+			for _, line := range tt.lines {
+				if err := w.WriteString(line + "\n"); err != nil {
+					t.Fatalf("cannot write: %v", err)
+				}
+			}
+
+			if err := w.Close(); err != nil {
+				t.Fatalf("cannot close writer: %v", err)
+			}
+
+			if c, err := DetectCodec(fileName); err != nil || c.Ext() != tt.ext {
+				t.Errorf("expected codec %s, got %v, error %v", tt.ext, c, err)
+			}
+
+			for _, o := range open {
+				s, err := o.open(fileName)
+				if err != nil {
+					t.Fatalf("%s: cannot create scanner: %v", o.name, err)
+				}
+
+				i := 0
+				for s.Scan() {
+					if i >= len(tt.lines) {
+						t.Fatalf("%s: unexpected line %d: %q", o.name, i, s.Text())
+					}
+
+					if got := s.Text(); got != tt.lines[i] {
+						t.Fatalf("%s: line %d: expected %q, got %q", o.name, i, tt.lines[i], got)
+					}
+
+					i++
+				}
+
+				if err := s.Err(); err != nil {
+					t.Fatalf("%s: cannot scan: %v", o.name, err)
+				}
+
+				if err := s.Close(); err != nil {
+					t.Errorf("%s: cannot close scanner: %v", o.name, err)
+				}
+
+				if i != len(tt.lines) {
+					t.Errorf("%s: expected %d lines, got %d", o.name, len(tt.lines), i)
+				}
+			}
+		})
+	}
+}
+
+func TestCodecRoundTripAppend(t *testing.T) {
+	t.Parallel()
+
+	for _, ext := range []string{".zst", ".lz4"} {
+		fileName := filepath.Join(t.TempDir(), "test.csv"+ext)
+		for i, part := range []string{"a;1\nb;2\n", "c;3\n"} {
+			w, err := CreateWriter(fileName, WriterOptions{Append: i > 0})
+			if err != nil {
+				t.Fatalf("%s: cannot create writer: %v", ext, err)
+			}
+
+			if err := w.WriteString(part); err != nil {
+				t.Fatalf("%s: cannot write: %v", ext, err)
+			}
+
+			if err := w.Close(); err != nil {
+				t.Fatalf("%s: cannot close writer: %v", ext, err)
+			}
+		}
+
+		for _, open := range []func(string) (LineScanner, error){OpenScanner, OpenScannerMmap} {
+			s, err := open(fileName)
+			if err != nil {
+				t.Fatalf("%s: cannot create scanner: %v", ext, err)
+			}
+
+			var actual []string
+			for s.Scan() {
+				actual = append(actual, s.Text())
+			}
+
+			if err := s.Err(); err != nil {
+				t.Errorf("%s: cannot scan: %v", ext, err)
+			}
+			s.Close()
+
+			if expected := []string{"a;1", "b;2", "c;3"}; fmt.Sprint(actual) != fmt.Sprint(expected) {
+				t.Errorf("%s: expected the concatenated frames %v, got %v", ext, expected, actual)
+			}
+		}
+	}
+}
+
+// bz2Lines are the "a;1\nb;2\n" lines compressed by bzip2 -9.
+var bz2Lines = []byte{
+	0x42, 0x5a, 0x68, 0x39, 0x31, 0x41, 0x59, 0x26, 0x53, 0x59, 0xa4, 0xdd, 0xfc, 0x03, 0x00, 0x00,
+	0x03, 0x49, 0x00, 0x00, 0x10, 0x30, 0x08, 0x30, 0x00, 0x20, 0x00, 0x21, 0x80, 0x0c, 0x01, 0xb8,
+	0x2e, 0xc3, 0x8b, 0xb9, 0x22, 0x9c, 0x28, 0x48, 0x52, 0x6e, 0xfe, 0x01, 0x80,
+}
+
+func TestDetectMismatchedExt(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		fileName string
+		codec    Codec
+		raw      []byte
+		expected Codec
+	}{
+		{"gzip named csv", "test.csv", CodecGz, nil, CodecGz},
+		{"zstd named gz", "test.csv.gz", CodecZst, nil, CodecZst},
+		{"lz4 named zst", "test.csv.zst", CodecLz4, nil, CodecLz4},
+		{"xz named bz2", "test.csv.bz2", CodecXz, nil, CodecXz},
+		// Written by the reference bzip2 tool.
+		{"bzip2 named txt", "test.txt", CodecBz2, bz2Lines, CodecBz2},
+		{"gzip named upper case ZST", "test.CSV.ZST", CodecGz, nil, CodecGz},
+	}
+
+	expected := []string{"a;1", "b;2"}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			fileName := filepath.Join(t.TempDir(), tt.fileName)
+			if tt.raw != nil {
+				if err := os.WriteFile(fileName, tt.raw, 0644); err != nil {
+					t.Fatal(err)
+				}
+			} else {
+				w, err := CreateWriter(fileName, WriterOptions{Codec: tt.codec})
+				if err != nil {
+					t.Fatalf("cannot create writer: %v", err)
+				}
+
+				if err := w.WriteString("a;1\nb;2\n"); err != nil {
+					t.Fatalf("cannot write: %v", err)
+				}
+
+				if err := w.Close(); err != nil {
+					t.Fatalf("cannot close writer: %v", err)
+				}
+			}
+
+			if c, err := DetectCodec(fileName); err != nil || c != tt.expected {
+				t.Errorf("expected codec %v, got %v, error %v", tt.expected, c, err)
+			}
+
+			for _, open := range []func(string) (LineScanner, error){OpenScanner, OpenScannerMmap} {
+				s, err := open(fileName)
+				if err != nil {
+					t.Fatalf("cannot create scanner: %v", err)
+				}
+
+				var actual []string
+				for s.Scan() {
+					actual = append(actual, s.Text())
+				}
+
+				if err := s.Err(); err != nil {
+					t.Errorf("cannot scan: %v", err)
+				}
+				s.Close()
+
+				if fmt.Sprint(actual) != fmt.Sprint(expected) {
+					t.Errorf("expected %v, got %v", expected, actual)
+				}
+			}
+		})
+	}
+}
+
+func TestDetectExtFallback(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	tests := []struct {
+		fileName string
+		content  string
+		expected Codec
+	}{
+		// Plain text has no magic bytes, the extension decides.
+		{"plain.csv", "a;1\n", CodecNone},
+		{"plain.csv.gz", "a;1\n", CodecGz},
+		{"empty.csv.zst", "", CodecZst},
+		// Fewer bytes than a magic number.
+		{"short.csv", "\x1f", CodecNone},
+	}
+
+	for _, tt := range tests {
+		fileName := filepath.Join(dir, tt.fileName)
+		if err := os.WriteFile(fileName, []byte(tt.content), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		if c, err := DetectCodec(fileName); err != nil || c != tt.expected {
+			t.Errorf("%s: expected codec %v, got %v, error %v", tt.fileName, tt.expected, c, err)
+		}
+	}
+
+	if _, err := DetectCodec(filepath.Join(dir, "missing.csv")); err == nil {
+		t.Errorf("expected an error for a missing file")
+	}
+
+	if _, err := OpenScanner(filepath.Join(dir, "missing.csv.gz")); err == nil {
+		t.Errorf("expected an error for a missing file")
+	}
+}
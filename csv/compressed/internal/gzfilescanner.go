@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"io"
 	"os"
-	"strings"
 )
 
 type GzFileScanner struct {
@@ -16,12 +15,6 @@ type GzFileScanner struct {
 }
 
 func NewGzFileScanner(fileName string) (*GzFileScanner, error) {
-	const extGz = ".gz"
-
-	if !strings.HasSuffix(fileName, extGz) {
-		return nil, fmt.Errorf("input gzip file %q should have %q extension", fileName, extGz)
-	}
-
 	f, err := os.Open(fileName)
 	if err != nil {
 		return nil, fmt.Errorf("cannot open %q file: %w", fileName, err)
@@ -5,7 +5,6 @@ import (
 	"compress/gzip"
 	"fmt"
 	"io"
-	"strings"
 
 	"compressed/internal/mmap"
 )
@@ -17,12 +16,6 @@ type GzFileScannerMmap struct {
 }
 
 func NewGzFileScannerMmap(fileName string) (*GzFileScannerMmap, error) {
-	const extGz = ".gz"
-
-	if !strings.HasSuffix(fileName, extGz) {
-		return nil, fmt.Errorf("input gzip file %q should have %q extension", fileName, extGz)
-	}
-
 	m, err := mmap.OpenFile(fileName, mmap.ReadOnly)
 	if err != nil {
 		return nil, fmt.Errorf("cannot mmap %q file: %w", fileName, err)
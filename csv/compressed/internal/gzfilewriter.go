@@ -4,25 +4,42 @@ import (
 	"bufio"
 	"compress/gzip"
 	"fmt"
+	"io"
 	"os"
 )
 
+type gzWriter interface {
+	io.WriteCloser
+	Flush() error
+}
+
 type GzFileWriter struct {
 	f  *os.File
-	gw *gzip.Writer
+	gw gzWriter
 	bw *bufio.Writer
 }
 
-func NewGzFileWriter(fileName string, append bool) (*GzFileWriter, error) {
+// NewGzFileWriter creates a gzip file writer.
+// The level is a gzip compression level from 0, no compression, to 9,
+// gzip.DefaultCompression (-1) means the best compression.
+// If workers is greater than one, the input is split into blocks
+// compressed in parallel into a multi-member gzip stream.
+func NewGzFileWriter(fileName string, append bool, level, workers int) (*GzFileWriter, error) {
+	if level == gzip.DefaultCompression {
+		level = gzip.BestCompression
+	}
+
 	flag := os.O_WRONLY | os.O_CREATE
 	if append {
 		flag |= os.O_APPEND
+	} else {
+		flag |= os.O_TRUNC
 	}
 
 	if f, err := os.OpenFile(fileName, flag, 0666); err != nil {
 		return nil, fmt.Errorf("cannot open '%s' file: %w", fileName, err)
 	} else {
-		if gw, err := gzip.NewWriterLevel(f, gzip.BestCompression); err != nil {
+		if gw, err := newGzWriter(f, level, workers); err != nil {
 			f.Close()
 			return nil, fmt.Errorf("cannot create gzip writer: %w", err)
 		} else {
@@ -90,3 +107,11 @@ func (w *GzFileWriter) WriteBytes(b []byte) error {
 
 	return nil
 }
+
+func newGzWriter(w io.Writer, level, workers int) (gzWriter, error) {
+	if workers > 1 {
+		return newGzParallelWriter(w, level, workers)
+	}
+
+	return gzip.NewWriterLevel(w, level)
+}
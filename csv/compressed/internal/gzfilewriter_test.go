@@ -0,0 +1,181 @@
+package internal
+
+import (
+	"compress/gzip"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGzFileWriterRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	// About 3.5 blocks, so the parallel writer produces several gzip members.
+	lines := make([]string, 0, 100000)
+	for i := 0; len(lines) < cap(lines); i++ {
+		lines = append(lines, fmt.Sprintf("2023-06-22 15:30:%02d;%d.%03d;%d", i%60, 100+i%7, i%1000, i))
+	}
+
+	tests := []struct {
+		name    string
+		workers int
+		lines   []string
+	}{
+		{"single worker", 1, lines},
+		{"two workers", 2, lines},
+		{"eight workers", 8, lines},
+		{"eight workers small input", 8, lines[:10]},
+		{"eight workers empty input", 8, nil},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			fileName := filepath.Join(t.TempDir(), "test.csv.gz")
+
+			w, err := NewGzFileWriter(fileName, false, gzip.DefaultCompression, tt.workers)
+			if err != nil {
+				t.Fatalf("cannot create writer: %v", err)
+			}
+
+			for _, line := range tt.lines {
+				if err := w.WriteString(line + "\n"); err != nil {
+					t.Fatalf("cannot write: %v", err)
+				}
+			}
+
+			if err := w.Close(); err != nil {
+				t.Fatalf("cannot close writer: %v", err)
+			}
+
+			s, err := NewGzFileScanner(fileName)
+			if err != nil {
+				t.Fatalf("cannot create scanner: %v", err)
+			}
+			defer s.Close()
+
+			i := 0
+			for s.Scan() {
+				if i >= len(tt.lines) {
+					t.Fatalf("unexpected line %d: %q", i, s.Text())
+				}
+
+				if got := s.Text(); got != tt.lines[i] {
+					t.Fatalf("line %d: expected %q, got %q", i, tt.lines[i], got)
+				}
+
+				i++
+			}
+
+			if err := s.Err(); err != nil {
+				t.Fatalf("cannot scan: %v", err)
+			}
+
+			if i != len(tt.lines) {
+				t.Errorf("expected %d lines, got %d", len(tt.lines), i)
+			}
+		})
+	}
+}
+
+func TestGzFileWriterFlush(t *testing.T) {
+	t.Parallel()
+
+	fileName := filepath.Join(t.TempDir(), "test.csv.gz")
+
+	w, err := NewGzFileWriter(fileName, false, gzip.DefaultCompression, 4)
+	if err != nil {
+		t.Fatalf("cannot create writer: %v", err)
+	}
+
+	expected := []string{"first", "second", "third"}
+	for _, line := range expected {
+		if err := w.WriteString(line + "\n"); err != nil {
+			t.Fatalf("cannot write: %v", err)
+		}
+
+		if err := w.Flush(); err != nil {
+			t.Fatalf("cannot flush: %v", err)
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatalf("cannot close writer: %v", err)
+	}
+
+	s, err := NewGzFileScanner(fileName)
+	if err != nil {
+		t.Fatalf("cannot create scanner: %v", err)
+	}
+	defer s.Close()
+
+	var actual []string
+	for s.Scan() {
+		actual = append(actual, s.Text())
+	}
+
+	if err := s.Err(); err != nil {
+		t.Fatalf("cannot scan: %v", err)
+	}
+
+	if fmt.Sprint(actual) != fmt.Sprint(expected) {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+}
+
+func TestGzFileWriterLevel(t *testing.T) {
+	t.Parallel()
+
+	content := strings.Repeat("2023-06-22 15:30:00;100.000;1\n", 1000)
+	tests := []struct {
+		name       string
+		create     func(fileName string) (LineWriter, error)
+		compressed bool
+	}{
+		{"no compression", func(fileName string) (LineWriter, error) {
+			return NewGzFileWriter(fileName, false, gzip.NoCompression, 1)
+		}, false},
+		{"default compression", func(fileName string) (LineWriter, error) {
+			return NewGzFileWriter(fileName, false, gzip.DefaultCompression, 1)
+		}, true},
+		{"no compression parallel", func(fileName string) (LineWriter, error) {
+			return NewGzFileWriter(fileName, false, gzip.NoCompression, 4)
+		}, false},
+		{"no compression option", func(fileName string) (LineWriter, error) {
+			return CreateWriter(fileName, WriterOptions{Level: NoCompression})
+		}, false},
+		{"default option", func(fileName string) (LineWriter, error) {
+			return CreateWriter(fileName, WriterOptions{})
+		}, true},
+	}
+
+	for _, tt := range tests {
+		fileName := filepath.Join(t.TempDir(), "test.csv.gz")
+
+		w, err := tt.create(fileName)
+		if err != nil {
+			t.Fatalf("%s: cannot create writer: %v", tt.name, err)
+		}
+
+		if err := w.WriteString(content); err != nil {
+			t.Fatalf("%s: cannot write: %v", tt.name, err)
+		}
+
+		if err := w.Close(); err != nil {
+			t.Fatalf("%s: cannot close writer: %v", tt.name, err)
+		}
+
+		fi, err := os.Stat(fileName)
+		if err != nil {
+			t.Fatalf("%s: unexpected error %v", tt.name, err)
+		}
+
+		if compressed := fi.Size() < int64(len(content)); compressed != tt.compressed {
+			t.Errorf("%s: expected compressed %v, got %d bytes of %d", tt.name, tt.compressed, fi.Size(), len(content))
+		}
+	}
+}
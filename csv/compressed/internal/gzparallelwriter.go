@@ -0,0 +1,190 @@
+package internal
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// gzBlockSize is the size of the uncompressed block compressed
+// as an independent gzip member by a parallel gzip writer.
+const gzBlockSize = 1 << 20
+
+type gzBlock struct {
+	data []byte
+	err  error
+}
+
+// gzParallelWriter splits the input into blocks and compresses
+// every block into an independent gzip member on a pool of workers.
+// The members are written in the input order, so the output is a valid
+// multi-member gzip stream readable by any gzip reader.
+type gzParallelWriter struct {
+	w       io.Writer
+	level   int
+	buf     []byte
+	blocks  int
+	pending chan chan gzBlock
+	sem     chan struct{}
+	done    chan struct{}
+	wg      sync.WaitGroup
+	mu      sync.Mutex
+	err     error
+	closed  bool
+}
+
+func newGzParallelWriter(w io.Writer, level, workers int) (*gzParallelWriter, error) {
+	if level < gzip.HuffmanOnly || level > gzip.BestCompression {
+		return nil, fmt.Errorf("invalid gzip compression level %d", level)
+	}
+
+	if workers < 1 {
+		return nil, fmt.Errorf("invalid number of workers %d", workers)
+	}
+
+	z := &gzParallelWriter{
+		w:       w,
+		level:   level,
+		buf:     make([]byte, 0, gzBlockSize),
+		pending: make(chan chan gzBlock, 2*workers),
+		sem:     make(chan struct{}, workers),
+		done:    make(chan struct{}),
+	}
+
+	go z.collect()
+	return z, nil
+}
+
+// Write implements the io.Writer interface.
+func (z *gzParallelWriter) Write(p []byte) (int, error) {
+	if z.closed {
+		return 0, fmt.Errorf("write to closed writer")
+	}
+
+	n := 0
+	for len(p) > 0 {
+		if err := z.error(); err != nil {
+			return n, err
+		}
+
+		c := copy(z.buf[len(z.buf):cap(z.buf)], p)
+		z.buf = z.buf[:len(z.buf)+c]
+		p = p[c:]
+		n += c
+
+		if len(z.buf) == cap(z.buf) {
+			z.dispatch()
+		}
+	}
+
+	return n, nil
+}
+
+// Flush compresses the buffered data and waits until
+// all compressed blocks are written to the underlying writer.
+func (z *gzParallelWriter) Flush() error {
+	if z.closed {
+		return z.error()
+	}
+
+	z.dispatch()
+	z.wg.Wait()
+	return z.error()
+}
+
+// Close flushes the buffered data and stops the writer.
+// It does not close the underlying writer.
+func (z *gzParallelWriter) Close() error {
+	if z.closed {
+		return z.error()
+	}
+
+	// An empty input still produces a valid gzip stream.
+	if z.blocks == 0 && len(z.buf) == 0 {
+		z.blocks++
+		z.enqueue(nil)
+	}
+
+	z.dispatch()
+	z.closed = true
+	close(z.pending)
+	<-z.done
+	return z.error()
+}
+
+func (z *gzParallelWriter) dispatch() {
+	if len(z.buf) == 0 {
+		return
+	}
+
+	data := z.buf
+	z.buf = make([]byte, 0, gzBlockSize)
+	z.blocks++
+	z.enqueue(data)
+}
+
+func (z *gzParallelWriter) enqueue(data []byte) {
+	ch := make(chan gzBlock, 1)
+	z.wg.Add(1)
+	z.pending <- ch
+
+	go func() {
+		z.sem <- struct{}{}
+		ch <- compressGzBlock(data, z.level)
+		<-z.sem
+	}()
+}
+
+func (z *gzParallelWriter) collect() {
+	defer close(z.done)
+
+	for ch := range z.pending {
+		b := <-ch
+		if b.err == nil && z.error() == nil {
+			_, b.err = z.w.Write(b.data)
+		}
+
+		if b.err != nil {
+			z.setError(b.err)
+		}
+
+		z.wg.Done()
+	}
+}
+
+func (z *gzParallelWriter) error() error {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+
+	return z.err
+}
+
+func (z *gzParallelWriter) setError(err error) {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+
+	if z.err == nil {
+		z.err = err
+	}
+}
+
+func compressGzBlock(data []byte, level int) gzBlock {
+	var buf bytes.Buffer
+
+	gw, err := gzip.NewWriterLevel(&buf, level)
+	if err != nil {
+		return gzBlock{err: fmt.Errorf("cannot create gzip writer: %w", err)}
+	}
+
+	if _, err := gw.Write(data); err != nil {
+		return gzBlock{err: fmt.Errorf("cannot compress block: %w", err)}
+	}
+
+	if err := gw.Close(); err != nil {
+		return gzBlock{err: fmt.Errorf("cannot close gzip writer: %w", err)}
+	}
+
+	return gzBlock{data: buf.Bytes()}
+}
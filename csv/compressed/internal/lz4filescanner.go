@@ -0,0 +1,93 @@
+package internal
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/pierrec/lz4/v4"
+)
+
+type Lz4FileScanner struct {
+	f  *os.File
+	zr *lz4FramesReader
+	s  *bufio.Scanner
+}
+
+// lz4FramesReader decompresses all concatenated lz4 frames, like the ones
+// of an appended file, the lz4 reader stops at the end of the first frame.
+type lz4FramesReader struct {
+	br *bufio.Reader
+	zr *lz4.Reader
+}
+
+func newLz4FramesReader(r io.Reader) *lz4FramesReader {
+	br := bufio.NewReaderSize(r, 32768)
+	return &lz4FramesReader{
+		br: br,
+		zr: lz4.NewReader(br),
+	}
+}
+
+func (r *lz4FramesReader) Read(p []byte) (int, error) {
+	for {
+		n, err := r.zr.Read(p)
+		if err != io.EOF {
+			return n, err
+		}
+
+		if _, perr := r.br.Peek(1); perr != nil {
+			return n, err
+		}
+
+		r.zr.Reset(r.br)
+		if n > 0 {
+			return n, nil
+		}
+	}
+}
+
+func NewLz4FileScanner(fileName string) (*Lz4FileScanner, error) {
+	f, err := os.Open(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open %q file: %w", fileName, err)
+	}
+
+	zr := newLz4FramesReader(f)
+
+	s := bufio.NewScanner(zr)
+	return &Lz4FileScanner{
+		f:  f,
+		zr: zr,
+		s:  s,
+	}, nil
+}
+
+func (s *Lz4FileScanner) Close() error {
+	if err := s.f.Close(); err != nil {
+		return fmt.Errorf("cannot close file: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Lz4FileScanner) Scan() bool {
+	return s.s.Scan()
+}
+
+func (s *Lz4FileScanner) Text() string {
+	return s.s.Text()
+}
+
+func (s *Lz4FileScanner) Bytes() []byte {
+	return s.s.Bytes()
+}
+
+func (s *Lz4FileScanner) Err() error {
+	return s.s.Err()
+}
+
+func (s *Lz4FileScanner) CopyTo(dst io.Writer) (int64, error) {
+	return io.Copy(dst, s.zr)
+}
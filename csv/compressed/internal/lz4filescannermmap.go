@@ -0,0 +1,64 @@
+package internal
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+
+	"compressed/internal/mmap"
+)
+
+type Lz4FileScannerMmap struct {
+	m  *mmap.Mmap
+	zr *lz4FramesReader
+	s  *bufio.Scanner
+}
+
+func NewLz4FileScannerMmap(fileName string) (*Lz4FileScannerMmap, error) {
+	m, err := mmap.OpenFile(fileName, mmap.ReadOnly)
+	if err != nil {
+		return nil, fmt.Errorf("cannot mmap %q file: %w", fileName, err)
+	}
+
+	zr := newLz4FramesReader(m)
+
+	s := bufio.NewScanner(zr)
+	return &Lz4FileScannerMmap{
+		m:  m,
+		zr: zr,
+		s:  s,
+	}, nil
+}
+
+// Data returns mapped memory.
+func (m *Lz4FileScannerMmap) Data() []byte {
+	return m.m.Data()
+}
+
+func (m *Lz4FileScannerMmap) Close() error {
+	if err := m.m.Close(); err != nil {
+		return fmt.Errorf("cannot close mmap: %w", err)
+	}
+
+	return nil
+}
+
+func (m *Lz4FileScannerMmap) Scan() bool {
+	return m.s.Scan()
+}
+
+func (m *Lz4FileScannerMmap) Text() string {
+	return m.s.Text()
+}
+
+func (m *Lz4FileScannerMmap) Bytes() []byte {
+	return m.s.Bytes()
+}
+
+func (m *Lz4FileScannerMmap) Err() error {
+	return m.s.Err()
+}
+
+func (s *Lz4FileScannerMmap) CopyTo(dst io.Writer) (int64, error) {
+	return io.Copy(dst, s.zr)
+}
@@ -0,0 +1,111 @@
+package internal
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+
+	"github.com/pierrec/lz4/v4"
+)
+
+type Lz4FileWriter struct {
+	f  *os.File
+	zw *lz4.Writer
+	bw *bufio.Writer
+}
+
+// NewLz4FileWriter creates a lz4 file writer.
+// The level is a lz4 compression level from 1 to 9,
+// zero means the fast compression.
+func NewLz4FileWriter(fileName string, append bool, level int) (*Lz4FileWriter, error) {
+	if level < 0 || level > 9 {
+		return nil, fmt.Errorf("invalid lz4 compression level %d", level)
+	}
+
+	lvl := lz4.Fast
+	if level > 0 {
+		lvl = lz4.CompressionLevel(1 << (8 + level))
+	}
+
+	flag := os.O_WRONLY | os.O_CREATE
+	if append {
+		flag |= os.O_APPEND
+	} else {
+		flag |= os.O_TRUNC
+	}
+
+	if f, err := os.OpenFile(fileName, flag, 0666); err != nil {
+		return nil, fmt.Errorf("cannot open '%s' file: %w", fileName, err)
+	} else {
+		zw := lz4.NewWriter(f)
+		if err := zw.Apply(lz4.CompressionLevelOption(lvl)); err != nil {
+			f.Close()
+			return nil, fmt.Errorf("cannot create lz4 writer: %w", err)
+		} else if _, err := zw.Write(nil); err != nil {
+			// The frame header is written on the first write, an empty input would have none.
+			f.Close()
+			return nil, fmt.Errorf("cannot write lz4 frame header: %w", err)
+		} else {
+			bw := bufio.NewWriterSize(zw, 32768)
+			return &Lz4FileWriter{
+				f:  f,
+				zw: zw,
+				bw: bw,
+			}, nil
+		}
+	}
+}
+
+func (w *Lz4FileWriter) Flush() error {
+	if err := w.bw.Flush(); err != nil {
+		w.zw.Flush()
+		w.f.Sync()
+		return fmt.Errorf("cannot flush bufio writer: %w", err)
+	}
+
+	if err := w.zw.Flush(); err != nil {
+		w.f.Sync()
+		return fmt.Errorf("cannot flush lz4 writer: %w", err)
+	}
+
+	if err := w.f.Sync(); err != nil {
+		return fmt.Errorf("cannot sync file: %w", err)
+	}
+
+	return nil
+}
+
+func (w *Lz4FileWriter) Close() error {
+	if err := w.bw.Flush(); err != nil {
+		w.zw.Close()
+		w.f.Close()
+		return fmt.Errorf("cannot flush bufio writer: %w", err)
+	}
+
+	if err := w.zw.Close(); err != nil {
+		w.f.Close()
+		return fmt.Errorf("cannot close lz4 writer: %w", err)
+	}
+
+	if err := w.f.Close(); err != nil {
+		return fmt.Errorf("cannot close file: %w", err)
+	}
+
+	return nil
+}
+
+func (w *Lz4FileWriter) WriteString(s string) error {
+	if _, err := w.bw.WriteString(s); err != nil {
+		return fmt.Errorf("cannot write string: %w", err)
+	}
+
+	return nil
+}
+
+func (w *Lz4FileWriter) WriteBytes(b []byte) error {
+	if _, err := w.bw.Write(b); err != nil {
+		return fmt.Errorf("cannot write bytes: %w", err)
+	}
+
+	return nil
+}
@@ -5,7 +5,7 @@ import (
 	"fmt"
 	"io"
 	"os"
-	"unsafe"
+	"runtime"
 )
 
 // Mode specifies how a mmap file should be opened.
@@ -17,26 +17,81 @@ const (
 	ReadWrite Mode = Mode(os.O_RDWR)   // ReadWrite enables read-write-access to a mmap file.
 )
 
+// Advice is a hint about the expected access pattern of the mapped memory.
+type Advice int
+
+const (
+	AdviceNormal     Advice = iota // AdviceNormal is the default access pattern.
+	AdviceSequential               // AdviceSequential expects sequential access, pages may be read ahead aggressively.
+	AdviceRandom                   // AdviceRandom expects random access, read-ahead may be disabled.
+	AdviceWillNeed                 // AdviceWillNeed expects access in the near future, pages may be read ahead now.
+)
+
 // Mmap represents a file mapped into memory.
+//
+// The mapped memory returned by Data is only valid until
+// the next Grow, Truncate or Close call.
 type Mmap struct {
 	data     []byte
 	c        int
 	f        *os.File
-	fi       os.FileInfo
 	writable bool
 	readable bool
 }
 
 var errNotWritable = errors.New("not writable")
 var errNotReadable = errors.New("not readable")
-var errNotMapped = errors.New("not mapped")
+var errClosed = errors.New("closed")
 var errInvalidWhence = errors.New("invalid whence")
 var errNegativePosition = errors.New("negative position")
+var errNegativeSize = errors.New("negative size")
+var errTooLarge = errors.New("too large")
 
 // OpenFile memory-maps the named file for reading/writing, depending on
 // the mode value: ReadOnly, WriteOnly, ReadWrite.
+// The file is created if it does not exist and the mode is writable.
+// An empty file is not mapped until it is grown.
 func OpenFile(fileName string, mode Mode) (*Mmap, error) {
-	return mmap(fileName, mode)
+	writable := mode == WriteOnly || mode == ReadWrite
+	readable := mode == ReadOnly || mode == ReadWrite
+
+	// A shared writable mapping needs a file opened for reading as well.
+	flag := os.O_RDONLY
+	if writable {
+		flag = os.O_RDWR | os.O_CREATE
+	}
+
+	f, err := os.OpenFile(fileName, flag, 0666)
+	if err != nil {
+		return nil, fmt.Errorf("mmap: couldn't open %q: %w", fileName, err)
+	}
+
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("mmap: couldn't stat %q: %w", fileName, err)
+	}
+
+	size := fi.Size()
+	if size < 0 {
+		f.Close()
+		return nil, fmt.Errorf("mmap: file %q has negative size", fileName)
+	}
+	if size != int64(int(size)) {
+		f.Close()
+		return nil, fmt.Errorf("mmap: file %q is too large", fileName)
+	}
+
+	m := &Mmap{f: f, writable: writable, readable: readable}
+	if size > 0 {
+		if m.data, err = mapFile(f, int(size), writable); err != nil {
+			f.Close()
+			return nil, fmt.Errorf("mmap: %w", err)
+		}
+	}
+
+	runtime.SetFinalizer(m, (*Mmap).Close)
+	return m, nil
 }
 
 // Len returns the length of the underlying memory-mapped file.
@@ -51,22 +106,101 @@ func (m *Mmap) Data() []byte {
 
 // Flush synchronizes the mapping's contents to the file's contents on disk.
 func (m *Mmap) Flush() error {
-	return m.sync()
+	if m.f == nil {
+		return errClosed
+	}
+
+	if m.writable && m.data != nil {
+		if err := flushData(m.data, m.f); err != nil {
+			return fmt.Errorf("sync: %w", err)
+		}
+	}
+
+	return nil
 }
 
 // Lock keeps the mapped region in physical memory.
 func (m *Mmap) Lock() error {
-	return m.lock()
+	if m.data != nil {
+		if err := lockData(m.data); err != nil {
+			return fmt.Errorf("lock: %w", err)
+		}
+	}
+
+	return nil
 }
 
 // Unlock reverses the effect of Lock.
 func (m *Mmap) Unlock() error {
-	return m.unlock()
+	if m.data != nil {
+		if err := unlockData(m.data); err != nil {
+			return fmt.Errorf("unlock: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Advise gives the operating system a hint about the expected access
+// pattern of the mapped memory. It is a no-op where not supported.
+func (m *Mmap) Advise(advice Advice) error {
+	if advice < AdviceNormal || advice > AdviceWillNeed {
+		return fmt.Errorf("advise: invalid advice %d", advice)
+	}
+
+	if m.data != nil {
+		if err := adviseData(m.data, advice); err != nil {
+			return fmt.Errorf("advise: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Grow extends the file and the mapping by n bytes.
+// The new bytes are zero. The current position is not changed.
+func (m *Mmap) Grow(n int) error {
+	if n < 0 {
+		return fmt.Errorf("grow: %w", errNegativeSize)
+	}
+
+	size := len(m.data) + n
+	if size < len(m.data) {
+		return fmt.Errorf("grow: %w", errTooLarge)
+	}
+
+	return m.resize(size)
+}
+
+// Truncate changes the size of the file and the mapping.
+// If the current position is beyond the new size, it is moved to the end.
+func (m *Mmap) Truncate(size int64) error {
+	if size < 0 {
+		return fmt.Errorf("truncate: %w", errNegativeSize)
+	}
+
+	if size != int64(int(size)) {
+		return fmt.Errorf("truncate: %w", errTooLarge)
+	}
+
+	return m.resize(int(size))
 }
 
 // Close implements the io.Closer interface.
+// It unmaps the memory and closes the file.
 func (m *Mmap) Close() error {
-	return m.unmap()
+	if m.f == nil {
+		return nil
+	}
+
+	runtime.SetFinalizer(m, nil)
+	err := m.unmap()
+	if cerr := m.f.Close(); cerr != nil && err == nil {
+		err = fmt.Errorf("close: %w", cerr)
+	}
+
+	m.f = nil
+	return err
 }
 
 // Read implements the io.Reader interface.
@@ -85,6 +219,7 @@ func (m *Mmap) Read(p []byte) (int, error) {
 }
 
 // Write implements the io.Writer interface.
+// It does not grow the mapping.
 func (m *Mmap) Write(p []byte) (int, error) {
 	if !m.writable {
 		return 0, errNotWritable
@@ -141,12 +276,8 @@ func (m *Mmap) ReadAt(p []byte, off int64) (int, error) {
 		return 0, errNotReadable
 	}
 
-	if m.data == nil {
-		return 0, errNotMapped
-	}
-
-	if m.c >= len(m.data) {
-		return 0, io.EOF
+	if m.f == nil {
+		return 0, errClosed
 	}
 
 	if off < 0 || int64(len(m.data)) < off {
@@ -162,13 +293,14 @@ func (m *Mmap) ReadAt(p []byte, off int64) (int, error) {
 }
 
 // WriteAt implements the io.WriterAt interface.
+// It does not grow the mapping.
 func (m *Mmap) WriteAt(p []byte, off int64) (int, error) {
 	if !m.writable {
 		return 0, errNotWritable
 	}
 
-	if m.data == nil {
-		return 0, errNotMapped
+	if m.f == nil {
+		return 0, errClosed
 	}
 
 	if off < 0 || int64(len(m.data)) < off {
@@ -192,7 +324,7 @@ func (m *Mmap) Seek(offset int64, whence int) (int64, error) {
 	case io.SeekCurrent:
 		c += int(offset)
 	case io.SeekEnd:
-		c = len(m.data) - int(offset)
+		c = len(m.data) + int(offset)
 	default:
 		return 0, errInvalidWhence
 	}
@@ -205,45 +337,55 @@ func (m *Mmap) Seek(offset int64, whence int) (int64, error) {
 	return int64(c), nil
 }
 
-func openFile(fileName string, mode Mode) (int, *Mmap, error) {
-	f, err := os.OpenFile(fileName, int(mode), 0666)
-	if err != nil {
-		return 0, nil, fmt.Errorf("couldn't open %q: %w", fileName, err)
+func (m *Mmap) resize(size int) error {
+	if !m.writable {
+		return errNotWritable
 	}
 
-	fi, err := f.Stat()
-	if err != nil {
-		f.Close()
-		return 0, nil, fmt.Errorf("couldn't stat %q: %w", fileName, err)
+	if m.f == nil {
+		return errClosed
 	}
 
-	writable := mode == WriteOnly || mode == ReadWrite
-	readable := mode == ReadOnly || mode == ReadWrite
-	size := fi.Size()
-	if size == 0 {
-		return 0, &Mmap{f: f, fi: fi, writable: writable, readable: readable}, nil
+	old := len(m.data)
+	if err := m.unmap(); err != nil {
+		return err
 	}
-	if size < 0 {
-		f.Close()
-		return 0, nil, fmt.Errorf("file %q has negative size", fileName)
+
+	if err := m.f.Truncate(int64(size)); err != nil {
+		// Keep the previous mapping if the file cannot be resized.
+		if old > 0 {
+			m.data, _ = mapFile(m.f, old, m.writable)
+		}
+
+		return fmt.Errorf("resize: %w", err)
 	}
-	if size != int64(int(size)) {
-		f.Close()
-		return 0, nil, fmt.Errorf("file %q is too large", fileName)
+
+	if size > 0 {
+		data, err := mapFile(m.f, size, m.writable)
+		if err != nil {
+			return fmt.Errorf("resize: %w", err)
+		}
+
+		m.data = data
 	}
 
-	return int(size), &Mmap{f: f, fi: fi, writable: writable, readable: readable}, nil
-}
+	if m.c > size {
+		m.c = size
+	}
 
-func (m *Mmap) addr() uintptr {
-	data := m.data
-	return uintptr(unsafe.Pointer(&data[0]))
+	return nil
 }
 
-func (m *Mmap) len() uintptr {
-	return uintptr(len(m.data))
-}
+func (m *Mmap) unmap() error {
+	if m.data == nil {
+		return nil
+	}
+
+	data := m.data
+	m.data = nil
+	if err := unmapData(data); err != nil {
+		return fmt.Errorf("unmap: %w", err)
+	}
 
-func (m *Mmap) fdescr() uintptr {
-	return m.f.Fd()
+	return nil
 }
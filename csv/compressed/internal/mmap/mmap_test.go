@@ -0,0 +1,344 @@
+package mmap
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func createFile(t *testing.T, content []byte) string {
+	t.Helper()
+
+	fileName := filepath.Join(t.TempDir(), "test.dat")
+	if err := os.WriteFile(fileName, content, 0666); err != nil {
+		t.Fatalf("cannot create file: %v", err)
+	}
+
+	return fileName
+}
+
+func TestReadOnly(t *testing.T) {
+	t.Parallel()
+
+	content := []byte("0123456789abcdef")
+	m, err := OpenFile(createFile(t, content), ReadOnly)
+	if err != nil {
+		t.Fatalf("cannot open: %v", err)
+	}
+	defer m.Close()
+
+	if m.Len() != len(content) {
+		t.Errorf("expected length %d, got %d", len(content), m.Len())
+	}
+
+	if !bytes.Equal(m.Data(), content) {
+		t.Errorf("expected data %q, got %q", content, m.Data())
+	}
+
+	all, err := io.ReadAll(m)
+	if err != nil {
+		t.Fatalf("cannot read all: %v", err)
+	}
+
+	if !bytes.Equal(all, content) {
+		t.Errorf("expected read %q, got %q", content, all)
+	}
+
+	if _, err := m.Write([]byte("x")); !errors.Is(err, errNotWritable) {
+		t.Errorf("expected not writable error on write, got %v", err)
+	}
+
+	if _, err := m.WriteAt([]byte("x"), 0); !errors.Is(err, errNotWritable) {
+		t.Errorf("expected not writable error on write at, got %v", err)
+	}
+
+	if err := m.Grow(1); !errors.Is(err, errNotWritable) {
+		t.Errorf("expected not writable error on grow, got %v", err)
+	}
+
+	if err := m.Truncate(1); !errors.Is(err, errNotWritable) {
+		t.Errorf("expected not writable error on truncate, got %v", err)
+	}
+}
+
+func TestReadAt(t *testing.T) {
+	t.Parallel()
+
+	content := []byte("0123456789")
+	m, err := OpenFile(createFile(t, content), ReadOnly)
+	if err != nil {
+		t.Fatalf("cannot open: %v", err)
+	}
+	defer m.Close()
+
+	// The current position does not affect ReadAt.
+	if _, err := m.Seek(0, io.SeekEnd); err != nil {
+		t.Fatalf("cannot seek: %v", err)
+	}
+
+	tests := []struct {
+		off      int64
+		size     int
+		expected string
+		err      error
+	}{
+		{0, 4, "0123", nil},
+		{6, 4, "6789", nil},
+		{8, 4, "89", io.EOF},
+		{10, 4, "", io.EOF},
+	}
+
+	for _, tt := range tests {
+		p := make([]byte, tt.size)
+		n, err := m.ReadAt(p, tt.off)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("ReadAt(%d): expected error %v, got %v", tt.off, tt.err, err)
+		}
+
+		if string(p[:n]) != tt.expected {
+			t.Errorf("ReadAt(%d): expected %q, got %q", tt.off, tt.expected, p[:n])
+		}
+	}
+
+	if _, err := m.ReadAt(make([]byte, 1), 11); err == nil {
+		t.Errorf("expected an error for the offset beyond the end")
+	}
+
+	if _, err := m.ReadAt(make([]byte, 1), -1); err == nil {
+		t.Errorf("expected an error for the negative offset")
+	}
+}
+
+func TestSeek(t *testing.T) {
+	t.Parallel()
+
+	m, err := OpenFile(createFile(t, []byte("0123456789")), ReadOnly)
+	if err != nil {
+		t.Fatalf("cannot open: %v", err)
+	}
+	defer m.Close()
+
+	tests := []struct {
+		offset   int64
+		whence   int
+		expected int64
+	}{
+		{3, io.SeekStart, 3},
+		{2, io.SeekCurrent, 5},
+		{-4, io.SeekEnd, 6},
+		{0, io.SeekEnd, 10},
+	}
+
+	for _, tt := range tests {
+		pos, err := m.Seek(tt.offset, tt.whence)
+		if err != nil {
+			t.Fatalf("Seek(%d, %d): %v", tt.offset, tt.whence, err)
+		}
+
+		if pos != tt.expected {
+			t.Errorf("Seek(%d, %d): expected %d, got %d", tt.offset, tt.whence, tt.expected, pos)
+		}
+	}
+
+	if _, err := m.Seek(-11, io.SeekEnd); !errors.Is(err, errNegativePosition) {
+		t.Errorf("expected negative position error, got %v", err)
+	}
+
+	if _, err := m.Seek(0, 42); !errors.Is(err, errInvalidWhence) {
+		t.Errorf("expected invalid whence error, got %v", err)
+	}
+}
+
+func TestEmptyFile(t *testing.T) {
+	t.Parallel()
+
+	m, err := OpenFile(createFile(t, nil), ReadOnly)
+	if err != nil {
+		t.Fatalf("cannot open: %v", err)
+	}
+
+	if m.Len() != 0 || m.Data() != nil {
+		t.Errorf("expected no mapping, got %d bytes", m.Len())
+	}
+
+	if _, err := m.Read(make([]byte, 1)); err != io.EOF {
+		t.Errorf("expected EOF on read, got %v", err)
+	}
+
+	if _, err := m.ReadAt(make([]byte, 1), 0); err != io.EOF {
+		t.Errorf("expected EOF on read at, got %v", err)
+	}
+
+	if err := m.Advise(AdviceSequential); err != nil {
+		t.Errorf("cannot advise: %v", err)
+	}
+
+	if err := m.Close(); err != nil {
+		t.Errorf("cannot close: %v", err)
+	}
+
+	if err := m.Close(); err != nil {
+		t.Errorf("cannot close twice: %v", err)
+	}
+}
+
+func TestReadWriteGrowTruncate(t *testing.T) {
+	t.Parallel()
+
+	// A writable mapping creates a missing file.
+	fileName := filepath.Join(t.TempDir(), "append.dat")
+	m, err := OpenFile(fileName, ReadWrite)
+	if err != nil {
+		t.Fatalf("cannot open: %v", err)
+	}
+
+	if m.Len() != 0 {
+		t.Fatalf("expected an empty mapping, got %d bytes", m.Len())
+	}
+
+	if _, err := m.Write([]byte("x")); !errors.Is(err, io.ErrShortWrite) {
+		t.Errorf("expected short write on an empty mapping, got %v", err)
+	}
+
+	// Append records, growing the file for each of them.
+	records := []string{"first;1\n", "second;2\n", "third;3\n"}
+	var expected []byte
+	for _, r := range records {
+		if err := m.Grow(len(r)); err != nil {
+			t.Fatalf("cannot grow: %v", err)
+		}
+
+		if n, err := m.Write([]byte(r)); err != nil || n != len(r) {
+			t.Fatalf("cannot write %q: %d, %v", r, n, err)
+		}
+
+		expected = append(expected, r...)
+	}
+
+	if !bytes.Equal(m.Data(), expected) {
+		t.Errorf("expected data %q, got %q", expected, m.Data())
+	}
+
+	// Reserve space ahead, then truncate it back.
+	if err := m.Grow(4096); err != nil {
+		t.Fatalf("cannot grow: %v", err)
+	}
+
+	if n, err := m.WriteAt([]byte("fourth;4\n"), int64(len(expected))); err != nil || n != 9 {
+		t.Fatalf("cannot write at: %d, %v", n, err)
+	}
+
+	expected = append(expected, "fourth;4\n"...)
+	if _, err := m.WriteAt([]byte("overflow"), int64(m.Len()-2)); !errors.Is(err, io.ErrShortWrite) {
+		t.Errorf("expected short write beyond the end, got %v", err)
+	}
+
+	if err := m.Truncate(int64(len(expected))); err != nil {
+		t.Fatalf("cannot truncate: %v", err)
+	}
+
+	if err := m.Flush(); err != nil {
+		t.Fatalf("cannot flush: %v", err)
+	}
+
+	p := make([]byte, len(expected))
+	if _, err := m.ReadAt(p, 0); err != nil || !bytes.Equal(p, expected) {
+		t.Errorf("expected read %q, got %q, %v", expected, p, err)
+	}
+
+	if err := m.Close(); err != nil {
+		t.Fatalf("cannot close: %v", err)
+	}
+
+	content, err := os.ReadFile(fileName)
+	if err != nil {
+		t.Fatalf("cannot read file: %v", err)
+	}
+
+	if !bytes.Equal(content, expected) {
+		t.Errorf("expected file %q, got %q", expected, content)
+	}
+}
+
+func TestTruncateMovesPosition(t *testing.T) {
+	t.Parallel()
+
+	m, err := OpenFile(createFile(t, []byte("0123456789")), ReadWrite)
+	if err != nil {
+		t.Fatalf("cannot open: %v", err)
+	}
+	defer m.Close()
+
+	if _, err := m.Seek(8, io.SeekStart); err != nil {
+		t.Fatalf("cannot seek: %v", err)
+	}
+
+	if err := m.Truncate(4); err != nil {
+		t.Fatalf("cannot truncate: %v", err)
+	}
+
+	if pos, _ := m.Seek(0, io.SeekCurrent); pos != 4 {
+		t.Errorf("expected position 4, got %d", pos)
+	}
+
+	if err := m.Truncate(0); err != nil {
+		t.Fatalf("cannot truncate: %v", err)
+	}
+
+	if m.Len() != 0 || m.Data() != nil {
+		t.Errorf("expected no mapping, got %d bytes", m.Len())
+	}
+
+	if err := m.Truncate(-1); !errors.Is(err, errNegativeSize) {
+		t.Errorf("expected negative size error, got %v", err)
+	}
+}
+
+func TestWriteOnly(t *testing.T) {
+	t.Parallel()
+
+	fileName := createFile(t, []byte("abc"))
+	m, err := OpenFile(fileName, WriteOnly)
+	if err != nil {
+		t.Fatalf("cannot open: %v", err)
+	}
+
+	if _, err := m.Read(make([]byte, 1)); !errors.Is(err, errNotReadable) {
+		t.Errorf("expected not readable error, got %v", err)
+	}
+
+	if _, err := m.Write([]byte("xyz")); err != nil {
+		t.Fatalf("cannot write: %v", err)
+	}
+
+	if err := m.Close(); err != nil {
+		t.Fatalf("cannot close: %v", err)
+	}
+
+	if content, _ := os.ReadFile(fileName); string(content) != "xyz" {
+		t.Errorf("expected file %q, got %q", "xyz", content)
+	}
+}
+
+func TestAdvise(t *testing.T) {
+	t.Parallel()
+
+	m, err := OpenFile(createFile(t, bytes.Repeat([]byte("0123456789"), 1000)), ReadOnly)
+	if err != nil {
+		t.Fatalf("cannot open: %v", err)
+	}
+	defer m.Close()
+
+	for _, a := range []Advice{AdviceSequential, AdviceRandom, AdviceWillNeed, AdviceNormal} {
+		if err := m.Advise(a); err != nil {
+			t.Errorf("cannot advise %d: %v", a, err)
+		}
+	}
+
+	if err := m.Advise(Advice(42)); err == nil {
+		t.Errorf("expected an error for an invalid advice")
+	}
+}
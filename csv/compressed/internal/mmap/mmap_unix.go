@@ -5,77 +5,71 @@ package mmap
 
 import (
 	"os"
-	"syscall"
-	"unsafe"
+
+	"golang.org/x/sys/unix"
 )
 
-func mmap(fileName string, mode Mode) (*Mmap, error) {
-	size, m, err := openFile(fileName, mode)
-	if err != nil {
-		return nil, fmt.Errorf("mmap: %w", err)
+func mapFile(f *os.File, size int, writable bool) ([]byte, error) {
+	prot := unix.PROT_READ
+	if writable {
+		prot |= unix.PROT_WRITE
 	}
 
-	if size == 0 {
-		return m, nil
+	data, err := unix.Mmap(int(f.Fd()), 0, size, prot, unix.MAP_SHARED)
+	if err != nil {
+		return nil, os.NewSyscallError("Mmap", err)
 	}
 
-	prot := syscall.PROT_READ
-	if m.writable {
-		prot |= syscall.PROT_WRITE
-	}
+	return data, nil
+}
 
-	data, err := syscall.Mmap(int(m.fdescr()), 0, int(size), prot, syscall.MAP_SHARED)
-	if err != nil {
-		return nil, fmt.Errorf("mmap: %w", os.NewSyscallError("Mmap", err))
+func unmapData(data []byte) error {
+	if err := unix.Munmap(data); err != nil {
+		return os.NewSyscallError("Munmap", err)
 	}
 
-	m.data = data
-
-	runtime.SetFinalizer(m, (*Mmap).unmap)
-	return m, nil
+	return nil
 }
 
-func (m *Mmap) sync() error {
-	if m.writable {
-		if _, _, err := syscall.Syscall(syscall.SYS_MSYNC, m.addr(), m.len(), syscall.MS_SYNC); err != nil {
-			return fmt.Errorf("sync: %w", os.NewSyscallError("SYS_MSYNC", err))
-		}
+func flushData(data []byte, _ *os.File) error {
+	if err := unix.Msync(data, unix.MS_SYNC); err != nil {
+		return os.NewSyscallError("Msync", err)
 	}
 
 	return nil
 }
 
-func (m *Mmap) lock() error {
-	if m.data == nil {
-		if err := syscall.Mlock(m.data); err != nil {
-			return fmt.Errorf("lock: %w", os.NewSyscallError("Mlock", err))
-		}
+func lockData(data []byte) error {
+	if err := unix.Mlock(data); err != nil {
+		return os.NewSyscallError("Mlock", err)
 	}
 
 	return nil
 }
 
-func (m *Mmap) unlock() error {
-	if m.data != nil {
-		if err := syscall.Munlock(m.data); err != nil {
-			return fmt.Errorf("unlock: %w", os.NewSyscallError("Munlock", err))
-		}
+func unlockData(data []byte) error {
+	if err := unix.Munlock(data); err != nil {
+		return os.NewSyscallError("Munlock", err)
 	}
 
 	return nil
 }
 
-func (m *Mmap) unmap() error {
-	if m.data == nil {
-		return nil
+func adviseData(data []byte, advice Advice) error {
+	var flag int
+	switch advice {
+	case AdviceSequential:
+		flag = unix.MADV_SEQUENTIAL
+	case AdviceRandom:
+		flag = unix.MADV_RANDOM
+	case AdviceWillNeed:
+		flag = unix.MADV_WILLNEED
+	default:
+		flag = unix.MADV_NORMAL
 	}
-	defer m.f.Close()
 
-	runtime.SetFinalizer(m, nil)
-	data := m.data
-	m.data = nil
-	if err := syscall.Munmap(data); err != nil {
-		return fmt.Errorf("unmap: %w", os.NewSyscallError("Munmap", err))
+	if err := unix.Madvise(data, flag); err != nil {
+		return os.NewSyscallError("Madvise", err)
 	}
 
 	return nil
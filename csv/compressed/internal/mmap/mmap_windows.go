@@ -4,9 +4,7 @@
 package mmap
 
 import (
-	"fmt"
 	"os"
-	"runtime"
 	"syscall"
 	"unsafe"
 )
@@ -16,98 +14,79 @@ import (
 // Then, we call MapviewToFile to get an actual pointer into memory.
 // Because we want to emulate a POSIX-style mmap, we don't want to expose
 // the handle -- only the pointer (a byte slice).
-func mmap(fileName string, mode Mode) (*Mmap, error) {
-	size, m, err := openFile(fileName, mode)
-	if err != nil {
-		return nil, fmt.Errorf("mmap: %w", err)
-	}
-
-	if size == 0 {
-		return m, nil
-	}
-
+func mapFile(f *os.File, size int, writable bool) ([]byte, error) {
 	prot := uint32(syscall.PAGE_READONLY)
 	view := uint32(syscall.FILE_MAP_READ)
-	if m.writable {
+	if writable {
 		prot = syscall.PAGE_READWRITE
 		view = syscall.FILE_MAP_WRITE
 	}
 
 	// The maximum size is the area of the file, starting from 0,
 	// that we wish to allow to be mappable.
-	maxSizeLow, maxSizeHigh := uint32(size), uint32(size>>32)
-	h, err := syscall.CreateFileMapping(m.hndl(), nil, prot, maxSizeHigh, maxSizeLow, nil)
+	maxSizeLow, maxSizeHigh := uint32(size), uint32(uint64(size)>>32)
+	h, err := syscall.CreateFileMapping(syscall.Handle(f.Fd()), nil, prot, maxSizeHigh, maxSizeLow, nil)
 	if err != nil {
-		m.f.Close()
-		return nil, fmt.Errorf("mmap: %w", os.NewSyscallError("CreateFileMapping", err))
+		return nil, os.NewSyscallError("CreateFileMapping", err)
 	}
 
 	// Actually map a view of the data into memory.
 	ptr, err := syscall.MapViewOfFile(h, view, 0, 0, uintptr(size))
 	if err != nil {
 		syscall.CloseHandle(h)
-		m.f.Close()
-		return nil, fmt.Errorf("mmap: %w", os.NewSyscallError("MapViewOfFile", err))
+		return nil, os.NewSyscallError("MapViewOfFile", err)
 	}
 
 	const maxBytes = 1<<50 - 1
 
 	//nolint:unsafeptr
-	m.data = (*[maxBytes]byte)(unsafe.Pointer(ptr))[:size]
+	data := (*[maxBytes]byte)(unsafe.Pointer(ptr))[:size:size]
 
 	syscall.CloseHandle(h)
-	runtime.SetFinalizer(m, (*Mmap).unmap)
-	return m, nil
+	return data, nil
 }
 
-func (m *Mmap) sync() error {
-	if m.writable {
-		if err := syscall.FlushViewOfFile(m.addr(), m.len()); err != nil {
-			return fmt.Errorf("sync: couldn't flush view: %w", os.NewSyscallError("FlushViewOfFile", err))
-		}
-
-		if err := syscall.FlushFileBuffers(m.hndl()); err != nil {
-			return fmt.Errorf("sync: couldn't flush buffers: %w", os.NewSyscallError("FlushFileBuffers", err))
-		}
+func unmapData(data []byte) error {
+	if err := syscall.UnmapViewOfFile(addr(data)); err != nil {
+		return os.NewSyscallError("UnmapViewOfFile", err)
 	}
 
 	return nil
 }
 
-func (m *Mmap) lock() error {
-	if err := syscall.VirtualLock(m.addr(), m.len()); err != nil {
-		return fmt.Errorf("lock: %w", os.NewSyscallError("VirtualLock", err))
+func flushData(data []byte, f *os.File) error {
+	if err := syscall.FlushViewOfFile(addr(data), uintptr(len(data))); err != nil {
+		return os.NewSyscallError("FlushViewOfFile", err)
+	}
+
+	if err := syscall.FlushFileBuffers(syscall.Handle(f.Fd())); err != nil {
+		return os.NewSyscallError("FlushFileBuffers", err)
 	}
 
 	return nil
 }
 
-func (m *Mmap) unlock() error {
-	if err := syscall.VirtualUnlock(m.addr(), m.len()); err != nil {
-		return fmt.Errorf("unlock: %w", os.NewSyscallError("VirtualUnlock", err))
+func lockData(data []byte) error {
+	if err := syscall.VirtualLock(addr(data), uintptr(len(data))); err != nil {
+		return os.NewSyscallError("VirtualLock", err)
 	}
 
 	return nil
 }
 
-func (m *Mmap) unmap() error {
-	if m.data == nil {
-		return nil
+func unlockData(data []byte) error {
+	if err := syscall.VirtualUnlock(addr(data), uintptr(len(data))); err != nil {
+		return os.NewSyscallError("VirtualUnlock", err)
 	}
-	defer m.f.Close()
-
-	runtime.SetFinalizer(m, nil)
-	addr := m.addr()
-	m.data = nil
 
-	err := syscall.UnmapViewOfFile(addr)
-	if err != nil {
-		return fmt.Errorf("unmap: %w", os.NewSyscallError("UnmapViewOfFile", err))
-	}
+	return nil
+}
 
+// The syscall package has no PrefetchVirtualMemory, so the advice is ignored.
+func adviseData(data []byte, advice Advice) error {
 	return nil
 }
 
-func (m *Mmap) hndl() syscall.Handle {
-	return syscall.Handle(m.fdescr())
+func addr(data []byte) uintptr {
+	return uintptr(unsafe.Pointer(&data[0]))
 }
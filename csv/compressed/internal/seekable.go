@@ -0,0 +1,673 @@
+package internal
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// A seekable file is a sequence of independently compressed frames followed
+// by a footer with the frame index. Every frame is a complete gzip member or
+// zstd frame, and the footer is stored in containers ignored by decompressors:
+// empty gzip members with the index in the header extra field, or a zstd
+// skippable frame. So a seekable file is still a valid gzip or zstd file.
+//
+// The footer payload is a sequence of 32-byte index entries, followed by
+// the 16-byte trailer: the offset of the footer and the magic.
+// An index entry holds the first and the last timestamps of the frame
+// in Unix nanoseconds, the frame offset and the compressed frame size,
+// all little-endian.
+
+const (
+	// DefaultFrameSize is the default uncompressed size of a seekable file frame.
+	DefaultFrameSize = 4 << 20
+
+	seekableMagic        = "CSVSEEK1"
+	seekableEntrySize    = 32
+	seekableTrailerSize  = 16
+	seekableNoTime       = math.MinInt64
+	seekableGzSubfield1  = 'C'
+	seekableGzSubfield2  = 'S'
+	seekableGzMaxData    = math.MaxUint16 - 4
+	seekableGzSuffixSize = 10
+	seekableZstMagic     = 0x184d2a5e
+)
+
+// Empty final fixed Huffman deflate block.
+var seekableGzEmptyDeflate = []byte{0x03, 0x00}
+
+// TimeFunc returns the timestamp of a line.
+// It returns false for lines without a timestamp, like headers or comments.
+type TimeFunc func(line []byte) (time.Time, bool)
+
+// TimeColumn returns a TimeFunc parsing the zero-based column of a line
+// separated by the sep character using the time layout.
+func TimeColumn(column int, sep byte, layout string) TimeFunc {
+	return func(line []byte) (time.Time, bool) {
+		for i := 0; i < column; i++ {
+			j := bytes.IndexByte(line, sep)
+			if j < 0 {
+				return time.Time{}, false
+			}
+
+			line = line[j+1:]
+		}
+
+		if j := bytes.IndexByte(line, sep); j >= 0 {
+			line = line[:j]
+		}
+
+		t, err := time.Parse(layout, string(bytes.TrimSpace(line)))
+		if err != nil {
+			return time.Time{}, false
+		}
+
+		return t, true
+	}
+}
+
+// SeekableFrame describes an independently compressed frame of a seekable file.
+type SeekableFrame struct {
+	// First is the earliest timestamp in the frame, which is the timestamp
+	// of the first line for a time-ordered file.
+	// It is zero if the frame has no timestamped lines.
+	First time.Time
+
+	// Last is the latest timestamp in the frame.
+	Last time.Time
+
+	// Offset is the offset of the compressed frame in the file.
+	Offset int64
+
+	// Size is the size of the compressed frame.
+	Size int64
+}
+
+// Overlaps reports whether the frame may contain lines in the [from, to) range.
+func (fr *SeekableFrame) Overlaps(from, to time.Time) bool {
+	if fr.First.IsZero() {
+		return false
+	}
+
+	return fr.First.Before(to) && !fr.Last.Before(from)
+}
+
+// SeekableFileWriter writes a seekable file with a time index.
+type SeekableFileWriter struct {
+	f         *os.File
+	codec     Codec
+	level     int
+	frameSize int
+	time      TimeFunc
+	zw        *zstd.Encoder
+	buf       []byte
+	frames    []SeekableFrame
+	offset    int64
+}
+
+// NewSeekableFileWriter creates a seekable file writer.
+// The options should have a TimeFunc and the gz or zst codec, the codec
+// is derived from the file extension if the options do not specify one.
+// Appending to an existing seekable file is not supported.
+func NewSeekableFileWriter(fileName string, opts WriterOptions) (*SeekableFileWriter, error) {
+	if opts.Time == nil {
+		return nil, fmt.Errorf("seekable file writer requires a time function")
+	}
+
+	if opts.Append {
+		return nil, fmt.Errorf("seekable file writer cannot append")
+	}
+
+	codec := opts.Codec
+	if codec == CodecNone {
+		codec = CodecFromExt(fileName)
+	}
+
+	frameSize := opts.FrameSize
+	if frameSize <= 0 {
+		frameSize = DefaultFrameSize
+	}
+
+	w := &SeekableFileWriter{
+		codec:     codec,
+		level:     opts.Level,
+		frameSize: frameSize,
+		time:      opts.Time,
+	}
+
+	switch codec {
+	case CodecGz:
+		w.level = gzipLevel(w.level)
+		if w.level == gzip.DefaultCompression {
+			w.level = gzip.BestCompression
+		}
+	case CodecZst:
+		if w.level < 0 || w.level > 22 {
+			return nil, fmt.Errorf("invalid zstd compression level %d", w.level)
+		}
+
+		lvl := zstd.SpeedDefault
+		if w.level > 0 {
+			lvl = zstd.EncoderLevelFromZstd(w.level)
+		}
+
+		zw, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(lvl))
+		if err != nil {
+			return nil, fmt.Errorf("cannot create zstd writer: %w", err)
+		}
+
+		w.zw = zw
+	default:
+		return nil, fmt.Errorf("seekable file writer does not support %v codec", codec)
+	}
+
+	f, err := os.OpenFile(fileName, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0666)
+	if err != nil {
+		if w.zw != nil {
+			w.zw.Close()
+		}
+
+		return nil, fmt.Errorf("cannot open '%s' file: %w", fileName, err)
+	}
+
+	w.f = f
+	w.buf = make([]byte, 0, frameSize)
+	return w, nil
+}
+
+// Frames returns the index of the frames written so far.
+func (w *SeekableFileWriter) Frames() []SeekableFrame {
+	return w.frames
+}
+
+// Flush writes all buffered complete lines as a frame and syncs the file.
+func (w *SeekableFileWriter) Flush() error {
+	if i := bytes.LastIndexByte(w.buf, '\n'); i >= 0 {
+		if err := w.writeFrame(i + 1); err != nil {
+			w.f.Sync()
+			return err
+		}
+	}
+
+	if err := w.f.Sync(); err != nil {
+		return fmt.Errorf("cannot sync file: %w", err)
+	}
+
+	return nil
+}
+
+// Close writes the buffered data and the footer index and closes the file.
+func (w *SeekableFileWriter) Close() error {
+	if w.zw != nil {
+		defer w.zw.Close()
+	}
+
+	if len(w.buf) > 0 {
+		if err := w.writeFrame(len(w.buf)); err != nil {
+			w.f.Close()
+			return err
+		}
+	}
+
+	if err := w.writeFooter(); err != nil {
+		w.f.Close()
+		return err
+	}
+
+	if err := w.f.Close(); err != nil {
+		return fmt.Errorf("cannot close file: %w", err)
+	}
+
+	return nil
+}
+
+func (w *SeekableFileWriter) WriteString(s string) error {
+	w.buf = append(w.buf, s...)
+	return w.cut()
+}
+
+func (w *SeekableFileWriter) WriteBytes(b []byte) error {
+	w.buf = append(w.buf, b...)
+	return w.cut()
+}
+
+// cut writes frames while the buffer has enough data.
+// Frames are cut at line boundaries only.
+func (w *SeekableFileWriter) cut() error {
+	for len(w.buf) >= w.frameSize {
+		i := bytes.LastIndexByte(w.buf, '\n')
+		if i < 0 {
+			// A line longer than the frame, wait for its end.
+			return nil
+		}
+
+		if err := w.writeFrame(i + 1); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeFrame compresses and writes the first n bytes of the buffer.
+func (w *SeekableFileWriter) writeFrame(n int) error {
+	data := w.buf[:n]
+	frame := SeekableFrame{Offset: w.offset}
+
+	for line := data; len(line) > 0; {
+		i := bytes.IndexByte(line, '\n')
+		if i < 0 {
+			i = len(line)
+		}
+
+		if t, ok := w.time(bytes.TrimSuffix(line[:i], []byte{'\r'})); ok {
+			if frame.First.IsZero() || t.Before(frame.First) {
+				frame.First = t
+			}
+
+			if frame.Last.IsZero() || t.After(frame.Last) {
+				frame.Last = t
+			}
+		}
+
+		if i < len(line) {
+			i++
+		}
+
+		line = line[i:]
+	}
+
+	var compressed []byte
+	switch w.codec {
+	case CodecGz:
+		b := compressGzBlock(data, w.level)
+		if b.err != nil {
+			return b.err
+		}
+
+		compressed = b.data
+	case CodecZst:
+		compressed = w.zw.EncodeAll(data, nil)
+	}
+
+	if _, err := w.f.Write(compressed); err != nil {
+		return fmt.Errorf("cannot write frame: %w", err)
+	}
+
+	frame.Size = int64(len(compressed))
+	w.offset += frame.Size
+	w.frames = append(w.frames, frame)
+
+	rest := copy(w.buf, w.buf[n:])
+	w.buf = w.buf[:rest]
+	return nil
+}
+
+func (w *SeekableFileWriter) writeFooter() error {
+	body := make([]byte, 0, len(w.frames)*seekableEntrySize)
+	for i := range w.frames {
+		body = appendSeekableEntry(body, &w.frames[i])
+	}
+
+	trailer := make([]byte, 0, seekableTrailerSize)
+	trailer = binary.LittleEndian.AppendUint64(trailer, uint64(w.offset))
+	trailer = append(trailer, seekableMagic...)
+
+	var footer []byte
+	switch w.codec {
+	case CodecGz:
+		// The extra field is limited, so the footer may need several members.
+		const maxBody = seekableGzMaxData - seekableTrailerSize
+		for len(body) > maxBody {
+			footer = appendSeekableGzMember(footer, body[:maxBody])
+			body = body[maxBody:]
+		}
+
+		footer = appendSeekableGzMember(footer, append(body, trailer...))
+	case CodecZst:
+		payload := append(body, trailer...)
+		footer = binary.LittleEndian.AppendUint32(footer, seekableZstMagic)
+		footer = binary.LittleEndian.AppendUint32(footer, uint32(len(payload)))
+		footer = append(footer, payload...)
+	}
+
+	if _, err := w.f.Write(footer); err != nil {
+		return fmt.Errorf("cannot write footer: %w", err)
+	}
+
+	return nil
+}
+
+func appendSeekableEntry(b []byte, fr *SeekableFrame) []byte {
+	first, last := int64(seekableNoTime), int64(seekableNoTime)
+	if !fr.First.IsZero() {
+		first, last = fr.First.UnixNano(), fr.Last.UnixNano()
+	}
+
+	b = binary.LittleEndian.AppendUint64(b, uint64(first))
+	b = binary.LittleEndian.AppendUint64(b, uint64(last))
+	b = binary.LittleEndian.AppendUint64(b, uint64(fr.Offset))
+	return binary.LittleEndian.AppendUint64(b, uint64(fr.Size))
+}
+
+// appendSeekableGzMember appends an empty gzip member
+// with the data in the header extra field.
+func appendSeekableGzMember(b, data []byte) []byte {
+	const (
+		flagExtra = 0x04
+		osUnknown = 0xff
+	)
+
+	b = append(b, 0x1f, 0x8b, 0x08, flagExtra, 0, 0, 0, 0, 0, osUnknown)
+	b = binary.LittleEndian.AppendUint16(b, uint16(len(data)+4))
+	b = append(b, seekableGzSubfield1, seekableGzSubfield2)
+	b = binary.LittleEndian.AppendUint16(b, uint16(len(data)))
+	b = append(b, data...)
+	b = append(b, seekableGzEmptyDeflate...)
+
+	// The CRC-32 and the size of the empty content.
+	return append(b, 0, 0, 0, 0, 0, 0, 0, 0)
+}
+
+var errNotSeekable = errors.New("not a seekable file")
+
+// SeekableFile reads a seekable file written by the SeekableFileWriter.
+type SeekableFile struct {
+	f      *os.File
+	codec  Codec
+	time   TimeFunc
+	frames []SeekableFrame
+}
+
+// OpenSeekableFile opens a seekable file and reads its index.
+// The time function should be the one used to write the file.
+func OpenSeekableFile(fileName string, fn TimeFunc) (*SeekableFile, error) {
+	if fn == nil {
+		return nil, fmt.Errorf("seekable file requires a time function")
+	}
+
+	c, err := detect(fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open %q file: %w", fileName, err)
+	}
+
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("cannot stat %q file: %w", fileName, err)
+	}
+
+	frames, err := readSeekableIndex(f, fi.Size(), c.codec)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("cannot read %q index: %w", fileName, err)
+	}
+
+	return &SeekableFile{
+		f:      f,
+		codec:  c.codec,
+		time:   fn,
+		frames: frames,
+	}, nil
+}
+
+// Codec returns the codec of the frames.
+func (s *SeekableFile) Codec() Codec {
+	return s.codec
+}
+
+// Frames returns the frame index.
+func (s *SeekableFile) Frames() []SeekableFrame {
+	return s.frames
+}
+
+func (s *SeekableFile) Close() error {
+	if err := s.f.Close(); err != nil {
+		return fmt.Errorf("cannot close file: %w", err)
+	}
+
+	return nil
+}
+
+// ScanRange returns a scanner over the lines with timestamps in the [from, to) range.
+// Only the frames overlapping the range are decompressed.
+// The scanner is valid until the seekable file is closed.
+func (s *SeekableFile) ScanRange(from, to time.Time) *SeekableRangeScanner {
+	var frames []SeekableFrame
+	for i := range s.frames {
+		if s.frames[i].Overlaps(from, to) {
+			frames = append(frames, s.frames[i])
+		}
+	}
+
+	return &SeekableRangeScanner{
+		file:   s,
+		from:   from,
+		to:     to,
+		frames: frames,
+	}
+}
+
+func readSeekableIndex(r io.ReaderAt, size int64, codec Codec) ([]SeekableFrame, error) {
+	suffix := int64(0)
+	if codec == CodecGz {
+		suffix = seekableGzSuffixSize
+	} else if codec != CodecZst {
+		return nil, errNotSeekable
+	}
+
+	if size < seekableTrailerSize+suffix {
+		return nil, errNotSeekable
+	}
+
+	trailer := make([]byte, seekableTrailerSize)
+	if _, err := r.ReadAt(trailer, size-suffix-seekableTrailerSize); err != nil {
+		return nil, err
+	}
+
+	if string(trailer[8:]) != seekableMagic {
+		return nil, errNotSeekable
+	}
+
+	offset := int64(binary.LittleEndian.Uint64(trailer))
+	if offset < 0 || offset > size-suffix-seekableTrailerSize {
+		return nil, fmt.Errorf("invalid footer offset %d", offset)
+	}
+
+	footer := make([]byte, size-offset)
+	if _, err := r.ReadAt(footer, offset); err != nil {
+		return nil, err
+	}
+
+	var payload []byte
+	switch codec {
+	case CodecGz:
+		for len(footer) > 0 {
+			data, rest, err := parseSeekableGzMember(footer)
+			if err != nil {
+				return nil, err
+			}
+
+			payload = append(payload, data...)
+			footer = rest
+		}
+	case CodecZst:
+		if len(footer) < 8 || binary.LittleEndian.Uint32(footer) != seekableZstMagic ||
+			int(binary.LittleEndian.Uint32(footer[4:])) != len(footer)-8 {
+			return nil, fmt.Errorf("invalid zstd footer frame")
+		}
+
+		payload = footer[8:]
+	}
+
+	if len(payload) < seekableTrailerSize {
+		return nil, fmt.Errorf("invalid footer size %d", len(payload))
+	}
+
+	body := payload[:len(payload)-seekableTrailerSize]
+	if len(body)%seekableEntrySize != 0 {
+		return nil, fmt.Errorf("invalid index size %d", len(body))
+	}
+
+	frames := make([]SeekableFrame, 0, len(body)/seekableEntrySize)
+	for ; len(body) > 0; body = body[seekableEntrySize:] {
+		first := int64(binary.LittleEndian.Uint64(body))
+		last := int64(binary.LittleEndian.Uint64(body[8:]))
+		fr := SeekableFrame{
+			Offset: int64(binary.LittleEndian.Uint64(body[16:])),
+			Size:   int64(binary.LittleEndian.Uint64(body[24:])),
+		}
+
+		if first != seekableNoTime {
+			fr.First = time.Unix(0, first).UTC()
+			fr.Last = time.Unix(0, last).UTC()
+		}
+
+		if fr.Offset < 0 || fr.Size < 0 || fr.Offset+fr.Size > offset {
+			return nil, fmt.Errorf("invalid frame offset %d or size %d", fr.Offset, fr.Size)
+		}
+
+		frames = append(frames, fr)
+	}
+
+	return frames, nil
+}
+
+func parseSeekableGzMember(b []byte) ([]byte, []byte, error) {
+	const headerSize = 16
+
+	if len(b) < headerSize || b[0] != 0x1f || b[1] != 0x8b || b[3] != 0x04 ||
+		b[12] != seekableGzSubfield1 || b[13] != seekableGzSubfield2 {
+		return nil, nil, fmt.Errorf("invalid gzip footer member")
+	}
+
+	n := int(binary.LittleEndian.Uint16(b[14:]))
+	end := headerSize + n + seekableGzSuffixSize
+	if int(binary.LittleEndian.Uint16(b[10:])) != n+4 || len(b) < end {
+		return nil, nil, fmt.Errorf("invalid gzip footer member size")
+	}
+
+	return b[headerSize : headerSize+n], b[end:], nil
+}
+
+// SeekableRangeScanner scans the lines of a seekable file within a time range.
+type SeekableRangeScanner struct {
+	file   *SeekableFile
+	from   time.Time
+	to     time.Time
+	frames []SeekableFrame
+	zr     *zstd.Decoder
+	s      *bufio.Scanner
+	err    error
+}
+
+// Frames returns the frames the scanner decompresses.
+func (s *SeekableRangeScanner) Frames() []SeekableFrame {
+	return s.frames
+}
+
+// Close releases the decoder resources, it does not close the seekable file.
+func (s *SeekableRangeScanner) Close() error {
+	if s.zr != nil {
+		s.zr.Close()
+		s.zr = nil
+	}
+
+	return nil
+}
+
+func (s *SeekableRangeScanner) Scan() bool {
+	for s.err == nil {
+		if s.s == nil {
+			if len(s.frames) == 0 {
+				return false
+			}
+
+			data, err := s.decompress(&s.frames[0])
+			if err != nil {
+				s.err = err
+				return false
+			}
+
+			s.frames = s.frames[1:]
+			s.s = bufio.NewScanner(bytes.NewReader(data))
+		}
+
+		for s.s.Scan() {
+			if t, ok := s.file.time(s.s.Bytes()); ok && !t.Before(s.from) && t.Before(s.to) {
+				return true
+			}
+		}
+
+		s.err = s.s.Err()
+		s.s = nil
+	}
+
+	return false
+}
+
+func (s *SeekableRangeScanner) Text() string {
+	return s.s.Text()
+}
+
+func (s *SeekableRangeScanner) Bytes() []byte {
+	return s.s.Bytes()
+}
+
+func (s *SeekableRangeScanner) Err() error {
+	return s.err
+}
+
+func (s *SeekableRangeScanner) decompress(fr *SeekableFrame) ([]byte, error) {
+	compressed := make([]byte, fr.Size)
+	if _, err := s.file.f.ReadAt(compressed, fr.Offset); err != nil {
+		return nil, fmt.Errorf("cannot read frame at offset %d: %w", fr.Offset, err)
+	}
+
+	switch s.file.codec {
+	case CodecGz:
+		gr, err := gzip.NewReader(bytes.NewReader(compressed))
+		if err != nil {
+			return nil, fmt.Errorf("cannot create gzip reader: %w", err)
+		}
+
+		data, err := io.ReadAll(gr)
+		if err != nil {
+			return nil, fmt.Errorf("cannot decompress frame at offset %d: %w", fr.Offset, err)
+		}
+
+		return data, nil
+	case CodecZst:
+		if s.zr == nil {
+			zr, err := zstd.NewReader(nil)
+			if err != nil {
+				return nil, fmt.Errorf("cannot create zstd reader: %w", err)
+			}
+
+			s.zr = zr
+		}
+
+		data, err := s.zr.DecodeAll(compressed, nil)
+		if err != nil {
+			return nil, fmt.Errorf("cannot decompress frame at offset %d: %w", fr.Offset, err)
+		}
+
+		return data, nil
+	}
+
+	return nil, errNotSeekable
+}
@@ -0,0 +1,171 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSeekableFileRange(t *testing.T) {
+	t.Parallel()
+
+	const layout = "2006-01-02 15:04:05"
+
+	start := time.Date(2023, 6, 22, 9, 0, 0, 0, time.UTC)
+	lines := []string{"time;price;volume"}
+	for i := 0; i < 20000; i++ {
+		tm := start.Add(time.Duration(i) * time.Second)
+		lines = append(lines, fmt.Sprintf("%s;%d.%02d;%d", tm.Format(layout), 100+i%7, i%100, i))
+	}
+
+	timeFn := TimeColumn(0, ';', layout)
+
+	tests := []struct {
+		name string
+		ext  string
+		from time.Time
+		to   time.Time
+	}{
+		{"gz middle", ".csv.gz", start.Add(5000 * time.Second), start.Add(5100 * time.Second)},
+		{"gz tail", ".csv.gz", start.Add(19990 * time.Second), start.Add(30000 * time.Second)},
+		{"gz before", ".csv.gz", start.Add(-time.Hour), start.Add(-time.Minute)},
+		{"zst middle", ".csv.zst", start.Add(12345 * time.Second), start.Add(14000 * time.Second)},
+		{"zst all", ".csv.zst", start, start.Add(20000 * time.Second)},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			fileName := filepath.Join(t.TempDir(), "test"+tt.ext)
+
+			w, err := CreateWriter(fileName, WriterOptions{Time: timeFn, FrameSize: 16384})
+			if err != nil {
+				t.Fatalf("cannot create writer: %v", err)
+			}
+
+			for _, line := range lines {
+				if err := w.WriteString(line); err != nil {
+					t.Fatalf("cannot write: %v", err)
+				}
+
+				if err := w.WriteString("\n"); err != nil {
+					t.Fatalf("cannot write: %v", err)
+				}
+			}
+
+			if err := w.Close(); err != nil {
+				t.Fatalf("cannot close writer: %v", err)
+			}
+
+			// A seekable file is readable sequentially as an ordinary compressed file.
+			s, err := OpenScanner(fileName)
+			if err != nil {
+				t.Fatalf("cannot open scanner: %v", err)
+			}
+
+			n := 0
+			for s.Scan() {
+				if n < len(lines) && s.Text() != lines[n] {
+					t.Fatalf("line %d: expected %q, got %q", n, lines[n], s.Text())
+				}
+
+				n++
+			}
+
+			if err := s.Err(); err != nil {
+				t.Fatalf("cannot scan: %v", err)
+			}
+
+			s.Close()
+			if n != len(lines) {
+				t.Fatalf("expected %d lines, got %d", len(lines), n)
+			}
+
+			sf, err := OpenSeekableFile(fileName, timeFn)
+			if err != nil {
+				t.Fatalf("cannot open seekable file: %v", err)
+			}
+			defer sf.Close()
+
+			if len(sf.Frames()) < 10 {
+				t.Fatalf("expected many frames, got %d", len(sf.Frames()))
+			}
+
+			var expected []string
+			for _, line := range lines {
+				if tm, ok := timeFn([]byte(line)); ok && !tm.Before(tt.from) && tm.Before(tt.to) {
+					expected = append(expected, line)
+				}
+			}
+
+			rs := sf.ScanRange(tt.from, tt.to)
+			defer rs.Close()
+
+			if len(expected) > 0 && len(rs.Frames()) == len(sf.Frames()) && tt.name != "zst all" {
+				t.Errorf("expected a subset of %d frames to be decompressed", len(sf.Frames()))
+			}
+
+			var actual []string
+			for rs.Scan() {
+				actual = append(actual, rs.Text())
+			}
+
+			if err := rs.Err(); err != nil {
+				t.Fatalf("cannot scan range: %v", err)
+			}
+
+			if len(actual) != len(expected) {
+				t.Fatalf("expected %d lines, got %d", len(expected), len(actual))
+			}
+
+			for i := range expected {
+				if actual[i] != expected[i] {
+					t.Fatalf("line %d: expected %q, got %q", i, expected[i], actual[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSeekableFileNotSeekable(t *testing.T) {
+	t.Parallel()
+
+	fileName := filepath.Join(t.TempDir(), "test.csv.gz")
+
+	w, err := CreateWriter(fileName, WriterOptions{})
+	if err != nil {
+		t.Fatalf("cannot create writer: %v", err)
+	}
+
+	if err := w.WriteString("2023-06-22;1\n"); err != nil {
+		t.Fatalf("cannot write: %v", err)
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatalf("cannot close writer: %v", err)
+	}
+
+	if _, err := OpenSeekableFile(fileName, TimeColumn(0, ';', "2006-01-02")); err == nil {
+		t.Errorf("expected an error for an ordinary gzip file")
+	}
+}
+
+func TestSeekableIndexShortFooter(t *testing.T) {
+	t.Parallel()
+
+	// The zstd footer frame of 20 bytes at the offset 12 overlaps the trailer,
+	// so the payload is shorter than the trailer.
+	b := make([]byte, 12)
+	b = binary.LittleEndian.AppendUint32(b, seekableZstMagic)
+	b = binary.LittleEndian.AppendUint64(b, 12)
+	b = append(b, seekableMagic...)
+
+	if _, err := readSeekableIndex(bytes.NewReader(b), int64(len(b)), CodecZst); err == nil {
+		t.Errorf("expected an error for a short footer")
+	}
+}
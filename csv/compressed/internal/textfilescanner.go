@@ -3,6 +3,7 @@ package internal
 import (
 	"bufio"
 	"fmt"
+	"io"
 	"os"
 )
 
@@ -46,3 +47,7 @@ func (s *TextFileScanner) Bytes() []byte {
 func (s *TextFileScanner) Err() error {
 	return s.s.Err()
 }
+
+func (s *TextFileScanner) CopyTo(dst io.Writer) (int64, error) {
+	return io.Copy(dst, s.f)
+}
@@ -3,6 +3,7 @@ package internal
 import (
 	"bufio"
 	"fmt"
+	"io"
 
 	"compressed/internal/mmap"
 )
@@ -52,3 +53,7 @@ func (m *TextFileScannerMmap) Bytes() []byte {
 func (m *TextFileScannerMmap) Err() error {
 	return m.s.Err()
 }
+
+func (s *TextFileScannerMmap) CopyTo(dst io.Writer) (int64, error) {
+	return io.Copy(dst, s.m)
+}
@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"io"
 	"os"
-	"strings"
 
 	"github.com/ulikunitz/xz"
 )
@@ -17,12 +16,6 @@ type XzFileScanner struct {
 }
 
 func NewXzFileScanner(fileName string) (*XzFileScanner, error) {
-	const extXz = ".xz"
-
-	if !strings.HasSuffix(fileName, extXz) {
-		return nil, fmt.Errorf("input xz file %q should have %q extension", fileName, extXz)
-	}
-
 	f, err := os.Open(fileName)
 	if err != nil {
 		return nil, fmt.Errorf("cannot open %q file: %w", fileName, err)
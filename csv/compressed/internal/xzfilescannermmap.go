@@ -4,7 +4,6 @@ import (
 	"bufio"
 	"fmt"
 	"io"
-	"strings"
 
 	"github.com/ulikunitz/xz"
 
@@ -18,12 +17,6 @@ type XzFileScannerMmap struct {
 }
 
 func NewXzFileScannerMmap(fileName string) (*XzFileScannerMmap, error) {
-	const extXz = ".xz"
-
-	if !strings.HasSuffix(fileName, extXz) {
-		return nil, fmt.Errorf("input xz file %q should have %q extension", fileName, extXz)
-	}
-
 	m, err := mmap.OpenFile(fileName, mmap.ReadOnly)
 	if err != nil {
 		return nil, fmt.Errorf("cannot mmap %q file: %w", fileName, err)
@@ -18,6 +18,8 @@ func NewXzFileWriter(fileName string, append bool) (*XzFileWriter, error) {
 	flag := os.O_WRONLY | os.O_CREATE
 	if append {
 		flag |= os.O_APPEND
+	} else {
+		flag |= os.O_TRUNC
 	}
 
 	if f, err := os.OpenFile(fileName, flag, 0666); err != nil {
@@ -0,0 +1,66 @@
+package internal
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+type ZstFileScanner struct {
+	f  *os.File
+	zr *zstd.Decoder
+	s  *bufio.Scanner
+}
+
+func NewZstFileScanner(fileName string) (*ZstFileScanner, error) {
+	f, err := os.Open(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open %q file: %w", fileName, err)
+	}
+
+	br := bufio.NewReaderSize(f, 32768)
+	zr, err := zstd.NewReader(br)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("cannot create zstd reader: %w", err)
+	}
+
+	s := bufio.NewScanner(zr)
+	return &ZstFileScanner{
+		f:  f,
+		zr: zr,
+		s:  s,
+	}, nil
+}
+
+func (s *ZstFileScanner) Close() error {
+	s.zr.Close()
+	if err := s.f.Close(); err != nil {
+		return fmt.Errorf("cannot close file: %w", err)
+	}
+
+	return nil
+}
+
+func (s *ZstFileScanner) Scan() bool {
+	return s.s.Scan()
+}
+
+func (s *ZstFileScanner) Text() string {
+	return s.s.Text()
+}
+
+func (s *ZstFileScanner) Bytes() []byte {
+	return s.s.Bytes()
+}
+
+func (s *ZstFileScanner) Err() error {
+	return s.s.Err()
+}
+
+func (s *ZstFileScanner) CopyTo(dst io.Writer) (int64, error) {
+	return io.Copy(dst, s.zr)
+}
@@ -0,0 +1,71 @@
+package internal
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+
+	"compressed/internal/mmap"
+)
+
+type ZstFileScannerMmap struct {
+	m  *mmap.Mmap
+	zr *zstd.Decoder
+	s  *bufio.Scanner
+}
+
+func NewZstFileScannerMmap(fileName string) (*ZstFileScannerMmap, error) {
+	m, err := mmap.OpenFile(fileName, mmap.ReadOnly)
+	if err != nil {
+		return nil, fmt.Errorf("cannot mmap %q file: %w", fileName, err)
+	}
+
+	zr, err := zstd.NewReader(m)
+	if err != nil {
+		m.Close()
+		return nil, fmt.Errorf("cannot create zstd reader: %w", err)
+	}
+
+	s := bufio.NewScanner(zr)
+	return &ZstFileScannerMmap{
+		m:  m,
+		zr: zr,
+		s:  s,
+	}, nil
+}
+
+// Data returns mapped memory.
+func (m *ZstFileScannerMmap) Data() []byte {
+	return m.m.Data()
+}
+
+func (m *ZstFileScannerMmap) Close() error {
+	m.zr.Close()
+	if err := m.m.Close(); err != nil {
+		return fmt.Errorf("cannot close mmap: %w", err)
+	}
+
+	return nil
+}
+
+func (m *ZstFileScannerMmap) Scan() bool {
+	return m.s.Scan()
+}
+
+func (m *ZstFileScannerMmap) Text() string {
+	return m.s.Text()
+}
+
+func (m *ZstFileScannerMmap) Bytes() []byte {
+	return m.s.Bytes()
+}
+
+func (m *ZstFileScannerMmap) Err() error {
+	return m.s.Err()
+}
+
+func (s *ZstFileScannerMmap) CopyTo(dst io.Writer) (int64, error) {
+	return io.Copy(dst, s.zr)
+}
@@ -0,0 +1,106 @@
+package internal
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+type ZstFileWriter struct {
+	f  *os.File
+	zw *zstd.Encoder
+	bw *bufio.Writer
+}
+
+// NewZstFileWriter creates a zstd file writer.
+// The level is a zstd compression level from 1 to 22,
+// zero means the default level.
+func NewZstFileWriter(fileName string, append bool, level int) (*ZstFileWriter, error) {
+	if level < 0 || level > 22 {
+		return nil, fmt.Errorf("invalid zstd compression level %d", level)
+	}
+
+	lvl := zstd.SpeedDefault
+	if level > 0 {
+		lvl = zstd.EncoderLevelFromZstd(level)
+	}
+
+	flag := os.O_WRONLY | os.O_CREATE
+	if append {
+		flag |= os.O_APPEND
+	} else {
+		flag |= os.O_TRUNC
+	}
+
+	if f, err := os.OpenFile(fileName, flag, 0666); err != nil {
+		return nil, fmt.Errorf("cannot open '%s' file: %w", fileName, err)
+	} else {
+		if zw, err := zstd.NewWriter(f, zstd.WithEncoderLevel(lvl)); err != nil {
+			f.Close()
+			return nil, fmt.Errorf("cannot create zstd writer: %w", err)
+		} else {
+			bw := bufio.NewWriterSize(zw, 32768)
+			return &ZstFileWriter{
+				f:  f,
+				zw: zw,
+				bw: bw,
+			}, nil
+		}
+	}
+}
+
+func (w *ZstFileWriter) Flush() error {
+	if err := w.bw.Flush(); err != nil {
+		w.zw.Flush()
+		w.f.Sync()
+		return fmt.Errorf("cannot flush bufio writer: %w", err)
+	}
+
+	if err := w.zw.Flush(); err != nil {
+		w.f.Sync()
+		return fmt.Errorf("cannot flush zstd writer: %w", err)
+	}
+
+	if err := w.f.Sync(); err != nil {
+		return fmt.Errorf("cannot sync file: %w", err)
+	}
+
+	return nil
+}
+
+func (w *ZstFileWriter) Close() error {
+	if err := w.bw.Flush(); err != nil {
+		w.zw.Close()
+		w.f.Close()
+		return fmt.Errorf("cannot flush bufio writer: %w", err)
+	}
+
+	if err := w.zw.Close(); err != nil {
+		w.f.Close()
+		return fmt.Errorf("cannot close zstd writer: %w", err)
+	}
+
+	if err := w.f.Close(); err != nil {
+		return fmt.Errorf("cannot close file: %w", err)
+	}
+
+	return nil
+}
+
+func (w *ZstFileWriter) WriteString(s string) error {
+	if _, err := w.bw.WriteString(s); err != nil {
+		return fmt.Errorf("cannot write string: %w", err)
+	}
+
+	return nil
+}
+
+func (w *ZstFileWriter) WriteBytes(b []byte) error {
+	if _, err := w.bw.Write(b); err != nil {
+		return fmt.Errorf("cannot write bytes: %w", err)
+	}
+
+	return nil
+}
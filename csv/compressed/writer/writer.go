@@ -0,0 +1,39 @@
+// Package writer creates compressed line writers for the modules outside of the compressed module.
+package writer
+
+import "compressed/internal"
+
+// LineWriter writes lines into a possibly compressed file.
+type LineWriter = internal.LineWriter
+
+// Options are the options of a line writer.
+type Options = internal.WriterOptions
+
+// NoCompression is the Options level storing the data uncompressed,
+// only the gz codec supports it.
+const NoCompression = internal.NoCompression
+
+// Codec identifies a compression format.
+type Codec = internal.Codec
+
+// The compression codecs.
+const (
+	CodecNone = internal.CodecNone
+	CodecGz   = internal.CodecGz
+	CodecBz2  = internal.CodecBz2
+	CodecXz   = internal.CodecXz
+	CodecZst  = internal.CodecZst
+	CodecLz4  = internal.CodecLz4
+)
+
+// ParseCodec returns the codec with the given short name:
+// none, gz, bz2, xz, zst or lz4.
+func ParseCodec(name string) (Codec, error) {
+	return internal.ParseCodec(name)
+}
+
+// Create creates a line writer on the file, choosing the codec
+// by the file name extension unless the options specify one.
+func Create(fileName string, opts Options) (LineWriter, error) {
+	return internal.CreateWriter(fileName, opts)
+}
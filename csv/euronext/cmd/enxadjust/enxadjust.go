@@ -0,0 +1,126 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"euronext/euronext"
+)
+
+type options struct {
+	tolerance float64
+	infer     bool
+	readjust  bool
+	report    bool
+}
+
+func main() {
+	opt := options{}
+	flag.Float64Var(&opt.tolerance, "tolerance", euronext.DefaultCorporateActionTolerance,
+		"relative adjustment factor change below which factors are equal")
+	flag.BoolVar(&opt.infer, "infer", false, "add the inferred corporate actions to the ledgers")
+	flag.BoolVar(&opt.readjust, "readjust", false, "regenerate the adjusted columns from the raw ones and the ledgers")
+	flag.BoolVar(&opt.report, "report", false, "report the inferred actions not matching the known splits")
+	flag.Parse()
+
+	if flag.NArg() == 0 || !(opt.infer || opt.readjust || opt.report) {
+		usage()
+		return
+	}
+
+	t := time.Now().Format("2006-01-02 15-04-05")
+	fmt.Println("=======================================")
+	fmt.Println(t)
+	fmt.Println("=======================================")
+
+	files, err := euronext.FindCombinedDailyHistoryFiles(flag.Args())
+	if err != nil {
+		panic(err.Error())
+	}
+
+	failed := 0
+	for i, file := range files {
+		log := fmt.Sprintf("(%d of %d) %s ... ", i+1, len(files), file)
+		messages, err := process(file, &opt)
+		if err != nil {
+			failed++
+			fmt.Println(log + err.Error())
+			continue
+		}
+
+		for _, m := range messages {
+			fmt.Println(log + m)
+		}
+	}
+
+	fmt.Printf("\n%d files processed, %d failed\n", len(files), failed)
+	fmt.Println("finished " + time.Now().Format("2006-01-02 15-04-05"))
+}
+
+func usage() {
+	fmt.Println("usage:")
+	fmt.Println("enxadjust {-tolerance=x} {-infer} {-readjust} {-report} path...")
+	fmt.Println("-tolerance - relative adjustment factor change below which factors are equal")
+	fmt.Println("-infer     - add the corporate actions inferred from the adjustment factors to the ledgers")
+	fmt.Println("-readjust  - regenerate the adjusted columns from the raw ones and the ledgers")
+	fmt.Println("-report    - report the inferred actions not matching the known splits in the ledgers")
+	fmt.Println("path       - daily history files (*.1d.csv, *.1d.csv.gz) or folders containing them")
+	fmt.Println("")
+	fmt.Println("the ledger of 'x.1d.csv.gz' is 'x.actions.csv', manual entries have the 'manual' source")
+}
+
+// process infers, reports and re-adjusts a history file and returns the messages.
+func process(file string, opt *options) ([]string, error) {
+	messages := []string{}
+	hist, es, err := euronext.ReadCombinedDailyHistoryCsv(file)
+	if err != nil {
+		return messages, fmt.Errorf("%s%w", es, err)
+	}
+
+	ledgerFile := euronext.CorporateActionsFileName(file)
+	ledger := []euronext.CorporateAction{}
+	if _, err := os.Stat(ledgerFile); err == nil {
+		ledger, es, err = euronext.ReadCorporateActionsCsv(ledgerFile)
+		if err != nil {
+			return messages, fmt.Errorf("%s%w", es, err)
+		}
+	} else if !os.IsNotExist(err) {
+		return messages, fmt.Errorf("error checking if file '%s' exists: %w", ledgerFile, err)
+	}
+
+	inferred := euronext.InferCorporateActions(hist, opt.tolerance)
+	if opt.report {
+		messages = append(messages, euronext.CorporateActionsReport(inferred, ledger)...)
+	}
+
+	if opt.infer {
+		var added []euronext.CorporateAction
+		ledger, added = euronext.MergeCorporateActions(ledger, inferred)
+		for _, a := range added {
+			messages = append(messages, "added "+a.String())
+		}
+
+		if len(added) > 0 {
+			if es, err := euronext.WriteCorporateActionsCsv(ledgerFile, ledger); err != nil {
+				return messages, fmt.Errorf("%s%w", es, err)
+			}
+		}
+	}
+
+	if opt.readjust {
+		hist = euronext.ReadjustCombinedDailyHistory(hist, ledger)
+		if es, err := euronext.BackupFile(file); err != nil {
+			return messages, fmt.Errorf("%s%w", es, err)
+		}
+
+		if es, err := euronext.WriteCombinedDailyHistoryCsv(file, hist); err != nil {
+			return messages, fmt.Errorf("%s%w", es, err)
+		}
+
+		messages = append(messages, fmt.Sprintf("re-adjusted with %d corporate actions", len(ledger)))
+	}
+
+	return messages, nil
+}
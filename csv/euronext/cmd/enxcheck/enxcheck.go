@@ -0,0 +1,90 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"euronext/euronext"
+)
+
+func main() {
+	opt := euronext.DefaultCheckOptions()
+	formatPtr := flag.String("format", "json", "report format, json or csv")
+	outPtr := flag.String("out", "", "report file, default is the standard output")
+	severityPtr := flag.String("severity", "info", "minimal reported severity, info, warning or error")
+	flag.Float64Var(&opt.VwapTolerance, "vwap", opt.VwapTolerance, "relative tolerance of the vwap range")
+	flag.Float64Var(&opt.TurnoverTolerance, "turnover", opt.TurnoverTolerance,
+		"relative tolerance of the turnover to shares times vwap")
+	flag.Float64Var(&opt.AdjustmentTolerance, "adjustment", opt.AdjustmentTolerance,
+		"relative adjustment factor change below which factors are equal")
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		usage()
+		return
+	}
+
+	severity, err := euronext.ParseSeverity(*severityPtr)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	write := euronext.WriteFindingsJson
+	switch *formatPtr {
+	case "json":
+	case "csv":
+		write = euronext.WriteFindingsCsv
+	default:
+		panic(fmt.Sprintf("unknown format '%s'", *formatPtr))
+	}
+
+	files, err := euronext.FindCombinedDailyHistoryFiles(flag.Args())
+	if err != nil {
+		panic(err.Error())
+	}
+
+	findings := []euronext.Finding{}
+	failed := 0
+	for i, file := range files {
+		f, err := euronext.CheckCombinedDailyHistoryFile(file, opt)
+		if err != nil {
+			failed++
+			fmt.Fprintf(os.Stderr, "(%d of %d) %s ... %s\n", i+1, len(files), file, err)
+			continue
+		}
+
+		findings = append(findings, euronext.FilterFindings(f, severity)...)
+	}
+
+	var w io.Writer = os.Stdout
+	if *outPtr != "" {
+		f, err := os.Create(*outPtr)
+		if err != nil {
+			panic(fmt.Sprintf("cannot create report file %s: %s", *outPtr, err))
+		}
+		defer f.Close()
+		w = f
+	}
+
+	if err := write(w, findings); err != nil {
+		panic(fmt.Sprintf("cannot write report: %s", err))
+	}
+
+	counts := euronext.CountFindings(findings)
+	fmt.Fprintf(os.Stderr, "%d files checked, %d failed to read, %d errors, %d warnings, %d infos\n",
+		len(files), failed, counts[euronext.SeverityError], counts[euronext.SeverityWarning], counts[euronext.SeverityInfo])
+}
+
+func usage() {
+	fmt.Println("usage:")
+	fmt.Println("enxcheck {-format=json|csv} {-out=file} {-severity=info|warning|error} {-vwap=x} {-turnover=x} {-adjustment=x} path...")
+	fmt.Println("-format     - report format, default is json")
+	fmt.Println("-out        - report file, default is the standard output")
+	fmt.Println("-severity   - minimal reported severity, default is info")
+	fmt.Println("-vwap       - relative tolerance of the vwap range")
+	fmt.Println("-turnover   - relative tolerance of the turnover to shares times vwap")
+	fmt.Println("-adjustment - relative adjustment factor change below which factors are equal")
+	fmt.Println("path        - daily history files (*.1d.csv, *.1d.csv.gz) or repository folders containing them")
+}
@@ -13,24 +13,29 @@ import (
 	"euronext/euronext"
 	"euronext/euronext/discovery"
 	"euronext/euronext/enrichment"
+	"euronext/euronext/fetch"
 )
 
 const configFileName = "enxdisc.json"
 
 type config struct {
-	DownloadRetries             int    `json:"downloadRetries"`
-	DownloadTimeoutSec          int    `json:"downloadTimeoutSec"`
-	DownloadPauseBeforeRetrySec int    `json:"downloadPauseBeforeRetrySec"`
-	VerboseDownload             bool   `json:"verboseDownload"`
-	UserAgent                   string `json:"userAgent"`
-	ZipDownloadedFolder         bool   `json:"zipDownloadedFolder"`
-	DeleteDownloadedFolder      bool   `json:"deleteDownloadedFolder"`
-	EnrichDiscoveredInstruments bool   `json:"enrichDiscoveredInstruments"`
-	GzipBackupXmlInstruments    bool   `json:"gzipBackupXmlInstruments"`
-	DownloadsFolder             string `json:"downloadsFolder"`
-	RepositoryFolder            string `json:"repositoryFolder"`
-	XmlInstrumntsFile           string `json:"xmlInstrumentsFile"`
-	XmlInstrumntsFileOther      string `json:"xmlInstrumentsFileOther"`
+	DownloadRetries             int                  `json:"downloadRetries"`
+	DownloadTimeoutSec          int                  `json:"downloadTimeoutSec"`
+	DownloadPauseBeforeRetrySec int                  `json:"downloadPauseBeforeRetrySec"`
+	VerboseDownload             bool                 `json:"verboseDownload"`
+	UserAgent                   string               `json:"userAgent"`
+	ZipDownloadedFolder         bool                 `json:"zipDownloadedFolder"`
+	DeleteDownloadedFolder      bool                 `json:"deleteDownloadedFolder"`
+	EnrichDiscoveredInstruments bool                 `json:"enrichDiscoveredInstruments"`
+	GzipBackupXmlInstruments    bool                 `json:"gzipBackupXmlInstruments"`
+	DownloadsFolder             string               `json:"downloadsFolder"`
+	RepositoryFolder            string               `json:"repositoryFolder"`
+	XmlInstrumntsFile           string               `json:"xmlInstrumentsFile"`
+	XmlInstrumntsFileOther      string               `json:"xmlInstrumentsFileOther"`
+	InstrumentMaster            string               `json:"instrumentMaster"`
+	DelistAfterDays             int                  `json:"delistAfterDays"`
+	Fetch                       string               `json:"fetch"`
+	Limiter                     *fetch.LimiterConfig `json:"limiter"`
 }
 
 func main() {
@@ -49,6 +54,13 @@ func main() {
 		log.Panicf("cannot read configuration file %s: %s", configFileName, err)
 	}
 
+	fetch.Default, err = fetch.New(cfg.Fetch)
+	if err != nil {
+		log.Panicf("cannot create fetcher: %s\n", err)
+	}
+
+	fetch.Default = fetch.NewLimiter(fetch.Default, cfg.Limiter.OrDefault(), log.Printf)
+
 	log.Println("download folder:", cfg.DownloadsFolder)
 	log.Println("repository folder:", cfg.RepositoryFolder)
 	log.Println("download retries:", cfg.DownloadRetries)
@@ -59,6 +71,8 @@ func main() {
 	log.Println("zip download folder:", cfg.ZipDownloadedFolder)
 	log.Println("enrich discovered instruments:", cfg.EnrichDiscoveredInstruments)
 	log.Println("gzip backup xml instruments:", cfg.GzipBackupXmlInstruments)
+	log.Println("instrument master:", cfg.InstrumentMaster)
+	log.Println("delist after days:", cfg.DelistAfterDays)
 
 	err = ensureDirectoryExists(cfg.RepositoryFolder)
 	if err != nil {
@@ -92,6 +106,7 @@ func main() {
 
 	log.Printf("found %d instruments in %s\n", len(instruments.Instrument), cfg.XmlInstrumntsFile)
 	log.Printf("found %d instruments in %s\n", len(instrumentsOther.Instrument), cfg.XmlInstrumntsFileOther)
+	ownInstruments := instruments.Instrument
 	instruments.Instrument = append(instruments.Instrument, instrumentsOther.Instrument...)
 	log.Printf("total instruments: %d\n", len(instruments.Instrument))
 
@@ -141,8 +156,11 @@ func main() {
 				continue
 			}
 
-			enrichment.EnrichInstrument(xmlIns, cfg.DownloadRetries, cfg.DownloadTimeoutSec, cfg.DownloadPauseBeforeRetrySec,
-				cfg.VerboseDownload, cfg.UserAgent)
+			provenance := enrichment.EnrichInstrument(xmlIns, cfg.DownloadRetries, cfg.DownloadTimeoutSec,
+				cfg.DownloadPauseBeforeRetrySec, cfg.VerboseDownload, cfg.UserAgent)
+			for _, p := range provenance {
+				log.Println("  " + p.String())
+			}
 
 			if contains(discovery.KnownEuronextMics, ai.Mic) {
 				newInstruments = append(newInstruments, *xmlIns)
@@ -163,6 +181,20 @@ func main() {
 		log.Printf("cannot append new instruments to xml file %s: %s", cfg.XmlInstrumntsFileOther, err)
 	}
 
+	log.Println("=======================================")
+	updateLifecycle(cfg.XmlInstrumntsFile, ownInstruments, actualInstrumentsMap, now, cfg.DelistAfterDays)
+	updateLifecycle(cfg.XmlInstrumntsFileOther, instrumentsOther.Instrument, actualInstrumentsMap, now, cfg.DelistAfterDays)
+
+	if cfg.InstrumentMaster != "" {
+		if err := updateInstrumentMaster(cfg.InstrumentMaster, now, cfg.XmlInstrumntsFile, cfg.XmlInstrumntsFileOther); err != nil {
+			log.Printf("cannot update instrument master %s: %s", cfg.InstrumentMaster, err)
+		}
+	}
+
+	if l, ok := fetch.Default.(*fetch.Limiter); ok {
+		log.Println("limiter: " + l.Status())
+	}
+
 	log.Println("finished")
 }
 
@@ -282,3 +314,45 @@ func appendToXmlInstrumentsFile(filePath string, now time.Time, instruments []eu
 
 	return nil
 }
+
+func updateInstrumentMaster(fileName string, now time.Time, xmlFiles ...string) error {
+	all := &euronext.XmlInstruments{}
+	for _, xmlFile := range xmlFiles {
+		ins, err := euronext.ReadXmlInstrumentsFile(xmlFile)
+		if err != nil {
+			return fmt.Errorf("cannot read instruments: %w", err)
+		}
+
+		all.Instrument = append(all.Instrument, ins.Instrument...)
+	}
+
+	m, err := euronext.ReadMasterFile(fileName)
+	if err != nil {
+		return err
+	}
+
+	changes, err := m.Import(all, now, false)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("instrument master: %d changes\n", len(changes))
+	for _, c := range changes {
+		log.Println("  " + c.String())
+	}
+
+	return euronext.WriteMasterFile(fileName, m)
+}
+
+func updateLifecycle(filePath string, instruments []euronext.XmlInstrument, actual map[string]*discovery.InstrumentInfo,
+	now time.Time, delistAfterDays int) {
+	updates := discovery.DetectLifecycle(instruments, actual, now, delistAfterDays)
+	log.Printf("updating lifecycle status of %d instruments in %s\n", len(updates), filePath)
+	for _, u := range updates {
+		log.Println("  " + u.String())
+	}
+
+	if _, err := euronext.UpdateXmlInstrumentsFileStatus(filePath, updates); err != nil {
+		log.Printf("cannot update lifecycle status in xml file %s: %s", filePath, err)
+	}
+}
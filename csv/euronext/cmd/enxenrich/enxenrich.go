@@ -0,0 +1,202 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"euronext/euronext"
+	"euronext/euronext/enrichment"
+	"euronext/euronext/fetch"
+)
+
+// enriched is the provenance of the enriched fields of an instrument.
+type enriched struct {
+	Mic        string                  `json:"mic"`
+	Isin       string                  `json:"isin"`
+	Symbol     string                  `json:"symbol"`
+	Provenance []enrichment.Provenance `json:"provenance"`
+}
+
+func main() {
+	opts := enrichment.Options{}
+	dryRunPtr := flag.Bool("dry-run", false, "print what the enrichment would change without writing")
+	typesPtr := flag.String("type", "", "comma-separated instrument types to enrich, default is all")
+	isinPtr := flag.String("isin", "", "enrich only the instrument with the ISIN")
+	outPtr := flag.String("out", "", "enriched instruments file, xml or json")
+	provenancePtr := flag.String("provenance", "", "json file of the provenance of the enriched fields")
+	fetchPtr := flag.String("fetch", "", "fetcher: [http, record:folder, replay:folder]")
+	timeoutPtr := flag.Int("timeout", 30, "download timeout in seconds")
+	pausePtr := flag.Int("pause", 3, "pause before a download retry in seconds")
+	flag.IntVar(&opts.Retries, "retries", 3, "download retries")
+	flag.BoolVar(&opts.Verbose, "verbose", false, "log the downloaded urls")
+	flag.StringVar(&opts.UserAgent, "user-agent", "Mozilla/5.0", "user agent of the requests")
+	flag.Parse()
+
+	if flag.NArg() != 1 || (!*dryRunPtr && *outPtr == "") {
+		usage()
+		return
+	}
+
+	opts.Timeout = time.Duration(*timeoutPtr) * time.Second
+	opts.PauseBeforeRetry = time.Duration(*pausePtr) * time.Second
+
+	var err error
+	fetch.Default, err = fetch.New(*fetchPtr)
+	if err != nil {
+		panic(fmt.Sprintf("cannot create fetcher: %s", err))
+	}
+
+	// The download retries are logged, the changes are printed.
+	log.SetOutput(os.Stderr)
+
+	fileName := flag.Arg(0)
+	instruments, err := readInstruments(fileName)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	types := map[string]bool{}
+	for _, t := range strings.Split(*typesPtr, ",") {
+		if t = strings.ToLower(strings.TrimSpace(t)); t != "" {
+			types[t] = true
+		}
+	}
+
+	provenance := []enriched{}
+	total, changed := 0, 0
+	for i := range instruments.Instrument {
+		ins := &instruments.Instrument[i]
+		if len(types) > 0 && !types[strings.ToLower(ins.Type)] {
+			continue
+		}
+
+		if *isinPtr != "" && !strings.EqualFold(ins.Isin, *isinPtr) {
+			continue
+		}
+
+		if enrichment.Lookup(ins.Type) == nil {
+			continue
+		}
+
+		enr, err := clone(ins)
+		if err != nil {
+			panic(err.Error())
+		}
+
+		total++
+		p := enrichment.Enrich(enr, opts)
+		changes := euronext.DiffXmlInstrument(ins, enr)
+		if len(changes) > 0 {
+			changed++
+		}
+
+		for _, c := range changes {
+			fmt.Printf("%s %s %s%s\n", c, ins.Symbol, ins.Type, source(c.Field, p))
+		}
+
+		provenance = append(provenance, enriched{Mic: ins.Mic, Isin: ins.Isin, Symbol: ins.Symbol, Provenance: p})
+		if !*dryRunPtr {
+			*ins = *enr
+		}
+	}
+
+	fmt.Printf("\n%d instruments enriched, %d changed\n", total, changed)
+	if *provenancePtr != "" {
+		if err := writeJson(*provenancePtr, provenance); err != nil {
+			panic(err.Error())
+		}
+	}
+
+	if *dryRunPtr {
+		return
+	}
+
+	if strings.HasSuffix(strings.ToLower(*outPtr), ".json") {
+		err = euronext.WriteJsonInstrumentsFile(*outPtr, instruments)
+	} else {
+		err = euronext.WriteXmlInstrumentsFile(*outPtr, instruments)
+	}
+
+	if err != nil {
+		panic(fmt.Sprintf("cannot write instruments file '%s': %s", *outPtr, err))
+	}
+}
+
+func usage() {
+	fmt.Println("usage:")
+	fmt.Println("enxenrich {-dry-run} {-type=stock,etf} {-isin=isin} {-out=file} {-provenance=file} {-fetch=spec} {-retries=3} {-timeout=30} {-pause=3} {-verbose} {-user-agent=agent} file")
+	fmt.Println("-dry-run    - print what the enrichment would change without writing")
+	fmt.Println("-type       - comma-separated instrument types to enrich, default is all: " + strings.Join(enrichment.Types(), ", "))
+	fmt.Println("-isin       - enrich only the instrument with the ISIN")
+	fmt.Println("-out        - enriched instruments file, xml or json chosen by the .json extension, required without -dry-run")
+	fmt.Println("-provenance - json file of the provenance of the enriched fields: block, url and fetch time")
+	fmt.Println("-fetch      - fetcher: http, record:folder or replay:folder, default is http")
+	fmt.Println("-retries    - download retries, default is 3")
+	fmt.Println("-timeout    - download timeout in seconds, default is 30")
+	fmt.Println("-pause      - pause before a download retry in seconds, default is 3")
+	fmt.Println("-verbose    - log the downloaded urls")
+	fmt.Println("-user-agent - user agent of the requests")
+	fmt.Println("file        - xml or json instruments file, chosen by the .json extension")
+	fmt.Println("")
+	fmt.Println("the changes are printed as 'MIC ISIN changed field: 'old' -> 'new' symbol type (block fetch-time)'")
+}
+
+func readInstruments(fileName string) (*euronext.XmlInstruments, error) {
+	var ins *euronext.XmlInstruments
+	var err error
+	if strings.HasSuffix(strings.ToLower(fileName), ".json") {
+		ins, err = euronext.ReadJsonInstrumentsFile(fileName)
+	} else {
+		ins, err = euronext.ReadXmlInstrumentsFile(fileName)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("cannot read instruments file '%s': %w", fileName, err)
+	}
+
+	return ins, nil
+}
+
+// clone returns a deep copy of the instrument.
+func clone(ins *euronext.XmlInstrument) (*euronext.XmlInstrument, error) {
+	bs, err := json.Marshal(ins)
+	if err != nil {
+		return nil, fmt.Errorf("cannot marshal instrument %s %s: %w", ins.Mic, ins.Isin, err)
+	}
+
+	c := &euronext.XmlInstrument{}
+	if err := json.Unmarshal(bs, c); err != nil {
+		return nil, fmt.Errorf("cannot unmarshal instrument %s %s: %w", ins.Mic, ins.Isin, err)
+	}
+
+	return c, nil
+}
+
+// source returns the block and the fetch time of the field, or an empty string.
+func source(field string, provenance []enrichment.Provenance) string {
+	for _, p := range provenance {
+		if p.Field == field {
+			return fmt.Sprintf(" (%s %s)", p.Block, p.Fetched.Format(time.RFC3339))
+		}
+	}
+
+	return ""
+}
+
+func writeJson(fileName string, v any) error {
+	bs, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("cannot marshal '%s': %w", fileName, err)
+	}
+
+	if err := os.WriteFile(fileName, bs, 0644); err != nil {
+		return fmt.Errorf("cannot write '%s': %w", fileName, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"euronext/euronext"
+	"euronext/euronext/columnar"
+	"euronext/euronext/intraday"
+)
+
+// isinRegexp matches an ISIN part of the intraday file name.
+var isinRegexp = regexp.MustCompile(`^[A-Z]{2}[A-Z0-9]{9}[0-9]$`)
+
+// exporter appends to the parquet files, the files are removed before the first append in the full mode.
+type exporter struct {
+	out     string
+	codec   columnar.Codec
+	full    bool
+	removed map[string]bool
+}
+
+func main() {
+	xmlPtr := flag.String("xml", "", "xml instruments file")
+	outPtr := flag.String("out", "", "output folder of the parquet files")
+	codecPtr := flag.String("codec", "zstd", "compression codec: [none, gzip, zstd]")
+	fullPtr := flag.Bool("full", false, "rebuild the parquet files instead of appending the new sessions")
+	dailyPtr := flag.String("daily", "", "repository folder of the combined daily histories")
+	includeRetiredPtr := flag.Bool("include-retired", false, "export the delisted and migrated instruments too")
+	flag.Parse()
+
+	if *xmlPtr == "" || *outPtr == "" || (*dailyPtr == "" && flag.NArg() == 0) {
+		usage()
+		return
+	}
+
+	codec, err := columnar.ParseCodec(*codecPtr)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	instruments, err := euronext.ReadInstruments(*xmlPtr)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	if !*includeRetiredPtr {
+		instruments = euronext.ActiveInstruments(instruments)
+	}
+
+	e := &exporter{out: *outPtr, codec: codec, full: *fullPtr, removed: map[string]bool{}}
+	failed := 0
+	if *dailyPtr != "" {
+		failed += e.exportDaily(instruments, *dailyPtr)
+	}
+
+	if flag.NArg() > 0 {
+		failed += e.exportTrades(instruments, flag.Args())
+	}
+
+	fmt.Printf("\n%d failed\n", failed)
+}
+
+func usage() {
+	fmt.Println("usage:")
+	fmt.Println("enxexport -xml=file -out=folder {-codec=zstd} {-full} {-include-retired} {-daily=folder} {path...}")
+	fmt.Println("-xml             - xml instruments file")
+	fmt.Println("-out             - output folder of the parquet files")
+	fmt.Println("-codec           - compression codec of the parquet files: none, gzip, zstd, default is zstd")
+	fmt.Println("-full            - rebuild the parquet files instead of appending the new sessions")
+	fmt.Println("-include-retired - export the delisted and migrated instruments too")
+	fmt.Println("-daily           - repository folder of the combined daily histories 'mic/type/mnemonic/*.1d.csv[.gz]'")
+	fmt.Println("path             - downloaded intraday json files 'MIC_MNEMONIC_ISIN_*.json' or folders containing them")
+	fmt.Println("")
+	fmt.Println("the files are written as the h5 paths of the instruments, e.g. 'xams/index/ASCX.h5:/XAMS_ASCX_NL0000249142'")
+	fmt.Println("is exported to 'xams/index/ASCX/XAMS_ASCX_NL0000249142.1d.parquet' and '.trades.parquet'")
+	fmt.Println("only the sessions after the last exported session are appended, unless -full is given")
+}
+
+// fileName returns the parquet file of the instrument, removing it before the first append in the full mode.
+func (e *exporter) fileName(s *euronext.Instrument, suffix string) (string, error) {
+	file := filepath.Join(e.out, columnar.Path(euronext.H5Path(s.Mic, s.Isin, s.Mnemonic, s.Type), suffix))
+	if err := os.MkdirAll(filepath.Dir(file), os.ModePerm); err != nil {
+		return "", fmt.Errorf("cannot create directory '%s': %w", filepath.Dir(file), err)
+	}
+
+	if e.full && !e.removed[file] {
+		e.removed[file] = true
+		if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+			return "", fmt.Errorf("cannot remove '%s': %w", file, err)
+		}
+	}
+
+	return file, nil
+}
+
+// exportDaily exports the combined daily histories of the instruments and returns the number of failures.
+func (e *exporter) exportDaily(instruments []euronext.Instrument, repository string) int {
+	failed := 0
+	for i := range instruments {
+		s := &instruments[i]
+		name := fmt.Sprintf("%s_%s_%s.1d.csv", s.Mnemonic, s.Isin, s.Mic)
+		log := fmt.Sprintf("(%d of %d) %s ... ", i+1, len(instruments), name)
+		file := filepath.Join(repository, s.FileFolder(), name)
+		if _, err := os.Stat(file + ".gz"); err == nil {
+			file += ".gz"
+		} else if _, err := os.Stat(file); err != nil {
+			fmt.Println(log + "no history")
+			continue
+		}
+
+		history, es, err := euronext.ReadCombinedDailyHistoryCsv(file)
+		if err != nil {
+			failed++
+			fmt.Println(log + es + err.Error())
+			continue
+		}
+
+		out, err := e.fileName(s, columnar.DailySuffix)
+		if err == nil {
+			var n int
+			if n, err = columnar.AppendDaily(out, strings.ToUpper(s.Mnemonic), history, e.codec); err == nil {
+				fmt.Printf("%s%d sessions appended to %s\n", log, n, out)
+				continue
+			}
+		}
+
+		failed++
+		fmt.Println(log + err.Error())
+	}
+
+	return failed
+}
+
+// exportTrades exports the trades of the intraday json files in the order of the file names,
+// so the sessions of an instrument are appended in time order. It returns the number of failures.
+func (e *exporter) exportTrades(instruments []euronext.Instrument, paths []string) int {
+	byKey := map[string]*euronext.Instrument{}
+	for i := range instruments {
+		byKey[strings.ToUpper(instruments[i].Mic+"_"+instruments[i].Isin)] = &instruments[i]
+	}
+
+	files, err := findJsonFiles(paths)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	failed := 0
+	for i, file := range files {
+		log := fmt.Sprintf("(%d of %d) %s ... ", i+1, len(files), file)
+		s := byKey[instrumentKey(filepath.Base(file))]
+		if s == nil {
+			fmt.Println(log + "no instrument")
+			continue
+		}
+
+		n, out, err := e.appendTrades(s, file)
+		if err != nil {
+			failed++
+			fmt.Println(log + err.Error())
+			continue
+		}
+
+		if out == "" {
+			fmt.Println(log + "no trades")
+			continue
+		}
+
+		fmt.Printf("%s%d trades appended to %s\n", log, n, out)
+	}
+
+	return failed
+}
+
+func (e *exporter) appendTrades(s *euronext.Instrument, file string) (int, string, error) {
+	js, err := intraday.ReadJsonIntradayFile(file)
+	if err != nil {
+		return 0, "", err
+	}
+
+	// A session without trades has no date.
+	if len(js.Rows) == 0 {
+		return 0, "", nil
+	}
+
+	trades, err := js.Trades()
+	if err != nil {
+		return 0, "", err
+	}
+
+	out, err := e.fileName(s, columnar.TradesSuffix)
+	if err != nil {
+		return 0, "", err
+	}
+
+	n, err := columnar.AppendTrades(out, strings.ToUpper(s.Mnemonic), trades, e.codec)
+	return n, out, err
+}
+
+// instrumentKey returns the "MIC_ISIN" key of the "MTAA_WKME24_IT0005432668_20230412_eoi.json" file name.
+func instrumentKey(name string) string {
+	parts := strings.Split(strings.ToUpper(strings.TrimSuffix(name, ".json")), "_")
+	for _, p := range parts[1:] {
+		if isinRegexp.MatchString(p) {
+			return parts[0] + "_" + p
+		}
+	}
+
+	return ""
+}
+
+// findJsonFiles returns the sorted files as is and the json files of the folders walked recursively.
+func findJsonFiles(paths []string) ([]string, error) {
+	files := []string{}
+	for _, path := range paths {
+		fi, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("cannot stat '%s': %w", path, err)
+		}
+
+		if !fi.IsDir() {
+			files = append(files, path)
+			continue
+		}
+
+		err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+
+			if !d.IsDir() && strings.HasSuffix(d.Name(), ".json") {
+				files = append(files, p)
+			}
+
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("cannot walk '%s': %w", path, err)
+		}
+	}
+
+	sort.Slice(files, func(i, j int) bool { return filepath.Base(files[i]) < filepath.Base(files[j]) })
+	return files, nil
+}
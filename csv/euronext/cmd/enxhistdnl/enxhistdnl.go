@@ -14,20 +14,24 @@ import (
 
 	"euronext/euronext"
 	"euronext/euronext/endofday"
+	"euronext/euronext/fetch"
 )
 
 const configFileName = "enxhistdnl.json"
 
 type config struct {
-	XmlInstrumntsFile           string `json:"xmlInstrumentsFile"`
-	DownloadsFolder             string `json:"downloadsFolder"`
-	ZipDownloadedFolder         bool   `json:"zipDownloadedFolder"`
-	DeleteDownloadedFolder      bool   `json:"deleteDownloadedFolder"`
-	VerboseDownload             bool   `json:"verboseDownload"`
-	DownloadRetryDelaySeconds   []int  `json:"downloadRetryDelaySeconds"`
-	DownloadTimeoutSeconds      int    `json:"downloadTimeoutSeconds"`
-	Concurrency                 int    `json:"concurrency"`
-	UserAgent                   string `json:"userAgent"`
+	XmlInstrumntsFile           string               `json:"xmlInstrumentsFile"`
+	DownloadsFolder             string               `json:"downloadsFolder"`
+	ZipDownloadedFolder         bool                 `json:"zipDownloadedFolder"`
+	DeleteDownloadedFolder      bool                 `json:"deleteDownloadedFolder"`
+	VerboseDownload             bool                 `json:"verboseDownload"`
+	DownloadRetryDelaySeconds   []int                `json:"downloadRetryDelaySeconds"`
+	DownloadTimeoutSeconds      int                  `json:"downloadTimeoutSeconds"`
+	Concurrency                 int                  `json:"concurrency"`
+	UserAgent                   string               `json:"userAgent"`
+	Fetch                       string               `json:"fetch"`
+	Limiter                     *fetch.LimiterConfig `json:"limiter"`
+	IncludeRetired              bool                 `json:"includeRetired"`
 	DownloadRetryDelayDurations []time.Duration
 	DownloadTimeoutDuration     time.Duration
 }
@@ -61,6 +65,13 @@ func main() {
 		log.Panicf("cannot read configuration file %s: %s\n", configFileName, err)
 	}
 
+	fetch.Default, err = fetch.New(cfg.Fetch)
+	if err != nil {
+		log.Panicf("cannot create fetcher: %s\n", err)
+	}
+
+	fetch.Default = fetch.NewLimiter(fetch.Default, cfg.Limiter.OrDefault(), log.Printf)
+
 	log.Println("xml instruments file:", cfg.XmlInstrumntsFile)
 	log.Println("download folder:", cfg.DownloadsFolder)
 	log.Println("download retry delay seconds:", cfg.DownloadRetryDelaySeconds)
@@ -91,7 +102,7 @@ func main() {
 	}
 
 	log.Println("xml instruments file: " + cfg.XmlInstrumntsFile)
-	instruments, err := readInstruments(cfg.XmlInstrumntsFile)
+	instruments, err := readInstruments(cfg.XmlInstrumntsFile, cfg.IncludeRetired)
 	if err != nil {
 		log.Panicf("cannot read instruments: %s\n", err)
 	}
@@ -146,6 +157,10 @@ func main() {
 
 	log.Println("=======================================")
 	archive(downloadPath, cfg.ZipDownloadedFolder, cfg.DeleteDownloadedFolder)
+	if l, ok := fetch.Default.(*fetch.Limiter); ok {
+		log.Println("limiter: " + l.Status())
+	}
+
 	log.Println("finished")
 }
 
@@ -192,7 +207,7 @@ func readConfig(fileName string) (*config, error) {
 	return &conf, nil
 }
 
-func readInstruments(fileName string) ([]instrument, error) {
+func readInstruments(fileName string, includeRetired bool) ([]instrument, error) {
 	instruments := []instrument{}
 	instrs, err := euronext.ReadXmlInstrumentsFile(fileName)
 	if err != nil {
@@ -200,7 +215,13 @@ func readInstruments(fileName string) ([]instrument, error) {
 	}
 
 	log.Printf(" %d instruments read from %s\n", len(instrs.Instrument), fileName)
+	retired := 0
 	for _, inst := range instrs.Instrument {
+		if !includeRetired && euronext.IsRetiredStatus(inst.LifecycleStatus()) {
+			retired++
+			continue
+		}
+
 		ins := instrument{
 			Mnemonic: strings.ToLower(inst.Symbol),
 			Mep:      strings.ToLower(inst.Mep),
@@ -211,6 +232,10 @@ func readInstruments(fileName string) ([]instrument, error) {
 		instruments = append(instruments, ins)
 	}
 
+	if retired > 0 {
+		log.Printf(" %d retired instruments skipped\n", retired)
+	}
+
 	return instruments, nil
 }
 
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"compressed/writer"
+	"euronext/euronext/intraday"
+)
+
+func main() {
+	opt := intraday.AggregateOptions{}
+	intervalPtr := flag.String("interval", "1m", "bar interval from 1s to 1d, e.g. 1s, 5m, 1h, 1d")
+	codecPtr := flag.String("codec", "gz", "compression codec: [none, gz, bz2, xz, zst, lz4]")
+	outPtr := flag.String("out", "", "output folder, default is the folder of the json file")
+	flag.BoolVar(&opt.ExcludeOffBook, "exclude-offbook", false, "exclude the off-book trades")
+	flag.BoolVar(&opt.ExcludeAuction, "exclude-auction", false, "exclude the auction and opening trades")
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		usage()
+		return
+	}
+
+	var err error
+	if opt.Interval, err = intraday.ParseInterval(*intervalPtr); err != nil {
+		panic(err.Error())
+	}
+
+	codec, err := writer.ParseCodec(*codecPtr)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	files, err := findJsonFiles(flag.Args())
+	if err != nil {
+		panic(err.Error())
+	}
+
+	failed := 0
+	for i, file := range files {
+		folder := *outPtr
+		if folder == "" {
+			folder = filepath.Dir(file)
+		}
+
+		name := strings.TrimSuffix(filepath.Base(file), ".json")
+		out := filepath.Join(folder, fmt.Sprintf("%s.%s.csv%s", name, *intervalPtr, codec.Ext()))
+		log := fmt.Sprintf("(%d of %d) %s ... ", i+1, len(files), file)
+		n, err := process(file, out, &opt, codec)
+		if err != nil {
+			failed++
+			fmt.Println(log + err.Error())
+			continue
+		}
+
+		fmt.Printf("%s%d bars written to %s\n", log, n, out)
+	}
+
+	fmt.Printf("\n%d files processed, %d failed\n", len(files), failed)
+}
+
+func usage() {
+	fmt.Println("usage:")
+	fmt.Println("enxintrbar {-interval=1m} {-codec=gz} {-out=folder} {-exclude-offbook} {-exclude-auction} path...")
+	fmt.Println("-interval        - bar interval from 1s to 1d, e.g. 1s, 5m, 1h, 1d, default is 1m")
+	fmt.Println("-codec           - compression codec of the bar files: none, gz, bz2, xz, zst, lz4, default is gz")
+	fmt.Println("-out             - output folder, default is the folder of the json file")
+	fmt.Println("-exclude-offbook - exclude the off-book trades")
+	fmt.Println("-exclude-auction - exclude the auction and opening trades")
+	fmt.Println("path             - downloaded intraday json files or folders containing them")
+	fmt.Println("")
+	fmt.Println("the bars of 'x.json' are written to 'x.<interval>.csv.gz' as time;open;high;low;close;volume;vwap;trades")
+}
+
+// process aggregates the trades of the json file and writes the bars, returning the number of bars.
+func process(file, out string, opt *intraday.AggregateOptions, codec writer.Codec) (int, error) {
+	js, err := intraday.ReadJsonIntradayFile(file)
+	if err != nil {
+		return 0, err
+	}
+
+	trades, err := js.Trades()
+	if err != nil {
+		return 0, err
+	}
+
+	bars, err := intraday.Aggregate(trades, *opt)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(out), os.ModePerm); err != nil {
+		return 0, fmt.Errorf("cannot create directory '%s': %w", filepath.Dir(out), err)
+	}
+
+	if err := intraday.WriteBarsCsv(out, bars, writer.Options{Codec: codec}); err != nil {
+		return 0, err
+	}
+
+	return len(bars), nil
+}
+
+// findJsonFiles returns the files as is and the json files of the folders walked recursively.
+func findJsonFiles(paths []string) ([]string, error) {
+	files := []string{}
+	for _, path := range paths {
+		fi, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("cannot stat '%s': %w", path, err)
+		}
+
+		if !fi.IsDir() {
+			files = append(files, path)
+			continue
+		}
+
+		err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+
+			if !d.IsDir() && strings.HasSuffix(d.Name(), ".json") {
+				files = append(files, p)
+			}
+
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("cannot walk '%s': %w", path, err)
+		}
+	}
+
+	return files, nil
+}
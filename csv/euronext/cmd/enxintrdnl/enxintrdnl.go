@@ -12,24 +12,29 @@ import (
 	"sync"
 	"time"
 
+	"calendar"
 	"euronext/euronext"
+	"euronext/euronext/fetch"
 	"euronext/euronext/intraday"
 )
 
 const configFileName = "enxintrdnl.json"
 
 type config struct {
-	CheckPassphrase             bool   `json:"checkPassphrase"`
-	StartDateDaysBack           int    `json:"startDateDaysBack"`
-	XmlInstrumntsFile           string `json:"xmlInstrumentsFile"`
-	DownloadsFolder             string `json:"downloadsFolder"`
-	ZipDownloadedFolder         bool   `json:"zipDownloadedFolder"`
-	DeleteDownloadedFolder      bool   `json:"deleteDownloadedFolder"`
-	VerboseDownload             bool   `json:"verboseDownload"`
-	DownloadRetryDelaySeconds   []int  `json:"downloadRetryDelaySeconds"`
-	DownloadTimeoutSeconds      int    `json:"downloadTimeoutSeconds"`
-	Concurrency                 int    `json:"concurrency"`
-	UserAgent                   string `json:"userAgent"`
+	CheckPassphrase             bool                 `json:"checkPassphrase"`
+	StartDateDaysBack           int                  `json:"startDateDaysBack"`
+	XmlInstrumntsFile           string               `json:"xmlInstrumentsFile"`
+	DownloadsFolder             string               `json:"downloadsFolder"`
+	ZipDownloadedFolder         bool                 `json:"zipDownloadedFolder"`
+	DeleteDownloadedFolder      bool                 `json:"deleteDownloadedFolder"`
+	VerboseDownload             bool                 `json:"verboseDownload"`
+	DownloadRetryDelaySeconds   []int                `json:"downloadRetryDelaySeconds"`
+	DownloadTimeoutSeconds      int                  `json:"downloadTimeoutSeconds"`
+	Concurrency                 int                  `json:"concurrency"`
+	UserAgent                   string               `json:"userAgent"`
+	Fetch                       string               `json:"fetch"`
+	Limiter                     *fetch.LimiterConfig `json:"limiter"`
+	IncludeRetired              bool                 `json:"includeRetired"`
 	DownloadRetryDelayDurations []time.Duration
 	DownloadTimeoutDuration     time.Duration
 	Passphrase                  string
@@ -64,6 +69,13 @@ func main() {
 		log.Panicf("cannot read configuration file %s: %s\n", configFileName, err)
 	}
 
+	fetch.Default, err = fetch.New(cfg.Fetch)
+	if err != nil {
+		log.Panicf("cannot create fetcher: %s\n", err)
+	}
+
+	fetch.Default = fetch.NewLimiter(fetch.Default, cfg.Limiter.OrDefault(), log.Printf)
+
 	cfg.Passphrase = intraday.DefaultPassphrase
 	if cfg.CheckPassphrase {
 		fetched, err := intraday.FetchPassphrase()
@@ -91,7 +103,7 @@ func main() {
 		log.Panicf("cannot get session date: %s\n", err)
 	}
 	if cfg.StartDateDaysBack > 0 {
-		sessionDate = sessionDate.AddDate(0, 0, -cfg.StartDateDaysBack)
+		sessionDate = calendar.Euronext.AddSessions(sessionDate, -cfg.StartDateDaysBack)
 	}
 	log.Printf("days back: %d", cfg.StartDateDaysBack)
 	log.Println("trading session date: " + sessionDate.Format("2006-01-02"))
@@ -111,7 +123,7 @@ func main() {
 	}
 
 	log.Println("xml instruments file: " + cfg.XmlInstrumntsFile)
-	instruments, err := readInstruments(cfg.XmlInstrumntsFile)
+	instruments, err := readInstruments(cfg.XmlInstrumntsFile, cfg.IncludeRetired)
 	if err != nil {
 		log.Panicf("cannot read instruments: %s\n", err)
 	}
@@ -164,6 +176,10 @@ func main() {
 
 	log.Println("=======================================")
 	archive(downloadPath, cfg.ZipDownloadedFolder, cfg.DeleteDownloadedFolder)
+	if l, ok := fetch.Default.(*fetch.Limiter); ok {
+		log.Println("limiter: " + l.Status())
+	}
+
 	log.Println("finished")
 }
 
@@ -210,7 +226,7 @@ func readConfig(fileName string) (*config, error) {
 	return &conf, nil
 }
 
-func readInstruments(fileName string) ([]instrument, error) {
+func readInstruments(fileName string, includeRetired bool) ([]instrument, error) {
 	instruments := []instrument{}
 	instrs, err := euronext.ReadXmlInstrumentsFile(fileName)
 	if err != nil {
@@ -218,7 +234,13 @@ func readInstruments(fileName string) ([]instrument, error) {
 	}
 
 	log.Printf(" %d instruments read from %s\n", len(instrs.Instrument), fileName)
+	retired := 0
 	for _, inst := range instrs.Instrument {
+		if !includeRetired && euronext.IsRetiredStatus(inst.LifecycleStatus()) {
+			retired++
+			continue
+		}
+
 		ins := instrument{
 			Mnemonic: strings.ToLower(inst.Symbol),
 			Mep:      strings.ToLower(inst.Mep),
@@ -229,6 +251,10 @@ func readInstruments(fileName string) ([]instrument, error) {
 		instruments = append(instruments, ins)
 	}
 
+	if retired > 0 {
+		log.Printf(" %d retired instruments skipped\n", retired)
+	}
+
 	return instruments, nil
 }
 
@@ -0,0 +1,255 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"euronext/euronext"
+)
+
+const defaultMasterFile = "instruments.master.json"
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		return
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "import":
+		err = importCmd(os.Args[2:])
+	case "export":
+		err = exportCmd(os.Args[2:])
+	case "diff":
+		err = diffCmd(os.Args[2:])
+	case "history":
+		err = historyCmd(os.Args[2:])
+	default:
+		usage()
+		return
+	}
+
+	if err != nil {
+		panic(err.Error())
+	}
+}
+
+func usage() {
+	fmt.Println("usage:")
+	fmt.Println("enxmaster import {-db=file} {-date=yyyy-mm-dd} {-retire} file...")
+	fmt.Println("enxmaster export {-db=file} {-date=yyyy-mm-dd} file")
+	fmt.Println("enxmaster diff old-file new-file")
+	fmt.Println("enxmaster diff {-db=file} -from=yyyy-mm-dd {-to=yyyy-mm-dd}")
+	fmt.Println("enxmaster history {-db=file} isin mic")
+	fmt.Println("-db     - instrument master file, default is " + defaultMasterFile)
+	fmt.Println("-date   - date of the imported instruments or of the exported versions, default is today or current,\n         an import date cannot be before the latest date of the master")
+	fmt.Println("-retire - retire the current instruments missing from the imported files")
+	fmt.Println("-from   - date of the old versions")
+	fmt.Println("-to     - date of the new versions, default is current")
+	fmt.Println("file    - xml or json instruments file, chosen by the .json extension")
+}
+
+func parseDate(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+
+	d, err := time.Parse(euronext.MasterDateFormat, s)
+	if err != nil {
+		return d, fmt.Errorf("cannot parse date '%s': %w", s, err)
+	}
+
+	return d, nil
+}
+
+func readInstruments(fileName string) (*euronext.XmlInstruments, error) {
+	var ins *euronext.XmlInstruments
+	var err error
+	if strings.HasSuffix(strings.ToLower(fileName), ".json") {
+		ins, err = euronext.ReadJsonInstrumentsFile(fileName)
+	} else {
+		ins, err = euronext.ReadXmlInstrumentsFile(fileName)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("cannot read instruments file '%s': %w", fileName, err)
+	}
+
+	return ins, nil
+}
+
+func printChanges(changes []euronext.InstrumentChange) {
+	counts := map[string]int{}
+	for _, c := range changes {
+		fmt.Println(c)
+		counts[c.Kind]++
+	}
+
+	fmt.Printf("\n%d added, %d removed, %d changed fields, %d duplicates\n", counts[euronext.InstrumentAdded],
+		counts[euronext.InstrumentRemoved], counts[euronext.InstrumentChanged], counts[euronext.InstrumentDuplicate])
+}
+
+func importCmd(args []string) error {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	db := fs.String("db", defaultMasterFile, "instrument master file")
+	date := fs.String("date", time.Now().Format(euronext.MasterDateFormat), "date of the imported instruments")
+	retire := fs.Bool("retire", false, "retire the current instruments missing from the imported files")
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		usage()
+		return nil
+	}
+
+	d, err := parseDate(*date)
+	if err != nil {
+		return err
+	}
+
+	// All files are imported at once, so the retired instruments are the ones missing from all of them.
+	all := &euronext.XmlInstruments{}
+	for _, fileName := range fs.Args() {
+		ins, err := readInstruments(fileName)
+		if err != nil {
+			return err
+		}
+
+		all.Instrument = append(all.Instrument, ins.Instrument...)
+	}
+
+	m, err := euronext.ReadMasterFile(*db)
+	if err != nil {
+		return err
+	}
+
+	changes, err := m.Import(all, d, *retire)
+	if err != nil {
+		return err
+	}
+
+	printChanges(changes)
+	return euronext.WriteMasterFile(*db, m)
+}
+
+func exportCmd(args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	db := fs.String("db", defaultMasterFile, "instrument master file")
+	date := fs.String("date", "", "date of the exported versions, default is current")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		usage()
+		return nil
+	}
+
+	d, err := parseDate(*date)
+	if err != nil {
+		return err
+	}
+
+	m, err := euronext.ReadMasterFile(*db)
+	if err != nil {
+		return err
+	}
+
+	fileName := fs.Arg(0)
+	ins := m.AsOf(d)
+	if strings.HasSuffix(strings.ToLower(fileName), ".json") {
+		err = euronext.WriteJsonInstrumentsFile(fileName, ins)
+	} else {
+		err = euronext.WriteXmlInstrumentsFile(fileName, ins)
+	}
+
+	if err != nil {
+		return fmt.Errorf("cannot write instruments file '%s': %w", fileName, err)
+	}
+
+	fmt.Printf("%d instruments exported to %s\n", len(ins.Instrument), fileName)
+	return nil
+}
+
+func diffCmd(args []string) error {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	db := fs.String("db", defaultMasterFile, "instrument master file")
+	from := fs.String("from", "", "date of the old versions")
+	to := fs.String("to", "", "date of the new versions, default is current")
+	fs.Parse(args)
+
+	var old, new *euronext.XmlInstruments
+	switch {
+	case fs.NArg() == 2:
+		var err error
+		if old, err = readInstruments(fs.Arg(0)); err != nil {
+			return err
+		}
+
+		if new, err = readInstruments(fs.Arg(1)); err != nil {
+			return err
+		}
+	case fs.NArg() == 0 && *from != "":
+		f, err := parseDate(*from)
+		if err != nil {
+			return err
+		}
+
+		t, err := parseDate(*to)
+		if err != nil {
+			return err
+		}
+
+		m, err := euronext.ReadMasterFile(*db)
+		if err != nil {
+			return err
+		}
+
+		old, new = m.AsOf(f), m.AsOf(t)
+	default:
+		usage()
+		return nil
+	}
+
+	printChanges(euronext.DiffXmlInstruments(old, new))
+	return nil
+}
+
+func historyCmd(args []string) error {
+	fs := flag.NewFlagSet("history", flag.ExitOnError)
+	db := fs.String("db", defaultMasterFile, "instrument master file")
+	fs.Parse(args)
+
+	if fs.NArg() != 2 {
+		usage()
+		return nil
+	}
+
+	m, err := euronext.ReadMasterFile(*db)
+	if err != nil {
+		return err
+	}
+
+	e := m.Entry(fs.Arg(0), fs.Arg(1))
+	if e == nil {
+		return fmt.Errorf("instrument %s %s is not found in '%s'", fs.Arg(0), fs.Arg(1), *db)
+	}
+
+	for i := range e.Versions {
+		v := &e.Versions[i]
+		to := v.ValidTo
+		if to == "" {
+			to = "current"
+		}
+
+		fmt.Printf("%s .. %s %s %s\n", v.ValidFrom, to, v.Instrument.Symbol, v.Instrument.Name)
+		if i > 0 {
+			for _, c := range euronext.DiffXmlInstrument(&e.Versions[i-1].Instrument, &v.Instrument) {
+				fmt.Printf("  %s: '%s' -> '%s'\n", c.Field, c.Old, c.New)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"euronext/euronext"
+)
+
+const (
+	prefixMic = "mic"
+	prefixMep = "mep"
+
+	dateLayout = "2006-01-02"
+)
+
+// source describes a daily input file of an instrument.
+//
+// The input file is inputs + yyyymmdd + folder + "/" + prefix + yyyymmdd + suffix,
+// where the prefix is built from the upper-case MIC or MEP, mnemonic and ISIN.
+// It is stored in the zip as mic_mnemonic_isin_ + yyyy-mm-dd + entry.
+type source struct {
+	Prefix string `json:"prefix"`
+	Folder string `json:"folder"`
+	Suffix string `json:"suffix"`
+	Entry  string `json:"entry"`
+}
+
+// archiveStep collects the daily input files of a vintage into a zip file per instrument.
+type archiveStep struct {
+	Name           string   `json:"name"`
+	Layout         string   `json:"layout"`
+	XmlInstruments string   `json:"xmlInstruments"`
+	Inputs         string   `json:"inputs"`
+	Downloads      string   `json:"downloads"`
+	From           string   `json:"from"`
+	To             string   `json:"to"`
+	Zip            string   `json:"zip"`
+	FixHeader      bool     `json:"fixHeader"`
+	Sources        []source `json:"sources"`
+
+	layout *layout
+	from   time.Time
+	to     time.Time
+}
+
+type download struct {
+	FileName string
+	Content  []byte
+}
+
+func (s *archiveStep) init(c *config) error {
+	if s.Name == "" {
+		return errors.New("step name is empty")
+	}
+
+	l, err := c.layout(s.Layout)
+	if err != nil {
+		return err
+	}
+	s.layout = l
+
+	s.from, err = time.Parse(dateLayout, s.From)
+	if err != nil {
+		return fmt.Errorf("cannot parse from date '%s': %w", s.From, err)
+	}
+
+	s.to, err = time.Parse(dateLayout, s.To)
+	if err != nil {
+		return fmt.Errorf("cannot parse to date '%s': %w", s.To, err)
+	}
+
+	if !s.from.Before(s.to) {
+		return fmt.Errorf("from date %s is not before to date %s", s.From, s.To)
+	}
+
+	if len(s.Sources) == 0 {
+		return errors.New("no sources")
+	}
+
+	for _, src := range s.Sources {
+		if src.Prefix != prefixMic && src.Prefix != prefixMep {
+			return fmt.Errorf("unknown source prefix '%s'", src.Prefix)
+		}
+	}
+
+	if s.Zip == "" {
+		return errors.New("zip suffix is empty")
+	}
+
+	s.Inputs = folder(s.Inputs)
+	s.Downloads = folder(s.Downloads)
+	return nil
+}
+
+func (s *archiveStep) run() error {
+	fmt.Printf("archive step %s, layout %s, %s .. %s\n", s.Name, s.Layout, s.From, s.To)
+
+	err := euronext.EnsureDirectoryExists(s.Downloads)
+	if err != nil {
+		return fmt.Errorf("cannot create directory %s: %w", s.Downloads, err)
+	}
+
+	fmt.Println("xml file: " + s.XmlInstruments)
+	instruments, err := readInstruments(s.XmlInstruments)
+	if err != nil {
+		return fmt.Errorf("cannot read instruments: %w", err)
+	}
+
+	l := len(instruments)
+	for i, ins := range instruments {
+		err := s.archive(&ins, i, l)
+		if err != nil {
+			fmt.Printf("\n%s\n", err)
+		}
+	}
+
+	return nil
+}
+
+func (s *archiveStep) archive(ins *instrument, el, elen int) error {
+	insFolder := ins.fileFolder()
+	insOutputPrefix := ins.filePrefix()
+	downloads := make([]download, 0)
+	log := fmt.Sprintf("(%d of %d) %s to %s ... ", el+1, elen, ins.inputPrefix(prefixMic), insFolder)
+
+	for date := s.from; date.Before(s.to); date = date.AddDate(0, 0, 1) {
+		sd := date.Format("20060102")
+		for _, src := range s.Sources {
+			file := fmt.Sprintf("%s%s%s/%s%s%s", s.Inputs, sd, src.Folder, ins.inputPrefix(src.Prefix), sd, src.Suffix)
+			if _, err := os.Stat(file); err != nil {
+				continue
+			}
+
+			bs, err := os.ReadFile(file)
+			if err != nil {
+				return fmt.Errorf("%s cannot read file '%s': %w", log, file, err)
+			}
+
+			if s.FixHeader {
+				bs = s.layout.fixHeader(bs)
+			}
+
+			file = fmt.Sprintf("%s%s%s", insOutputPrefix, date.Format(dateLayout), src.Entry)
+			downloads = append(downloads, download{FileName: file, Content: bs})
+		}
+	}
+
+	if len(downloads) == 0 {
+		fmt.Println(log + "no files found")
+		return nil
+	}
+
+	file := s.Downloads + insFolder
+	err := euronext.EnsureDirectoryExists(file)
+	if err != nil {
+		return fmt.Errorf("%s cannot create instrument download directory '%s': %w", log, file, err)
+	}
+
+	file = s.Downloads + ins.filePathZip(s.Zip)
+	z, err := os.Create(file)
+	if err != nil {
+		return fmt.Errorf("%s cannot create zip file '%s': %w", log, file, err)
+	}
+	defer z.Close()
+
+	w := zip.NewWriter(z)
+	defer w.Close()
+
+	for _, d := range downloads {
+		f, err := w.Create(d.FileName)
+		if err != nil {
+			return fmt.Errorf("%s cannot create zip entry '%s': %w", log, d.FileName, err)
+		}
+
+		_, err = f.Write(d.Content)
+		if err != nil {
+			return fmt.Errorf("%s cannot write zip entry '%s': %w", log, d.FileName, err)
+		}
+	}
+
+	fmt.Println(log + " " + fmt.Sprint(len(downloads)) + " archived")
+	return nil
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"euronext/euronext"
+)
+
+const configFileName = "enxmigrate.json"
+
+// config describes the layouts of the archive vintages and the migration steps.
+//
+// An archive step collects the daily files of a vintage into a zip file per instrument,
+// an import step converts these zip files and merges them into the repository.
+type config struct {
+	Layouts  map[string]*layout `json:"layouts"`
+	Archives []*archiveStep     `json:"archives"`
+	Imports  []*importStep      `json:"imports"`
+}
+
+type instrument struct {
+	Mnemonic string `json:"mnemonic"`
+	Mep      string `json:"mep"`
+	Mic      string `json:"mic"`
+	Isin     string `json:"isin"`
+	Type     string `json:"type"`
+}
+
+func main() {
+	configPtr := flag.String("config", configFileName, "configuration file with layouts and steps")
+	listPtr := flag.Bool("list", false, "list configured steps and exit")
+	flag.Parse()
+
+	cfg, err := readConfig(*configPtr)
+	if err != nil {
+		panic(fmt.Sprintf("cannot read configuration file %s: %s", *configPtr, err))
+	}
+
+	if *listPtr || flag.NArg() == 0 {
+		usage(cfg)
+		return
+	}
+
+	t := time.Now().Format("2006-01-02 15-04-05")
+	fmt.Println("=======================================")
+	fmt.Println(t)
+	fmt.Println("=======================================")
+
+	for _, name := range flag.Args() {
+		if err := cfg.run(name); err != nil {
+			panic(err.Error())
+		}
+	}
+
+	fmt.Println("\nfinished " + time.Now().Format("2006-01-02 15-04-05"))
+}
+
+func usage(cfg *config) {
+	fmt.Println("usage:")
+	fmt.Println("enxmigrate {-config=file} step...")
+	fmt.Println("-config - configuration file with layouts and steps, default is '" + configFileName + "'")
+	fmt.Println("-list   - list configured steps")
+	fmt.Println("step    - names of the steps to run in the given order")
+	fmt.Println("")
+	fmt.Println("archive steps, collect daily files into a zip file per instrument:")
+	for _, s := range cfg.Archives {
+		fmt.Printf("  %-16s layout %-10s %s .. %s -> %s\n", s.Name, s.Layout, s.From, s.To, s.Downloads)
+	}
+
+	fmt.Println("import steps, merge zip files per instrument into the repository:")
+	for _, s := range cfg.Imports {
+		fmt.Printf("  %-16s layout %-10s %s -> %s\n", s.Name, s.Layout, s.Downloads, s.Repository)
+	}
+}
+
+func (c *config) run(name string) error {
+	for _, s := range c.Archives {
+		if s.Name == name {
+			return s.run()
+		}
+	}
+
+	for _, s := range c.Imports {
+		if s.Name == name {
+			return s.run()
+		}
+	}
+
+	return fmt.Errorf("unknown step '%s'", name)
+}
+
+func readConfig(fileName string) (*config, error) {
+	var conf config
+
+	f, err := os.Open(fileName)
+	if err != nil {
+		return &conf, fmt.Errorf("cannot open '%s' file: %w", fileName, err)
+	}
+	defer f.Close()
+
+	decoder := json.NewDecoder(f)
+	decoder.DisallowUnknownFields()
+
+	err = decoder.Decode(&conf)
+	if err != nil {
+		return &conf, fmt.Errorf("cannot decode '%s' file: %w", fileName, err)
+	}
+
+	for name, l := range conf.Layouts {
+		if err := l.validate(); err != nil {
+			return &conf, fmt.Errorf("layout '%s': %w", name, err)
+		}
+	}
+
+	names := make(map[string]bool)
+	for _, s := range conf.Archives {
+		if err := s.init(&conf); err != nil {
+			return &conf, fmt.Errorf("archive step '%s': %w", s.Name, err)
+		}
+
+		if names[s.Name] {
+			return &conf, fmt.Errorf("duplicate step '%s'", s.Name)
+		}
+		names[s.Name] = true
+	}
+
+	for _, s := range conf.Imports {
+		if err := s.init(&conf); err != nil {
+			return &conf, fmt.Errorf("import step '%s': %w", s.Name, err)
+		}
+
+		if names[s.Name] {
+			return &conf, fmt.Errorf("duplicate step '%s'", s.Name)
+		}
+		names[s.Name] = true
+	}
+
+	return &conf, nil
+}
+
+func (c *config) layout(name string) (*layout, error) {
+	l, ok := c.Layouts[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown layout '%s'", name)
+	}
+
+	return l, nil
+}
+
+func folder(s string) string {
+	if !strings.HasSuffix(s, "/") {
+		s += "/"
+	}
+
+	return s
+}
+
+func readInstruments(fileName string) ([]instrument, error) {
+	instruments := []instrument{}
+	instrs, err := euronext.ReadXmlInstrumentsFile(fileName)
+	if err != nil {
+		return instruments, fmt.Errorf("cannot read instruments xml file '%s': %w", fileName, err)
+	}
+
+	fmt.Println(len(instrs.Instrument), "instruments read from", fileName)
+	for _, inst := range instrs.Instrument {
+		ins := instrument{
+			Mnemonic: strings.ToLower(inst.Symbol),
+			Mep:      strings.ToLower(inst.Mep),
+			Mic:      strings.ToLower(inst.Mic),
+			Isin:     strings.ToLower(inst.Isin),
+			Type:     strings.ToLower(inst.Type),
+		}
+		instruments = append(instruments, ins)
+	}
+
+	return instruments, nil
+}
+
+func (s *instrument) safeMnemonic() string {
+	mnemonic := s.Mnemonic
+	if mnemonic == "prn" || mnemonic == "com" || mnemonic == "lpt" || mnemonic == "aux" || mnemonic == "com5" {
+		mnemonic += "_"
+	}
+
+	return mnemonic
+}
+
+// filePrefix is the prefix of the archived and imported files.
+func (s *instrument) filePrefix() string {
+	return fmt.Sprintf("%s_%s_%s_", s.Mic, s.Mnemonic, s.Isin)
+}
+
+// inputPrefix is the prefix of the daily input files, older vintages use MEP instead of MIC.
+func (s *instrument) inputPrefix(prefix string) string {
+	market := s.Mic
+	if prefix == prefixMep {
+		market = s.Mep
+	}
+
+	return fmt.Sprintf("%s_%s_%s_", strings.ToUpper(market), strings.ToUpper(s.Mnemonic), strings.ToUpper(s.Isin))
+}
+
+func (s *instrument) fileFolder() string {
+	return fmt.Sprintf("%s/%s/%s/endofday/", s.Mic, s.Type, s.safeMnemonic())
+}
+
+func (s *instrument) filePathZip(zipSuffix string) string {
+	return s.fileFolder() + s.filePrefix() + zipSuffix + ".zip"
+}
+
+func (s *instrument) filePathCsvGz() string {
+	return fmt.Sprintf("%s/%s/%s/%s_%s_%s.1d.csv.gz",
+		s.Mic, s.Type, s.safeMnemonic(), s.Mic, s.Mnemonic, s.Isin)
+}
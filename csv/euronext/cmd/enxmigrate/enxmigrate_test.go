@@ -0,0 +1,200 @@
+package main
+
+import (
+	"compress/gzip"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+const testInstruments = "testdata/instruments.xml"
+
+// TestVintages runs the configured archive and import steps of every vintage
+// on the inputs in testdata/<archive step>/inputs and compares the merged histories
+// and the import output with the golden files in the same folder.
+// The golden files are made by the original eodarc and eodimp commands, see testdata/golden.sh.
+// A vintage without an import step, e.g. eodarc2020, is imported with the layout and zip suffix of the archive step.
+func TestVintages(t *testing.T) {
+	cfg, err := readConfig(configFileName)
+	if err != nil {
+		t.Fatalf("cannot read configuration: %v", err)
+	}
+
+	tests := []struct {
+		archive string
+		imp     string
+	}{
+		{"eodarc20120901", "eodimp20120901"},
+		{"eodarc20131228", "eodimp20131228"},
+		{"eodarc2014", "eodimp2014"},
+		{"eodarc2015", "eodimp2015"},
+		{"eodarc2016", "eodimp2016"},
+		{"eodarc2017", "eodimp2017"},
+		{"eodarc2018", "eodimp2018"}, // reads the 2017 zip
+		{"eodarc2019", "eodimp2019"},
+		{"eodarc2020", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.archive, func(t *testing.T) {
+			arc, imp := findSteps(t, cfg, tt.archive, tt.imp)
+			dir := filepath.Join("testdata", tt.archive)
+
+			a := *arc
+			a.XmlInstruments = testInstruments
+			a.Inputs = folder(filepath.Join(dir, "inputs"))
+			a.Downloads = folder(t.TempDir())
+			if err := a.run(); err != nil {
+				t.Fatalf("cannot run archive step: %v", err)
+			}
+
+			i := *imp
+			i.Downloads = a.Downloads
+			i.Repository = folder(t.TempDir())
+
+			instruments, err := readInstruments(testInstruments)
+			if err != nil {
+				t.Fatalf("cannot read instruments: %v", err)
+			}
+
+			var log strings.Builder
+			for k, ins := range instruments {
+				if err := i.imp(&log, &ins, k, len(instruments)); err != nil {
+					t.Fatalf("cannot import %s: %v", ins.filePrefix(), err)
+				}
+			}
+
+			csv := readRepository(t, i.Repository)
+			if csv == "" {
+				t.Fatalf("no instruments imported")
+			}
+
+			compareGolden(t, filepath.Join(dir, "expected.csv"), csv)
+			compareGolden(t, filepath.Join(dir, "expected.log"), log.String())
+		})
+	}
+}
+
+// readRepository concatenates the histories in the repository in the order of their paths,
+// every history is preceded by a comment line with its name.
+func readRepository(t *testing.T, repository string) string {
+	t.Helper()
+
+	var files []string
+	err := filepath.WalkDir(repository, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && strings.HasSuffix(path, ".1d.csv.gz") {
+			files = append(files, path)
+		}
+
+		return err
+	})
+	if err != nil {
+		t.Fatalf("cannot walk repository: %v", err)
+	}
+
+	sort.Strings(files)
+
+	var csv strings.Builder
+	for _, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			t.Fatalf("cannot open history: %v", err)
+		}
+		defer f.Close()
+
+		r, err := gzip.NewReader(f)
+		if err != nil {
+			t.Fatalf("cannot read history: %v", err)
+		}
+
+		bs, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatalf("cannot read history: %v", err)
+		}
+
+		csv.WriteString("# " + strings.TrimSuffix(filepath.Base(file), ".1d.csv.gz") + "\n")
+		csv.Write(bs)
+	}
+
+	return csv.String()
+}
+
+func TestReadConfigErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"unknown field", `{"layouts":{},"unknown":1}`},
+		{"unknown layout", `{"imports":[{"name":"a","layout":"x","zip":"2014"}]}`},
+		{"bad adjustment", `{"layouts":{"x":{"format":"json","dateFormat":"02/01/2006","adjustment":"none","merge":"keep"}}}`},
+		{"update split", `{"layouts":{"x":{"format":"json","dateFormat":"02/01/2006","adjustment":"split","merge":"update"}}}`},
+		{"unknown column", `{"layouts":{"x":{"format":"csv","separator":";","columns":["date","volume"],"dateFormat":"01/02/06","adjustment":"combined","merge":"keep"}}}`},
+		{"duplicate step", `{"layouts":{"x":{"format":"json","dateFormat":"02/01/2006","adjustment":"split","merge":"keep"}},` +
+			`"imports":[{"name":"a","layout":"x","zip":"2014"},{"name":"a","layout":"x","zip":"2015"}]}`},
+		{"bad dates", `{"layouts":{"x":{"format":"json","dateFormat":"02/01/2006","adjustment":"split","merge":"keep"}},` +
+			`"archives":[{"name":"a","layout":"x","from":"2015-01-01","to":"2014-01-01","zip":"2014","sources":[{"prefix":"mic"}]}]}`},
+	}
+
+	for _, tt := range tests {
+		fileName := filepath.Join(t.TempDir(), "config.json")
+		if err := os.WriteFile(fileName, []byte(tt.content), 0666); err != nil {
+			t.Fatalf("cannot write config: %v", err)
+		}
+
+		if _, err := readConfig(fileName); err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+	}
+}
+
+func findSteps(t *testing.T, cfg *config, archive, imp string) (*archiveStep, *importStep) {
+	t.Helper()
+
+	var a *archiveStep
+	for _, s := range cfg.Archives {
+		if s.Name == archive {
+			a = s
+		}
+	}
+
+	var i *importStep
+	for _, s := range cfg.Imports {
+		if s.Name == imp {
+			i = s
+		}
+	}
+
+	if imp == "" && a != nil {
+		i = &importStep{Name: archive, Layout: a.Layout, Zip: a.Zip}
+		if err := i.init(cfg); err != nil {
+			t.Fatalf("cannot init import of %s: %v", archive, err)
+		}
+	}
+
+	if a == nil || i == nil {
+		t.Fatalf("steps %s and %s are not configured", archive, imp)
+	}
+
+	if a.Layout != i.Layout || a.Zip != i.Zip {
+		t.Fatalf("steps %s and %s differ in layout or zip suffix", archive, imp)
+	}
+
+	return a, i
+}
+
+func compareGolden(t *testing.T, fileName, actual string) {
+	t.Helper()
+
+	bs, err := os.ReadFile(fileName)
+	if err != nil {
+		t.Fatalf("cannot read golden file: %v", err)
+	}
+
+	if string(bs) != actual {
+		t.Errorf("%s mismatch\nexpected:\n%s\nactual:\n%s", fileName, bs, actual)
+	}
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"euronext/euronext"
+)
+
+// importStep converts the zip file of every instrument and merges it into the repository.
+type importStep struct {
+	Name           string `json:"name"`
+	Layout         string `json:"layout"`
+	XmlInstruments string `json:"xmlInstruments"`
+	Downloads      string `json:"downloads"`
+	Repository     string `json:"repository"`
+	Zip            string `json:"zip"`
+
+	layout *layout
+}
+
+func (s *importStep) init(c *config) error {
+	if s.Name == "" {
+		return errors.New("step name is empty")
+	}
+
+	l, err := c.layout(s.Layout)
+	if err != nil {
+		return err
+	}
+	s.layout = l
+
+	if s.Zip == "" {
+		return errors.New("zip suffix is empty")
+	}
+
+	s.Downloads = folder(s.Downloads)
+	s.Repository = folder(s.Repository)
+	return nil
+}
+
+func (s *importStep) run() error {
+	fmt.Printf("import step %s, layout %s\n", s.Name, s.Layout)
+
+	err := euronext.EnsureDirectoryExists(s.Repository)
+	if err != nil {
+		return fmt.Errorf("cannot create directory %s: %w", s.Repository, err)
+	}
+
+	fmt.Println("xml file: " + s.XmlInstruments)
+	instruments, err := readInstruments(s.XmlInstruments)
+	if err != nil {
+		return fmt.Errorf("cannot read instruments: %w", err)
+	}
+
+	l := len(instruments)
+	for i, ins := range instruments {
+		err := s.imp(os.Stdout, &ins, i, l)
+		if err != nil {
+			fmt.Printf("\n%s\n", err)
+		}
+	}
+
+	return nil
+}
+
+// imp imports the zip file of an instrument and writes the progress, the conversion warnings
+// and the merge messages to w.
+func (s *importStep) imp(w io.Writer, ins *instrument, el, elen int) error {
+	insZip := s.Downloads + ins.filePathZip(s.Zip)
+	insCsvGz := s.Repository + ins.filePathCsvGz()
+	fmt.Fprintf(w, "(%d of %d) %s ... ", el+1, elen, ins.filePrefix())
+
+	if _, err := os.Stat(insZip); err != nil {
+		if os.IsNotExist(err) {
+			fmt.Fprintln(w, "no zip found")
+			return nil
+		} else {
+			return fmt.Errorf("cannot check zip file '%s': %w", insZip, err)
+		}
+	}
+
+	zipReader, err := zip.OpenReader(insZip)
+	if err != nil {
+		return fmt.Errorf("cannot open zip file '%s': %w", insZip, err)
+	}
+	defer zipReader.Close()
+
+	histOld, _, err := readCombinedDailyHistoryCsv(insCsvGz)
+	if err != nil {
+		return fmt.Errorf("cannot read old history file '%s': %w", insCsvGz, err)
+	}
+
+	sta := statis{}
+	lenBefore := len(histOld)
+	hist, err := importEntries(w, histOld, zipReader.File, s.layout, &sta)
+	if err != nil {
+		return err
+	}
+
+	err = writeCombinedDailyHistoryCsv(insCsvGz, hist)
+	if err != nil {
+		fmt.Fprintf(w, "\ncannot write history file '%s': %s", insCsvGz, err)
+	}
+
+	fmt.Fprintf(w, "\n(lines before %d -> after %d) input files %d [marked input %d, merged old %d merged replace (same %d, diff %d), merged new %d]\n",
+		lenBefore, len(hist), len(zipReader.File), sta.markedInput, sta.merged.Old, sta.merged.Same, sta.merged.Diff, sta.merged.New)
+	return nil
+}
+
+// importEntries converts and merges the zip entries in their order
+// and writes the conversion warnings and the merge messages to w.
+func importEntries(w io.Writer, histOld []euronext.CombinedDailyHistory, files []*zip.File, l *layout, sta *statis) ([]euronext.CombinedDailyHistory, error) {
+	for i, file := range files {
+		content, err := readZipEntry(file)
+		if err != nil {
+			return histOld, err
+		}
+
+		unadjusted := strings.Contains(file.Name, "unadjusted")
+		histNew, warnings, err := l.convert(content, unadjusted, sta)
+		for _, warning := range warnings {
+			fmt.Fprintf(w, "\n%s", warning)
+		}
+
+		if err != nil {
+			return histOld, fmt.Errorf("cannot convert zip entry '%s': %w", file.Name, err)
+		}
+
+		var messages []string
+		histOld, messages = merge(histOld, histNew, l, unadjusted, &sta.merged)
+		if len(messages) > 0 {
+			fmt.Fprintf(w, "\n[%d] %s messages:\n", i+1, file.Name)
+			fmt.Fprint(w, strings.Join(messages, "\n"))
+		}
+	}
+
+	return histOld, nil
+}
+
+// merge merges the converted content of a daily file into the old history as prescribed by the layout.
+// The unadjusted flag is only used by the split adjustment.
+func merge(histOld, histNew []euronext.CombinedDailyHistory, l *layout, unadjusted bool, sta *euronext.MergeStatistics) ([]euronext.CombinedDailyHistory, []string) {
+	switch {
+	case l.Merge == mergeUpdate:
+		return euronext.MergeCombinedDailyHistory(histOld, histNew)
+	case l.Adjustment == adjustmentSplit:
+		return euronext.MergeCombinedDailyHistorySplit(histOld, histNew, unadjusted, sta)
+	default:
+		return euronext.MergeCombinedDailyHistoryKeep(histOld, histNew, sta)
+	}
+}
+
+func readZipEntry(file *zip.File) ([]byte, error) {
+	rc, err := file.Open()
+	if err != nil {
+		return nil, fmt.Errorf("cannot open zip entry '%s': %w", file.Name, err)
+	}
+	defer rc.Close()
+
+	content, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read zip entry '%s': %w", file.Name, err)
+	}
+
+	return content, nil
+}
+
+func readCombinedDailyHistoryCsv(file string) ([]euronext.CombinedDailyHistory, string, error) {
+	if _, err := os.Stat(file); err == nil {
+		return euronext.ReadCombinedDailyHistoryCsv(file)
+	} else if os.IsNotExist(err) {
+		return make([]euronext.CombinedDailyHistory, 0), "", nil
+	} else {
+		es := fmt.Sprintf("error checking if file '%s' exists: ", file)
+		return make([]euronext.CombinedDailyHistory, 0), es, fmt.Errorf("%s%w", es, err)
+	}
+}
+
+func writeCombinedDailyHistoryCsv(file string, content []euronext.CombinedDailyHistory) error {
+	dir := filepath.Dir(file)
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return fmt.Errorf("cannot create directory '%s': %w", dir, err)
+	}
+
+	if _, err := os.Stat(file); err == nil {
+		err = os.Remove(file)
+		if err != nil {
+			return fmt.Errorf("cannot remove file '%s': %w", file, err)
+		}
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("error checking if file '%s' exists: %w", file, err)
+	}
+
+	_, err := euronext.WriteCombinedDailyHistoryCsv(file, content)
+	return err
+}
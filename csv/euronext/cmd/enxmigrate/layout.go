@@ -0,0 +1,418 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"euronext/euronext"
+)
+
+const (
+	formatCsv  = "csv"
+	formatJson = "json"
+
+	adjustmentCombined = "combined" // a file has prices used as both unadjusted and adjusted
+	adjustmentSplit    = "split"    // a file has either unadjusted or adjusted prices
+
+	mergeKeep   = "keep"   // keep the old non-zero values which are zero in the new file
+	mergeUpdate = "update" // newer file wins, older adjusted values are rescaled
+)
+
+// Column indices of a record.
+const (
+	colDate = iota
+	colOpen
+	colHigh
+	colLow
+	colClose
+	colShares
+	colTrades
+	colTurnover
+	numColumns
+)
+
+var columnNames = [numColumns]string{"date", "open", "high", "low", "close", "shares", "trades", "turnover"}
+
+// layout describes the daily file format of an archive vintage.
+type layout struct {
+	// Format is either csv or json.
+	Format string `json:"format"`
+
+	// DateFormat is the Go layout of the date column.
+	DateFormat string `json:"dateFormat"`
+
+	// HeaderLine is the one-based line number of a csv header, lines above it are ignored.
+	HeaderLine int `json:"headerLine"`
+
+	// Header is the expected prefix of a csv header line.
+	Header string `json:"header"`
+
+	// Separator is the csv column separator.
+	Separator string `json:"separator"`
+
+	// Columns maps csv columns to the date, open, high, low, close, shares, trades and turnover.
+	// Missing columns are zero.
+	Columns []string `json:"columns"`
+
+	// Thousands is the thousands separator removed from the numbers.
+	Thousands string `json:"thousands"`
+
+	// MarkEmpty marks a bar having an empty or a dash value.
+	MarkEmpty bool `json:"markEmpty"`
+
+	// MarkVolumes applies the MarkEmpty rule to the shares, trades and turnover too.
+	MarkVolumes bool `json:"markVolumes"`
+
+	// FillPrices fills zero prices from the non-zero ones and marks the bar.
+	FillPrices bool `json:"fillPrices"`
+
+	// Adjustment is either combined or split.
+	Adjustment string `json:"adjustment"`
+
+	// Merge is either keep or update.
+	Merge string `json:"merge"`
+
+	columns [numColumns]int
+}
+
+// record is a parsed daily line with fields indexed by the column indices.
+type record struct {
+	where  string
+	text   string
+	fields [numColumns]string
+}
+
+type statis struct {
+	markedInput int
+	merged      euronext.MergeStatistics
+}
+
+func (l *layout) validate() error {
+	if l.DateFormat == "" {
+		return errors.New("date format is empty")
+	}
+
+	switch l.Adjustment {
+	case adjustmentCombined, adjustmentSplit:
+	default:
+		return fmt.Errorf("unknown adjustment '%s'", l.Adjustment)
+	}
+
+	switch l.Merge {
+	case mergeKeep, mergeUpdate:
+	default:
+		return fmt.Errorf("unknown merge '%s'", l.Merge)
+	}
+
+	if l.Merge == mergeUpdate && l.Adjustment != adjustmentCombined {
+		return errors.New("update merge needs the combined adjustment")
+	}
+
+	switch l.Format {
+	case formatJson:
+		if len(l.Columns) > 0 {
+			return errors.New("json format has fixed columns")
+		}
+
+		for i := range l.columns {
+			l.columns[i] = i
+		}
+	case formatCsv:
+		if l.Separator == "" {
+			return errors.New("csv separator is empty")
+		}
+
+		for i := range l.columns {
+			l.columns[i] = -1
+		}
+
+		for i, name := range l.Columns {
+			found := false
+			for j, n := range columnNames {
+				if n == name {
+					if l.columns[j] >= 0 {
+						return fmt.Errorf("duplicate column '%s'", name)
+					}
+
+					l.columns[j] = i
+					found = true
+					break
+				}
+			}
+
+			if !found && name != "" {
+				return fmt.Errorf("unknown column '%s'", name)
+			}
+		}
+
+		if l.columns[colDate] < 0 {
+			return errors.New("csv columns have no date")
+		}
+	default:
+		return fmt.Errorf("unknown format '%s'", l.Format)
+	}
+
+	return nil
+}
+
+// records splits the content of a daily file into records.
+func (l *layout) records(bs []byte) ([]record, error) {
+	if l.Format == formatJson {
+		return l.jsonRecords(bs)
+	}
+
+	return l.csvRecords(bs)
+}
+
+func (l *layout) jsonRecords(bs []byte) ([]record, error) {
+	if len(bs) < 8 {
+		return nil, nil
+	}
+
+	jsonEod := euronext.JsonEod{}
+	err := json.Unmarshal(bs, &jsonEod)
+	if err != nil {
+		return nil, fmt.Errorf("cannot unmarshal json file: %w", err)
+	}
+
+	records := make([]record, 0, len(jsonEod.Data))
+	for i, data := range jsonEod.Data {
+		records = append(records, record{
+			where: fmt.Sprintf("item %d", i),
+			text:  fmt.Sprintf("%v", data),
+			fields: [numColumns]string{
+				data.Date, data.Open, data.High, data.Low, data.Close,
+				data.Nymberofshares, data.Numoftrades, data.Turnover,
+			},
+		})
+	}
+
+	return records, nil
+}
+
+func (l *layout) csvRecords(bs []byte) ([]record, error) {
+	lines := strings.Split(string(bs), "\n")
+	records := []record{}
+
+	for i, line := range lines {
+		if i < l.HeaderLine-1 {
+			continue
+		}
+
+		if i == l.HeaderLine-1 {
+			if !strings.HasPrefix(line, l.Header) {
+				return records, fmt.Errorf("line %d: unexpected header line: %s", i+1, line)
+			}
+
+			continue
+		}
+
+		if len(line) == 0 {
+			continue
+		}
+
+		parts := strings.Split(line, l.Separator)
+		if len(parts) != len(l.Columns) {
+			return records, fmt.Errorf("line %d: expected %d line parts, got %d: %s", i+1, len(l.Columns), len(parts), line)
+		}
+
+		r := record{where: fmt.Sprintf("line %d", i+1), text: line}
+		for j, c := range l.columns {
+			if c >= 0 {
+				r.fields[j] = parts[c]
+			}
+		}
+
+		records = append(records, r)
+	}
+
+	return records, nil
+}
+
+// fixHeader splits the first data line glued to the header line.
+func (l *layout) fixHeader(bs []byte) []byte {
+	if l.Format != formatCsv || l.HeaderLine < 1 || l.Header == "" {
+		return bs
+	}
+
+	lines := strings.Split(string(bs), "\n")
+	i := l.HeaderLine - 1
+	if len(lines) <= i || !strings.HasPrefix(lines[i], l.Header) || len(lines[i]) == len(l.Header) {
+		return bs
+	}
+
+	remainingText := lines[i][len(l.Header):]
+	lines[i] = l.Header
+	lines = append(lines[:i+1], append([]string{remainingText}, lines[i+1:]...)...)
+	return []byte(strings.Join(lines, "\n"))
+}
+
+// combinedHeader is the header of the lines converted by euronext.ConvertToCombinedDailyHistory.
+const combinedHeader = "Date;Open;High;Low;Last;Close;Number of shares;Number of Trades;Turnover;vwap;" +
+	"Date;Open;High;Low;Last;Close;Number of shares;Number of Trades;Turnover;vwap"
+
+// convert converts the content of a daily file.
+// The records are normalized to the lines of the combined unadjusted and adjusted histories
+// and converted by euronext.ConvertToCombinedDailyHistory.
+// The unadjusted flag is only used by the split adjustment, the missing half of a bar has -1 values.
+// The returned warnings report zero prices.
+func (l *layout) convert(bs []byte, unadjusted bool, sta *statis) ([]euronext.CombinedDailyHistory, []string, error) {
+	warnings := []string{}
+
+	records, err := l.records(bs)
+	if err != nil {
+		return nil, warnings, err
+	}
+
+	// The shared converter skips three lines before the header.
+	lines := []string{"", "", "", combinedHeader}
+	for _, r := range records {
+		line, warns, err := l.combinedLine(r, unadjusted)
+		warnings = append(warnings, warns...)
+		if err != nil {
+			return nil, warnings, err
+		}
+
+		if strings.HasPrefix(line, "'") {
+			sta.markedInput += 1
+		}
+
+		lines = append(lines, line)
+	}
+
+	combinedHist, err := euronext.ConvertToCombinedDailyHistory(lines)
+	if err != nil {
+		return combinedHist, warnings, err
+	}
+
+	for i := range combinedHist {
+		h := &combinedHist[i]
+		h.AdjustmentFactor = 1
+		switch {
+		case l.Adjustment == adjustmentCombined:
+			h.HasMarkingAdjusted = h.HasMarking
+		case !unadjusted:
+			h.HasMarkingAdjusted = h.HasMarking
+			h.HasMarking = false
+		}
+	}
+
+	return combinedHist, warnings, nil
+}
+
+// combinedLine parses a record and formats it as a line of the combined unadjusted and adjusted histories.
+// A marked line has the date prefixed with a quote.
+func (l *layout) combinedLine(r record, unadjusted bool) (string, []string, error) {
+	warnings := []string{}
+
+	s := strings.TrimSpace(r.fields[colDate])
+	s = strings.ReplaceAll(s, "\\/", "/")
+	date, err := time.Parse(l.DateFormat, s)
+	if err != nil {
+		return "", warnings, fmt.Errorf("%s: cannot parse date '%s' in '%s': %w", r.where, s, r.text, err)
+	}
+
+	var values [numColumns]float64
+	marking := false
+	for c := colOpen; c < numColumns; c++ {
+		if l.columns[c] < 0 {
+			continue
+		}
+
+		v, m, err := l.parseFloat(r.fields[c])
+		if err != nil {
+			return "", warnings, fmt.Errorf("%s: cannot parse %s '%s' in '%s': %w", r.where, columnNames[c], r.fields[c], r.text, err)
+		}
+
+		values[c] = v
+		if m && (c <= colClose || l.MarkVolumes) {
+			marking = true
+		}
+	}
+
+	open, high, low, close := values[colOpen], values[colHigh], values[colLow], values[colClose]
+	if l.FillPrices {
+		open, high, low, close, marking = fillPrices(open, high, low, close, marking)
+	}
+
+	if open != 0 || high != 0 || low != 0 || close != 0 {
+		for _, p := range []struct {
+			name  string
+			value float64
+		}{{"open", open}, {"high", high}, {"low", low}, {"close", close}} {
+			if p.value == 0 {
+				warnings = append(warnings, fmt.Sprintf("%s: zero %s value in '%s'", r.where, p.name, r.text))
+			}
+		}
+	} else {
+		warnings = append(warnings, fmt.Sprintf("%s: zero price values in '%s'", r.where, r.text))
+	}
+
+	sd := date.Format("02/01/2006")
+	bar := sd
+	for _, v := range []float64{open, high, low, close, close, values[colShares], values[colTrades], values[colTurnover], 0} {
+		bar += ";" + strconv.FormatFloat(v, 'f', -1, 64)
+	}
+
+	missing := sd + strings.Repeat(";-1", 9)
+	line := bar + ";" + bar
+	if l.Adjustment == adjustmentSplit {
+		if unadjusted {
+			line = bar + ";" + missing
+		} else {
+			line = missing + ";" + bar
+		}
+	}
+
+	if marking {
+		line = "'" + line
+	}
+
+	return line, warnings, nil
+}
+
+// fillPrices fills zero prices from the non-zero ones in the order of close, open, high and low.
+func fillPrices(open, high, low, close float64, marking bool) (float64, float64, float64, float64, bool) {
+	fill := func(src float64, dst ...*float64) {
+		if src == 0 {
+			return
+		}
+
+		for _, d := range dst {
+			if *d == 0 {
+				*d = src
+				marking = true
+			}
+		}
+	}
+
+	fill(close, &open, &high, &low)
+	fill(open, &close, &high, &low)
+	fill(high, &close, &open, &low)
+	fill(low, &close, &open, &high)
+
+	return open, high, low, close, marking
+}
+
+// parseFloat returns the value and if it should be marked.
+func (l *layout) parseFloat(s string) (float64, bool, error) {
+	s = strings.TrimSpace(s)
+	if len(s) == 0 || s[0] == '-' {
+		return 0, l.MarkEmpty, nil
+	}
+
+	if s == "0" || s == "0.0" {
+		return 0, false, nil
+	}
+
+	if l.Thousands != "" {
+		s = strings.ReplaceAll(s, l.Thousands, "")
+	}
+
+	v, err := strconv.ParseFloat(s, 64)
+	return v, false, err
+}
@@ -0,0 +1 @@
+{"data":[{"ISIN":"FR0000120073","MIC":"Euronext Paris","date":"24\/12\/2013","open":"99.10","high":"99.85","low":"98.90","close":"99.52","nymberofshares":"402,118","numoftrades":"3,104","turnover":"40,012,345.50","currency":"EUR"},{"ISIN":"FR0000120073","MIC":"Euronext Paris","date":"26\/12\/2013","open":"-","high":"","low":"","close":"100.05","nymberofshares":"0","numoftrades":"0","turnover":"0","currency":"EUR"}]}
\ No newline at end of file
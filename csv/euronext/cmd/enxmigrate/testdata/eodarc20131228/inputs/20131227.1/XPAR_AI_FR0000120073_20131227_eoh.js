@@ -0,0 +1 @@
+{"data":[{"ISIN":"FR0000120073","MIC":"Euronext Paris","date":"26\/12\/2013","open":"99.60","high":"100.20","low":"99.40","close":"100.05","nymberofshares":"0","numoftrades":"0","turnover":"0","currency":"EUR"},{"ISIN":"FR0000120073","MIC":"Euronext Paris","date":"27\/12\/2013","open":"100.10","high":"100.90","low":"99.95","close":"100.70","nymberofshares":"512,440","numoftrades":"4,021","turnover":"51,533,210.00","currency":"EUR"}]}
\ No newline at end of file
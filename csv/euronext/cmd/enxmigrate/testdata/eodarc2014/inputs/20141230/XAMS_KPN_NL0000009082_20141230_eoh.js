@@ -0,0 +1 @@
+{"data":[{"ISIN":"NL0000009082","MIC":"Euronext Amsterdam","date":"29\/12\/2014","open":"2.612","high":"2.640","low":"2.600","close":"2.631","nymberofshares":"12,345,678","numoftrades":"5,432","turnover":"32,456,789.12","currency":"EUR"},{"ISIN":"NL0000009082","MIC":"Euronext Amsterdam","date":"30\/12\/2014","open":"2.630","high":"2.655","low":"2.621","close":"2.648","nymberofshares":"9,876,543","numoftrades":"4,321","turnover":"26,123,456.78","currency":"EUR"}]}
\ No newline at end of file
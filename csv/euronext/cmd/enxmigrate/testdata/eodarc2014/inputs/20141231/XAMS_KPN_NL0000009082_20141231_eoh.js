@@ -0,0 +1 @@
+{"data":[{"ISIN":"NL0000009082","MIC":"Euronext Amsterdam","date":"30\/12\/2014","open":"2.630","high":"2.655","low":"2.621","close":"2.650","nymberofshares":"9,876,543","numoftrades":"4,321","turnover":"26,123,456.78","currency":"EUR"},{"ISIN":"NL0000009082","MIC":"Euronext Amsterdam","date":"31\/12\/2014","open":"2.648","high":"2.660","low":"2.610","close":"2.615","nymberofshares":"-","numoftrades":"-","turnover":"-","currency":"EUR"}]}
\ No newline at end of file
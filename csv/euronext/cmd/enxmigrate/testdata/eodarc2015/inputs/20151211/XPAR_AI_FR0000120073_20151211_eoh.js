@@ -0,0 +1 @@
+{"data":[{"ISIN":"FR0000120073","MIC":"Euronext Paris","date":"10\/12\/2015","open":"100.000","high":"101.000","low":"99.000","close":"100.500","nymberofshares":"1,200,300","numoftrades":"3,100","turnover":"12,345,678.12","currency":"EUR"},{"ISIN":"FR0000120073","MIC":"Euronext Paris","date":"11\/12\/2015","open":"101.000","high":"102.010","low":"99.990","close":"101.505","nymberofshares":"900,100","numoftrades":"2,200","turnover":"9,876,543.21","currency":"EUR"}]}
\ No newline at end of file
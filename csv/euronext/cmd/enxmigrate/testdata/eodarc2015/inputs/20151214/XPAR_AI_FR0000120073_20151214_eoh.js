@@ -0,0 +1 @@
+{"data":[{"ISIN":"FR0000120073","MIC":"Euronext Paris","date":"11\/12\/2015","open":"101.000","high":"102.010","low":"99.990","close":"101.525","nymberofshares":"900,100","numoftrades":"2,200","turnover":"9,876,543.21","currency":"EUR"},{"ISIN":"FR0000120073","MIC":"Euronext Paris","date":"14\/12\/2015","open":"102.000","high":"103.020","low":"100.980","close":"102.510","nymberofshares":"-","numoftrades":"-","turnover":"-","currency":"EUR"}]}
\ No newline at end of file
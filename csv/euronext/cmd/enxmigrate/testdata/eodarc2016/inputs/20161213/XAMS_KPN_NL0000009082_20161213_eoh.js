@@ -0,0 +1 @@
+{"data":[{"ISIN":"NL0000009082","MIC":"Euronext Amsterdam","date":"12\/12\/2016","open":"2.900","high":"2.929","low":"2.871","close":"2.914","nymberofshares":"1,210,301","numoftrades":"3,101","turnover":"13,345,678.12","currency":"EUR"},{"ISIN":"NL0000009082","MIC":"Euronext Amsterdam","date":"13\/12\/2016","open":"2.929","high":"2.958","low":"2.900","close":"2.944","nymberofshares":"899,101","numoftrades":"2,201","turnover":"10,876,543.21","currency":"EUR"}]}
\ No newline at end of file
@@ -0,0 +1 @@
+{"data":[{"ISIN":"NL0000009082","MIC":"Euronext Amsterdam","date":"13\/12\/2016","open":"2.929","high":"2.958","low":"2.900","close":"2.944","nymberofshares":"899,101","numoftrades":"2,201","turnover":"10,876,543.21","currency":"EUR"},{"ISIN":"NL0000009082","MIC":"Euronext Amsterdam","date":"14\/12\/2016","open":"2.958","high":"2.988","low":"2.928","close":"2.973","nymberofshares":"-","numoftrades":"-","turnover":"-","currency":"EUR"}]}
\ No newline at end of file
@@ -0,0 +1 @@
+{"data":[{"ISIN":"FR0000120073","MIC":"Euronext Paris","date":"11\/12\/2017","open":"110.000","high":"111.100","low":"108.900","close":"110.550","nymberofshares":"1,220,302","numoftrades":"3,102","turnover":"14,345,678.12","currency":"EUR"},{"ISIN":"FR0000120073","MIC":"Euronext Paris","date":"12\/12\/2017","open":"111.100","high":"112.211","low":"109.989","close":"111.655","nymberofshares":"898,102","numoftrades":"2,202","turnover":"11,876,543.21","currency":"EUR"}]}
\ No newline at end of file
@@ -0,0 +1 @@
+{"data":[{"ISIN":"FR0000120073","MIC":"Euronext Paris","date":"12\/12\/2017","open":"111.100","high":"112.211","low":"109.989","close":"111.675","nymberofshares":"898,102","numoftrades":"2,202","turnover":"11,876,543.21","currency":"EUR"},{"ISIN":"FR0000120073","MIC":"Euronext Paris","date":"13\/12\/2017","open":"112.200","high":"113.322","low":"111.078","close":"112.761","nymberofshares":"-","numoftrades":"-","turnover":"-","currency":"EUR"}]}
\ No newline at end of file
@@ -0,0 +1 @@
+{"data":[{"ISIN":"NL0000009082","MIC":"Euronext Amsterdam","date":"10\/12\/2018","open":"3.100","high":"3.131","low":"3.069","close":"3.115","nymberofshares":"1,230,303","numoftrades":"3,103","turnover":"15,345,678.12","currency":"EUR"},{"ISIN":"NL0000009082","MIC":"Euronext Amsterdam","date":"11\/12\/2018","open":"3.131","high":"3.162","low":"3.100","close":"3.147","nymberofshares":"897,103","numoftrades":"2,203","turnover":"12,876,543.21","currency":"EUR"}]}
\ No newline at end of file
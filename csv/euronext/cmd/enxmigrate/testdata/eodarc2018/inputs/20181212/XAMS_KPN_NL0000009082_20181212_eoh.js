@@ -0,0 +1 @@
+{"data":[{"ISIN":"NL0000009082","MIC":"Euronext Amsterdam","date":"11\/12\/2018","open":"3.131","high":"3.162","low":"3.100","close":"3.149","nymberofshares":"897,103","numoftrades":"2,203","turnover":"12,876,543.21","currency":"EUR"},{"ISIN":"NL0000009082","MIC":"Euronext Amsterdam","date":"12\/12\/2018","open":"3.162","high":"3.194","low":"3.130","close":"3.178","nymberofshares":"-","numoftrades":"-","turnover":"-","currency":"EUR"}]}
\ No newline at end of file
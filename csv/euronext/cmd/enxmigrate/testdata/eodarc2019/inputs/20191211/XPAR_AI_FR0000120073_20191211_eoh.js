@@ -0,0 +1 @@
+{"data":[{"ISIN":"FR0000120073","MIC":"Euronext Paris","date":"10\/12\/2019","open":"120.000","high":"121.200","low":"118.800","close":"120.600","nymberofshares":"1,240,304","numoftrades":"3,104","turnover":"16,345,678.12","currency":"EUR"},{"ISIN":"FR0000120073","MIC":"Euronext Paris","date":"11\/12\/2019","open":"121.200","high":"122.412","low":"119.988","close":"121.806","nymberofshares":"896,104","numoftrades":"2,204","turnover":"13,876,543.21","currency":"EUR"}]}
\ No newline at end of file
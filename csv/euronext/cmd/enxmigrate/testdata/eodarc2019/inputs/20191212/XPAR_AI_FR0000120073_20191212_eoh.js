@@ -0,0 +1 @@
+{"data":[{"ISIN":"FR0000120073","MIC":"Euronext Paris","date":"11\/12\/2019","open":"121.200","high":"122.412","low":"119.988","close":"121.806","nymberofshares":"896,104","numoftrades":"2,204","turnover":"13,876,543.21","currency":"EUR"},{"ISIN":"FR0000120073","MIC":"Euronext Paris","date":"12\/12\/2019","open":"122.400","high":"123.624","low":"121.176","close":"123.012","nymberofshares":"-","numoftrades":"-","turnover":"-","currency":"EUR"}]}
\ No newline at end of file
@@ -0,0 +1 @@
+{"data":[{"ISIN":"NL0000009082","MIC":"Euronext Amsterdam","date":"10\/12\/2020","open":"3.300","high":"3.333","low":"3.267","close":"3.316","nymberofshares":"1,250,305","numoftrades":"3,105","turnover":"17,345,678.12","currency":"EUR"},{"ISIN":"NL0000009082","MIC":"Euronext Amsterdam","date":"11\/12\/2020","open":"3.333","high":"3.366","low":"3.300","close":"3.350","nymberofshares":"895,105","numoftrades":"2,205","turnover":"14,876,543.21","currency":"EUR"}]}
\ No newline at end of file
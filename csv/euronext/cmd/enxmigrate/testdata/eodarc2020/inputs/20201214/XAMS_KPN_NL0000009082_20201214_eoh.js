@@ -0,0 +1 @@
+{"data":[{"ISIN":"NL0000009082","MIC":"Euronext Amsterdam","date":"11\/12\/2020","open":"3.333","high":"3.366","low":"3.300","close":"3.352","nymberofshares":"895,105","numoftrades":"2,205","turnover":"14,876,543.21","currency":"EUR"},{"ISIN":"NL0000009082","MIC":"Euronext Amsterdam","date":"14\/12\/2020","open":"3.366","high":"3.400","low":"3.332","close":"3.383","nymberofshares":"-","numoftrades":"-","turnover":"-","currency":"EUR"}]}
\ No newline at end of file
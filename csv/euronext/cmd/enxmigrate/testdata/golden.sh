@@ -0,0 +1,55 @@
+#!/bin/bash
+# Regenerates the golden files of every vintage with the original eodarc and eodimp commands
+# of the given commit (the one before the commands were replaced by enxmigrate).
+#
+# usage: testdata/golden.sh <commit>
+#
+# The expected.csv of a vintage is the merged history of every imported instrument,
+# the expected.log is the eodimp output of every instrument between the instrument list
+# and the final timestamp.
+# There was no eodimp2020, eodarc2020 is imported by eodimp2019 from a renamed zip file.
+set -e
+
+commit=${1:?usage: $0 <commit>}
+testdata=$(cd "$(dirname "$0")" && pwd)
+work=$(mktemp -d)
+trap 'git -C "$testdata" worktree remove --force "$work/src" >/dev/null 2>&1; rm -rf "$work"' EXIT
+
+git -C "$testdata" worktree add --detach "$work/src" "$commit" >/dev/null
+migration="$work/src/csv/euronext/cmd/migration"
+mkdir -p "$work/bin"
+for d in "$migration"/eodarc* "$migration"/eodimp*; do
+    (cd "$migration/.." && cd .. && go build -o "$work/bin/$(basename "$d")" "./cmd/migration/$(basename "$d")")
+done
+
+vintages="20120901:20120901 20131228:20131228 2014:2014 2015:2015 2016:2016 2017:2017 2018:2018 2019:2019 2020:2019"
+for v in $vintages; do
+    arc=${v%%:*}
+    imp=${v##*:}
+    dir="$testdata/eodarc$arc"
+    run="$work/eodarc$arc"
+    mkdir -p "$run"
+
+    cat >"$run/eodarc$arc.json" <<EOF
+{"inputs": "$dir/inputs/", "downloads": "$run/downloads/", "xmlInstruments": "$testdata/instruments.xml"}
+EOF
+    (cd "$run" && "$work/bin/eodarc$arc" >/dev/null)
+
+    if [ "$arc" != "$imp" ]; then
+        for z in $(find "$run/downloads" -name "*_$arc.zip"); do
+            mv "$z" "${z%_$arc.zip}_$imp.zip"
+        done
+    fi
+
+    cat >"$run/eodimp$imp.json" <<EOF
+{"repository": "$run/repository/", "downloads": "$run/downloads/", "xmlInstruments": "$testdata/instruments.xml"}
+EOF
+    (cd "$run" && "$work/bin/eodimp$imp") >"$run/stdout"
+    sed -e '1,/ instruments read from /d' -e '/^finished /,$d' "$run/stdout" | sed -e '${/^$/d}' >"$dir/expected.log"
+
+    : >"$dir/expected.csv"
+    for f in $(cd "$run/repository" && find . -name "*.1d.csv.gz" | sort); do
+        echo "# $(basename "$f" .1d.csv.gz)" >>"$dir/expected.csv"
+        gzip -dc "$run/repository/$f" >>"$dir/expected.csv"
+    done
+done
@@ -0,0 +1,60 @@
+// Package atomicfile replaces files so that a failed write keeps the previous content.
+package atomicfile
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Rename replaces the files by the written temporary files, replaced in the tests.
+var Rename = os.Rename
+
+// WriteFile writes the data to a temporary file in the same folder and renames it to the file name,
+// so a failed write keeps the previous file. The mode of an existing file is kept,
+// a new file has the 0644 mode.
+func WriteFile(fileName string, data []byte) error {
+	return Write(fileName, func(w io.Writer) error {
+		_, err := io.Copy(w, bytes.NewReader(data))
+		return err
+	})
+}
+
+// Write is the same as WriteFile with the content written by the write function.
+func Write(fileName string, write func(w io.Writer) error) error {
+	perm := os.FileMode(0644)
+	if fi, err := os.Stat(fileName); err == nil {
+		perm = fi.Mode().Perm()
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(fileName), filepath.Base(fileName)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("cannot create temporary file: %w", err)
+	}
+
+	if err := write(tmp); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("cannot write temporary file '%s': %w", tmp.Name(), err)
+	}
+
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("cannot change mode of temporary file '%s': %w", tmp.Name(), err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("cannot close temporary file '%s': %w", tmp.Name(), err)
+	}
+
+	if err := Rename(tmp.Name(), fileName); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("cannot rename temporary file to '%s': %w", fileName, err)
+	}
+
+	return nil
+}
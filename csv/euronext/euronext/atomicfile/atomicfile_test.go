@@ -0,0 +1,87 @@
+package atomicfile
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteFile(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	name := filepath.Join(dir, "master.json")
+	for _, content := range []string{"first", "second"} {
+		if err := WriteFile(name, []byte(content)); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+
+		if b, err := os.ReadFile(name); err != nil || string(b) != content {
+			t.Errorf("expected %s, got %s, error %v", content, b, err)
+		}
+	}
+
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("expected no temporary files left, got %d files", len(entries))
+	}
+
+	if err := WriteFile(filepath.Join(dir, "missing", "master.json"), []byte("x")); err == nil {
+		t.Errorf("expected an error for a missing folder")
+	}
+}
+
+func TestWriteFileMode(t *testing.T) {
+	t.Parallel()
+
+	name := filepath.Join(t.TempDir(), "master.json")
+	if err := WriteFile(name, []byte("first")); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if fi, err := os.Stat(name); err != nil || fi.Mode().Perm() != 0644 {
+		t.Errorf("expected the 0644 mode of a new file, got %v, error %v", fi, err)
+	}
+
+	if err := os.Chmod(name, 0640); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := WriteFile(name, []byte("second")); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if fi, err := os.Stat(name); err != nil || fi.Mode().Perm() != 0640 {
+		t.Errorf("expected the 0640 mode of the existing file kept, got %v, error %v", fi, err)
+	}
+}
+
+func TestWriteFailed(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	name := filepath.Join(dir, "test.parquet")
+	if err := WriteFile(name, []byte("first")); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	err := Write(name, func(w io.Writer) error {
+		if _, err := w.Write([]byte("partial")); err != nil {
+			return err
+		}
+
+		return errors.New("disk full")
+	})
+	if err == nil {
+		t.Fatalf("expected an error for a failed write")
+	}
+
+	if b, _ := os.ReadFile(name); string(b) != "first" {
+		t.Errorf("expected the previous file kept, got %s", b)
+	}
+
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("expected the temporary file removed, got %d files", len(entries))
+	}
+}
@@ -0,0 +1,358 @@
+package euronext
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"calendar"
+)
+
+// Severity is the severity of a finding.
+type Severity string
+
+const (
+	SeverityInfo    Severity = "info"
+	SeverityWarning Severity = "warning"
+	SeverityError   Severity = "error"
+)
+
+// Rank orders the severities, unknown severities rank below the info.
+func (s Severity) Rank() int {
+	switch s {
+	case SeverityInfo:
+		return 1
+	case SeverityWarning:
+		return 2
+	case SeverityError:
+		return 3
+	default:
+		return 0
+	}
+}
+
+// ParseSeverity parses the severity name.
+func ParseSeverity(name string) (Severity, error) {
+	s := Severity(strings.ToLower(name))
+	if s.Rank() == 0 {
+		return s, fmt.Errorf("unknown severity '%s'", name)
+	}
+
+	return s, nil
+}
+
+// Names of the checks.
+const (
+	CheckDuplicate   = "duplicate"
+	CheckMissing     = "missing"
+	CheckNotSession  = "not session"
+	CheckNonPositive = "non-positive"
+	CheckOhlc        = "ohlc"
+	CheckVwap        = "vwap"
+	CheckTurnover    = "turnover"
+	CheckAdjustment  = "adjustment"
+)
+
+// Finding is a problem found in a combined daily history.
+type Finding struct {
+	File     string    `json:"file,omitempty"`
+	Date     time.Time `json:"date"`
+	Check    string    `json:"check"`
+	Severity Severity  `json:"severity"`
+	Message  string    `json:"message"`
+}
+
+// CheckOptions are the options of the combined daily history checks.
+type CheckOptions struct {
+	// IsSession reports if the date is a trading session,
+	// nil means the calendar of the mic of the history file or the Euronext calendar.
+	IsSession func(date time.Time) bool
+
+	// VwapTolerance is the relative tolerance of the vwap range check.
+	VwapTolerance float64
+
+	// TurnoverTolerance is the relative tolerance of the turnover to the number of shares times vwap.
+	TurnoverTolerance float64
+
+	// AdjustmentTolerance is the relative adjustment factor change below which factors are equal.
+	AdjustmentTolerance float64
+}
+
+// DefaultCheckOptions returns the default check options.
+func DefaultCheckOptions() CheckOptions {
+	return CheckOptions{
+		VwapTolerance:       1e-3,
+		TurnoverTolerance:   0.01,
+		AdjustmentTolerance: DefaultCorporateActionTolerance,
+	}
+}
+
+// CheckCombinedDailyHistory checks the history and returns the findings sorted by date.
+func CheckCombinedDailyHistory(history []CombinedDailyHistory, opt CheckOptions) []Finding {
+	if opt.IsSession == nil {
+		opt.IsSession = calendar.Euronext.IsSession
+	}
+
+	findings := []Finding{}
+	add := func(date time.Time, check string, severity Severity, format string, args ...any) {
+		findings = append(findings, Finding{
+			Date: date, Check: check, Severity: severity, Message: fmt.Sprintf(format, args...)})
+	}
+
+	sorted := SortCombinedDailyHistory(history)
+	for i, h := range sorted {
+		if i > 0 {
+			prev := sorted[i-1].Date
+			if h.Date.Equal(prev) {
+				add(h.Date, CheckDuplicate, SeverityError, "duplicate entry")
+			} else {
+				missing := 0
+				first := time.Time{}
+				for d := prev.AddDate(0, 0, 1); d.Before(h.Date); d = d.AddDate(0, 0, 1) {
+					if opt.IsSession(d) {
+						if missing == 0 {
+							first = d
+						}
+						missing++
+					}
+				}
+
+				if missing > 0 {
+					add(first, CheckMissing, SeverityWarning, "%d missing sessions from %s to %s",
+						missing, first.Format(CombinedDailyHistoryDateFormat), h.Date.AddDate(0, 0, -1).Format(CombinedDailyHistoryDateFormat))
+				}
+			}
+		}
+
+		if !opt.IsSession(h.Date) {
+			add(h.Date, CheckNotSession, SeverityWarning, "entry on a %s which is not a trading session", h.Date.Weekday())
+		}
+
+		checkPrices(h, &opt, add)
+	}
+
+	for _, a := range InferCorporateActions(sorted, opt.AdjustmentTolerance) {
+		severity := SeverityInfo
+		if a.Kind == CorporateActionUnknown {
+			severity = SeverityWarning
+		}
+
+		add(a.Date, CheckAdjustment, severity, "adjustment factor jump: %s %g", a.Kind, a.Ratio)
+	}
+
+	SortFindings(findings)
+	return findings
+}
+
+func checkPrices(h CombinedDailyHistory, opt *CheckOptions,
+	add func(date time.Time, check string, severity Severity, format string, args ...any)) {
+	prices := []struct {
+		name  string
+		value float64
+	}{
+		{"open", h.Open}, {"high", h.High}, {"low", h.Low}, {"last", h.Last}, {"close", h.Close}, {"vwap", h.Vwap},
+	}
+
+	for _, p := range prices {
+		if p.value <= 0 {
+			add(h.Date, CheckNonPositive, SeverityError, "non-positive %s %g", p.name, p.value)
+		}
+	}
+
+	if h.NumberOfShares < 0 || h.Turnover < 0 {
+		add(h.Date, CheckNonPositive, SeverityError, "negative number of shares %g or turnover %g", h.NumberOfShares, h.Turnover)
+	}
+
+	if h.High > 0 && h.Low > 0 {
+		if h.High < h.Low {
+			add(h.Date, CheckOhlc, SeverityError, "high %g is below low %g", h.High, h.Low)
+		} else {
+			for _, p := range prices[:5] {
+				if p.value > 0 && p.name != "high" && p.name != "low" && (p.value > h.High || p.value < h.Low) {
+					add(h.Date, CheckOhlc, SeverityError, "%s %g is outside low %g and high %g", p.name, p.value, h.Low, h.High)
+				}
+			}
+
+			if h.Vwap > 0 && (h.Vwap > h.High*(1+opt.VwapTolerance) || h.Vwap < h.Low*(1-opt.VwapTolerance)) {
+				add(h.Date, CheckVwap, SeverityWarning, "vwap %g is outside low %g and high %g", h.Vwap, h.Low, h.High)
+			}
+		}
+	}
+
+	if h.Turnover > 0 && h.NumberOfShares > 0 && h.Vwap > 0 {
+		expected := h.NumberOfShares * h.Vwap
+		if math.Abs(h.Turnover-expected)/h.Turnover > opt.TurnoverTolerance {
+			add(h.Date, CheckTurnover, SeverityWarning, "turnover %g differs from shares %g times vwap %g = %g",
+				h.Turnover, h.NumberOfShares, h.Vwap, expected)
+		}
+	}
+}
+
+// SortFindings sorts the findings by file and date in place, keeping the order of the checks.
+func SortFindings(findings []Finding) {
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].File != findings[j].File {
+			return findings[i].File < findings[j].File
+		}
+
+		return findings[i].Date.Before(findings[j].Date)
+	})
+}
+
+// FilterFindings returns the findings with the severity at least the minimal one.
+func FilterFindings(findings []Finding, min Severity) []Finding {
+	filtered := []Finding{}
+	for _, f := range findings {
+		if f.Severity.Rank() >= min.Rank() {
+			filtered = append(filtered, f)
+		}
+	}
+
+	return filtered
+}
+
+// CheckCombinedDailyHistoryFile reads the history file and checks it.
+func CheckCombinedDailyHistoryFile(fileName string, opt CheckOptions) ([]Finding, error) {
+	hist, es, err := ReadCombinedDailyHistoryCsv(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("%s%w", es, err)
+	}
+
+	if opt.IsSession == nil {
+		if cal, err := calendar.ForMic(micOfFileName(fileName)); err == nil {
+			opt.IsSession = cal.IsSession
+		}
+	}
+
+	findings := CheckCombinedDailyHistory(hist, opt)
+	for i := range findings {
+		findings[i].File = fileName
+	}
+
+	return findings, nil
+}
+
+// micOfFileName returns the mic of the history file named as "mnemonic_isin_mic.1d.csv" by enxhist
+// or as "mic_mnemonic_isin.1d.csv.gz" by enxmigrate. The last and the first segments are tried
+// against the known calendars, the last segment is returned if neither is known.
+func micOfFileName(fileName string) string {
+	name := filepath.Base(fileName)
+	if i := strings.Index(name, "."); i >= 0 {
+		name = name[:i]
+	}
+
+	segments := strings.Split(name, "_")
+	if len(segments) < 2 {
+		return ""
+	}
+
+	for _, s := range []string{segments[len(segments)-1], segments[0]} {
+		if _, err := calendar.ForMic(s); err == nil {
+			return s
+		}
+	}
+
+	return segments[len(segments)-1]
+}
+
+// FindCombinedDailyHistoryFiles returns the daily history files (*.1d.csv, *.1d.csv.gz) of the paths.
+// The files are returned as is, the folders are walked recursively.
+func FindCombinedDailyHistoryFiles(paths []string) ([]string, error) {
+	isHistory := func(name string) bool {
+		return strings.HasSuffix(name, ".1d.csv") || strings.HasSuffix(name, ".1d.csv.gz")
+	}
+
+	files := []string{}
+	for _, path := range paths {
+		fi, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("cannot stat '%s': %w", path, err)
+		}
+
+		if !fi.IsDir() {
+			files = append(files, path)
+			continue
+		}
+
+		err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+
+			if !d.IsDir() && isHistory(d.Name()) {
+				files = append(files, p)
+			}
+
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("cannot walk '%s': %w", path, err)
+		}
+	}
+
+	return files, nil
+}
+
+// WriteFindingsJson writes the findings as an indented json array.
+func WriteFindingsJson(w io.Writer, findings []Finding) error {
+	type jsonFinding struct {
+		File     string   `json:"file,omitempty"`
+		Date     string   `json:"date"`
+		Check    string   `json:"check"`
+		Severity Severity `json:"severity"`
+		Message  string   `json:"message"`
+	}
+
+	js := make([]jsonFinding, len(findings))
+	for i, f := range findings {
+		js[i] = jsonFinding{f.File, f.Date.Format(CombinedDailyHistoryDateFormat), f.Check, f.Severity, f.Message}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(js); err != nil {
+		return fmt.Errorf("cannot encode findings: %w", err)
+	}
+
+	return nil
+}
+
+// WriteFindingsCsv writes the findings as csv with a header.
+func WriteFindingsCsv(w io.Writer, findings []Finding) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"file", "date", "check", "severity", "message"}); err != nil {
+		return fmt.Errorf("cannot write header: %w", err)
+	}
+
+	for _, f := range findings {
+		row := []string{f.File, f.Date.Format(CombinedDailyHistoryDateFormat), f.Check, string(f.Severity), f.Message}
+		if err := cw.Write(row); err != nil {
+			return fmt.Errorf("cannot write row: %w", err)
+		}
+	}
+
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("cannot flush csv: %w", err)
+	}
+
+	return nil
+}
+
+// CountFindings counts the findings per severity.
+func CountFindings(findings []Finding) map[Severity]int {
+	counts := map[Severity]int{}
+	for _, f := range findings {
+		counts[f.Severity]++
+	}
+
+	return counts
+}
@@ -0,0 +1,174 @@
+package euronext
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func bar(d time.Time, o, h, l, c, shares, vwap float64) CombinedDailyHistory {
+	return CombinedDailyHistory{
+		Date: d, Open: o, High: h, Low: l, Last: c, Close: c, Vwap: vwap,
+		NumberOfShares: shares, NumberOfTrades: 10, Turnover: shares * vwap,
+		CloseAdjusted: c, AdjustmentFactor: 1,
+	}
+}
+
+func TestMicOfFileName(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]string{
+		"/repo/xpar/ai_fr0000120073_xpar.1d.csv.gz": "xpar",
+		"eqnr_no0010096985_xosl.1d.csv":             "xosl",
+		"history.1d.csv":                            "",
+		// The enxmigrate repository naming.
+		"/repo/XPAR/stock/AI/XPAR_AI_FR0000120073.1d.csv.gz": "XPAR",
+		"xams_ASML_NL0010273215.1d.csv.gz":                   "xams",
+	}
+
+	for name, mic := range tests {
+		if m := micOfFileName(name); m != mic {
+			t.Errorf("%s: expected mic %q, got %q", name, mic, m)
+		}
+	}
+}
+
+func TestCheckCombinedDailyHistory(t *testing.T) {
+	t.Parallel()
+
+	hist := []CombinedDailyHistory{
+		bar(day(3), 10, 11, 9, 10, 100, 10),   // ok
+		bar(day(4), 10, 11, 9, 10, 100, 10),   // ok
+		bar(day(4), 10, 11, 9, 10, 100, 10),   // duplicate
+		bar(day(7), 12, 11, 9, 10, 100, 10),   // 5th and 6th missing, open above high
+		bar(day(8), 10, 11, 9, 10, 100, 10),   // Saturday
+		bar(day(10), 10, 9, 11, 10, 100, 10),  // high below low
+		bar(day(11), 10, 11, 9, 10, 100, 12),  // vwap above high
+		bar(day(12), 10, 11, 9, 0, 100, 10),   // zero last and close
+		bar(day(13), -1, 11, 9, 10, 100, 10),  // negative open
+		bar(day(14), 10, 11, 9, 10, 100, 9.5), // turnover below
+		bar(day(17), 10, 0, 9, 10, 100, 0),    // zero high and vwap
+	}
+	hist[9].Turnover = 1200
+	hist[9].AdjustmentFactor = 0.93 // unknown adjustment
+	hist[10].AdjustmentFactor = 0.93
+
+	findings := CheckCombinedDailyHistory(hist, DefaultCheckOptions())
+	expected := []struct {
+		date     time.Time
+		check    string
+		severity Severity
+	}{
+		{day(4), CheckDuplicate, SeverityError},
+		{day(5), CheckMissing, SeverityWarning},
+		{day(7), CheckOhlc, SeverityError},
+		{day(8), CheckNotSession, SeverityWarning},
+		{day(10), CheckOhlc, SeverityError},
+		{day(11), CheckVwap, SeverityWarning},
+		{day(12), CheckNonPositive, SeverityError},
+		{day(12), CheckNonPositive, SeverityError},
+		{day(13), CheckNonPositive, SeverityError},
+		{day(14), CheckTurnover, SeverityWarning},
+		{day(14), CheckAdjustment, SeverityWarning},
+		{day(17), CheckNonPositive, SeverityError},
+		{day(17), CheckNonPositive, SeverityError},
+	}
+
+	if len(findings) != len(expected) {
+		t.Fatalf("expected %d findings, got %d: %+v", len(expected), len(findings), findings)
+	}
+
+	for i, e := range expected {
+		f := findings[i]
+		if !f.Date.Equal(e.date) || f.Check != e.check || f.Severity != e.severity {
+			t.Errorf("finding %d: expected %s %s %s, got %s %s %s (%s)", i,
+				e.date.Format("2006-01-02"), e.check, e.severity, f.Date.Format("2006-01-02"), f.Check, f.Severity, f.Message)
+		}
+	}
+
+	if m := findings[1].Message; m != "2 missing sessions from 2024-06-05 to 2024-06-06" {
+		t.Errorf("unexpected missing message %s", m)
+	}
+
+	if m := findings[7].Message + ", " + findings[12].Message; m != "non-positive close 0, non-positive vwap 0" {
+		t.Errorf("unexpected non-positive messages %s", m)
+	}
+
+	if n := len(FilterFindings(findings, SeverityError)); n != 8 {
+		t.Errorf("expected 8 errors, got %d", n)
+	}
+}
+
+func TestCheckMigratedFileUsesCalendar(t *testing.T) {
+	t.Parallel()
+
+	// The 25th and 26th of December are Euronext holidays, not missing sessions.
+	christmas := func(d int) time.Time { return time.Date(2024, 12, d, 0, 0, 0, 0, time.UTC) }
+	hist := []CombinedDailyHistory{bar(christmas(24), 10, 11, 9, 10, 100, 10), bar(christmas(27), 10, 11, 9, 10, 100, 10)}
+	for _, name := range []string{"XPAR_AI_FR0000120073.1d.csv", "ai_fr0000120073_xpar.1d.csv"} {
+		file := filepath.Join(t.TempDir(), name)
+		if _, err := WriteCombinedDailyHistoryCsv(file, hist); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		findings, err := CheckCombinedDailyHistoryFile(file, DefaultCheckOptions())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(findings) != 0 {
+			t.Errorf("%s: expected no findings, got %+v", name, findings)
+		}
+	}
+}
+
+func TestWriteFindings(t *testing.T) {
+	t.Parallel()
+
+	file := filepath.Join(t.TempDir(), "ai_fr0000120073_xpar.1d.csv")
+	hist := []CombinedDailyHistory{bar(day(3), 10, 11, 9, 10, 100, 10), bar(day(5), 10, 11, 9, 10, 100, 12)}
+	if _, err := WriteCombinedDailyHistoryCsv(file, hist); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	files, err := FindCombinedDailyHistoryFiles([]string{filepath.Dir(file)})
+	if err != nil || len(files) != 1 || files[0] != file {
+		t.Fatalf("unexpected files %v: %v", files, err)
+	}
+
+	findings, err := CheckCombinedDailyHistoryFile(file, DefaultCheckOptions())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(findings) != 2 || findings[0].File != file {
+		t.Fatalf("unexpected findings %+v", findings)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteFindingsJson(&buf, findings); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	decoded := []map[string]string{}
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("cannot unmarshal report: %v", err)
+	}
+
+	if len(decoded) != 2 || decoded[0]["date"] != "2024-06-04" || decoded[0]["check"] != CheckMissing || decoded[1]["severity"] != "warning" {
+		t.Errorf("unexpected json report %v", decoded)
+	}
+
+	buf.Reset()
+	if err := WriteFindingsCsv(&buf, findings); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 || lines[0] != "file,date,check,severity,message" || !strings.Contains(lines[2], ",2024-06-05,vwap,warning,") {
+		t.Errorf("unexpected csv report %q", lines)
+	}
+}
@@ -0,0 +1,173 @@
+// Package conformance checks that the parquet files written by the columnar package
+// are read by the arrow reader, an independent implementation.
+// It is a separate module, so the arrow dependency is not a dependency of the euronext module.
+package conformance
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"euronext/euronext/columnar"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/apache/arrow-go/v18/parquet/file"
+	"github.com/apache/arrow-go/v18/parquet/pqarrow"
+)
+
+var testSchema = columnar.Schema{
+	{Name: "symbol", Kind: columnar.KindString, Dictionary: true},
+	{Name: "date", Kind: columnar.KindDate},
+	{Name: "time", Kind: columnar.KindTimestamp},
+	{Name: "price", Kind: columnar.KindDouble},
+	{Name: "volume", Kind: columnar.KindInt64},
+	{Name: "note", Kind: columnar.KindString},
+	{Name: "flag", Kind: columnar.KindBool},
+}
+
+func testColumns(symbols ...string) []any {
+	n := len(symbols)
+	dates, times, prices, volumes, notes, flags := []time.Time{}, []time.Time{}, []float64{}, []int64{}, []string{}, []bool{}
+	d := time.Date(2024, 6, 3, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < n; i++ {
+		dates = append(dates, d.AddDate(0, 0, i))
+		times = append(times, d.Add(time.Duration(i)*time.Minute+1500*time.Millisecond))
+		prices = append(prices, 10.25+float64(i))
+		volumes = append(volumes, int64(100*i))
+		notes = append(notes, string(rune('a'+i)))
+		flags = append(flags, i%3 == 0)
+	}
+
+	return []any{symbols, dates, times, prices, volumes, notes, flags}
+}
+
+// emptyColumns returns the empty column slices of the schema.
+func emptyColumns(schema columnar.Schema) []any {
+	columns := make([]any, len(schema))
+	for i, c := range schema {
+		switch c.Kind {
+		case columnar.KindDouble:
+			columns[i] = []float64{}
+		case columnar.KindInt64:
+			columns[i] = []int64{}
+		case columnar.KindDate, columnar.KindTimestamp:
+			columns[i] = []time.Time{}
+		case columnar.KindString:
+			columns[i] = []string{}
+		case columnar.KindBool:
+			columns[i] = []bool{}
+		}
+	}
+
+	return columns
+}
+
+// readArrow reads the columns of the parquet file with the arrow reader, an independent implementation.
+func readArrow(t *testing.T, fileName string, schema columnar.Schema) ([]any, int, map[string]string) {
+	t.Helper()
+
+	r, err := file.OpenParquetFile(fileName, false)
+	if err != nil {
+		t.Fatalf("arrow cannot open %s: %v", fileName, err)
+	}
+	defer r.Close()
+
+	meta := map[string]string{}
+	for _, kv := range r.MetaData().KeyValueMetadata() {
+		meta[kv.Key] = *kv.Value
+	}
+
+	fr, err := pqarrow.NewFileReader(r, pqarrow.ArrowReadProperties{}, memory.DefaultAllocator)
+	if err != nil {
+		t.Fatalf("arrow cannot read %s: %v", fileName, err)
+	}
+
+	table, err := fr.ReadTable(context.Background())
+	if err != nil {
+		t.Fatalf("arrow cannot read table of %s: %v", fileName, err)
+	}
+	defer table.Release()
+
+	columns := emptyColumns(schema)
+	for i, c := range schema {
+		indices := table.Schema().FieldIndices(c.Name)
+		if len(indices) != 1 {
+			t.Fatalf("arrow: column %s not found", c.Name)
+		}
+
+		for _, chunk := range table.Column(indices[0]).Data().Chunks() {
+			for j := 0; j < chunk.Len(); j++ {
+				switch a := chunk.(type) {
+				case *array.Float64:
+					columns[i] = append(columns[i].([]float64), a.Value(j))
+				case *array.Int64:
+					columns[i] = append(columns[i].([]int64), a.Value(j))
+				case *array.Date32:
+					columns[i] = append(columns[i].([]time.Time), a.Value(j).ToTime())
+				case *array.Timestamp:
+					columns[i] = append(columns[i].([]time.Time), a.Value(j).ToTime(arrow.Millisecond))
+				case *array.String:
+					columns[i] = append(columns[i].([]string), a.Value(j))
+				case *array.Boolean:
+					columns[i] = append(columns[i].([]bool), a.Value(j))
+				default:
+					t.Fatalf("arrow: column %s: unexpected array %T", c.Name, chunk)
+				}
+			}
+		}
+	}
+
+	return columns, r.NumRowGroups(), meta
+}
+
+func TestConformanceArrowReadsAppendedFile(t *testing.T) {
+	t.Parallel()
+
+	for _, codec := range []columnar.Codec{columnar.CodecNone, columnar.CodecGzip, columnar.CodecZstd} {
+		fileName := filepath.Join(t.TempDir(), "test.parquet")
+		parts := [][]any{testColumns("AI", "AI", "KPN"), testColumns("KPN"), testColumns("ASML", "AI")}
+		for i, part := range parts {
+			if err := columnar.Append(fileName, testSchema, codec, part, map[string]string{"part": string(rune('1' + i))}); err != nil {
+				t.Fatalf("codec %d: unexpected error %v", codec, err)
+			}
+		}
+
+		columns, rowGroups, meta := readArrow(t, fileName, testSchema)
+		if rowGroups != len(parts) || meta["part"] != "3" {
+			t.Errorf("codec %d: unexpected row groups %d, metadata %v", codec, rowGroups, meta)
+		}
+
+		expected := emptyColumns(testSchema)
+		for _, part := range parts {
+			for i := range expected {
+				expected[i] = reflect.AppendSlice(reflect.ValueOf(expected[i]), reflect.ValueOf(part[i])).Interface()
+			}
+		}
+
+		for i, c := range testSchema {
+			if !reflect.DeepEqual(columns[i], expected[i]) {
+				t.Errorf("codec %d: column %s: expected %v, got %v", codec, c.Name, expected[i], columns[i])
+			}
+		}
+	}
+}
+
+func TestConformanceArrowReadsCompactedFile(t *testing.T) {
+	t.Parallel()
+
+	fileName := filepath.Join(t.TempDir(), "test.parquet")
+	for i := 0; i < columnar.CompactRowGroups; i++ {
+		if err := columnar.Append(fileName, testSchema, columnar.CodecZstd, testColumns("AI"), nil); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+	}
+
+	columns, rowGroups, _ := readArrow(t, fileName, testSchema)
+	if rowGroups != 1 || len(columns[0].([]string)) != columnar.CompactRowGroups {
+		t.Errorf("unexpected row groups %d, rows %d", rowGroups, len(columns[0].([]string)))
+	}
+}
@@ -0,0 +1,183 @@
+package columnar
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"euronext/euronext"
+	"euronext/euronext/intraday"
+)
+
+// LastKey is the metadata key of the last exported session date, "2006-01-02".
+const LastKey = "euronext.last"
+
+// Suffixes of the exported files.
+const (
+	DailySuffix  = ".1d.parquet"
+	TradesSuffix = ".trades.parquet"
+)
+
+// DailySchema is the schema of the exported combined daily histories.
+var DailySchema = Schema{
+	{Name: "symbol", Kind: KindString, Dictionary: true},
+	{Name: "date", Kind: KindDate},
+	{Name: "open", Kind: KindDouble},
+	{Name: "high", Kind: KindDouble},
+	{Name: "low", Kind: KindDouble},
+	{Name: "last", Kind: KindDouble},
+	{Name: "close", Kind: KindDouble},
+	{Name: "number_of_shares", Kind: KindDouble},
+	{Name: "number_of_trades", Kind: KindDouble},
+	{Name: "turnover", Kind: KindDouble},
+	{Name: "vwap", Kind: KindDouble},
+	{Name: "open_adjusted", Kind: KindDouble},
+	{Name: "high_adjusted", Kind: KindDouble},
+	{Name: "low_adjusted", Kind: KindDouble},
+	{Name: "last_adjusted", Kind: KindDouble},
+	{Name: "close_adjusted", Kind: KindDouble},
+	{Name: "number_of_shares_adjusted", Kind: KindDouble},
+	{Name: "number_of_trades_adjusted", Kind: KindDouble},
+	{Name: "turnover_adjusted", Kind: KindDouble},
+	{Name: "vwap_adjusted", Kind: KindDouble},
+	{Name: "adjustment_factor", Kind: KindDouble},
+	{Name: "has_marking", Kind: KindBool},
+	{Name: "has_marking_adjusted", Kind: KindBool},
+}
+
+// TradesSchema is the schema of the exported intraday trades.
+var TradesSchema = Schema{
+	{Name: "symbol", Kind: KindString, Dictionary: true},
+	{Name: "time", Kind: KindTimestamp},
+	{Name: "id", Kind: KindString},
+	{Name: "price", Kind: KindDouble},
+	{Name: "volume", Kind: KindInt64},
+	{Name: "code", Kind: KindString, Dictionary: true},
+	{Name: "type", Kind: KindString, Dictionary: true},
+}
+
+// Path returns the parquet file path of the "xams/index/ASCX.h5:/XAMS_ASCX_NL0000249142" h5 path,
+// e.g. "xams/index/ASCX/XAMS_ASCX_NL0000249142.1d.parquet" for the DailySuffix.
+func Path(h5, suffix string) string {
+	file, dataset, ok := strings.Cut(h5, ":/")
+	if !ok {
+		return h5 + suffix
+	}
+
+	return strings.TrimSuffix(file, ".h5") + "/" + dataset + suffix
+}
+
+// lastExported returns the last exported session date of the file, zero time if the file does not exist.
+func lastExported(fileName string) (time.Time, error) {
+	if _, err := os.Stat(fileName); os.IsNotExist(err) {
+		return time.Time{}, nil
+	}
+
+	info, err := ReadInfo(fileName)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	s, ok := info.Meta[LastKey]
+	if !ok {
+		return time.Time{}, nil
+	}
+
+	t, err := time.Parse(euronext.CombinedDailyHistoryDateFormat, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("cannot parse %s '%s': %w", LastKey, s, err)
+	}
+
+	return t, nil
+}
+
+// sessionDate returns the calendar date of the time in its own location.
+func sessionDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// AppendDaily appends the daily history sessions after the last exported session to the parquet file
+// and returns the number of appended rows. The history must be sorted by date.
+func AppendDaily(fileName, symbol string, history []euronext.CombinedDailyHistory, codec Codec) (int, error) {
+	last, err := lastExported(fileName)
+	if err != nil {
+		return 0, fmt.Errorf("cannot append daily history to '%s': %w", fileName, err)
+	}
+
+	columns := make([][]float64, 19)
+	symbols, dates := []string{}, []time.Time{}
+	markings, markingsAdjusted := []bool{}, []bool{}
+	for _, h := range history {
+		if !sessionDate(h.Date).After(last) {
+			continue
+		}
+
+		symbols = append(symbols, symbol)
+		dates = append(dates, h.Date)
+		for i, v := range []float64{
+			h.Open, h.High, h.Low, h.Last, h.Close, h.NumberOfShares, h.NumberOfTrades, h.Turnover, h.Vwap,
+			h.OpenAdjusted, h.HighAdjusted, h.LowAdjusted, h.LastAdjusted, h.CloseAdjusted,
+			h.NumberOfSharesAdjusted, h.NumberOfTradesAdjusted, h.TurnoverAdjusted, h.VwapAdjusted,
+			h.AdjustmentFactor,
+		} {
+			columns[i] = append(columns[i], v)
+		}
+		markings = append(markings, h.HasMarking)
+		markingsAdjusted = append(markingsAdjusted, h.HasMarkingAdjusted)
+	}
+
+	if len(dates) == 0 {
+		return 0, nil
+	}
+
+	values := []any{symbols, dates}
+	for _, c := range columns {
+		values = append(values, c)
+	}
+	values = append(values, markings, markingsAdjusted)
+
+	meta := map[string]string{LastKey: dates[len(dates)-1].Format(euronext.CombinedDailyHistoryDateFormat)}
+	if err := Append(fileName, DailySchema, codec, values, meta); err != nil {
+		return 0, err
+	}
+
+	return len(dates), nil
+}
+
+// AppendTrades appends the intraday trades of the sessions after the last exported session to the parquet file
+// and returns the number of appended rows. The trades must be sorted by time.
+func AppendTrades(fileName, symbol string, trades []intraday.Trade, codec Codec) (int, error) {
+	last, err := lastExported(fileName)
+	if err != nil {
+		return 0, fmt.Errorf("cannot append trades to '%s': %w", fileName, err)
+	}
+
+	symbols, times, ids, codes, types := []string{}, []time.Time{}, []string{}, []string{}, []string{}
+	prices, volumes := []float64{}, []int64{}
+	for _, t := range trades {
+		if !sessionDate(t.Time).After(last) {
+			continue
+		}
+
+		symbols = append(symbols, symbol)
+		times = append(times, t.Time)
+		ids = append(ids, t.ID)
+		prices = append(prices, t.Price.Float64())
+		volumes = append(volumes, t.Volume)
+		codes = append(codes, t.Code)
+		types = append(types, t.Type.Label)
+	}
+
+	if len(times) == 0 {
+		return 0, nil
+	}
+
+	meta := map[string]string{LastKey: sessionDate(times[len(times)-1]).Format(euronext.CombinedDailyHistoryDateFormat)}
+	values := []any{symbols, times, ids, prices, volumes, codes, types}
+	if err := Append(fileName, TradesSchema, codec, values, meta); err != nil {
+		return 0, err
+	}
+
+	return len(times), nil
+}
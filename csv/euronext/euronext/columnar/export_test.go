@@ -0,0 +1,96 @@
+package columnar
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"euronext/euronext"
+	"euronext/euronext/intraday"
+)
+
+func TestPath(t *testing.T) {
+	t.Parallel()
+
+	h5 := euronext.H5Path("xams", "nl0000249142", "ascx", "index")
+	if expected := "xams/index/ASCX/XAMS_ASCX_NL0000249142.1d.parquet"; Path(h5, DailySuffix) != expected {
+		t.Errorf("expected %s, got %s", expected, Path(h5, DailySuffix))
+	}
+}
+
+func TestAppendDailyIncremental(t *testing.T) {
+	t.Parallel()
+
+	fileName := filepath.Join(t.TempDir(), "daily.parquet")
+	d := time.Date(2024, 6, 3, 0, 0, 0, 0, time.UTC)
+	history := []euronext.CombinedDailyHistory{
+		{Date: d, Open: 1, Close: 2, HasMarking: true},
+		{Date: d.AddDate(0, 0, 1), Open: 2, Close: 3, AdjustmentFactor: 1},
+	}
+
+	if n, err := AppendDaily(fileName, "AI", history, CodecZstd); err != nil || n != 2 {
+		t.Fatalf("expected 2 rows, got %d, error %v", n, err)
+	}
+
+	history = append(history, euronext.CombinedDailyHistory{Date: d.AddDate(0, 0, 2), Open: 3, Close: 4})
+	if n, err := AppendDaily(fileName, "AI", history, CodecZstd); err != nil || n != 1 {
+		t.Fatalf("expected 1 row, got %d, error %v", n, err)
+	}
+
+	if n, err := AppendDaily(fileName, "AI", history, CodecZstd); err != nil || n != 0 {
+		t.Fatalf("expected no rows, got %d, error %v", n, err)
+	}
+
+	info, err := ReadInfo(fileName)
+	if err != nil || info.NumRows != 3 || info.Meta[LastKey] != "2024-06-05" {
+		t.Fatalf("unexpected info %+v, error %v", info, err)
+	}
+
+	columns, err := Read(fileName, DailySchema)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if !reflect.DeepEqual(columns[0], []string{"AI", "AI", "AI"}) ||
+		!reflect.DeepEqual(columns[2], []float64{1, 2, 3}) ||
+		!reflect.DeepEqual(columns[6], []float64{2, 3, 4}) ||
+		!reflect.DeepEqual(columns[21], []bool{true, false, false}) {
+		t.Errorf("unexpected columns %v", columns)
+	}
+}
+
+func TestAppendTradesIncremental(t *testing.T) {
+	t.Parallel()
+
+	cet, _ := time.LoadLocation("Europe/Amsterdam")
+	fileName := filepath.Join(t.TempDir(), "trades.parquet")
+	t1 := time.Date(2024, 6, 3, 9, 0, 0, 0, cet)
+	trades := []intraday.Trade{
+		{ID: "1", Time: t1, Price: intraday.Decimal{Units: 10}, Volume: 100, Code: "AUC", Type: intraday.TradeType{Label: "Auction"}},
+		{ID: "2", Time: t1.Add(time.Second), Price: intraday.Decimal{Units: 105, Scale: 1}, Volume: 50, Code: "EXC", Type: intraday.TradeType{Label: "Exchange Continuous"}},
+	}
+
+	if n, err := AppendTrades(fileName, "AI", trades, CodecGzip); err != nil || n != 2 {
+		t.Fatalf("expected 2 rows, got %d, error %v", n, err)
+	}
+
+	next := append(trades, intraday.Trade{ID: "3", Time: t1.AddDate(0, 0, 1), Price: intraday.Decimal{Units: 11}, Volume: 10, Code: "EXC"})
+	if n, err := AppendTrades(fileName, "AI", next, CodecGzip); err != nil || n != 1 {
+		t.Fatalf("expected 1 row, got %d, error %v", n, err)
+	}
+
+	columns, err := Read(fileName, TradesSchema)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	times := columns[1].([]time.Time)
+	if len(times) != 3 || !times[0].Equal(t1) || !times[2].Equal(t1.AddDate(0, 0, 1)) {
+		t.Errorf("unexpected times %v", times)
+	}
+
+	if !reflect.DeepEqual(columns[5], []string{"AUC", "EXC", "EXC"}) || !reflect.DeepEqual(columns[4], []int64{100, 50, 10}) {
+		t.Errorf("unexpected columns %v", columns)
+	}
+}
@@ -0,0 +1,605 @@
+package columnar
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"euronext/euronext/atomicfile"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/parquet-go/parquet-go/compress"
+	"github.com/parquet-go/parquet-go/encoding/thrift"
+	"github.com/parquet-go/parquet-go/format"
+)
+
+// Kind is the type of a column.
+type Kind int
+
+const (
+	KindDouble    Kind = iota // KindDouble is a DOUBLE column of float64 values.
+	KindInt64                 // KindInt64 is an INT64 column of int64 values.
+	KindDate                  // KindDate is an INT32 DATE column of time.Time values, the days since the epoch.
+	KindTimestamp             // KindTimestamp is an INT64 TIMESTAMP(MILLIS) column of time.Time values in UTC.
+	KindString                // KindString is a BYTE_ARRAY UTF8 column of string values.
+	KindBool                  // KindBool is a BOOLEAN column of bool values.
+)
+
+// Column is a required column of a parquet file.
+type Column struct {
+	Name string
+	Kind Kind
+
+	// Dictionary encodes a string column with a dictionary, e.g. the repeated symbols.
+	Dictionary bool
+}
+
+// Schema is the flat schema of a parquet file.
+// The columns are stored in the name order in the file, the values are always in the schema order.
+type Schema []Column
+
+// Codec is the compression codec of the pages.
+type Codec int
+
+const (
+	CodecNone Codec = iota
+	CodecGzip
+	CodecZstd
+)
+
+// ParseCodec parses the codec name: none, gzip or zstd.
+func ParseCodec(s string) (Codec, error) {
+	switch strings.ToLower(s) {
+	case "none", "":
+		return CodecNone, nil
+	case "gzip", "gz":
+		return CodecGzip, nil
+	case "zstd", "zst":
+		return CodecZstd, nil
+	default:
+		return CodecNone, fmt.Errorf("unknown parquet codec '%s', expected none, gzip or zstd", s)
+	}
+}
+
+const (
+	parquetMagic = "PAR1"
+	createdBy    = "euronext columnar"
+	version      = "1.1.0"
+)
+
+func (c Codec) compression() compress.Codec {
+	switch c {
+	case CodecGzip:
+		return &parquet.Gzip
+	case CodecZstd:
+		return &parquet.Zstd
+	default:
+		return &parquet.Uncompressed
+	}
+}
+
+// node returns the parquet node of the column.
+func (c Column) node() parquet.Node {
+	var n parquet.Node
+	switch c.Kind {
+	case KindDouble:
+		n = parquet.Leaf(parquet.DoubleType)
+	case KindInt64:
+		n = parquet.Leaf(parquet.Int64Type)
+	case KindDate:
+		n = parquet.Date()
+	case KindTimestamp:
+		n = parquet.Timestamp(parquet.Millisecond)
+	case KindString:
+		n = parquet.String()
+	default:
+		n = parquet.Leaf(parquet.BooleanType)
+	}
+
+	if c.Dictionary {
+		n = parquet.Encoded(n, &parquet.RLEDictionary)
+	}
+
+	return n
+}
+
+// parquetSchema returns the parquet schema and the leaf column index of every schema column.
+func (s Schema) parquetSchema() (*parquet.Schema, []int) {
+	group := parquet.Group{}
+	for _, c := range s {
+		group[c.Name] = c.node()
+	}
+
+	ps := parquet.NewSchema("schema", group)
+	leaves := make([]int, len(s))
+	for i, c := range s {
+		leaf, _ := ps.Lookup(c.Name)
+		leaves[i] = leaf.ColumnIndex
+	}
+
+	return ps, leaves
+}
+
+// validate checks the column values match the schema and have the same length, it returns the length.
+func (s Schema) validate(columns []any) (int, error) {
+	if len(columns) != len(s) {
+		return 0, fmt.Errorf("expected %d columns, got %d", len(s), len(columns))
+	}
+
+	n := -1
+	for i, c := range s {
+		var l int
+		ok := true
+		switch c.Kind {
+		case KindDouble:
+			v, k := columns[i].([]float64)
+			l, ok = len(v), k
+		case KindInt64:
+			v, k := columns[i].([]int64)
+			l, ok = len(v), k
+		case KindDate, KindTimestamp:
+			v, k := columns[i].([]time.Time)
+			l, ok = len(v), k
+		case KindString:
+			v, k := columns[i].([]string)
+			l, ok = len(v), k
+		case KindBool:
+			v, k := columns[i].([]bool)
+			l, ok = len(v), k
+		}
+
+		if !ok {
+			return 0, fmt.Errorf("column %s: unexpected values %T", c.Name, columns[i])
+		}
+
+		if n >= 0 && l != n {
+			return 0, fmt.Errorf("column %s: expected %d values, got %d", c.Name, n, l)
+		}
+		n = l
+	}
+
+	return n, nil
+}
+
+// epochDays returns the days since the epoch of the calendar date of the time.
+func epochDays(t time.Time) int32 {
+	return int32(time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC).Unix() / 86400)
+}
+
+// rows returns the n rows of the columns, the values are at the leaf column indices.
+func (s Schema) rows(leaves []int, columns []any, n int) []parquet.Row {
+	rows := make([]parquet.Row, n)
+	for r := range rows {
+		rows[r] = make(parquet.Row, len(s))
+	}
+
+	for i, c := range s {
+		j := leaves[i]
+		for r := range rows {
+			var v parquet.Value
+			switch c.Kind {
+			case KindDouble:
+				v = parquet.DoubleValue(columns[i].([]float64)[r])
+			case KindInt64:
+				v = parquet.Int64Value(columns[i].([]int64)[r])
+			case KindDate:
+				v = parquet.Int32Value(epochDays(columns[i].([]time.Time)[r]))
+			case KindTimestamp:
+				v = parquet.Int64Value(columns[i].([]time.Time)[r].UnixMilli())
+			case KindString:
+				v = parquet.ByteArrayValue([]byte(columns[i].([]string)[r]))
+			case KindBool:
+				v = parquet.BooleanValue(columns[i].([]bool)[r])
+			}
+			rows[r][j] = v.Level(0, 0, j)
+		}
+	}
+
+	return rows
+}
+
+// newWriter returns a parquet writer of the schema with the key-value metadata.
+func newWriter(w io.Writer, ps *parquet.Schema, codec Codec, meta map[string]string) *parquet.Writer {
+	options := []parquet.WriterOption{ps, parquet.Compression(codec.compression()), parquet.CreatedBy(createdBy, version, "")}
+	for _, k := range sortedKeys(meta) {
+		options = append(options, parquet.KeyValueMetadata(k, meta[k]))
+	}
+
+	return parquet.NewWriter(w, options...)
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// Info is the metadata of a parquet file.
+type Info struct {
+	NumRows   int64
+	RowGroups int
+	Meta      map[string]string
+}
+
+func infoOf(md *format.FileMetaData) *Info {
+	info := &Info{NumRows: md.NumRows, RowGroups: len(md.RowGroups), Meta: map[string]string{}}
+	for _, kv := range md.KeyValueMetadata {
+		info.Meta[kv.Key] = kv.Value
+	}
+
+	return info
+}
+
+// openFile opens the parquet file without the page indices.
+func openFile(f *os.File) (*parquet.File, error) {
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	return parquet.OpenFile(f, fi.Size(), parquet.SkipPageIndex(true), parquet.SkipBloomFilters(true))
+}
+
+// ReadInfo reads the metadata of a parquet file.
+func ReadInfo(fileName string) (*Info, error) {
+	f, err := os.Open(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open parquet file '%s': %w", fileName, err)
+	}
+	defer f.Close()
+
+	pf, err := openFile(f)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read parquet file '%s': %w", fileName, err)
+	}
+
+	return infoOf(pf.Metadata()), nil
+}
+
+// CompactRows is the number of rows below which a row group is small.
+const CompactRows = 1 << 16
+
+// CompactRowGroups is the number of trailing small row groups which are compacted by Append.
+const CompactRowGroups = 64
+
+// Append appends the columns as a new row group, creating the file if it does not exist.
+// The key-value metadata are merged into the metadata of the file.
+//
+// The row group is written in place of the footer of an existing file, followed by the new footer.
+// If the append fails, the previous footer is restored.
+//
+// When the file would end with CompactRowGroups row groups of less than CompactRows rows,
+// the small row groups are rewritten with the columns as one row group, so the daily appends
+// do not accumulate tiny row groups. The preceding row groups are copied.
+// A compacted or a new file is written to a temporary file renamed at the end.
+//
+// The schema of an existing file must be the same.
+func Append(fileName string, schema Schema, codec Codec, columns []any, meta map[string]string) error {
+	n, err := schema.validate(columns)
+	if err != nil {
+		return fmt.Errorf("cannot append to parquet file '%s': %w", fileName, err)
+	}
+
+	ps, leaves := schema.parquetSchema()
+	rows := schema.rows(leaves, columns, n)
+
+	f, err := os.OpenFile(fileName, os.O_RDWR, 0)
+	if os.IsNotExist(err) {
+		return atomicfile.Write(fileName, func(w io.Writer) error {
+			pw := newWriter(w, ps, codec, meta)
+			if _, err := pw.WriteRows(rows); err != nil {
+				return err
+			}
+
+			return pw.Close()
+		})
+	} else if err != nil {
+		return fmt.Errorf("cannot open parquet file '%s': %w", fileName, err)
+	}
+	defer f.Close()
+
+	pf, err := openFile(f)
+	if err != nil {
+		return fmt.Errorf("cannot read parquet file '%s': %w", fileName, err)
+	}
+
+	if !parquet.EqualNodes(pf.Schema(), ps) {
+		return fmt.Errorf("cannot append to parquet file '%s': schema mismatch", fileName)
+	}
+
+	merged := infoOf(pf.Metadata()).Meta
+	for k, v := range meta {
+		merged[k] = v
+	}
+
+	rgs := pf.Metadata().RowGroups
+	keep := len(rgs)
+	for keep > 0 && rgs[keep-1].NumRows < CompactRows {
+		keep--
+	}
+
+	if n < CompactRows && len(rgs)-keep+1 >= CompactRowGroups {
+		err = compact(fileName, pf, keep, ps, codec, rows, merged)
+	} else {
+		err = splice(f, pf, ps, codec, rows, merged)
+	}
+
+	if err != nil {
+		return fmt.Errorf("cannot append to parquet file '%s': %w", fileName, err)
+	}
+
+	return nil
+}
+
+// compact rewrites the file with the row groups before the keep index copied
+// and the following row groups written with the rows as one row group.
+func compact(fileName string, pf *parquet.File, keep int, ps *parquet.Schema, codec Codec, rows []parquet.Row, meta map[string]string) error {
+	return atomicfile.Write(fileName, func(w io.Writer) error {
+		pw := newWriter(w, ps, codec, meta)
+		for i, rg := range pf.RowGroups() {
+			if i < keep {
+				if _, err := pw.WriteRowGroup(rg); err != nil {
+					return err
+				}
+				continue
+			}
+
+			rr := rg.Rows()
+			_, err := parquet.CopyRows(pw, rr)
+			rr.Close()
+			if err != nil {
+				return err
+			}
+		}
+
+		if _, err := pw.WriteRows(rows); err != nil {
+			return err
+		}
+
+		return pw.Close()
+	})
+}
+
+// splice writes the rows as a new row group in place of the footer of the file, followed by the merged footer.
+//
+// The row group is encoded as a separate parquet file, its column chunks and page indices
+// are copied with the offsets moved to the position of the footer.
+func splice(f *os.File, pf *parquet.File, ps *parquet.Schema, codec Codec, rows []parquet.Row, meta map[string]string) error {
+	var buf bytes.Buffer
+	pw := newWriter(&buf, ps, codec, nil)
+	if _, err := pw.WriteRows(rows); err != nil {
+		return err
+	}
+
+	if err := pw.Close(); err != nil {
+		return err
+	}
+
+	part := buf.Bytes()
+	partMeta, partFooter, err := readFooter(part)
+	if err != nil {
+		return fmt.Errorf("cannot read encoded row group: %w", err)
+	}
+
+	// The previous footer is kept with its length and magic for a restore.
+	size := pf.Size()
+	var tail [8]byte
+	if _, err := f.ReadAt(tail[:], size-8); err != nil {
+		return err
+	}
+
+	start := size - 8 - int64(binary.LittleEndian.Uint32(tail[:]))
+	oldFooter := make([]byte, size-start)
+	if _, err := f.ReadAt(oldFooter, start); err != nil {
+		return err
+	}
+
+	shift := start - int64(len(parquetMagic))
+
+	// The page indices follow the column chunks of the row group.
+	end := partFooter
+	for _, rg := range partMeta.RowGroups {
+		for _, cc := range rg.Columns {
+			for _, o := range []int64{cc.ColumnIndexOffset, cc.OffsetIndexOffset} {
+				if o > 0 && o < end {
+					end = o
+				}
+			}
+		}
+	}
+
+	out := bytes.NewBuffer(append([]byte{}, part[len(parquetMagic):end]...))
+	md := *pf.Metadata()
+	md.RowGroups = append([]format.RowGroup{}, md.RowGroups...)
+	for _, rg := range partMeta.RowGroups {
+		rg.FileOffset += shift
+		rg.Ordinal = int16(len(md.RowGroups))
+		for i := range rg.Columns {
+			cc := &rg.Columns[i]
+			cc.FileOffset += shift
+			cc.MetaData.DataPageOffset += shift
+			if cc.MetaData.DictionaryPageOffset > 0 {
+				cc.MetaData.DictionaryPageOffset += shift
+			}
+			if cc.MetaData.IndexPageOffset > 0 {
+				cc.MetaData.IndexPageOffset += shift
+			}
+			cc.MetaData.BloomFilterOffset, cc.MetaData.BloomFilterLength = 0, 0
+
+			if cc.ColumnIndexOffset > 0 {
+				b := part[cc.ColumnIndexOffset : cc.ColumnIndexOffset+int64(cc.ColumnIndexLength)]
+				cc.ColumnIndexOffset = start + int64(out.Len())
+				out.Write(b)
+			}
+
+			if cc.OffsetIndexOffset > 0 {
+				var oi format.OffsetIndex
+				b := part[cc.OffsetIndexOffset : cc.OffsetIndexOffset+int64(cc.OffsetIndexLength)]
+				if err := thrift.Unmarshal(new(thrift.CompactProtocol), b, &oi); err != nil {
+					return fmt.Errorf("cannot decode offset index: %w", err)
+				}
+
+				for j := range oi.PageLocations {
+					oi.PageLocations[j].Offset += shift
+				}
+
+				if b, err = thrift.Marshal(new(thrift.CompactProtocol), &oi); err != nil {
+					return fmt.Errorf("cannot encode offset index: %w", err)
+				}
+
+				cc.OffsetIndexOffset, cc.OffsetIndexLength = start+int64(out.Len()), int32(len(b))
+				out.Write(b)
+			}
+		}
+
+		md.RowGroups = append(md.RowGroups, rg)
+		md.NumRows += rg.NumRows
+	}
+
+	md.KeyValueMetadata = nil
+	for _, k := range sortedKeys(meta) {
+		md.KeyValueMetadata = append(md.KeyValueMetadata, format.KeyValue{Key: k, Value: meta[k]})
+	}
+	md.CreatedBy = partMeta.CreatedBy
+
+	footer, err := thrift.Marshal(new(thrift.CompactProtocol), &md)
+	if err != nil {
+		return fmt.Errorf("cannot encode footer: %w", err)
+	}
+
+	out.Write(footer)
+	out.Write(binary.LittleEndian.AppendUint32(nil, uint32(len(footer))))
+	out.WriteString(parquetMagic)
+
+	if _, err := f.WriteAt(out.Bytes(), start); err != nil {
+		return restore(f, start, oldFooter, err)
+	}
+
+	if err := f.Sync(); err != nil {
+		return restore(f, start, oldFooter, err)
+	}
+
+	return nil
+}
+
+// restore writes back the previous footer after a failed splice.
+func restore(f *os.File, start int64, footer []byte, err error) error {
+	if _, e := f.WriteAt(footer, start); e != nil {
+		return errors.Join(err, e)
+	}
+
+	return errors.Join(err, f.Truncate(start+int64(len(footer))))
+}
+
+// readFooter decodes the footer of the parquet file bytes, returning it with the offset of the footer.
+func readFooter(b []byte) (*format.FileMetaData, int64, error) {
+	if len(b) < 12 || string(b[:4]) != parquetMagic || string(b[len(b)-4:]) != parquetMagic {
+		return nil, 0, errors.New("not a parquet file")
+	}
+
+	offset := int64(len(b)) - 8 - int64(binary.LittleEndian.Uint32(b[len(b)-8:]))
+	if offset < 4 {
+		return nil, 0, errors.New("invalid footer length")
+	}
+
+	md := &format.FileMetaData{}
+	if err := thrift.Unmarshal(new(thrift.CompactProtocol), b[offset:len(b)-8], md); err != nil {
+		return nil, 0, err
+	}
+
+	return md, offset, nil
+}
+
+// Read reads the columns of all row groups of a parquet file written by Append.
+// The columns are []float64, []int64, []time.Time, []string or []bool slices as in the schema.
+func Read(fileName string, schema Schema) ([]any, error) {
+	f, err := os.Open(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open parquet file '%s': %w", fileName, err)
+	}
+	defer f.Close()
+
+	pf, err := openFile(f)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read parquet file '%s': %w", fileName, err)
+	}
+
+	ps, leaves := schema.parquetSchema()
+	if !parquet.EqualNodes(pf.Schema(), ps) {
+		return nil, fmt.Errorf("cannot read parquet file '%s': schema mismatch", fileName)
+	}
+
+	columns := emptyColumns(schema)
+	buf := make([]parquet.Row, 1024)
+	for _, rg := range pf.RowGroups() {
+		rr := rg.Rows()
+		for {
+			n, err := rr.ReadRows(buf)
+			for _, row := range buf[:n] {
+				appendRow(schema, leaves, columns, row)
+			}
+
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				rr.Close()
+				return nil, fmt.Errorf("cannot read parquet file '%s': %w", fileName, err)
+			}
+		}
+		rr.Close()
+	}
+
+	return columns, nil
+}
+
+// emptyColumns returns the empty column slices of the schema.
+func emptyColumns(schema Schema) []any {
+	columns := make([]any, len(schema))
+	for i, c := range schema {
+		switch c.Kind {
+		case KindDouble:
+			columns[i] = []float64{}
+		case KindInt64:
+			columns[i] = []int64{}
+		case KindDate, KindTimestamp:
+			columns[i] = []time.Time{}
+		case KindString:
+			columns[i] = []string{}
+		case KindBool:
+			columns[i] = []bool{}
+		}
+	}
+
+	return columns
+}
+
+// appendRow appends the values of the row at the leaf column indices to the columns.
+func appendRow(schema Schema, leaves []int, columns []any, row parquet.Row) {
+	for i, c := range schema {
+		v := row[leaves[i]]
+		switch c.Kind {
+		case KindDouble:
+			columns[i] = append(columns[i].([]float64), v.Double())
+		case KindInt64:
+			columns[i] = append(columns[i].([]int64), v.Int64())
+		case KindDate:
+			columns[i] = append(columns[i].([]time.Time), time.Unix(int64(v.Int32())*86400, 0).UTC())
+		case KindTimestamp:
+			columns[i] = append(columns[i].([]time.Time), time.UnixMilli(v.Int64()).UTC())
+		case KindString:
+			columns[i] = append(columns[i].([]string), string(v.ByteArray()))
+		case KindBool:
+			columns[i] = append(columns[i].([]bool), v.Boolean())
+		}
+	}
+}
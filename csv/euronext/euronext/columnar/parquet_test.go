@@ -0,0 +1,175 @@
+package columnar
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var testSchema = Schema{
+	{Name: "symbol", Kind: KindString, Dictionary: true},
+	{Name: "date", Kind: KindDate},
+	{Name: "time", Kind: KindTimestamp},
+	{Name: "price", Kind: KindDouble},
+	{Name: "volume", Kind: KindInt64},
+	{Name: "note", Kind: KindString},
+	{Name: "flag", Kind: KindBool},
+}
+
+func testColumns(symbols ...string) []any {
+	n := len(symbols)
+	dates, times, prices, volumes, notes, flags := []time.Time{}, []time.Time{}, []float64{}, []int64{}, []string{}, []bool{}
+	d := time.Date(2024, 6, 3, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < n; i++ {
+		dates = append(dates, d.AddDate(0, 0, i))
+		times = append(times, d.Add(time.Duration(i)*time.Minute+1500*time.Millisecond))
+		prices = append(prices, 10.25+float64(i))
+		volumes = append(volumes, int64(100*i))
+		notes = append(notes, string(rune('a'+i)))
+		flags = append(flags, i%3 == 0)
+	}
+
+	return []any{symbols, dates, times, prices, volumes, notes, flags}
+}
+
+func TestAppendAndRead(t *testing.T) {
+	t.Parallel()
+
+	for _, codec := range []Codec{CodecNone, CodecGzip, CodecZstd} {
+		fileName := filepath.Join(t.TempDir(), "test.parquet")
+		first := testColumns("AI", "AI", "KPN", "AI")
+		if err := Append(fileName, testSchema, codec, first, map[string]string{"a": "1", "b": "2"}); err != nil {
+			t.Fatalf("codec %d: unexpected error %v", codec, err)
+		}
+
+		second := testColumns("KPN", "KPN")
+		if err := Append(fileName, testSchema, codec, second, map[string]string{"b": "3"}); err != nil {
+			t.Fatalf("codec %d: unexpected error %v", codec, err)
+		}
+
+		info, err := ReadInfo(fileName)
+		if err != nil {
+			t.Fatalf("codec %d: unexpected error %v", codec, err)
+		}
+
+		if info.NumRows != 6 || info.RowGroups != 2 || !reflect.DeepEqual(info.Meta, map[string]string{"a": "1", "b": "3"}) {
+			t.Errorf("codec %d: unexpected info %+v", codec, info)
+		}
+
+		columns, err := Read(fileName, testSchema)
+		if err != nil {
+			t.Fatalf("codec %d: unexpected error %v", codec, err)
+		}
+
+		for i := range testSchema {
+			expected := reflect.AppendSlice(reflect.ValueOf(first[i]), reflect.ValueOf(second[i])).Interface()
+			if !reflect.DeepEqual(columns[i], expected) {
+				t.Errorf("codec %d: column %s: expected %v, got %v", codec, testSchema[i].Name, expected, columns[i])
+			}
+		}
+	}
+}
+
+func TestAppendCompactsTrailingRowGroups(t *testing.T) {
+	t.Parallel()
+
+	fileName := filepath.Join(t.TempDir(), "test.parquet")
+	symbols := make([]string, CompactRows)
+	for i := range symbols {
+		symbols[i] = "AI"
+	}
+
+	if err := Append(fileName, testSchema, CodecZstd, testColumns(symbols...), nil); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	created, err := os.Stat(fileName)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	// Every small append adds a row group in place until there are CompactRowGroups small row groups.
+	for i := 0; i < CompactRowGroups-1; i++ {
+		if err := Append(fileName, testSchema, CodecZstd, testColumns("KPN", "ASML"), nil); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+	}
+
+	info, err := ReadInfo(fileName)
+	if err != nil || info.NumRows != CompactRows+2*(CompactRowGroups-1) || info.RowGroups != CompactRowGroups {
+		t.Fatalf("unexpected info %+v, error %v", info, err)
+	}
+
+	if appended, err := os.Stat(fileName); err != nil || !os.SameFile(created, appended) {
+		t.Errorf("expected the file appended in place, error %v", err)
+	}
+
+	// The full row group is kept, the small row groups are compacted into one row group.
+	if err := Append(fileName, testSchema, CodecZstd, testColumns("KPN", "ASML"), nil); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	n := CompactRows + 2*CompactRowGroups
+	info, err = ReadInfo(fileName)
+	if err != nil || info.NumRows != int64(n) || info.RowGroups != 2 {
+		t.Fatalf("unexpected info %+v, error %v", info, err)
+	}
+
+	columns, err := Read(fileName, testSchema)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	read := columns[0].([]string)
+	if len(read) != n || read[CompactRows-1] != "AI" || read[CompactRows] != "KPN" || read[n-1] != "ASML" {
+		t.Errorf("unexpected symbols read back")
+	}
+
+	if prices := columns[3].([]float64); prices[n-1] != 11.25 {
+		t.Errorf("unexpected last price %v", prices[n-1])
+	}
+}
+
+func TestAppendSchemaMismatch(t *testing.T) {
+	t.Parallel()
+
+	fileName := filepath.Join(t.TempDir(), "test.parquet")
+	if err := Append(fileName, testSchema, CodecNone, testColumns("AI"), nil); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	before, _ := os.ReadFile(fileName)
+	other := append(Schema{}, testSchema...)
+	other[3] = Column{Name: "last", Kind: KindDouble}
+	if err := Append(fileName, other, CodecNone, testColumns("AI"), nil); err == nil {
+		t.Errorf("expected a schema mismatch error")
+	}
+
+	after, _ := os.ReadFile(fileName)
+	if !bytes.Equal(before, after) {
+		t.Errorf("expected the file unchanged after a failed append")
+	}
+
+	columns := testColumns("AI", "KPN")
+	columns[3] = []float64{1}
+	if err := Append(fileName, testSchema, CodecNone, columns, nil); err == nil {
+		t.Errorf("expected a column length error")
+	}
+}
+
+func TestParseCodec(t *testing.T) {
+	t.Parallel()
+
+	for s, expected := range map[string]Codec{"none": CodecNone, "GZIP": CodecGzip, "zstd": CodecZstd} {
+		if c, err := ParseCodec(s); err != nil || c != expected {
+			t.Errorf("%s: expected %d, got %d, error %v", s, expected, c, err)
+		}
+	}
+
+	if _, err := ParseCodec("snappy"); err == nil {
+		t.Errorf("expected an error for an unknown codec")
+	}
+}
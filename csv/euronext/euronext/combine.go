@@ -0,0 +1,229 @@
+package euronext
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Combine joins the lines of the unadjusted and the adjusted history downloads
+// into "unadjusted;adjusted" lines skipping the lines empty in both.
+// It returns the combined lines and the numbers of the unadjusted and adjusted lines.
+func Combine(bsRaw, bsAdj []byte) ([]string, int, int) {
+	linesRaw := strings.Split(string(bsRaw), "\n")
+	linesAdj := strings.Split(string(bsAdj), "\n")
+	lenRaw := len(linesRaw)
+	lenAdj := len(linesAdj)
+	combined := []string{}
+	if lenRaw == lenAdj {
+		for i := 0; i < lenRaw; i++ {
+			if len(linesRaw[i]) == 0 && len(linesAdj[i]) == 0 {
+				continue
+			}
+			combined = append(combined, linesRaw[i]+";"+linesAdj[i])
+		}
+	} else {
+		l := max(lenRaw, lenAdj)
+		for i := 0; i < l; i++ {
+			if i < lenRaw && i < lenAdj {
+				if len(linesRaw[i]) == 0 && len(linesAdj[i]) == 0 {
+					continue
+				}
+				combined = append(combined, linesRaw[i]+";"+linesAdj[i])
+			} else if i < lenRaw {
+				if len(linesRaw[i]) == 0 {
+					continue
+				}
+				combined = append(combined, linesRaw[i]+";")
+			} else {
+				if len(linesAdj[i]) == 0 {
+					continue
+				}
+				combined = append(combined, ";"+linesAdj[i])
+			}
+		}
+	}
+
+	return combined, lenRaw, lenAdj
+}
+
+// ConvertToCombinedDailyHistory converts the lines returned by Combine.
+// A value prefixed with a quote marks the entry.
+func ConvertToCombinedDailyHistory(lines []string) ([]CombinedDailyHistory, error) {
+	combinedHist := []CombinedDailyHistory{}
+	expectedParts := 20
+	for i, line := range lines {
+		if i < 3 {
+			continue
+		}
+
+		if i == 3 {
+			if !strings.HasPrefix(line, "Date;Open;") {
+				return combinedHist, fmt.Errorf("line 4: unexpected header line: %s", line)
+			}
+
+			continue
+		}
+
+		if i == 4 && len(line) < 10 {
+			return combinedHist, nil // Empty history
+		}
+
+		parts := strings.Split(line, ";")
+		if len(parts) != expectedParts {
+			return combinedHist, fmt.Errorf("line %d: expected %d line parts, got %d: %s", i+1, expectedParts, len(parts), line)
+		}
+
+		s0, marking := cleanString(parts[0], false)
+		time, err := time.Parse("02/01/2006", s0)
+		if err != nil {
+			return combinedHist, fmt.Errorf("line %d: cannot parse date '%s' in line '%s': %w", i+1, s0, line, err)
+		}
+
+		s, marking := cleanString(parts[10], marking)
+		if s0 != s {
+			return combinedHist, fmt.Errorf("line %d: date '%s' does not match adjusted date '%s' in line '%s'", i+1, s0, s, line)
+		}
+
+		s, openRaw, marking, err := parseFloat(parts[1], marking)
+		if err != nil {
+			return combinedHist, fmt.Errorf("line %d: cannot parse open price '%s' in line '%s': %w", i+1, s, line, err)
+		}
+
+		s, openAdj, marking, err := parseFloat(parts[11], marking)
+		if err != nil {
+			return combinedHist, fmt.Errorf("line %d: cannot parse open adjusted price '%s' in line '%s': %w", i+1, s, line, err)
+		}
+
+		s, highRaw, marking, err := parseFloat(parts[2], marking)
+		if err != nil {
+			return combinedHist, fmt.Errorf("line %d: cannot parse high price '%s' in line '%s': %w", i+1, s, line, err)
+		}
+
+		s, highAdj, marking, err := parseFloat(parts[12], marking)
+		if err != nil {
+			return combinedHist, fmt.Errorf("line %d: cannot parse high adjusted price '%s' in line '%s': %w", i+1, s, line, err)
+		}
+
+		s, lowRaw, marking, err := parseFloat(parts[3], marking)
+		if err != nil {
+			return combinedHist, fmt.Errorf("line %d: cannot parse low price '%s' in line '%s': %w", i+1, s, line, err)
+		}
+
+		s, lowAdj, marking, err := parseFloat(parts[13], marking)
+		if err != nil {
+			return combinedHist, fmt.Errorf("line %d: cannot parse low adjusted price '%s' in line '%s': %w", i+1, s, line, err)
+		}
+
+		s, lastRaw, marking, err := parseFloat(parts[4], marking)
+		if err != nil {
+			return combinedHist, fmt.Errorf("line %d: cannot parse last price '%s' in line '%s': %w", i+1, s, line, err)
+		}
+
+		s, lastAdj, marking, err := parseFloat(parts[14], marking)
+		if err != nil {
+			return combinedHist, fmt.Errorf("line %d: cannot parse last adjusted price '%s' in line '%s': %w", i+1, s, line, err)
+		}
+
+		s, closeRaw, marking, err := parseFloat(parts[5], marking)
+		if err != nil {
+			return combinedHist, fmt.Errorf("line %d: cannot parse close price '%s' in line '%s': %w", i+1, s, line, err)
+		}
+
+		s, closeAdj, marking, err := parseFloat(parts[15], marking)
+		if err != nil {
+			return combinedHist, fmt.Errorf("line %d: cannot parse close adjusted price '%s' in line '%s': %w", i+1, s, line, err)
+		}
+
+		s, sharesRaw, marking, err := parseFloat(parts[6], marking)
+		if err != nil {
+			return combinedHist, fmt.Errorf("line %d: cannot parse number of shares '%s' in line '%s': %w", i+1, s, line, err)
+		}
+
+		s, sharesAdj, marking, err := parseFloat(parts[16], marking)
+		if err != nil {
+			return combinedHist, fmt.Errorf("line %d: cannot parse number of adjusted shares '%s' in line '%s': %w", i+1, s, line, err)
+		}
+
+		s, tradesRaw, marking, err := parseFloat(parts[7], marking)
+		if err != nil {
+			return combinedHist, fmt.Errorf("line %d: cannot parse number of trades '%s' in line '%s': %w", i+1, s, line, err)
+		}
+
+		s, tradesAdj, marking, err := parseFloat(parts[17], marking)
+		if err != nil {
+			return combinedHist, fmt.Errorf("line %d: cannot parse number of adjusted trades '%s' in line '%s': %w", i+1, s, line, err)
+		}
+
+		s, turnoverRaw, marking, err := parseFloat(parts[8], marking)
+		if err != nil {
+			return combinedHist, fmt.Errorf("line %d: cannot parse turnover '%s' in line '%s': %w", i+1, s, line, err)
+		}
+
+		s, turnoverAdj, marking, err := parseFloat(parts[18], marking)
+		if err != nil {
+			return combinedHist, fmt.Errorf("line %d: cannot parse adjusted turnover '%s' in line '%s': %w", i+1, s, line, err)
+		}
+
+		s, vwapRaw, marking, err := parseFloat(parts[9], marking)
+		if err != nil {
+			return combinedHist, fmt.Errorf("line %d: cannot parse vwap '%s' in line '%s': %w", i+1, s, line, err)
+		}
+
+		s, vwapAdj, marking, err := parseFloat(parts[19], marking)
+		if err != nil {
+			return combinedHist, fmt.Errorf("line %d: cannot parse adjusted vwap '%s' in line '%s': %w", i+1, s, line, err)
+		}
+
+		factor := 1.
+		if closeRaw != closeAdj && closeRaw != 0 {
+			factor = closeAdj / closeRaw
+		}
+
+		entry := CombinedDailyHistory{
+			Date:                   time,
+			Open:                   openRaw,
+			High:                   highRaw,
+			Low:                    lowRaw,
+			Last:                   lastRaw,
+			Close:                  closeRaw,
+			NumberOfShares:         sharesRaw,
+			NumberOfTrades:         tradesRaw,
+			Turnover:               turnoverRaw,
+			Vwap:                   vwapRaw,
+			OpenAdjusted:           openAdj,
+			HighAdjusted:           highAdj,
+			LowAdjusted:            lowAdj,
+			LastAdjusted:           lastAdj,
+			CloseAdjusted:          closeAdj,
+			NumberOfSharesAdjusted: sharesAdj,
+			NumberOfTradesAdjusted: tradesAdj,
+			TurnoverAdjusted:       turnoverAdj,
+			VwapAdjusted:           vwapAdj,
+			AdjustmentFactor:       factor,
+			HasMarking:             marking,
+		}
+		combinedHist = append(combinedHist, entry)
+	}
+
+	return combinedHist, nil
+}
+
+func parseFloat(s string, marking bool) (string, float64, bool, error) {
+	s, marking = cleanString(s, marking)
+	if len(s) == 0 || s == "0" || s == "0.0" {
+		return s, 0, marking, nil
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	return s, v, marking, err
+}
+
+func cleanString(s string, marking bool) (string, bool) {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "'") {
+		s = s[1:]
+		marking = true
+	}
+	return s, marking
+}
@@ -0,0 +1,82 @@
+package euronext
+
+import (
+	"testing"
+	"time"
+)
+
+const (
+	testRaw = "\"Historical Data\"\n\"From 2024-06-03 to 2024-06-04\"\n\"XPAR\"\n" +
+		"Date;Open;High;Low;Last;Close;Number of Shares;Number of Trades;Turnover;vwap\n" +
+		"04/06/2024;10;11;9;10.5;10.5;1000;10;10500;10.5\n" +
+		"03/06/2024;'9;10;8;9.5;9.5;900;9;8550;9.5\n"
+	testAdj = "\"Historical Data\"\n\"From 2024-06-03 to 2024-06-04\"\n\"XPAR\"\n" +
+		"Date;Open;High;Low;Last;Close;Number of Shares;Number of Trades;Turnover;vwap\n" +
+		"04/06/2024;5;5.5;4.5;5.25;5.25;2000;10;10500;5.25\n" +
+		"03/06/2024;4.5;5;4;4.75;4.75;1800;9;8550;4.75\n"
+)
+
+func TestCombine(t *testing.T) {
+	t.Parallel()
+
+	combined, lenRaw, lenAdj := Combine([]byte("a\nb\n\n"), []byte("c\nd\n\n"))
+	if lenRaw != 4 || lenAdj != 4 {
+		t.Errorf("expected 4 and 4 lines, got %d and %d", lenRaw, lenAdj)
+	}
+
+	if len(combined) != 2 || combined[0] != "a;c" || combined[1] != "b;d" {
+		t.Errorf("unexpected combined lines %q", combined)
+	}
+
+	combined, lenRaw, lenAdj = Combine([]byte("a\nb"), []byte("c"))
+	if lenRaw != 2 || lenAdj != 1 {
+		t.Errorf("expected 2 and 1 lines, got %d and %d", lenRaw, lenAdj)
+	}
+
+	if len(combined) != 2 || combined[1] != "b;" {
+		t.Errorf("unexpected combined lines %q", combined)
+	}
+}
+
+func TestConvertToCombinedDailyHistory(t *testing.T) {
+	t.Parallel()
+
+	combined, _, _ := Combine([]byte(testRaw), []byte(testAdj))
+	hist, err := ConvertToCombinedDailyHistory(combined)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(hist) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(hist))
+	}
+
+	h := hist[0]
+	if !h.Date.Equal(time.Date(2024, 6, 4, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected date %v", h.Date)
+	}
+
+	if h.Close != 10.5 || h.CloseAdjusted != 5.25 || h.AdjustmentFactor != 0.5 || h.HasMarking {
+		t.Errorf("unexpected entry %+v", h)
+	}
+
+	if !hist[1].HasMarking || hist[1].Open != 9 {
+		t.Errorf("expected a marked entry with open 9, got %+v", hist[1])
+	}
+
+	bad := []struct {
+		name  string
+		lines []string
+	}{
+		{"header", []string{"", "", "", "Time;Open;"}},
+		{"parts", []string{"", "", "", "Date;Open;", "04/06/2024;1;2;3"}},
+		{"dates", []string{"", "", "", "Date;Open;", "04/06/2024;1;1;1;1;1;1;1;1;1;05/06/2024;1;1;1;1;1;1;1;1;1"}},
+		{"float", []string{"", "", "", "Date;Open;", "04/06/2024;x;1;1;1;1;1;1;1;1;04/06/2024;1;1;1;1;1;1;1;1;1"}},
+	}
+
+	for _, b := range bad {
+		if _, err := ConvertToCombinedDailyHistory(b.lines); err == nil {
+			t.Errorf("%s: expected an error", b.name)
+		}
+	}
+}
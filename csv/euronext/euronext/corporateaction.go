@@ -0,0 +1,377 @@
+package euronext
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CorporateActionKind is the kind of a corporate action.
+type CorporateActionKind string
+
+const (
+	// CorporateActionSplit increases the number of shares, the ratio is above one.
+	CorporateActionSplit CorporateActionKind = "split"
+
+	// CorporateActionReverseSplit decreases the number of shares, the ratio is below one.
+	CorporateActionReverseSplit CorporateActionKind = "reverse split"
+
+	// CorporateActionDistribution is a dividend or another distribution lowering the price a little.
+	CorporateActionDistribution CorporateActionKind = "distribution"
+
+	// CorporateActionUnknown is an adjustment which is neither a split nor a distribution.
+	CorporateActionUnknown CorporateActionKind = "unknown"
+)
+
+// Sources of the corporate actions.
+const (
+	// CorporateActionInferred means the action is inferred from the adjustment factors.
+	CorporateActionInferred = "inferred"
+
+	// CorporateActionManual means the action is entered by hand into the ledger.
+	CorporateActionManual = "manual"
+)
+
+// DefaultCorporateActionTolerance is the relative adjustment factor change
+// below which consecutive factors are considered equal.
+const DefaultCorporateActionTolerance = 1e-3
+
+// splitTolerance is the relative difference between a ratio and a split ratio n:m.
+const splitTolerance = 0.005
+
+// CorporateAction is an entry of the corporate action ledger of an instrument.
+//
+// The ratio is the adjustment factor on the date divided by the adjustment factor
+// of the previous trading date, so a 2:1 split has a ratio of 2
+// and a distribution of 2% of the price has a ratio of 1/0.98.
+type CorporateAction struct {
+	Date   time.Time           `json:"date"`
+	Kind   CorporateActionKind `json:"kind"`
+	Ratio  float64             `json:"ratio"`
+	Source string              `json:"source"`
+}
+
+// String returns a human-readable description of the action.
+func (a CorporateAction) String() string {
+	date := a.Date.Format(CombinedDailyHistoryDateFormat)
+	switch a.Kind {
+	case CorporateActionSplit, CorporateActionReverseSplit:
+		if n, m, ok := splitTerms(a.Ratio); ok {
+			return fmt.Sprintf("%s %s %d:%d (%s)", date, a.Kind, n, m, a.Source)
+		}
+	case CorporateActionDistribution:
+		return fmt.Sprintf("%s %s %.4g%% (%s)", date, a.Kind, 100*(1-1/a.Ratio), a.Source)
+	}
+
+	return fmt.Sprintf("%s %s ratio %g (%s)", date, a.Kind, a.Ratio, a.Source)
+}
+
+// splitTerms returns the split terms n:m with n, m up to 10 closest to the ratio.
+// Ratios between 0.8 and 1.25 have no split terms, they are distributions or unknown.
+func splitTerms(ratio float64) (int, int, bool) {
+	if ratio <= 0 || (ratio > 0.8 && ratio < 1.25) {
+		return 0, 0, false
+	}
+
+	for m := 1; m <= 10; m++ {
+		n := int(math.Round(ratio * float64(m)))
+		if n < 1 || n > 10 || n == m {
+			continue
+		}
+
+		if math.Abs(float64(n)/float64(m)/ratio-1) <= splitTolerance {
+			return n, m, true
+		}
+	}
+
+	return 0, 0, false
+}
+
+// ClassifyCorporateAction returns the kind of an action with the ratio.
+func ClassifyCorporateAction(ratio float64) CorporateActionKind {
+	if _, _, ok := splitTerms(ratio); ok {
+		if ratio > 1 {
+			return CorporateActionSplit
+		}
+
+		return CorporateActionReverseSplit
+	}
+
+	if ratio > 1 && ratio < 1.25 {
+		return CorporateActionDistribution
+	}
+
+	return CorporateActionUnknown
+}
+
+// InferCorporateActions derives the corporate actions from the changes of the adjustment factors
+// of consecutive entries. Entries without the adjustment factor are skipped.
+//
+// The tolerance is the relative factor change below which factors are considered equal,
+// zero or negative means DefaultCorporateActionTolerance.
+func InferCorporateActions(history []CombinedDailyHistory, tolerance float64) []CorporateAction {
+	if tolerance <= 0 {
+		tolerance = DefaultCorporateActionTolerance
+	}
+
+	actions := []CorporateAction{}
+	prev := 0.
+	for _, h := range SortCombinedDailyHistory(history) {
+		if h.AdjustmentFactor <= 0 {
+			continue
+		}
+
+		if prev > 0 {
+			ratio := h.AdjustmentFactor / prev
+			if math.Abs(ratio-1) > tolerance {
+				actions = append(actions, CorporateAction{
+					Date:   h.Date,
+					Kind:   ClassifyCorporateAction(ratio),
+					Ratio:  ratio,
+					Source: CorporateActionInferred,
+				})
+			}
+		}
+
+		prev = h.AdjustmentFactor
+	}
+
+	return actions
+}
+
+// SortCorporateActions sorts the actions by date in place.
+func SortCorporateActions(actions []CorporateAction) {
+	sort.SliceStable(actions, func(i, j int) bool {
+		return actions[i].Date.Before(actions[j].Date)
+	})
+}
+
+// MergeCorporateActions adds the actions on dates not in the ledger to the ledger.
+// The ledger entries win, so the manual entries are never replaced by the inferred ones.
+// It returns the merged ledger and the added actions.
+func MergeCorporateActions(ledger, actions []CorporateAction) ([]CorporateAction, []CorporateAction) {
+	dates := make(map[time.Time]struct{}, len(ledger))
+	for _, a := range ledger {
+		dates[a.Date] = struct{}{}
+	}
+
+	merged := make([]CorporateAction, len(ledger))
+	copy(merged, ledger)
+
+	added := []CorporateAction{}
+	for _, a := range actions {
+		if _, ok := dates[a.Date]; ok {
+			continue
+		}
+
+		dates[a.Date] = struct{}{}
+		merged = append(merged, a)
+		added = append(added, a)
+	}
+
+	SortCorporateActions(merged)
+	return merged, added
+}
+
+// ReadjustCombinedDailyHistory regenerates the adjusted columns and the adjustment factors
+// from the raw columns and the corporate actions.
+//
+// The adjustment factor of an entry is the product of the inverse ratios
+// of all actions after the entry date, so the latest entries have the factor of one.
+func ReadjustCombinedDailyHistory(history []CombinedDailyHistory, actions []CorporateAction) []CombinedDailyHistory {
+	sorted := make([]CorporateAction, len(actions))
+	copy(sorted, actions)
+	SortCorporateActions(sorted)
+
+	adjusted := SortCombinedDailyHistory(history)
+	factor := 1.
+	k := len(sorted) - 1
+	for i := len(adjusted) - 1; i >= 0; i-- {
+		h := &adjusted[i]
+		for k >= 0 && sorted[k].Date.After(h.Date) {
+			if sorted[k].Ratio > 0 {
+				factor /= sorted[k].Ratio
+			}
+			k--
+		}
+
+		h.AdjustmentFactor = factor
+		h.OpenAdjusted = h.Open * factor
+		h.HighAdjusted = h.High * factor
+		h.LowAdjusted = h.Low * factor
+		h.LastAdjusted = h.Last * factor
+		h.CloseAdjusted = h.Close * factor
+		h.VwapAdjusted = h.Vwap * factor
+		h.NumberOfSharesAdjusted = h.NumberOfShares / factor
+		h.NumberOfTradesAdjusted = h.NumberOfTrades
+		h.TurnoverAdjusted = h.Turnover
+		h.HasMarkingAdjusted = h.HasMarking
+	}
+
+	return adjusted
+}
+
+// CorporateActionsReport compares the inferred splits with the known ones,
+// where the known actions are the ledger entries not inferred from the adjustment factors.
+// It returns a message for every inferred split or unknown action without a known split
+// with the same date and a ratio within 0.5%, and for every known split which is not inferred.
+func CorporateActionsReport(inferred, ledger []CorporateAction) []string {
+	isSplit := func(a CorporateAction) bool {
+		return a.Kind == CorporateActionSplit || a.Kind == CorporateActionReverseSplit
+	}
+
+	matches := func(a, b CorporateAction) bool {
+		return a.Date.Equal(b.Date) && b.Ratio > 0 && math.Abs(a.Ratio/b.Ratio-1) <= splitTolerance
+	}
+
+	known := []CorporateAction{}
+	for _, a := range ledger {
+		if a.Source != CorporateActionInferred && isSplit(a) {
+			known = append(known, a)
+		}
+	}
+
+	messages := []string{}
+	for _, a := range inferred {
+		if !isSplit(a) && a.Kind != CorporateActionUnknown {
+			continue
+		}
+
+		found := false
+		for _, b := range known {
+			if matches(a, b) {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			messages = append(messages, fmt.Sprintf("%s does not match a known split", a))
+		}
+	}
+
+	for _, b := range known {
+		found := false
+		for _, a := range inferred {
+			if matches(a, b) {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			messages = append(messages, fmt.Sprintf("%s is not found in the adjustment factors", b))
+		}
+	}
+
+	return messages
+}
+
+// CorporateActionsFileName returns the ledger file name of the history file name,
+// "ai_fr0000120073_xpar.1d.csv.gz" has the ledger "ai_fr0000120073_xpar.actions.csv".
+func CorporateActionsFileName(historyFileName string) string {
+	name := strings.TrimSuffix(historyFileName, ".gz")
+	name = strings.TrimSuffix(name, ".csv")
+	name = strings.TrimSuffix(name, ".1d")
+	return name + ".actions.csv"
+}
+
+// WriteCorporateActionsCsv writes the ledger into the csv file.
+func WriteCorporateActionsCsv(fileName string, actions []CorporateAction) (string, error) {
+	file, err := os.Create(fileName)
+	if err != nil {
+		es := fmt.Sprintf("cannot create csv file %s: ", fileName)
+		return es, fmt.Errorf("%s%w", es, err)
+	}
+	defer file.Close()
+
+	w := csv.NewWriter(file)
+	defer w.Flush()
+
+	if err := w.Write([]string{"date", "kind", "ratio", "source"}); err != nil {
+		es := fmt.Sprintf("cannot write header to csv file %s: ", fileName)
+		return es, fmt.Errorf("%s%w", es, err)
+	}
+
+	for _, a := range actions {
+		row := []string{
+			a.Date.Format(CombinedDailyHistoryDateFormat),
+			string(a.Kind),
+			strconv.FormatFloat(a.Ratio, 'f', -1, 64),
+			a.Source,
+		}
+
+		if err := w.Write(row); err != nil {
+			es := fmt.Sprintf("cannot write row to csv file %s: ", fileName)
+			return es, fmt.Errorf("%s%w", es, err)
+		}
+	}
+
+	return "", nil
+}
+
+// ReadCorporateActionsCsv reads the ledger from the csv file.
+// An empty kind is classified from the ratio, an empty source means a manual entry.
+func ReadCorporateActionsCsv(fileName string) ([]CorporateAction, string, error) {
+	actions := []CorporateAction{}
+
+	file, err := os.Open(fileName)
+	if err != nil {
+		es := fmt.Sprintf("cannot open csv file %s: ", fileName)
+		return actions, es, fmt.Errorf("%s%w", es, err)
+	}
+	defer file.Close()
+
+	r := csv.NewReader(file)
+	r.FieldsPerRecord = 4
+	if _, err := r.Read(); err != nil {
+		es := fmt.Sprintf("cannot read header from csv file %s: ", fileName)
+		return actions, es, fmt.Errorf("%s%w", es, err)
+	}
+
+	for {
+		record, err := r.Read()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			es := fmt.Sprintf("cannot read row from csv file %s: ", fileName)
+			return actions, es, fmt.Errorf("%s%w", es, err)
+		}
+
+		date, err := time.Parse(CombinedDailyHistoryDateFormat, record[0])
+		if err != nil {
+			es := fmt.Sprintf("cannot parse date from csv file %s: ", fileName)
+			return actions, es, fmt.Errorf("%s%w", es, err)
+		}
+
+		ratio, err := strconv.ParseFloat(record[2], 64)
+		if err != nil || ratio <= 0 {
+			es := fmt.Sprintf("invalid ratio '%s' in csv file %s: ", record[2], fileName)
+			if err == nil {
+				err = fmt.Errorf("ratio is not positive")
+			}
+			return actions, es, fmt.Errorf("%s%w", es, err)
+		}
+
+		a := CorporateAction{Date: date, Kind: CorporateActionKind(record[1]), Ratio: ratio, Source: record[3]}
+		if a.Kind == "" {
+			a.Kind = ClassifyCorporateAction(ratio)
+		}
+
+		if a.Source == "" {
+			a.Source = CorporateActionManual
+		}
+
+		actions = append(actions, a)
+	}
+
+	SortCorporateActions(actions)
+	return actions, "", nil
+}
@@ -0,0 +1,180 @@
+package euronext
+
+import (
+	"math"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func day(d int) time.Time {
+	return time.Date(2024, 6, d, 0, 0, 0, 0, time.UTC)
+}
+
+// testHistory has a 2:1 split on the 5th and a 2% distribution on the 10th.
+func testHistory() []CombinedDailyHistory {
+	closes := map[int]float64{3: 100, 4: 102, 5: 51, 6: 52, 7: 53, 10: 51.94, 11: 52}
+	hist := []CombinedDailyHistory{}
+	for d, c := range closes {
+		factor := 1.
+		if d < 10 {
+			factor = 0.98
+		}
+		if d < 5 {
+			factor *= 0.5
+		}
+
+		// Rounding noise of the adjusted prices.
+		adj := math.Round(c*factor*1000) / 1000
+		hist = append(hist, CombinedDailyHistory{
+			Date: day(d), Open: c, High: c, Low: c, Last: c, Close: c, Vwap: c,
+			NumberOfShares: 1000, NumberOfTrades: 10, Turnover: 1000 * c,
+			CloseAdjusted: adj, AdjustmentFactor: adj / c,
+		})
+	}
+
+	return hist
+}
+
+func TestClassifyCorporateAction(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		ratio float64
+		kind  CorporateActionKind
+	}{
+		{2, CorporateActionSplit},
+		{1.5, CorporateActionSplit},
+		{10.02, CorporateActionSplit},
+		{0.1, CorporateActionReverseSplit},
+		{0.667, CorporateActionReverseSplit},
+		{1 / 0.98, CorporateActionDistribution},
+		{0.97, CorporateActionUnknown},
+		{37, CorporateActionUnknown},
+	}
+
+	for _, tt := range tests {
+		if kind := ClassifyCorporateAction(tt.ratio); kind != tt.kind {
+			t.Errorf("ratio %g: expected %s, got %s", tt.ratio, tt.kind, kind)
+		}
+	}
+}
+
+func TestInferCorporateActions(t *testing.T) {
+	t.Parallel()
+
+	actions := InferCorporateActions(testHistory(), 0)
+	if len(actions) != 2 {
+		t.Fatalf("expected 2 actions, got %v", actions)
+	}
+
+	a := actions[0]
+	if !a.Date.Equal(day(5)) || a.Kind != CorporateActionSplit || math.Abs(a.Ratio-2) > 1e-3 || a.Source != CorporateActionInferred {
+		t.Errorf("unexpected split %+v", a)
+	}
+
+	if s := a.String(); s != "2024-06-05 split 2:1 (inferred)" {
+		t.Errorf("unexpected split string %s", s)
+	}
+
+	a = actions[1]
+	if !a.Date.Equal(day(10)) || a.Kind != CorporateActionDistribution || math.Abs(a.Ratio-1/0.98) > 1e-3 {
+		t.Errorf("unexpected distribution %+v", a)
+	}
+
+	// A tolerance above the distribution hides it.
+	if actions := InferCorporateActions(testHistory(), 0.05); len(actions) != 1 {
+		t.Errorf("expected 1 action, got %v", actions)
+	}
+}
+
+func TestReadjustCombinedDailyHistory(t *testing.T) {
+	t.Parallel()
+
+	actions := []CorporateAction{
+		{Date: day(10), Kind: CorporateActionDistribution, Ratio: 1 / 0.98, Source: CorporateActionManual},
+		{Date: day(5), Kind: CorporateActionSplit, Ratio: 2, Source: CorporateActionManual},
+	}
+
+	hist := ReadjustCombinedDailyHistory(testHistory(), actions)
+	if len(hist) != 7 {
+		t.Fatalf("expected 7 entries, got %d", len(hist))
+	}
+
+	expected := map[int]float64{3: 0.49, 4: 0.49, 5: 0.98, 6: 0.98, 7: 0.98, 10: 1, 11: 1}
+	for _, h := range hist {
+		f := expected[h.Date.Day()]
+		if math.Abs(h.AdjustmentFactor-f) > 1e-12 {
+			t.Errorf("%s: expected factor %g, got %g", h.Date.Format("2006-01-02"), f, h.AdjustmentFactor)
+		}
+
+		if math.Abs(h.CloseAdjusted-h.Close*f) > 1e-9 || math.Abs(h.NumberOfSharesAdjusted-1000/f) > 1e-9 {
+			t.Errorf("%s: unexpected adjusted values %+v", h.Date.Format("2006-01-02"), h)
+		}
+	}
+
+	// The re-adjusted history infers the same actions.
+	inferred := InferCorporateActions(hist, 0)
+	if len(inferred) != 2 || !inferred[0].Date.Equal(day(5)) || !inferred[1].Date.Equal(day(10)) {
+		t.Errorf("unexpected inferred actions %v", inferred)
+	}
+}
+
+func TestMergeCorporateActionsAndReport(t *testing.T) {
+	t.Parallel()
+
+	inferred := InferCorporateActions(testHistory(), 0)
+	ledger := []CorporateAction{
+		{Date: day(5), Kind: CorporateActionSplit, Ratio: 2, Source: CorporateActionManual},
+		{Date: day(20), Kind: CorporateActionReverseSplit, Ratio: 0.1, Source: CorporateActionManual},
+	}
+
+	merged, added := MergeCorporateActions(ledger, inferred)
+	if len(merged) != 3 || len(added) != 1 || !added[0].Date.Equal(day(10)) {
+		t.Fatalf("unexpected merge %v, added %v", merged, added)
+	}
+
+	if merged[0].Source != CorporateActionManual || !merged[1].Date.Equal(day(10)) {
+		t.Errorf("unexpected merged ledger %v", merged)
+	}
+
+	report := CorporateActionsReport(inferred, merged)
+	if len(report) != 1 || report[0] != "2024-06-20 reverse split 1:10 (manual) is not found in the adjustment factors" {
+		t.Errorf("unexpected report %q", report)
+	}
+
+	ledger[0].Ratio = 3
+	report = CorporateActionsReport(inferred, ledger)
+	if len(report) != 3 {
+		t.Errorf("expected 3 messages, got %q", report)
+	}
+}
+
+func TestCorporateActionsCsv(t *testing.T) {
+	t.Parallel()
+
+	fileName := CorporateActionsFileName(filepath.Join(t.TempDir(), "ai_fr0000120073_xpar.1d.csv.gz"))
+	if filepath.Base(fileName) != "ai_fr0000120073_xpar.actions.csv" {
+		t.Errorf("unexpected ledger file name %s", fileName)
+	}
+
+	actions := InferCorporateActions(testHistory(), 0)
+	if _, err := WriteCorporateActionsCsv(fileName, actions); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	read, _, err := ReadCorporateActionsCsv(fileName)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(read) != len(actions) {
+		t.Fatalf("expected %d actions, got %d", len(actions), len(read))
+	}
+
+	for i := range read {
+		if read[i] != actions[i] {
+			t.Errorf("action %d: expected %+v, got %+v", i, actions[i], read[i])
+		}
+	}
+}
@@ -2,7 +2,6 @@ package discovery
 
 import (
 	"archive/zip"
-	"bytes"
 	"fmt"
 	"io"
 	"log"
@@ -13,6 +12,8 @@ import (
 	"strconv"
 	"strings"
 	"time"
+
+	"euronext/euronext/fetch"
 )
 
 type InstrumentInfo struct {
@@ -179,6 +180,9 @@ func downloadPost(
 		postData.Set(key, value)
 	}
 
+	header := fetch.Ajax(referer, userAgent)
+	header["Content-Type"] = "application/x-www-form-urlencoded"
+
 	firstTry := true
 	for retries > 0 {
 		// If this is not the first try, wait before retrying
@@ -204,27 +208,13 @@ func downloadPost(
 			log.Printf("%s POST body: %s\n", uri, pd)
 		}
 
-		req, err := http.NewRequest("POST", uri, bytes.NewBufferString(pd))
-		if err != nil {
-			log.Printf("failed to create request: %v\n", err)
-			retries--
-			continue
-		}
-		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-		req.Header.Set("User-Agent", userAgent)
-		req.Header.Set("Accept-Language", "en-us,en;q=0.5")
-		req.Header.Set("Accept-Charset", "ISO-8859-1,utf-8;q=0.7,*;q=0.7")
-		req.Header.Set("X-Requested-With", "XMLHttpRequest")
-		req.Header.Set("Referer", referer)
-		req.Header.Set("Accept", "application/json, text/javascript, */*")
-
-		// Create HTTP client with timeout and proxy settings
-		transport := &http.Transport{
-			Proxy: http.ProxyFromEnvironment, // Uses system proxy settings
-		}
-		client := &http.Client{Timeout: timeout, Transport: transport}
-
-		resp, err := client.Do(req)
+		resp, err := fetch.Default.Fetch(&fetch.Request{
+			Method:  "POST",
+			URL:     uri,
+			Header:  header,
+			Body:    pd,
+			Timeout: timeout,
+		})
 		if err != nil {
 			if retries > 1 {
 				log.Printf("file %s: download failed [%v], retrying (%d)\n", filePath, err, retries)
@@ -237,21 +227,13 @@ func downloadPost(
 		if resp.StatusCode != http.StatusOK {
 			log.Printf("file %s: download failed, status code %d is not OK, retrying (%d)\n", filePath, resp.StatusCode, retries)
 			retries--
-			resp.Body.Close()
-			continue
-		}
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
-			log.Printf("file %s: failed to read body: [%v], retrying (%d)\n", filePath, err, retries)
-			retries--
-			resp.Body.Close()
 			continue
 		}
+		body := resp.Body
 
 		if int64(len(body)) <= minimalLength {
 			log.Printf("file %s: downloaded length %d is smaller than the minimal length %d, retrying\n", filePath, len(body), minimalLength)
 			retries--
-			resp.Body.Close()
 			continue
 		}
 
@@ -259,11 +241,9 @@ func downloadPost(
 		if err != nil {
 			log.Printf("file %s: failed to write: [%v], retrying (%d)\n", filePath, err, retries)
 			retries--
-			resp.Body.Close()
 			continue
 		}
 
-		resp.Body.Close()
 		return true
 	}
 	log.Printf("file %s: failed to download after retries\n", filePath)
@@ -0,0 +1,41 @@
+package discovery
+
+import (
+	"testing"
+	"time"
+
+	"euronext/euronext/fetch"
+)
+
+func TestFetchReplay(t *testing.T) {
+	rep, err := fetch.NewReplayer("testdata/fixtures")
+	if err != nil {
+		t.Fatalf("cannot load fixtures: %v", err)
+	}
+	defer fetch.Replace(rep)()
+
+	// Only the XPAR stocks have a fixture, the other categories fail to download.
+	now := time.Date(2024, 6, 4, 0, 0, 0, 0, time.UTC)
+	infos := Fetch(t.TempDir(), now, 1, 1, 0, false, true, false, "agent")
+
+	expected := []InstrumentInfo{
+		{Mic: "XPAR", MicDescription: "Euronext Paris", Mep: "PAR", Isin: "FR0000120073", Name: "AIR LIQUIDE", Symbol: "AI", Key: "XPAR_AI_FR0000120073", Type: "stock"},
+		{Mic: "XPAR", MicDescription: "Euronext Paris", Mep: "PAR", Isin: "FR0000121014", Name: "LVMH", Symbol: "MC", Key: "XPAR_MC_FR0000121014", Type: "stock"},
+	}
+
+	if len(infos) != len(expected) {
+		t.Fatalf("expected %d instruments, got %d", len(expected), len(infos))
+	}
+
+	for _, e := range expected {
+		ii, ok := infos[e.Key]
+		if !ok {
+			t.Errorf("%s: not found", e.Key)
+			continue
+		}
+
+		if *ii != e {
+			t.Errorf("%s: expected %+v, got %+v", e.Key, e, *ii)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package discovery
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"euronext/euronext"
+)
+
+// DefaultDelistAfterDays is the number of days a suspended instrument stays missing
+// from the discovery before it is considered delisted.
+const DefaultDelistAfterDays = 30
+
+// DetectLifecycle compares the instruments of the previous snapshot with the discovered ones
+// and returns the lifecycle status updates sorted by MIC and ISIN.
+//
+// A missing instrument is migrated if its ISIN is discovered on another MIC, otherwise it becomes suspended
+// and then delisted after delistAfterDays days. A rediscovered instrument becomes active again.
+// Instruments of a MIC and type without any discovered instrument are left as is,
+// since the download of their category has most likely failed.
+func DetectLifecycle(
+	instruments []euronext.XmlInstrument,
+	discovered map[string]*InstrumentInfo,
+	now time.Time,
+	delistAfterDays int,
+) []euronext.LifecycleUpdate {
+	if delistAfterDays < 1 {
+		delistAfterDays = DefaultDelistAfterDays
+	}
+
+	present := map[string]bool{}
+	covered := map[string]bool{}
+	micsOfIsin := map[string][]string{}
+	for _, ai := range discovered {
+		mic, isin := strings.ToUpper(ai.Mic), strings.ToUpper(ai.Isin)
+		present[isin+"-"+mic] = true
+		covered[mic+"-"+strings.ToLower(ai.Type)] = true
+		micsOfIsin[isin] = append(micsOfIsin[isin], mic)
+	}
+
+	date := now.Format("2006-01-02")
+	updates := []euronext.LifecycleUpdate{}
+	for i := range instruments {
+		ins := &instruments[i]
+		mic, isin := strings.ToUpper(ins.Mic), strings.ToUpper(ins.Isin)
+		old := ins.LifecycleStatus()
+		u := euronext.LifecycleUpdate{Mic: ins.Mic, Isin: ins.Isin, Symbol: ins.Symbol, Old: old}
+
+		if present[isin+"-"+mic] {
+			if old != euronext.StatusActive {
+				u.New = euronext.StatusActive
+				updates = append(updates, u)
+			}
+			continue
+		}
+
+		if !covered[mic+"-"+strings.ToLower(ins.Type)] {
+			continue
+		}
+
+		since := date
+		if ins.StatusDate != nil && *ins.StatusDate != "" && old != euronext.StatusActive {
+			since = *ins.StatusDate
+		}
+
+		if to := migratedTo(micsOfIsin[isin], mic); to != "" {
+			if old != euronext.StatusMigrated || ins.MigratedTo == nil || !strings.EqualFold(*ins.MigratedTo, to) {
+				u.New, u.Date, u.MigratedTo = euronext.StatusMigrated, since, to
+				updates = append(updates, u)
+			}
+			continue
+		}
+
+		switch old {
+		case euronext.StatusActive, euronext.StatusMigrated:
+			u.New, u.Date = euronext.StatusSuspended, date
+			updates = append(updates, u)
+		case euronext.StatusSuspended:
+			if s, err := time.Parse("2006-01-02", since); err == nil && now.Sub(s) >= time.Duration(delistAfterDays)*24*time.Hour {
+				u.New, u.Date = euronext.StatusDelisted, since
+				updates = append(updates, u)
+			}
+		}
+	}
+
+	sort.SliceStable(updates, func(i, j int) bool {
+		if updates[i].Mic != updates[j].Mic {
+			return updates[i].Mic < updates[j].Mic
+		}
+
+		return updates[i].Isin < updates[j].Isin
+	})
+
+	return updates
+}
+
+// migratedTo returns the first other MIC in alphabetical order, or an empty string.
+func migratedTo(mics []string, mic string) string {
+	sort.Strings(mics)
+	for _, m := range mics {
+		if m != mic {
+			return m
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,55 @@
+package discovery
+
+import (
+	"testing"
+	"time"
+
+	"euronext/euronext"
+)
+
+func TestDetectLifecycle(t *testing.T) {
+	t.Parallel()
+
+	status := func(s, date string) (*string, *string) {
+		return &s, &date
+	}
+
+	instruments := []euronext.XmlInstrument{
+		{Mic: "XPAR", Isin: "FR0000120073", Symbol: "AI", Type: "stock"},
+		{Mic: "XPAR", Isin: "FR0000121014", Symbol: "MC", Type: "stock"},
+		{Mic: "XPAR", Isin: "FR0000131104", Symbol: "BNP", Type: "stock"},
+		{Mic: "XPAR", Isin: "FR0000130809", Symbol: "GLE", Type: "stock"},
+		{Mic: "XPAR", Isin: "FR0000120271", Symbol: "TTE", Type: "stock"},
+		{Mic: "XPAR", Isin: "FR0000120578", Symbol: "SAN", Type: "stock"},
+		{Mic: "XAMS", Isin: "NL0000009082", Symbol: "KPN", Type: "stock"},
+	}
+	instruments[2].Status, instruments[2].StatusDate = status(euronext.StatusSuspended, "2024-05-01")
+	instruments[3].Status, instruments[3].StatusDate = status(euronext.StatusSuspended, "2024-06-01")
+	instruments[4].Status, instruments[4].StatusDate = status(euronext.StatusDelisted, "2024-01-01")
+
+	discovered := map[string]*InstrumentInfo{
+		"XPAR_AI_FR0000120073":  {Mic: "XPAR", Isin: "FR0000120073", Symbol: "AI", Type: "stock"},
+		"ALXP_MC_FR0000121014":  {Mic: "ALXP", Isin: "FR0000121014", Symbol: "MC", Type: "stock"},
+		"XPAR_TTE_FR0000120271": {Mic: "XPAR", Isin: "FR0000120271", Symbol: "TTE", Type: "stock"},
+	}
+
+	now := time.Date(2024, 6, 4, 0, 0, 0, 0, time.UTC)
+	updates := DetectLifecycle(instruments, discovered, now, 30)
+
+	// GLE is suspended for less than 30 days, KPN is left as is since no XAMS stock is discovered.
+	expected := []string{
+		"XPAR TTE FR0000120271: delisted -> active",
+		"XPAR SAN FR0000120578: active -> suspended since 2024-06-04",
+		"XPAR MC FR0000121014: active -> migrated ALXP since 2024-06-04",
+		"XPAR BNP FR0000131104: suspended -> delisted since 2024-05-01",
+	}
+	if len(updates) != len(expected) {
+		t.Fatalf("expected updates %q, got %v", expected, updates)
+	}
+
+	for i, s := range expected {
+		if updates[i].String() != s {
+			t.Errorf("update %d: expected %q, got %q", i, s, updates[i].String())
+		}
+	}
+}
@@ -2,11 +2,11 @@ package endofday
 
 import (
 	"fmt"
-	"io"
 	"log"
-	"net/http"
 	"strings"
 	"time"
+
+	"euronext/euronext/fetch"
 )
 
 func get(
@@ -20,37 +20,19 @@ func get(
 		log.Println(uri)
 	}
 
-	req, err := http.NewRequest("GET", uri, nil)
-	if err != nil {
-		return nil, fmt.Errorf("cannot create request: %w", err)
-	}
-
-	// req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	req.Header.Set("User-Agent", userAgent)
-	req.Header.Set("Referer", referer)
-	req.Header.Set("Accept-Language", "en-us,en;q=0.5")
-	req.Header.Set("Accept-Charset", "ISO-8859-1,utf-8;q=0.7,*;q=0.7")
-	req.Header.Set("X-Requested-With", "XMLHttpRequest")
-	req.Header.Set("Accept", "application/json, text/javascript, */*")
-
-	// Create HTTP client with timeout and proxy settings
-	transport := &http.Transport{
-		Proxy: http.ProxyFromEnvironment, // Uses system proxy settings
+	req := &fetch.Request{
+		Method:  "GET",
+		URL:     uri,
+		Header:  fetch.Ajax(referer, userAgent),
+		Timeout: timeout,
 	}
-	httpClient := http.Client{Timeout: timeout, Transport: transport}
 
-	resp, err := httpClient.Do(req)
+	resp, err := fetch.Default.Fetch(req)
 	if err != nil {
 		return nil, fmt.Errorf("download failed %s: %w", uri, err)
 	}
-	defer resp.Body.Close()
 
-	contents, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("cannot read response body %s: %w", uri, err)
-	}
-
-	return contents, nil
+	return resp.Body, nil
 }
 
 func getWithRetries(
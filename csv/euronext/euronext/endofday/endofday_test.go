@@ -0,0 +1,43 @@
+package endofday
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"euronext/euronext/fetch"
+)
+
+func TestFetchEndofdayDataReplay(t *testing.T) {
+	rep, err := fetch.NewReplayer("testdata/fixtures")
+	if err != nil {
+		t.Fatalf("cannot load fixtures: %v", err)
+	}
+	defer fetch.Replace(rep)()
+
+	pauses := []time.Duration{0, 0}
+	for _, adjusted := range []bool{true, false} {
+		bs, err := FetchEndofdayData("fr0000120073", "xpar", "ai", "stock", time.Second, pauses, "agent", false, adjusted)
+		if err != nil {
+			t.Fatalf("adjusted %v: unexpected error: %v", adjusted, err)
+		}
+
+		lines := strings.Split(strings.TrimSpace(string(bs)), "\n")
+		if len(lines) != 6 || !strings.HasPrefix(lines[3], "Date;Open;") {
+			t.Errorf("adjusted %v: unexpected content %q", adjusted, bs)
+		}
+
+		close := "197.12"
+		if adjusted {
+			close = "179.2"
+		}
+
+		if parts := strings.Split(lines[4], ";"); parts[5] != close {
+			t.Errorf("adjusted %v: expected close %s, got %s", adjusted, close, parts[5])
+		}
+	}
+
+	if _, err := FetchEndofdayData("nl0000009082", "xams", "kpn", "stock", time.Second, pauses, "agent", false, true); err == nil {
+		t.Errorf("expected an error for an instrument without fixtures")
+	}
+}
@@ -0,0 +1,221 @@
+package enrichment
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"euronext/euronext"
+	"euronext/euronext/fetch"
+)
+
+func TestEnrichStockInstrumentReplay(t *testing.T) {
+	rep, err := fetch.NewReplayer("testdata/fixtures")
+	if err != nil {
+		t.Fatalf("cannot load fixtures: %v", err)
+	}
+	defer fetch.Replace(rep)()
+
+	instrument := &euronext.XmlInstrument{Isin: "NL0000336543", Mic: "XAMS", Type: "stock"}
+	provenance := EnrichInstrument(instrument, 2, 1, 0, false, "agent")
+
+	stock := instrument.Stock
+	if stock == nil || stock.Icb == nil {
+		t.Fatalf("stock element is not created")
+	}
+
+	if stock.Cfi != "ESVUFR" {
+		t.Errorf("expected cfi ESVUFR, got %s", stock.Cfi)
+	}
+
+	if stock.Currency != "EUR" || stock.TradingMode != "continuous" || stock.Shares != "1,431,522,482" || stock.Compartment != "B" {
+		t.Errorf("unexpected trading info %+v", stock)
+	}
+
+	icb := stock.Icb
+	if icb.Icb1 != "50" || icb.Icb2 != "5010" || icb.Icb3 != "501010" || icb.Icb4 != "50101010" {
+		t.Errorf("unexpected icb %+v", icb)
+	}
+
+	// The detailed quote fixture has a bad status.
+	if instrument.Name != "" {
+		t.Errorf("expected no name, got %s", instrument.Name)
+	}
+
+	if len(provenance) != 9 {
+		t.Fatalf("expected 9 enriched fields, got %v", provenance)
+	}
+
+	p := provenance[1]
+	if p.Field != "stock.icb.icb1" || p.Value != "50" || p.Block != "fs_icb_block" || p.Fetched.IsZero() ||
+		p.URL != "https://live.euronext.com/en/ajax/getFactsheetInfoBlock/STOCK/NL0000336543-XAMS/fs_icb_block" {
+		t.Errorf("unexpected provenance %+v", p)
+	}
+}
+
+func TestEnrichFundInstrumentReplay(t *testing.T) {
+	rep, err := fetch.NewReplayer("testdata/fixtures")
+	if err != nil {
+		t.Fatalf("cannot load fixtures: %v", err)
+	}
+	defer fetch.Replace(rep)()
+
+	instrument := &euronext.XmlInstrument{Isin: "LU2264552998", Mic: "ATFX", Type: "fund"}
+	provenance := Enrich(instrument, Options{Retries: 1, Timeout: time.Second, UserAgent: "agent"})
+
+	fund := instrument.Fund
+	if fund == nil {
+		t.Fatalf("fund element is not created")
+	}
+
+	// The shares outstanding are "-".
+	if fund.Cfi != "EUOISB" || fund.Issuer != "VARENNE UCITS" || fund.Currency != "EUR" || fund.TradingMode != "fixing" || fund.Shares != "" {
+		t.Errorf("unexpected fund %+v", fund)
+	}
+
+	if instrument.Name != "VARENNE VALEUR A" {
+		t.Errorf("unexpected name %q", instrument.Name)
+	}
+
+	fields := []string{"fund.cfi", "fund.issuer", "fund.currency", "fund.tradingMode", "name"}
+	if len(provenance) != len(fields) {
+		t.Fatalf("expected fields %v, got %v", fields, provenance)
+	}
+
+	for i, f := range fields {
+		if provenance[i].Field != f {
+			t.Errorf("provenance %d: expected field %s, got %s", i, f, provenance[i].Field)
+		}
+	}
+}
+
+func TestEnrichEtfInstrumentReplay(t *testing.T) {
+	rep, err := fetch.NewReplayer("testdata/fixtures")
+	if err != nil {
+		t.Fatalf("cannot load fixtures: %v", err)
+	}
+	defer fetch.Replace(rep)()
+
+	instrument := &euronext.XmlInstrument{Isin: "FR0010754135", Mic: "XPAR", Type: "etf"}
+	provenance := Enrich(instrument, Options{Retries: 1, Timeout: time.Second, UserAgent: "agent"})
+
+	etf := instrument.Etf
+	if etf == nil {
+		t.Fatalf("etf element is not created")
+	}
+
+	if etf.Cfi != "EUOM" || etf.Issuer != "AMUNDI" || etf.LaunchDate != "20100316" || etf.DividendFrequency != "annually" {
+		t.Errorf("unexpected general info %+v", etf)
+	}
+
+	if etf.Currency != "EUR" || etf.TradingMode != "continuous" || etf.ExpositionType != "synthetic" || etf.Shares != "1,250,000" || etf.Ter != "0.14%" {
+		t.Errorf("unexpected trading info %+v", etf)
+	}
+
+	if etf.Inav.Symbol != "INC13" || etf.Inav.Name != "AMUNDI C13 INAV" || etf.Inav.Isin != "QS0011161377" {
+		t.Errorf("unexpected inav %+v", etf.Inav)
+	}
+
+	if etf.Underlying.Name != "EuroMTS Eurozone Government Broad 1-3" || etf.Underlying.Symbol != "EMTSAR" {
+		t.Errorf("unexpected underlying %+v", etf.Underlying)
+	}
+
+	if instrument.Description == nil || *instrument.Description != "AMUNDI EURO GOVERNMENT BOND 1-3Y UCITS ETF" {
+		t.Errorf("unexpected description %v", instrument.Description)
+	}
+
+	if instrument.Name != "AMUNDI ETF EMTS1-3" {
+		t.Errorf("unexpected name %q", instrument.Name)
+	}
+
+	if len(provenance) != 16 {
+		t.Fatalf("expected 16 enriched fields, got %v", provenance)
+	}
+
+	p := provenance[len(provenance)-2]
+	if p.Field != "etf.ter" || p.Block != "fs_feessegmentation_block" ||
+		p.URL != "https://live.euronext.com/en/ajax/getFactsheetInfoBlock/TRACK/FR0010754135-XPAR/fs_feessegmentation_block" {
+		t.Errorf("unexpected provenance %+v", p)
+	}
+}
+
+func TestEnrichEtvInstrumentReplay(t *testing.T) {
+	rep, err := fetch.NewReplayer("testdata/fixtures")
+	if err != nil {
+		t.Fatalf("cannot load fixtures: %v", err)
+	}
+	defer fetch.Replace(rep)()
+
+	instrument := &euronext.XmlInstrument{Isin: "GB00B15KXP72", Mic: "XPAR", Type: "etv", Name: "ETFS COFFEE"}
+	provenance := Enrich(instrument, Options{Retries: 1, Timeout: time.Second, UserAgent: "agent"})
+
+	etv := instrument.Etv
+	if etv == nil {
+		t.Fatalf("etv element is not created")
+	}
+
+	// The French issuer label, the launch date and the expense ratio are "-".
+	if etv.Cfi != "DTZSPR" || etv.Issuer != "ETFS COMMODITY SECURITIES LTD" || etv.LaunchDate != "" || etv.DividendFrequency != "yearly" {
+		t.Errorf("unexpected general info %+v", etv)
+	}
+
+	if etv.Currency != "USD" || etv.TradingMode != "continuous" || etv.Shares != "944,000" || etv.AllInFees != "0,49%" || etv.ExpenseRatio != "" {
+		t.Errorf("unexpected trading info %+v", etv)
+	}
+
+	// The name is known, the detailed quote is not fetched.
+	fields := []string{"etv.cfi", "etv.issuer", "etv.dividendFrequency", "etv.currency", "etv.tradingMode", "etv.shares", "etv.allInFees"}
+	if len(provenance) != len(fields) {
+		t.Fatalf("expected fields %v, got %v", fields, provenance)
+	}
+
+	for i, f := range fields {
+		if provenance[i].Field != f {
+			t.Errorf("provenance %d: expected field %s, got %s", i, f, provenance[i].Field)
+		}
+	}
+}
+
+func TestEnrichInavInstrumentReplay(t *testing.T) {
+	rep, err := fetch.NewReplayer("testdata/fixtures")
+	if err != nil {
+		t.Fatalf("cannot load fixtures: %v", err)
+	}
+	defer fetch.Replace(rep)()
+
+	instrument := &euronext.XmlInstrument{Isin: "QS0011161385", Mic: "XPAR", Type: "inav"}
+	provenance := Enrich(instrument, Options{Retries: 1, Timeout: time.Second, UserAgent: "agent"})
+
+	if instrument.Inav == nil || len(instrument.Inav.Target) != 1 {
+		t.Fatalf("inav element is not created")
+	}
+
+	if instrument.Name != "AMUNDI C33 INAV" {
+		t.Errorf("unexpected name %q", instrument.Name)
+	}
+
+	if len(provenance) != 1 || provenance[0].Field != "name" || provenance[0].Block != detailedQuoteBlock ||
+		provenance[0].URL != "https://live.euronext.com/en/ajax/getDetailedQuote/QS0011161385-XPAR" {
+		t.Errorf("unexpected provenance %v", provenance)
+	}
+}
+
+func TestLookup(t *testing.T) {
+	t.Parallel()
+
+	for _, typ := range []string{"stock", "ETF", "etv", "fund", "inav", "index"} {
+		if e := Lookup(typ); e == nil || e.Type() != strings.ToLower(typ) {
+			t.Errorf("%s: unexpected enricher %v", typ, e)
+		}
+	}
+
+	if e := Lookup("bond"); e != nil {
+		t.Errorf("expected no enricher for bond, got %v", e)
+	}
+
+	// The enrichers without blocks only create the type element.
+	instrument := &euronext.XmlInstrument{Isin: "FR0014002B31", Mic: "XPAR", Name: "CAC", Type: "index"}
+	if p := Enrich(instrument, Options{}); len(p) != 0 || instrument.Index == nil || instrument.Index.Icb == nil {
+		t.Errorf("unexpected index enrichment %v %+v", p, instrument.Index)
+	}
+}
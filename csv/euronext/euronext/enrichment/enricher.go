@@ -0,0 +1,203 @@
+package enrichment
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"euronext/euronext"
+)
+
+// Options are the download options of the enrichers.
+type Options struct {
+	Retries          int
+	Timeout          time.Duration
+	PauseBeforeRetry time.Duration
+	Verbose          bool
+	UserAgent        string
+}
+
+// Provenance tells where the value of an enriched field comes from.
+type Provenance struct {
+	// Field is the dotted json path of the field, e.g. "stock.icb.icb1".
+	Field   string    `json:"field"`
+	Value   string    `json:"value"`
+	Block   string    `json:"block"`
+	URL     string    `json:"url"`
+	Fetched time.Time `json:"fetched"`
+}
+
+// String implements the fmt.Stringer interface.
+func (p Provenance) String() string {
+	return fmt.Sprintf("%s = '%s' from %s at %s", p.Field, p.Value, p.Block, p.Fetched.Format(time.RFC3339))
+}
+
+// Enricher enriches the instruments of a type with the metadata downloaded from Euronext.
+type Enricher interface {
+	// Type returns the instrument type, e.g. "stock".
+	Type() string
+
+	// Enrich sets the fields of the instrument in place and returns their provenance.
+	Enrich(instrument *euronext.XmlInstrument, opts Options) []Provenance
+}
+
+var enrichers = map[string]Enricher{}
+
+// Register registers the enricher of an instrument type, replacing the registered one.
+func Register(e Enricher) {
+	enrichers[strings.ToLower(e.Type())] = e
+}
+
+// Lookup returns the enricher of an instrument type, or nil.
+func Lookup(typ string) Enricher {
+	return enrichers[strings.ToLower(typ)]
+}
+
+// Types returns the sorted instrument types with a registered enricher.
+func Types() []string {
+	types := make([]string, 0, len(enrichers))
+	for t := range enrichers {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+
+	return types
+}
+
+// Enrich enriches the instrument with the enricher of its type and returns the provenance of the set fields.
+// An instrument without a registered enricher is left as is.
+func Enrich(instrument *euronext.XmlInstrument, opts Options) []Provenance {
+	if instrument == nil {
+		return nil
+	}
+
+	e := Lookup(instrument.Type)
+	if e == nil {
+		log.Printf("no enricher for instrument type '%s'\n", instrument.Type)
+		return nil
+	}
+
+	return e.Enrich(instrument, opts)
+}
+
+// EnrichInstrument enriches the instrument with the enricher of its type.
+func EnrichInstrument(
+	instrument *euronext.XmlInstrument,
+	retries int,
+	timeoutSec int,
+	pauseBeforeRetrySec int,
+	verbose bool,
+	userAgent string,
+) []Provenance {
+	return Enrich(instrument, Options{
+		Retries:          retries,
+		Timeout:          time.Duration(timeoutSec) * time.Second,
+		PauseBeforeRetry: time.Duration(pauseBeforeRetrySec) * time.Second,
+		Verbose:          verbose,
+		UserAgent:        userAgent,
+	})
+}
+
+// detailedQuoteBlock is the name of the detailed quote pseudo-block.
+const detailedQuoteBlock = "detailed_quote"
+
+// field is an enriched field of a factsheet block.
+type field struct {
+	// path is the dotted json path of the field.
+	path string
+
+	// block is the factsheet block name.
+	block string
+
+	// value returns the field value from the block, an empty value leaves the field as is.
+	value func(b *Block) string
+
+	// set sets the field value.
+	set func(ins *euronext.XmlInstrument, v string)
+
+	// needed tells if the field should be enriched, nil means always.
+	needed func(ins *euronext.XmlInstrument) bool
+}
+
+// factsheetEnricher enriches the fields of an instrument type from the Euronext factsheet blocks,
+// e.g. https://live.euronext.com/en/ajax/getFactsheetInfoBlock/STOCK/NL0000336543-XAMS/fs_cfi_block.
+type factsheetEnricher struct {
+	typ string
+
+	// product is the factsheet product, e.g. STOCK.
+	product string
+
+	// page is the product page of the referer, e.g. equities.
+	page string
+
+	// init creates the missing type element of the instrument.
+	init func(ins *euronext.XmlInstrument)
+
+	fields []field
+}
+
+// Type implements the Enricher interface.
+func (e *factsheetEnricher) Type() string {
+	return e.typ
+}
+
+// Enrich implements the Enricher interface.
+func (e *factsheetEnricher) Enrich(instrument *euronext.XmlInstrument, opts Options) []Provenance {
+	if instrument == nil {
+		return nil
+	}
+
+	if e.init != nil {
+		e.init(instrument)
+	}
+
+	// The blocks are downloaded once in the order of their first field.
+	blocks := map[string]*Block{}
+	provenance := []Provenance{}
+	for _, f := range e.fields {
+		if f.needed != nil && !f.needed(instrument) {
+			continue
+		}
+
+		b, ok := blocks[f.block]
+		if !ok {
+			b = e.fetchBlock(f.block, instrument.Isin, instrument.Mic, opts)
+			blocks[f.block] = b
+		}
+
+		if b == nil {
+			continue
+		}
+
+		if v := f.value(b); v != "" {
+			f.set(instrument, v)
+			provenance = append(provenance, Provenance{Field: f.path, Value: v, Block: b.Name, URL: b.URL, Fetched: b.Fetched})
+		}
+	}
+
+	return provenance
+}
+
+// fetchBlock downloads and parses a factsheet block, it returns nil if nothing is downloaded.
+func (e *factsheetEnricher) fetchBlock(name, isin, mic string, opts Options) *Block {
+	referer := fmt.Sprintf("https://live.euronext.com/en/product/%s/%s-%s", e.page, isin, mic)
+	uri := fmt.Sprintf("https://live.euronext.com/en/ajax/getFactsheetInfoBlock/%s/%s-%s/%s", e.product, isin, mic, name)
+	if name == detailedQuoteBlock {
+		uri = fmt.Sprintf("https://live.euronext.com/en/ajax/getDetailedQuote/%s-%s", isin, mic)
+	}
+
+	str := downloadTextString(name, uri, opts.Retries, opts.Timeout, opts.PauseBeforeRetry, referer, opts.Verbose, opts.UserAgent)
+	if str == "" {
+		log.Printf("no %s block fetched\n", name)
+		return nil
+	}
+
+	b, err := ParseBlock(name, uri, []byte(str), time.Now())
+	if err != nil {
+		log.Printf("cannot parse %s block, using the texts parsed so far: %v\n", name, err)
+	}
+
+	return b
+}
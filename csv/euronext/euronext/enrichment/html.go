@@ -0,0 +1,137 @@
+package enrichment
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// segment is a non-blank text of an HTML element.
+type segment struct {
+	tag  string
+	text string
+}
+
+// Block is a parsed Euronext factsheet block, e.g. fs_icb_block.
+//
+// The block is kept as the non-blank texts of its elements in document order,
+// which is enough for the label/value tables and spans of the factsheet blocks:
+//
+//	<tr><td>Trading currency</td><td><strong>EUR</strong></td></tr>
+//	<span>Issuer name : </span> <span><strong>VARENNE UCITS</strong></span>
+type Block struct {
+	// Name is the block name, e.g. fs_icb_block.
+	Name string
+
+	// URL is the url the block is fetched from.
+	URL string
+
+	// Fetched is the time the block is fetched.
+	Fetched time.Time
+
+	segments []segment
+}
+
+// scriptRegexp matches the script and style elements, their content is not well-formed.
+var scriptRegexp = regexp.MustCompile(`(?is)<script\b.*?</script>|<style\b.*?</style>`)
+
+// ParseBlock parses the HTML of a factsheet block.
+//
+// The HTML is parsed leniently with the HTML entities and the auto-closed void elements,
+// the script and style elements are dropped.
+// The returned block has the texts parsed before an error, so it is usable even with the error.
+func ParseBlock(name, url string, html []byte, fetched time.Time) (*Block, error) {
+	b := &Block{Name: name, URL: url, Fetched: fetched}
+
+	html = scriptRegexp.ReplaceAll(html, nil)
+	d := xml.NewDecoder(strings.NewReader("<html>" + string(html) + "</html>"))
+	d.Strict = false
+	d.AutoClose = xml.HTMLAutoClose
+	d.Entity = xml.HTMLEntity
+
+	tags := []string{}
+	for {
+		tok, err := d.Token()
+		if errors.Is(err, io.EOF) {
+			return b, nil
+		}
+
+		if err != nil {
+			return b, err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			tags = append(tags, strings.ToLower(t.Name.Local))
+		case xml.EndElement:
+			if len(tags) > 0 {
+				tags = tags[:len(tags)-1]
+			}
+		case xml.CharData:
+			tag := ""
+			if len(tags) > 0 {
+				tag = tags[len(tags)-1]
+			}
+
+			if s := strings.Join(strings.Fields(string(t)), " "); s != "" {
+				b.segments = append(b.segments, segment{tag: tag, text: s})
+			}
+		}
+	}
+}
+
+// normalizeLabel lower-cases the label and drops the trailing colon.
+func normalizeLabel(s string) string {
+	return strings.ToLower(strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(s), ":")))
+}
+
+// valueOf returns the text, "-" means no value.
+func valueOf(s string) string {
+	if s == "-" {
+		return ""
+	}
+
+	return s
+}
+
+// Value returns the text following the first found label, the labels are tried in order.
+// The labels are matched case-insensitively, ignoring a trailing colon.
+func (b *Block) Value(labels ...string) string {
+	for _, label := range labels {
+		l := normalizeLabel(label)
+		for i := 0; i < len(b.segments)-1; i++ {
+			if normalizeLabel(b.segments[i].text) == l {
+				if v := valueOf(b.segments[i+1].text); v != "" {
+					return v
+				}
+			}
+		}
+	}
+
+	return ""
+}
+
+// Prefixed returns the rest of the first text starting with the prefix, e.g. "CFI:ESVUFR".
+func (b *Block) Prefixed(prefix string) string {
+	for _, s := range b.segments {
+		if strings.HasPrefix(s.text, prefix) {
+			return valueOf(strings.TrimSpace(s.text[len(prefix):]))
+		}
+	}
+
+	return ""
+}
+
+// First returns the first text of an element with the tag, e.g. "strong".
+func (b *Block) First(tag string) string {
+	for _, s := range b.segments {
+		if s.tag == tag {
+			return valueOf(s.text)
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,80 @@
+package enrichment
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseBlock(t *testing.T) {
+	t.Parallel()
+
+	html := `<div class="table-responsive">
+<table class="table">
+<tr><td>CFI:esvufr</td></tr>
+<tr>
+  <td>Trading type</td>
+  <td><strong>Continous</strong><br></td>
+</tr>
+<tr><td>Shares outstanding</td><td><strong>-</strong></td></tr>
+<tr><td>Admitted shares</td><td><strong>1,431,522,482</strong></td></tr>
+<tr><td>Nom de l'&eacute;metteur</td><td><strong>HSBC&nbsp;GLOBAL FUNDS ICAV</strong></td></tr>
+</table>
+<script>var x = 1 < 2;</script>
+<span class="issuerName-column-left">Issuer name : </span> <span><strong>VARENNE UCITS</strong></span>
+</div>`
+
+	fetched := time.Date(2024, 6, 4, 10, 0, 0, 0, time.UTC)
+	b, err := ParseBlock("fs_test_block", "https://example.com/b", []byte(html), fetched)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if b.Name != "fs_test_block" || b.URL != "https://example.com/b" || !b.Fetched.Equal(fetched) {
+		t.Errorf("unexpected block %+v", b)
+	}
+
+	tests := []struct {
+		got      string
+		expected string
+	}{
+		{b.Prefixed("CFI:"), "esvufr"},
+		{b.Value("trading type"), "Continous"},
+		{tradingMode(b), "continuous"},
+		{b.Value("Shares outstanding"), ""},
+		{b.Value("Shares outstanding", "Admitted shares"), "1,431,522,482"},
+		{b.Value("Nom de l'émetteur"), "HSBC GLOBAL FUNDS ICAV"},
+		{b.Value("Issuer name"), "VARENNE UCITS"},
+		{b.Value("Missing"), ""},
+		{b.First("strong"), "Continous"},
+		{b.First("h1"), ""},
+	}
+
+	for i, tt := range tests {
+		if tt.got != tt.expected {
+			t.Errorf("%d: expected %q, got %q", i, tt.expected, tt.got)
+		}
+	}
+}
+
+func TestBlockHelpers(t *testing.T) {
+	t.Parallel()
+
+	html := `<table>
+<tr><td>Industry</td><td><strong>2000, Industrials</strong></td></tr>
+<tr><td>Sector</td><td><strong>-</strong></td></tr>
+</table>
+<div><strong>Eligible PEA</strong><strong>Compartment A (Large Cap)</strong></div>`
+
+	b, _ := ParseBlock("fs_icb_block", "", []byte(html), time.Time{})
+	if v := icbCode("Industry")(b); v != "2000" {
+		t.Errorf("expected icb 2000, got %q", v)
+	}
+
+	if v := icbCode("Sector")(b); v != "" {
+		t.Errorf("expected no sector, got %q", v)
+	}
+
+	if v := compartment(b); v != "A" {
+		t.Errorf("expected compartment A, got %q", v)
+	}
+}
@@ -0,0 +1,281 @@
+package enrichment
+
+import (
+	"strings"
+
+	"euronext/euronext"
+)
+
+func init() {
+	for _, e := range []Enricher{stockEnricher, etvEnricher, etfEnricher, fundEnricher, inavEnricher, indexEnricher} {
+		Register(e)
+	}
+}
+
+// labeled returns the transformed value following the first found label.
+func labeled(transform func(string) string, labels ...string) func(b *Block) string {
+	return func(b *Block) string {
+		return transform(b.Value(labels...))
+	}
+}
+
+func asIs(s string) string {
+	return s
+}
+
+// cfi returns the CFI code of the fs_cfi_block, e.g. <tr><td>CFI:CI</td></tr>.
+func cfi(b *Block) string {
+	return strings.ToUpper(b.Prefixed("CFI:"))
+}
+
+// icbCode returns the code of an ICB classification, e.g. <td><strong>2000, Industrials</strong></td>.
+func icbCode(label string) func(b *Block) string {
+	return func(b *Block) string {
+		v := b.Value(label)
+		if i := strings.Index(v, ","); i > 0 {
+			v = strings.TrimSpace(v[:i])
+		}
+
+		return valueOf(v)
+	}
+}
+
+// tradingMode returns the lower-case trading type with the Euronext typo fixed.
+func tradingMode(b *Block) string {
+	v := strings.ToLower(b.Value("Trading type"))
+	if v == "continous" {
+		v = "continuous"
+	}
+
+	return v
+}
+
+// compartment returns the compartment letter of the fs_tradinginfo_pea_block,
+// e.g. <strong>Compartment A (Large Cap)</strong>.
+func compartment(b *Block) string {
+	for _, s := range b.segments {
+		if s.tag != "strong" {
+			continue
+		}
+
+		for _, c := range []string{"A", "B", "C"} {
+			if strings.HasPrefix(s.text, "Compartment "+c+" ") {
+				return c
+			}
+		}
+	}
+
+	return ""
+}
+
+// nameField sets the missing instrument name from the detailed quote, e.g. <strong>BALLAST NEDAM</strong>.
+var nameField = field{
+	path:   "name",
+	block:  detailedQuoteBlock,
+	value:  func(b *Block) string { return b.First("strong") },
+	set:    func(ins *euronext.XmlInstrument, v string) { ins.Name = v },
+	needed: func(ins *euronext.XmlInstrument) bool { return ins.Name == "" },
+}
+
+// descriptionField sets the description from the legal name of the fs_generalinfo_block.
+var descriptionField = field{
+	path:  "description",
+	block: "fs_generalinfo_block",
+	value: labeled(strings.ToUpper, "ETF Legal Name"),
+	set:   func(ins *euronext.XmlInstrument, v string) { ins.Description = &v },
+}
+
+// issuerLabels are the labels of the issuer in the order of preference.
+var issuerLabels = []string{"Nom de l'émetteur", "Issuer Name", "Fund Manager"}
+
+// stockEnricher enriches the stock element.
+//
+//	<instrument vendor="Euronext" mep="AMS" isin="NL0000336543" symbol="BALNE" name="BALLAST NEDAM" type="stock" mic="XAMS">
+//	  <stock cfi="ES" compartment="B" tradingMode="continuous" currency="EUR" shares="1,431,522,482">
+//	    <icb icb1="2000" icb2="2300" icb3="2350" icb4="2357"/>
+//	  </stock>
+//	</instrument>
+var stockEnricher = &factsheetEnricher{
+	typ:     euronext.XmlInstrumentStockType,
+	product: "STOCK",
+	page:    "equities",
+	init: func(ins *euronext.XmlInstrument) {
+		if ins.Stock == nil {
+			ins.Stock = &euronext.XmlStock{Currency: "EUR"}
+		}
+
+		if ins.Stock.Icb == nil {
+			ins.Stock.Icb = &euronext.XmlIcb{}
+		}
+	},
+	fields: []field{
+		{path: "stock.cfi", block: "fs_cfi_block", value: cfi,
+			set: func(ins *euronext.XmlInstrument, v string) { ins.Stock.Cfi = v }},
+		{path: "stock.icb.icb1", block: "fs_icb_block", value: icbCode("Industry"),
+			set: func(ins *euronext.XmlInstrument, v string) { ins.Stock.Icb.Icb1 = v }},
+		{path: "stock.icb.icb2", block: "fs_icb_block", value: icbCode("SuperSector"),
+			set: func(ins *euronext.XmlInstrument, v string) { ins.Stock.Icb.Icb2 = v }},
+		{path: "stock.icb.icb3", block: "fs_icb_block", value: icbCode("Sector"),
+			set: func(ins *euronext.XmlInstrument, v string) { ins.Stock.Icb.Icb3 = v }},
+		{path: "stock.icb.icb4", block: "fs_icb_block", value: icbCode("Subsector"),
+			set: func(ins *euronext.XmlInstrument, v string) { ins.Stock.Icb.Icb4 = v }},
+		{path: "stock.currency", block: "fs_tradinginfo_block", value: labeled(strings.ToUpper, "Trading currency"),
+			set: func(ins *euronext.XmlInstrument, v string) { ins.Stock.Currency = v }},
+		{path: "stock.tradingMode", block: "fs_tradinginfo_block", value: tradingMode,
+			set: func(ins *euronext.XmlInstrument, v string) { ins.Stock.TradingMode = v }},
+		{path: "stock.shares", block: "fs_tradinginfo_block", value: labeled(strings.ToLower, "Shares outstanding", "Admitted shares"),
+			set: func(ins *euronext.XmlInstrument, v string) { ins.Stock.Shares = v }},
+		{path: "stock.compartment", block: "fs_tradinginfo_pea_block", value: compartment,
+			set: func(ins *euronext.XmlInstrument, v string) { ins.Stock.Compartment = v }},
+		nameField,
+	},
+}
+
+// etvEnricher enriches the etv element.
+//
+//	<instrument vendor="Euronext" mep="PAR" mic="XPAR" isin="GB00B15KXP72" symbol="COFFP" name="ETFS COFFEE" type="etv">
+//	  <etv cfi="DTZSPR" tradingMode="continuous" allInFees="0,49%" expenseRatio="" dividendFrequency="yearly" currency="EUR" issuer="ETFS COMMODITY SECURITIES LTD" shares="944,000">
+//	</instrument>
+var etvEnricher = &factsheetEnricher{
+	typ:     euronext.XmlInstrumentEtvType,
+	product: "TRACK",
+	page:    "etvs",
+	init: func(ins *euronext.XmlInstrument) {
+		if ins.Etv == nil {
+			ins.Etv = &euronext.XmlEtv{Currency: "EUR"}
+		}
+	},
+	fields: []field{
+		{path: "etv.cfi", block: "fs_cfi_block", value: cfi,
+			set: func(ins *euronext.XmlInstrument, v string) { ins.Etv.Cfi = v }},
+		descriptionField,
+		{path: "etv.issuer", block: "fs_generalinfo_block", value: labeled(strings.ToUpper, issuerLabels...),
+			set: func(ins *euronext.XmlInstrument, v string) { ins.Etv.Issuer = v }},
+		{path: "etv.launchDate", block: "fs_generalinfo_block", value: labeled(strings.ToUpper, "Launch Date"),
+			set: func(ins *euronext.XmlInstrument, v string) { ins.Etv.LaunchDate = v }},
+		{path: "etv.dividendFrequency", block: "fs_generalinfo_block", value: labeled(strings.ToLower, "Dividend frequency"),
+			set: func(ins *euronext.XmlInstrument, v string) { ins.Etv.DividendFrequency = v }},
+		{path: "etv.currency", block: "fs_tradinginfo_etfs_block", value: labeled(strings.ToUpper, "Trading currency"),
+			set: func(ins *euronext.XmlInstrument, v string) { ins.Etv.Currency = v }},
+		{path: "etv.tradingMode", block: "fs_tradinginfo_etfs_block", value: tradingMode,
+			set: func(ins *euronext.XmlInstrument, v string) { ins.Etv.TradingMode = v }},
+		{path: "etv.shares", block: "fs_tradinginfo_etfs_block", value: labeled(strings.ToLower, "Shares outstanding", "Admitted shares"),
+			set: func(ins *euronext.XmlInstrument, v string) { ins.Etv.Shares = v }},
+		{path: "etv.allInFees", block: "fs_feessegmentation_block", value: labeled(asIs, "All In Fees"),
+			set: func(ins *euronext.XmlInstrument, v string) { ins.Etv.AllInFees = v }},
+		{path: "etv.expenseRatio", block: "fs_feessegmentation_block", value: labeled(strings.ToLower, "Expense Ratio", "TER"),
+			set: func(ins *euronext.XmlInstrument, v string) { ins.Etv.ExpenseRatio = v }},
+		nameField,
+	},
+}
+
+// etfEnricher enriches the etf element.
+//
+//	<instrument vendor="Euronext" mep="PAR" mic="XPAR" isin="FR0010754135" symbol="C13" name="AMUNDI ETF EMTS1-3" type="etf">
+//	  <etf cfi="EUOM" ter="0.14" tradingMode="continuous" launchDate="20100316" currency="EUR" issuer="AMUNDI" fraction="1" dividendFrequency="Annually" indexFamily="EuroMTS" expositionType="synthetic">
+//	    <inav vendor="Euronext" mep="PAR" mic="XPAR" isin="QS0011161377" symbol="INC13" name="AMUNDI C13 INAV"/>
+//	    <underlying vendor="Euronext" mep="PAR" mic="XPAR" isin="QS0011052618" symbol="EMTSAR" name="EuroMTS Eurozone Government Broad 1-3"/>
+//	  </etf>
+//	</instrument>
+var etfEnricher = &factsheetEnricher{
+	typ:     euronext.XmlInstrumentEtfType,
+	product: "TRACK",
+	page:    "etfs",
+	init: func(ins *euronext.XmlInstrument) {
+		if ins.Etf == nil {
+			ins.Etf = &euronext.XmlEtf{Currency: "EUR"}
+		}
+	},
+	fields: []field{
+		{path: "etf.cfi", block: "fs_cfi_block", value: cfi,
+			set: func(ins *euronext.XmlInstrument, v string) { ins.Etf.Cfi = v }},
+		descriptionField,
+		{path: "etf.issuer", block: "fs_generalinfo_block", value: labeled(strings.ToUpper, issuerLabels...),
+			set: func(ins *euronext.XmlInstrument, v string) { ins.Etf.Issuer = v }},
+		{path: "etf.launchDate", block: "fs_generalinfo_block", value: labeled(strings.ToUpper, "Launch Date"),
+			set: func(ins *euronext.XmlInstrument, v string) { ins.Etf.LaunchDate = v }},
+		{path: "etf.dividendFrequency", block: "fs_generalinfo_block", value: labeled(strings.ToLower, "Dividend frequency"),
+			set: func(ins *euronext.XmlInstrument, v string) { ins.Etf.DividendFrequency = v }},
+		{path: "etf.currency", block: "fs_tradinginfo_etfs_block", value: labeled(strings.ToUpper, "Trading currency"),
+			set: func(ins *euronext.XmlInstrument, v string) { ins.Etf.Currency = v }},
+		{path: "etf.tradingMode", block: "fs_tradinginfo_etfs_block", value: tradingMode,
+			set: func(ins *euronext.XmlInstrument, v string) { ins.Etf.TradingMode = v }},
+		{path: "etf.expositionType", block: "fs_tradinginfo_etfs_block", value: labeled(strings.ToLower, "Exposition type"),
+			set: func(ins *euronext.XmlInstrument, v string) { ins.Etf.ExpositionType = v }},
+		{path: "etf.shares", block: "fs_tradinginfo_etfs_block", value: labeled(strings.ToLower, "Shares outstanding", "Admitted shares"),
+			set: func(ins *euronext.XmlInstrument, v string) { ins.Etf.Shares = v }},
+		{path: "etf.inav.symbol", block: "fs_tradinginfo_etfs_block", value: labeled(asIs, "Ticker INAV (Euronext)"),
+			set: func(ins *euronext.XmlInstrument, v string) { ins.Etf.Inav.Symbol = v }},
+		{path: "etf.inav.name", block: "fs_tradinginfo_etfs_block", value: labeled(asIs, "INAV Name"),
+			set: func(ins *euronext.XmlInstrument, v string) { ins.Etf.Inav.Name = v }},
+		{path: "etf.inav.isin", block: "fs_tradinginfo_etfs_block", value: labeled(asIs, "INAV ISIN code"),
+			set: func(ins *euronext.XmlInstrument, v string) { ins.Etf.Inav.Isin = v }},
+		{path: "etf.underlying.name", block: "fs_tradinginfo_etfs_block", value: labeled(asIs, "Underlying index"),
+			set: func(ins *euronext.XmlInstrument, v string) { ins.Etf.Underlying.Name = v }},
+		{path: "etf.underlying.symbol", block: "fs_tradinginfo_etfs_block", value: labeled(asIs, "Index"),
+			set: func(ins *euronext.XmlInstrument, v string) { ins.Etf.Underlying.Symbol = v }},
+		{path: "etf.ter", block: "fs_feessegmentation_block", value: labeled(strings.ToUpper, "TER"),
+			set: func(ins *euronext.XmlInstrument, v string) { ins.Etf.Ter = v }},
+		nameField,
+	},
+}
+
+// fundEnricher enriches the fund element.
+//
+//	<instrument vendor="Euronext" mep="AMS" mic="XAMS" isin="NL0006259996" symbol="AWAF" name="ACH WERELD AANDFD3" type="fund">
+//	  <fund cfi="EUOISB" tradingmode="fixing" currency="EUR" issuer="ACHMEA BELEGGINGSFONDSEN" shares="860,248">
+//	</instrument>
+var fundEnricher = &factsheetEnricher{
+	typ:     euronext.XmlInstrumentFundType,
+	product: "FUNDS",
+	page:    "funds",
+	init: func(ins *euronext.XmlInstrument) {
+		if ins.Fund == nil {
+			ins.Fund = &euronext.XmlFund{Currency: "EUR"}
+		}
+	},
+	fields: []field{
+		{path: "fund.cfi", block: "fs_cfi_block", value: cfi,
+			set: func(ins *euronext.XmlInstrument, v string) { ins.Fund.Cfi = v }},
+		// >Issuer name : </span> <span class="issuerName-column-right"><strong>VARENNE UCITS</strong>
+		{path: "fund.issuer", block: "fs_issuerinfo_block", value: labeled(strings.ToUpper, append([]string{"Issuer name"}, issuerLabels...)...),
+			set: func(ins *euronext.XmlInstrument, v string) { ins.Fund.Issuer = v }},
+		{path: "fund.currency", block: "fs_tradinginfo_funds_block", value: labeled(strings.ToUpper, "Trading currency"),
+			set: func(ins *euronext.XmlInstrument, v string) { ins.Fund.Currency = v }},
+		{path: "fund.shares", block: "fs_tradinginfo_funds_block", value: labeled(asIs, "Shares outstanding"),
+			set: func(ins *euronext.XmlInstrument, v string) { ins.Fund.Shares = v }},
+		{path: "fund.tradingMode", block: "fs_tradinginfo_funds_block", value: tradingMode,
+			set: func(ins *euronext.XmlInstrument, v string) { ins.Fund.TradingMode = v }},
+		nameField,
+	},
+}
+
+// inavEnricher creates the inav element, Euronext has no factsheet blocks for the INAVs,
+// so only the missing name is taken from the detailed quote.
+//
+//	<instrument vendor="Euronext" mep="PAR" isin="QS0011161385" symbol="INC33" name="AMUNDI C33 INAV" type="inav">
+//	  <inav currency="EUR">
+//	    <target vendor="Euronext" mep="PAR" mic="XPAR" isin="FR0010754168" symbol="C33" name="AMUNDI ETF GOV 3-5"/>
+//	  </inav>
+//	</instrument>
+var inavEnricher = &factsheetEnricher{
+	typ:  euronext.XmlInstrumentInavType,
+	page: "indices",
+	init: func(ins *euronext.XmlInstrument) {
+		if ins.Inav == nil {
+			ins.Inav = &euronext.XmlInav{Target: []euronext.XmlTarget{{}}}
+		}
+	},
+	fields: []field{nameField},
+}
+
+// indexEnricher creates the index element, e.g. https://live.euronext.com/en/product/indices/FR0014002B31-XPAR.
+var indexEnricher = &factsheetEnricher{
+	typ:  euronext.XmlInstrumentIndexType,
+	page: "indices",
+	init: func(ins *euronext.XmlInstrument) {
+		if ins.Index == nil {
+			ins.Index = &euronext.XmlIndex{Currency: "EUR", Icb: &euronext.XmlIcb{}}
+		}
+	},
+}
@@ -0,0 +1,39 @@
+package euronext
+
+import (
+	"testing"
+
+	"euronext/euronext/fetch"
+)
+
+func TestDownloadEodHistoryReplay(t *testing.T) {
+	rep, err := fetch.NewReplayer("testdata/fixtures")
+	if err != nil {
+		t.Fatalf("cannot load fixtures: %v", err)
+	}
+	defer fetch.Replace(rep)()
+
+	adj, raw, err := DownloadEodHistory("fr0000120073", "xpar")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	combined, lenRaw, lenAdj := Combine(raw, adj)
+	if lenRaw != 7 || lenAdj != 7 {
+		t.Errorf("expected 7 and 7 lines, got %d and %d", lenRaw, lenAdj)
+	}
+
+	hist, err := ConvertToCombinedDailyHistory(combined)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(hist) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(hist))
+	}
+
+	h := hist[0]
+	if h.Date.Format("2006-01-02") != "2024-06-04" || h.Close != 197.12 || h.CloseAdjusted != 179.2 || h.NumberOfShares != 465768 {
+		t.Errorf("unexpected entry %+v", h)
+	}
+}
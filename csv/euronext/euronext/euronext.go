@@ -1,56 +1,60 @@
 package euronext
 
 import (
+	"archive/zip"
+	"errors"
 	"fmt"
 	"io"
-	"net/http"
 	"os"
+	"path/filepath"
 	"strings"
 	"time"
+
+	"calendar"
+	"euronext/euronext/fetch"
 )
 
 const userAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/108.0.0.0 Safari/537.36"
 
+// isBlockPage reports if the response is an html page instead of the requested csv.
+func isBlockPage(resp *fetch.Response) bool {
+	return len(resp.Body) > 0 && resp.Body[0] == '<'
+}
+
 func get(targetURL string) ([]byte, error) {
-repeat:
-	req, err := http.NewRequest("GET", targetURL, nil)
-	if err != nil {
-		return nil, fmt.Errorf("cannot create request: %w", err)
+	req := &fetch.Request{
+		Method:    "GET",
+		URL:       targetURL,
+		Header:    map[string]string{"User-Agent": userAgent},
+		Timeout:   time.Duration(60) * time.Second,
+		BlockPage: isBlockPage,
 	}
 
-	req.Header.Set("User-Agent", userAgent)
-	httpClient := http.Client{Timeout: time.Duration(60) * time.Second}
-
-	var resp *http.Response
 	const retriesMax = 5
-	retries := retriesMax
-	for retries > 0 {
-		resp, err = httpClient.Do(req)
-		if err != nil {
-			err := fmt.Errorf("cannot do request, retries (%d of %d): %w", retries, retriesMax, err)
-			fmt.Println(err)
-			retries -= 1
-			if retries < 1 {
-				return nil, err
-			} else {
-				time.Sleep(2 * time.Second)
-			}
-		} else {
-			break
+	var err error
+	for retries := retriesMax; retries > 0; retries-- {
+		var resp *fetch.Response
+		resp, err = fetch.Default.Fetch(req)
+		if err == nil && fetch.Blocked(req, resp) {
+			err = fmt.Errorf("%w: %s", fetch.ErrBlocked, resp.Status)
 		}
-	}
-	defer resp.Body.Close()
 
-	contents, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("cannot read response body: %w", err)
-	}
+		if err == nil {
+			return resp.Body, nil
+		}
+
+		if errors.Is(err, fetch.ErrCircuitOpen) {
+			return nil, err
+		}
 
-	if contents[0] == '<' {
-		goto repeat
+		err = fmt.Errorf("cannot do request, retries (%d of %d): %w", retries, retriesMax, err)
+		fmt.Println(err)
+		if retries > 1 {
+			time.Sleep(2 * time.Second)
+		}
 	}
 
-	return contents, nil
+	return nil, err
 }
 
 func getEodHistoryURL(isin string, mic string, isAdjusted bool) string {
@@ -101,20 +105,20 @@ func EnsureDirectoryExists(directory string) error {
 	return nil
 }
 
+// sessionMic is the market whose calendar defines the session date of the downloaders.
+const sessionMic = "XPAR"
+
+// sessionCloseDelay is the delay after the close when the daily data of a session is published.
+const sessionCloseDelay = 90 * time.Minute
+
+// SessionDate returns the latest Euronext session date with the published daily data.
 func SessionDate() (time.Time, error) {
-	today := time.Now().UTC().Add(time.Hour * 1)
-	dow := today.Weekday()
-	switch dow {
-	case time.Saturday:
-		return today.AddDate(0, 0, -1), nil
-	case time.Sunday:
-		return today.AddDate(0, 0, -2), nil
-	default:
-		if today.Hour() < 19 {
-			return today.AddDate(0, 0, -1), nil
-		}
-		return today, nil
+	cal, err := calendar.ForMic(sessionMic)
+	if err != nil {
+		return time.Now(), fmt.Errorf("cannot get session calendar: %w", err)
 	}
+
+	return cal.LastClosedSession(time.Now(), sessionCloseDelay), nil
 }
 
 func BackupFile(filename string) (string, error) {
@@ -138,3 +142,47 @@ func BackupFile(filename string) (string, error) {
 
 	return "", nil
 }
+
+// ZipFolder zips the folder at srcDir (including the folder itself) into destZip.
+func ZipFolder(srcDir, destZip string) error {
+	z, err := os.Create(destZip)
+	if err != nil {
+		return fmt.Errorf("cannot create zip file '%s': %w", destZip, err)
+	}
+	defer z.Close()
+
+	w := zip.NewWriter(z)
+	defer w.Close()
+
+	parent := filepath.Dir(filepath.Clean(srcDir))
+	err = filepath.WalkDir(srcDir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil // skip directories, only add files
+		}
+		if filepath.Clean(path) == filepath.Clean(destZip) {
+			return nil // skip the zip file itself when it is inside the folder
+		}
+		relPath, err := filepath.Rel(parent, path)
+		if err != nil {
+			return err
+		}
+		relPath = filepath.ToSlash(relPath) // for zip standard
+
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		wr, err := w.Create(relPath)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(wr, f)
+		return err
+	})
+	return err
+}
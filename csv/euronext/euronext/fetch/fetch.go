@@ -0,0 +1,148 @@
+// Package fetch provides a pluggable HTTP transport for the Euronext downloaders.
+//
+// All downloaders go through the Default fetcher, which performs real HTTP requests.
+// It can be replaced by a Recorder, which saves the request/response pairs to a folder,
+// or by a Replayer, which serves the saved pairs back without network access.
+// A Limiter wrapping any of them paces the requests of all workers and stops hammering a blocking site.
+package fetch
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Request is a request to fetch.
+type Request struct {
+	Method  string
+	URL     string
+	Header  map[string]string
+	Body    string
+	Timeout time.Duration
+
+	// BlockPage reports if a response with an OK status is a block page
+	// instead of the requested content, nil means no block pages.
+	BlockPage func(resp *Response) bool
+}
+
+// ErrBlocked reports a blocked request, detected by the Blocked function.
+var ErrBlocked = errors.New("blocked")
+
+// Blocked reports if the site blocked or throttled the request:
+// the 429 or 503 status code or a block page of the request.
+func Blocked(req *Request, resp *Response) bool {
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		return true
+	}
+
+	return req.BlockPage != nil && req.BlockPage(resp)
+}
+
+// Response is a fetched response.
+type Response struct {
+	StatusCode int
+	Status     string
+	Body       []byte
+}
+
+// Fetcher fetches a request.
+//
+// A non-nil error means the response was not received,
+// a received response with a not OK status code is not an error.
+type Fetcher interface {
+	Fetch(req *Request) (*Response, error)
+}
+
+// Default is the fetcher used by the downloaders.
+// It should be replaced before the downloads start, it is not guarded against concurrent changes.
+var Default Fetcher = Client{}
+
+// Replace replaces the Default fetcher and returns a function restoring the previous one.
+func Replace(f Fetcher) func() {
+	prev := Default
+	Default = f
+	return func() { Default = prev }
+}
+
+// New creates a fetcher from the specification.
+//
+// An empty specification or "http" means the HTTP client,
+// "record:<folder>" means the HTTP client recording into the folder,
+// "replay:<folder>" means replaying from the folder.
+func New(spec string) (Fetcher, error) {
+	mode, folder, _ := strings.Cut(spec, ":")
+	switch mode {
+	case "", "http":
+		return Client{}, nil
+	case "record":
+		if folder == "" {
+			return nil, errors.New("record fetcher has no folder")
+		}
+
+		return NewRecorder(Client{}, folder), nil
+	case "replay":
+		if folder == "" {
+			return nil, errors.New("replay fetcher has no folder")
+		}
+
+		return NewReplayer(folder)
+	default:
+		return nil, fmt.Errorf("unknown fetcher '%s'", spec)
+	}
+}
+
+// Ajax returns the request headers of the Euronext ajax endpoints.
+func Ajax(referer string, userAgent string) map[string]string {
+	return map[string]string{
+		"User-Agent":       userAgent,
+		"Referer":          referer,
+		"Accept-Language":  "en-us,en;q=0.5",
+		"Accept-Charset":   "ISO-8859-1,utf-8;q=0.7,*;q=0.7",
+		"X-Requested-With": "XMLHttpRequest",
+		"Accept":           "application/json, text/javascript, */*",
+	}
+}
+
+// The transport is shared to reuse the connections.
+var transport = &http.Transport{
+	Proxy: http.ProxyFromEnvironment, // Uses system proxy settings
+}
+
+// Client fetches requests over HTTP.
+type Client struct{}
+
+// Fetch implements the Fetcher interface.
+func (Client) Fetch(r *Request) (*Response, error) {
+	var body io.Reader
+	if r.Body != "" {
+		body = bytes.NewBufferString(r.Body)
+	}
+
+	req, err := http.NewRequest(r.Method, r.URL, body)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create request: %w", err)
+	}
+
+	for k, v := range r.Header {
+		req.Header.Set(k, v)
+	}
+
+	client := http.Client{Timeout: r.Timeout, Transport: transport}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("cannot do request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	contents, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read response body: %w", err)
+	}
+
+	return &Response{StatusCode: resp.StatusCode, Status: resp.Status, Body: contents}, nil
+}
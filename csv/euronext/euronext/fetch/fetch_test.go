@@ -0,0 +1,192 @@
+package fetch
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/echo":
+			body, _ := io.ReadAll(r.Body)
+			_, _ = w.Write([]byte(r.Method + ";" + r.Header.Get("Referer") + ";" + string(body)))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func TestClient(t *testing.T) {
+	t.Parallel()
+
+	srv := newTestServer(t)
+	resp, err := Client{}.Fetch(&Request{
+		Method:  "POST",
+		URL:     srv.URL + "/echo",
+		Header:  Ajax("ref", "agent"),
+		Body:    "a=1",
+		Timeout: 5 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if resp.StatusCode != http.StatusOK || string(resp.Body) != "POST;ref;a=1" {
+		t.Errorf("unexpected response %d %q", resp.StatusCode, resp.Body)
+	}
+
+	resp, err = Client{}.Fetch(&Request{Method: "GET", URL: srv.URL + "/missing", Timeout: 5 * time.Second})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected status 404, got %d", resp.StatusCode)
+	}
+}
+
+func TestRecordReplay(t *testing.T) {
+	t.Parallel()
+
+	srv := newTestServer(t)
+	folder := filepath.Join(t.TempDir(), "fixtures")
+	rec := NewRecorder(Client{}, folder)
+
+	requests := []*Request{
+		{Method: "POST", URL: srv.URL + "/echo", Header: Ajax("ref", "agent"), Body: "page=1"},
+		{Method: "POST", URL: srv.URL + "/echo", Header: Ajax("ref", "agent"), Body: "page=2"},
+		{Method: "GET", URL: srv.URL + "/echo?x=1", Header: Ajax("ref", "agent")},
+		{Method: "GET", URL: srv.URL + "/missing"},
+	}
+
+	recorded := make([]*Response, len(requests))
+	for i, req := range requests {
+		resp, err := rec.Fetch(req)
+		if err != nil {
+			t.Fatalf("request %d: unexpected error: %v", i, err)
+		}
+
+		recorded[i] = resp
+	}
+
+	files, _ := filepath.Glob(filepath.Join(folder, "*"))
+	if len(files) != 2*len(requests) {
+		t.Errorf("expected %d fixture files, got %d", 2*len(requests), len(files))
+	}
+
+	// Replay without the server.
+	srv.Close()
+
+	rep, err := NewReplayer(folder)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for i, req := range requests {
+		resp, err := rep.Fetch(req)
+		if err != nil {
+			t.Fatalf("request %d: unexpected error: %v", i, err)
+		}
+
+		if resp.StatusCode != recorded[i].StatusCode || string(resp.Body) != string(recorded[i].Body) {
+			t.Errorf("request %d: expected %d %q, got %d %q", i,
+				recorded[i].StatusCode, recorded[i].Body, resp.StatusCode, resp.Body)
+		}
+	}
+
+	// The only fixture with the same method and URL matches any body.
+	resp, err := rep.Fetch(&Request{Method: "GET", URL: srv.URL + "/echo?x=1", Body: "date=2024-06-04"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if string(resp.Body) != string(recorded[2].Body) {
+		t.Errorf("unexpected loose match %q", resp.Body)
+	}
+
+	// Two fixtures with the same method and URL are ambiguous.
+	_, err = rep.Fetch(&Request{Method: "POST", URL: srv.URL + "/echo", Body: "page=3"})
+	if !errors.Is(err, ErrNoFixture) {
+		t.Errorf("expected ErrNoFixture, got %v", err)
+	}
+
+	_, err = rep.Fetch(&Request{Method: "GET", URL: srv.URL + "/other"})
+	if !errors.Is(err, ErrNoFixture) {
+		t.Errorf("expected ErrNoFixture, got %v", err)
+	}
+}
+
+func TestReplayerDefaults(t *testing.T) {
+	t.Parallel()
+
+	folder := t.TempDir()
+	fixture := `{"url": "https://example.com/a", "response": "a.csv"}`
+	if err := os.WriteFile(filepath.Join(folder, "a.json"), []byte(fixture), 0644); err != nil {
+		t.Fatalf("cannot write fixture: %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(folder, "a.csv"), []byte("a;b"), 0644); err != nil {
+		t.Fatalf("cannot write fixture response: %v", err)
+	}
+
+	rep, err := NewReplayer(folder)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	resp, err := rep.Fetch(&Request{Method: "GET", URL: "https://example.com/a"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if resp.StatusCode != http.StatusOK || resp.Status != "200 OK" || string(resp.Body) != "a;b" {
+		t.Errorf("unexpected response %d %s %q", resp.StatusCode, resp.Status, resp.Body)
+	}
+
+	if err := os.WriteFile(filepath.Join(folder, "b.json"), []byte("{"), 0644); err != nil {
+		t.Fatalf("cannot write fixture: %v", err)
+	}
+
+	if _, err := NewReplayer(folder); err == nil {
+		t.Errorf("expected an error for a malformed fixture")
+	}
+}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	folder := t.TempDir()
+	tests := []struct {
+		spec string
+		ok   bool
+	}{
+		{"", true},
+		{"http", true},
+		{"record:" + folder, true},
+		{"replay:" + folder, true},
+		{"record", false},
+		{"replay:", false},
+		{"ftp:x", false},
+	}
+
+	for _, tt := range tests {
+		f, err := New(tt.spec)
+		if tt.ok && (err != nil || f == nil) {
+			t.Errorf("%q: unexpected error: %v", tt.spec, err)
+		} else if !tt.ok && err == nil {
+			t.Errorf("%q: expected an error", tt.spec)
+		}
+	}
+}
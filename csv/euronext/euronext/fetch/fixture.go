@@ -0,0 +1,168 @@
+package fetch
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// ErrNoFixture is returned by the Replayer when no fixture matches a request.
+var ErrNoFixture = errors.New("no fixture")
+
+// fixture is a saved request/response pair.
+//
+// The fixture is a json file, the response body is in a separate file next to it.
+// The request headers are not saved, they do not take part in the matching.
+type fixture struct {
+	Method     string `json:"method"`
+	URL        string `json:"url"`
+	Body       string `json:"body,omitempty"`
+	StatusCode int    `json:"statusCode"`
+	Status     string `json:"status,omitempty"`
+	Response   string `json:"response"`
+}
+
+func fixtureName(r *Request) string {
+	h := sha256.Sum256([]byte(r.Method + "\n" + r.URL + "\n" + r.Body))
+	return hex.EncodeToString(h[:8])
+}
+
+// Recorder fetches requests with a fetcher and saves the request/response pairs into a folder.
+type Recorder struct {
+	fetcher Fetcher
+	folder  string
+	mu      sync.Mutex
+}
+
+// NewRecorder creates a recorder saving the pairs fetched by the fetcher into the folder.
+func NewRecorder(fetcher Fetcher, folder string) *Recorder {
+	return &Recorder{fetcher: fetcher, folder: folder}
+}
+
+// Fetch implements the Fetcher interface.
+// The pair is not saved when the fetch fails, a failure to save is returned as an error.
+func (r *Recorder) Fetch(req *Request) (*Response, error) {
+	resp, err := r.fetcher.Fetch(req)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := os.MkdirAll(r.folder, os.ModePerm); err != nil {
+		return nil, fmt.Errorf("cannot create fixture folder '%s': %w", r.folder, err)
+	}
+
+	name := fixtureName(req)
+	f := fixture{
+		Method:     req.Method,
+		URL:        req.URL,
+		Body:       req.Body,
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		Response:   name + ".body",
+	}
+
+	fileName := filepath.Join(r.folder, f.Response)
+	if err := os.WriteFile(fileName, resp.Body, 0644); err != nil {
+		return nil, fmt.Errorf("cannot write fixture response '%s': %w", fileName, err)
+	}
+
+	bs, err := json.MarshalIndent(&f, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("cannot marshal fixture: %w", err)
+	}
+
+	fileName = filepath.Join(r.folder, name+".json")
+	if err := os.WriteFile(fileName, append(bs, '\n'), 0644); err != nil {
+		return nil, fmt.Errorf("cannot write fixture '%s': %w", fileName, err)
+	}
+
+	return resp, nil
+}
+
+// Replayer serves the request/response pairs saved in a folder.
+//
+// A request matches a fixture with the same method, URL and body.
+// When there is no such fixture, it matches the only fixture with the same method and URL,
+// so requests with a date in the body can be replayed on any day.
+type Replayer struct {
+	folder string
+	exact  map[string]*fixture
+	loose  map[string][]*fixture
+}
+
+// NewReplayer loads the fixtures from the json files of the folder.
+// The fixture files may have any names, the recorded ones are named after a hash of the request.
+func NewReplayer(folder string) (*Replayer, error) {
+	files, err := filepath.Glob(filepath.Join(folder, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("cannot list fixtures in '%s': %w", folder, err)
+	}
+	sort.Strings(files)
+
+	r := &Replayer{
+		folder: folder,
+		exact:  make(map[string]*fixture),
+		loose:  make(map[string][]*fixture),
+	}
+
+	for _, file := range files {
+		bs, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read fixture '%s': %w", file, err)
+		}
+
+		f := &fixture{}
+		if err := json.Unmarshal(bs, f); err != nil {
+			return nil, fmt.Errorf("cannot unmarshal fixture '%s': %w", file, err)
+		}
+
+		if f.Method == "" {
+			f.Method = "GET"
+		}
+
+		if f.StatusCode == 0 {
+			f.StatusCode = 200
+		}
+
+		r.exact[f.Method+" "+f.URL+"\n"+f.Body] = f
+		r.loose[f.Method+" "+f.URL] = append(r.loose[f.Method+" "+f.URL], f)
+	}
+
+	return r, nil
+}
+
+// Fetch implements the Fetcher interface.
+func (r *Replayer) Fetch(req *Request) (*Response, error) {
+	f, ok := r.exact[req.Method+" "+req.URL+"\n"+req.Body]
+	if !ok {
+		fs := r.loose[req.Method+" "+req.URL]
+		if len(fs) != 1 {
+			return nil, fmt.Errorf("%s %s: %w", req.Method, req.URL, ErrNoFixture)
+		}
+
+		f = fs[0]
+	}
+
+	fileName := filepath.Join(r.folder, f.Response)
+	bs, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read fixture response '%s': %w", fileName, err)
+	}
+
+	status := f.Status
+	if status == "" {
+		status = fmt.Sprintf("%d %s", f.StatusCode, http.StatusText(f.StatusCode))
+	}
+
+	return &Response{StatusCode: f.StatusCode, Status: status, Body: bs}, nil
+}
@@ -0,0 +1,264 @@
+package fetch
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned by the Limiter when its circuit breaker gave up on the site.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// LimiterConfig is the configuration of a Limiter, zero fields take the default values.
+type LimiterConfig struct {
+	// Rate is the maximal number of requests per second.
+	Rate float64 `json:"rate"`
+
+	// Burst is the number of requests which can be done at once after a pause.
+	Burst int `json:"burst"`
+
+	// MinRate is the minimal number of requests per second after the backoffs.
+	MinRate float64 `json:"minRate"`
+
+	// Backoff multiplies the rate on every failure, it is between zero and one.
+	Backoff float64 `json:"backoff"`
+
+	// Recovery multiplies the rate on every success until it reaches the Rate, it is above one.
+	Recovery float64 `json:"recovery"`
+
+	// FailureThreshold is the number of consecutive failures opening the circuit breaker.
+	FailureThreshold int `json:"failureThreshold"`
+
+	// CooldownSeconds is the pause of an open circuit breaker, multiplied by the number of consecutive trips.
+	CooldownSeconds int `json:"cooldownSeconds"`
+
+	// MaxTrips is the number of consecutive trips after which the breaker gives up and fails all requests.
+	MaxTrips int `json:"maxTrips"`
+}
+
+// DefaultLimiterConfig returns the default limiter configuration.
+func DefaultLimiterConfig() LimiterConfig {
+	return LimiterConfig{
+		Rate:             2,
+		Burst:            4,
+		MinRate:          0.05,
+		Backoff:          0.5,
+		Recovery:         1.05,
+		FailureThreshold: 5,
+		CooldownSeconds:  60,
+		MaxTrips:         5,
+	}
+}
+
+// OrDefault returns the configuration, the default configuration if it is nil,
+// so the requests are paced even when a configuration file has no limiter.
+func (c *LimiterConfig) OrDefault() LimiterConfig {
+	if c == nil {
+		return DefaultLimiterConfig()
+	}
+
+	return *c
+}
+
+func (c LimiterConfig) withDefaults() LimiterConfig {
+	d := DefaultLimiterConfig()
+	if c.Rate <= 0 {
+		c.Rate = d.Rate
+	}
+	if c.Burst < 1 {
+		c.Burst = d.Burst
+	}
+	if c.MinRate <= 0 || c.MinRate > c.Rate {
+		c.MinRate = math.Min(d.MinRate, c.Rate)
+	}
+	if c.Backoff <= 0 || c.Backoff >= 1 {
+		c.Backoff = d.Backoff
+	}
+	if c.Recovery <= 1 {
+		c.Recovery = d.Recovery
+	}
+	if c.FailureThreshold < 1 {
+		c.FailureThreshold = d.FailureThreshold
+	}
+	if c.CooldownSeconds < 1 {
+		c.CooldownSeconds = d.CooldownSeconds
+	}
+	if c.MaxTrips < 1 {
+		c.MaxTrips = d.MaxTrips
+	}
+
+	return c
+}
+
+// Limiter is a fetcher shared by all download workers, pacing the requests of another fetcher
+// with a token bucket.
+//
+// The rate backs off on every failure, that is a fetch error, a 429 or 503 status code
+// or a block page, and recovers slowly on the successes.
+// After FailureThreshold consecutive failures the circuit breaker opens and the requests wait
+// for the cooldown, then a single probe request decides if the breaker closes or opens again.
+// After MaxTrips consecutive trips all requests fail with ErrCircuitOpen.
+type Limiter struct {
+	next Fetcher
+	cfg  LimiterConfig
+	logf func(format string, args ...any)
+
+	// now and sleep are replaced in tests.
+	now   func() time.Time
+	sleep func(d time.Duration)
+
+	mu        sync.Mutex
+	rate      float64
+	tokens    float64
+	last      time.Time
+	failures  int
+	trips     int
+	openUntil time.Time
+	probing   bool
+}
+
+// NewLimiter creates a limiter pacing the next fetcher, logf reports the status changes.
+func NewLimiter(next Fetcher, cfg LimiterConfig, logf func(format string, args ...any)) *Limiter {
+	cfg = cfg.withDefaults()
+	if logf == nil {
+		logf = func(string, ...any) {}
+	}
+
+	return &Limiter{
+		next:   next,
+		cfg:    cfg,
+		logf:   logf,
+		now:    time.Now,
+		sleep:  time.Sleep,
+		rate:   cfg.Rate,
+		tokens: float64(cfg.Burst),
+	}
+}
+
+// Fetch implements the Fetcher interface.
+func (l *Limiter) Fetch(req *Request) (*Response, error) {
+	probe, err := l.acquire()
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := l.next.Fetch(req)
+	l.record(err == nil && !Blocked(req, resp), probe)
+	return resp, err
+}
+
+// acquire waits for a token or for the end of the cooldown, probe means the request
+// is the single one allowed through a half-open breaker.
+func (l *Limiter) acquire() (probe bool, err error) {
+	for {
+		l.mu.Lock()
+		now := l.now()
+		if l.trips > l.cfg.MaxTrips {
+			l.mu.Unlock()
+			return false, ErrCircuitOpen
+		}
+
+		if !l.openUntil.IsZero() {
+			wait := l.openUntil.Sub(now)
+			if wait <= 0 && !l.probing {
+				l.probing = true
+				l.mu.Unlock()
+				l.logf("limiter: circuit breaker is half-open, probing\n")
+				return true, nil
+			}
+
+			if wait <= 0 {
+				// Another request is probing.
+				wait = time.Second
+			}
+
+			l.mu.Unlock()
+			l.sleep(wait)
+			continue
+		}
+
+		if !l.last.IsZero() {
+			l.tokens = math.Min(float64(l.cfg.Burst), l.tokens+now.Sub(l.last).Seconds()*l.rate)
+		}
+		l.last = now
+
+		if l.tokens >= 1 {
+			l.tokens--
+			l.mu.Unlock()
+			return false, nil
+		}
+
+		wait := time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+		l.mu.Unlock()
+		l.sleep(wait)
+	}
+}
+
+func (l *Limiter) record(ok, probe bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if ok {
+		l.failures = 0
+		if probe {
+			l.probing = false
+			l.openUntil = time.Time{}
+			l.trips = 0
+			l.tokens = 0
+			l.last = l.now()
+			l.logf("limiter: circuit breaker is closed, %s\n", l.status())
+		}
+
+		if l.rate < l.cfg.Rate {
+			l.rate = math.Min(l.cfg.Rate, l.rate*l.cfg.Recovery)
+			if l.rate == l.cfg.Rate {
+				l.logf("limiter: rate recovered, %s\n", l.status())
+			}
+		}
+
+		return
+	}
+
+	l.failures++
+	if rate := math.Max(l.cfg.MinRate, l.rate*l.cfg.Backoff); rate != l.rate {
+		l.rate = rate
+		l.logf("limiter: backing off, %s\n", l.status())
+	}
+
+	if probe || (l.openUntil.IsZero() && l.failures >= l.cfg.FailureThreshold) {
+		l.probing = false
+		l.trips++
+		if l.trips > l.cfg.MaxTrips {
+			l.logf("limiter: circuit breaker gave up, %s\n", l.status())
+			return
+		}
+
+		l.openUntil = l.now().Add(time.Duration(l.cfg.CooldownSeconds*l.trips) * time.Second)
+		l.logf("limiter: circuit breaker is open until %s, %s\n", l.openUntil.Format("15:04:05"), l.status())
+	}
+}
+
+func (l *Limiter) status() string {
+	breaker := "closed"
+	switch {
+	case l.trips > l.cfg.MaxTrips:
+		breaker = "given up"
+	case l.probing:
+		breaker = "half-open"
+	case !l.openUntil.IsZero():
+		breaker = "open"
+	}
+
+	return fmt.Sprintf("rate %.3g/s, breaker %s, %d consecutive failures, %d trips",
+		l.rate, breaker, l.failures, l.trips)
+}
+
+// Status returns the current rate and the circuit breaker state.
+func (l *Limiter) Status() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.status()
+}
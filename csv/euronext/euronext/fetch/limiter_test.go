@@ -0,0 +1,165 @@
+package fetch
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// scriptedFetcher returns the status codes in order, then 200.
+type scriptedFetcher struct {
+	codes []int
+	calls int
+}
+
+func (f *scriptedFetcher) Fetch(req *Request) (*Response, error) {
+	code := http.StatusOK
+	if f.calls < len(f.codes) {
+		code = f.codes[f.calls]
+	}
+	f.calls++
+
+	if code == 0 {
+		return nil, errors.New("connection reset")
+	}
+
+	body := "date;open"
+	if code == -1 {
+		code, body = http.StatusOK, "<html>blocked</html>"
+	}
+
+	return &Response{StatusCode: code, Body: []byte(body)}, nil
+}
+
+// newTestLimiter returns a limiter with a fake clock advanced by the sleeps.
+func newTestLimiter(next Fetcher, cfg LimiterConfig) (*Limiter, *time.Duration, *[]string) {
+	slept := new(time.Duration)
+	logs := &[]string{}
+	l := NewLimiter(next, cfg, func(format string, args ...any) {
+		*logs = append(*logs, strings.TrimSpace(format))
+	})
+
+	start := time.Date(2024, 6, 4, 10, 0, 0, 0, time.UTC)
+	l.now = func() time.Time { return start.Add(*slept) }
+	l.sleep = func(d time.Duration) { *slept += d }
+	return l, slept, logs
+}
+
+func TestBlocked(t *testing.T) {
+	t.Parallel()
+
+	req := &Request{BlockPage: func(resp *Response) bool { return strings.HasPrefix(string(resp.Body), "<") }}
+	tests := []struct {
+		code    int
+		body    string
+		blocked bool
+	}{
+		{http.StatusOK, "date;open", false},
+		{http.StatusOK, "<html>", true},
+		{http.StatusNotFound, "", false},
+		{http.StatusTooManyRequests, "", true},
+		{http.StatusServiceUnavailable, "", true},
+	}
+
+	for _, tt := range tests {
+		if b := Blocked(req, &Response{StatusCode: tt.code, Body: []byte(tt.body)}); b != tt.blocked {
+			t.Errorf("%d %q: expected %v, got %v", tt.code, tt.body, tt.blocked, b)
+		}
+	}
+
+	if Blocked(&Request{}, &Response{StatusCode: http.StatusOK, Body: []byte("<html>")}) {
+		t.Error("expected no block page without the BlockPage function")
+	}
+}
+
+func TestLimiterRate(t *testing.T) {
+	t.Parallel()
+
+	next := &scriptedFetcher{}
+	l, slept, _ := newTestLimiter(next, LimiterConfig{Rate: 2, Burst: 2})
+	for range 6 {
+		if _, err := l.Fetch(&Request{}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	// The burst of 2 is free, the next 4 requests wait half a second each.
+	if *slept != 2*time.Second {
+		t.Errorf("expected 2s of waiting, got %v", *slept)
+	}
+}
+
+func TestLimiterBackoffAndBreaker(t *testing.T) {
+	t.Parallel()
+
+	next := &scriptedFetcher{codes: []int{http.StatusTooManyRequests, -1, 0, http.StatusServiceUnavailable}}
+	cfg := LimiterConfig{Rate: 1, Burst: 1, MinRate: 0.1, Backoff: 0.5, FailureThreshold: 3, CooldownSeconds: 10, MaxTrips: 1}
+	l, slept, logs := newTestLimiter(next, cfg)
+	req := &Request{BlockPage: func(resp *Response) bool { return strings.HasPrefix(string(resp.Body), "<") }}
+
+	for range 3 {
+		if _, err := l.Fetch(req); err != nil && !strings.Contains(err.Error(), "reset") {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if s := l.Status(); s != "rate 0.125/s, breaker open, 3 consecutive failures, 1 trips" {
+		t.Errorf("unexpected status %s", s)
+	}
+
+	// The probe after the cooldown fails, the breaker gives up after the single trip.
+	before := *slept
+	if resp, err := l.Fetch(req); err != nil || resp.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("unexpected probe response %v: %v", resp, err)
+	}
+
+	if *slept-before < 10*time.Second {
+		t.Errorf("expected the cooldown wait, got %v", *slept-before)
+	}
+
+	if _, err := l.Fetch(req); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("expected the open circuit error, got %v", err)
+	}
+
+	if next.calls != 4 {
+		t.Errorf("expected 4 calls, got %d", next.calls)
+	}
+
+	if len(*logs) == 0 || !strings.Contains((*logs)[len(*logs)-1], "gave up") {
+		t.Errorf("unexpected logs %q", *logs)
+	}
+}
+
+func TestLimiterRecovery(t *testing.T) {
+	t.Parallel()
+
+	next := &scriptedFetcher{codes: []int{http.StatusTooManyRequests, http.StatusTooManyRequests}}
+	cfg := LimiterConfig{Rate: 1, Burst: 1, Recovery: 2, FailureThreshold: 2, CooldownSeconds: 5, MaxTrips: 3}
+	l, _, _ := newTestLimiter(next, cfg)
+
+	for range 4 {
+		if _, err := l.Fetch(&Request{}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	// The probe succeeded and closed the breaker, two successes restored the rate.
+	if s := l.Status(); s != "rate 1/s, breaker closed, 0 consecutive failures, 0 trips" {
+		t.Errorf("unexpected status %s", s)
+	}
+}
+
+func TestLimiterConfigOrDefault(t *testing.T) {
+	t.Parallel()
+
+	var missing *LimiterConfig
+	if c := missing.OrDefault(); c != DefaultLimiterConfig() {
+		t.Errorf("expected the default configuration, got %+v", c)
+	}
+
+	if c := (&LimiterConfig{Rate: 1}).OrDefault(); c.Rate != 1 {
+		t.Errorf("expected the configured rate, got %+v", c)
+	}
+}
@@ -0,0 +1,65 @@
+package euronext
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Instrument is an instrument of the xml instruments file with lower-case identifiers,
+// as used by the downloaders to build the file names.
+type Instrument struct {
+	Mnemonic string `json:"mnemonic"`
+	Mep      string `json:"mep"`
+	Mic      string `json:"mic"`
+	Isin     string `json:"isin"`
+	Type     string `json:"type"`
+	Status   string `json:"status"`
+}
+
+// ReadInstruments reads the xml instruments file.
+func ReadInstruments(fileName string) ([]Instrument, error) {
+	instruments := []Instrument{}
+	instrs, err := ReadXmlInstrumentsFile(fileName)
+	if err != nil {
+		return instruments, fmt.Errorf("cannot read instruments xml file '%s': %w", fileName, err)
+	}
+
+	for _, inst := range instrs.Instrument {
+		ins := Instrument{
+			Mnemonic: strings.ToLower(inst.Symbol),
+			Mep:      strings.ToLower(inst.Mep),
+			Mic:      strings.ToLower(inst.Mic),
+			Isin:     strings.ToLower(inst.Isin),
+			Type:     strings.ToLower(inst.Type),
+			Status:   inst.LifecycleStatus(),
+		}
+		instruments = append(instruments, ins)
+	}
+
+	return instruments, nil
+}
+
+// SafeMnemonic returns the mnemonic which can be used as a Windows folder name.
+func (s *Instrument) SafeMnemonic() string {
+	mnemonic := s.Mnemonic
+	if mnemonic == "prn" || mnemonic == "com" || mnemonic == "lpt" || mnemonic == "aux" {
+		mnemonic += "_"
+	}
+
+	return mnemonic
+}
+
+// FileFolder returns the "mic/type/mnemonic/" folder of the instrument.
+func (s *Instrument) FileFolder() string {
+	return fmt.Sprintf("%s/%s/%s/", s.Mic, s.Type, s.SafeMnemonic())
+}
+
+// StatisticsLine returns the "date;mep;mic;type;mnemonic;isin" prefixed statistics line.
+func (s *Instrument) StatisticsLine(date string, values ...any) string {
+	line := fmt.Sprintf("%s;%s;%s;%s;%s;%s", date, s.Mep, s.Mic, s.Type, s.Mnemonic, s.Isin)
+	for _, v := range values {
+		line += fmt.Sprintf(";%v", v)
+	}
+
+	return line
+}
@@ -0,0 +1,172 @@
+package intraday
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"compressed/writer"
+)
+
+// Bar is an OHLCV bar of the trades within an interval.
+type Bar struct {
+	// Time is the start of the interval in the time zone of the trades.
+	Time time.Time
+
+	Open, High, Low, Close float64
+	Volume                 int64
+
+	// Vwap is the volume weighted average price,
+	// the plain average price if the volume is zero, e.g. for the index values.
+	Vwap float64
+
+	// Trades is the number of the trades.
+	Trades int
+}
+
+// AggregateOptions are the options of the bar aggregation.
+type AggregateOptions struct {
+	// Interval is the bar interval from a second to a day.
+	Interval time.Duration
+
+	// ExcludeOffBook drops the off-book trades.
+	ExcludeOffBook bool
+
+	// ExcludeAuction drops the auction and opening trades.
+	ExcludeAuction bool
+}
+
+// ParseInterval parses the bar interval, a Go duration like 1s, 5m, 1h or a number of days like 1d.
+func ParseInterval(s string) (time.Duration, error) {
+	var d time.Duration
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("cannot parse interval '%s': %w", s, err)
+		}
+
+		d = time.Duration(n) * 24 * time.Hour
+	} else {
+		var err error
+		if d, err = time.ParseDuration(s); err != nil {
+			return 0, fmt.Errorf("cannot parse interval '%s': %w", s, err)
+		}
+	}
+
+	if d < time.Second || d > 24*time.Hour {
+		return 0, fmt.Errorf("interval '%s' is not between 1s and 1d", s)
+	}
+
+	return d, nil
+}
+
+// barStart returns the start of the interval containing the time,
+// the intervals are counted from the midnight in the time zone of the time.
+func barStart(t time.Time, interval time.Duration) time.Time {
+	y, m, d := t.Date()
+	midnight := time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+	return midnight.Add(t.Sub(midnight) / interval * interval)
+}
+
+// Aggregate builds the bars of the trades sorted by time, the intervals without trades have no bars.
+// The prices and the vwap are computed from the exact decimal prices and rounded to float64 once per bar.
+func Aggregate(trades []Trade, opt AggregateOptions) ([]Bar, error) {
+	if opt.Interval < time.Second || opt.Interval > 24*time.Hour {
+		return nil, fmt.Errorf("interval %v is not between 1s and 1d", opt.Interval)
+	}
+
+	bars := []Bar{}
+	var b *Bar
+	var open, high, low, last Decimal
+	amount, prices := new(big.Rat), new(big.Rat)
+	closeBar := func() {
+		if b == nil {
+			return
+		}
+
+		b.Open, b.High, b.Low, b.Close = open.Float64(), high.Float64(), low.Float64(), last.Float64()
+		if b.Volume > 0 {
+			b.Vwap, _ = amount.Quo(amount, big.NewRat(b.Volume, 1)).Float64()
+		} else {
+			b.Vwap, _ = prices.Quo(prices, big.NewRat(int64(b.Trades), 1)).Float64()
+		}
+
+		bars = append(bars, *b)
+	}
+
+	for i := range trades {
+		t := &trades[i]
+		if (opt.ExcludeOffBook && t.IsOffBook()) || (opt.ExcludeAuction && t.IsAuction()) {
+			continue
+		}
+
+		price := t.Price
+		start := barStart(t.Time, opt.Interval)
+		if b == nil || !start.Equal(b.Time) {
+			if b != nil && start.Before(b.Time) {
+				return nil, fmt.Errorf("trade %s at %v is before the previous trade", t.ID, t.Time)
+			}
+
+			closeBar()
+			b = &Bar{Time: start}
+			open, high, low = price, price, price
+			amount.SetInt64(0)
+			prices.SetInt64(0)
+		}
+
+		if price.Cmp(high) > 0 {
+			high = price
+		}
+		if price.Cmp(low) < 0 {
+			low = price
+		}
+		last = price
+		b.Volume += t.Volume
+		b.Trades++
+		r := price.Rat()
+		prices.Add(prices, r)
+		amount.Add(amount, r.Mul(r, big.NewRat(t.Volume, 1)))
+	}
+
+	closeBar()
+	return bars, nil
+}
+
+// BarsCsvHeader is the header line of the bars csv.
+const BarsCsvHeader = "time;open;high;low;close;volume;vwap;trades"
+
+// BarsCsvTimeFormat is the time format of the bars csv.
+const BarsCsvTimeFormat = "2006-01-02 15:04:05"
+
+// WriteBarsCsv writes the bars as semicolon-separated csv with a header,
+// the compression codec is chosen by the file name extension unless the options specify one.
+func WriteBarsCsv(fileName string, bars []Bar, opts writer.Options) error {
+	w, err := writer.Create(fileName, opts)
+	if err != nil {
+		return fmt.Errorf("cannot create bars file '%s': %w", fileName, err)
+	}
+
+	if !opts.Append {
+		if err := w.WriteString(BarsCsvHeader + "\n"); err != nil {
+			w.Close()
+			return fmt.Errorf("cannot write bars file '%s': %w", fileName, err)
+		}
+	}
+
+	for _, b := range bars {
+		line := fmt.Sprintf("%s;%v;%v;%v;%v;%d;%v;%d\n", b.Time.Format(BarsCsvTimeFormat),
+			b.Open, b.High, b.Low, b.Close, b.Volume, b.Vwap, b.Trades)
+		if err := w.WriteString(line); err != nil {
+			w.Close()
+			return fmt.Errorf("cannot write bars file '%s': %w", fileName, err)
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("cannot close bars file '%s': %w", fileName, err)
+	}
+
+	return nil
+}
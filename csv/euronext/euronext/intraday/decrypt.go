@@ -8,10 +8,11 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
 	"regexp"
 	"time"
+
+	"euronext/euronext/fetch"
 )
 
 const DefaultPassphrase = "24ayqVo7yJma"
@@ -119,22 +120,16 @@ var kyeRegexp = regexp.MustCompile(`var\s+kye\s*=\s*'([^']+)'`)
 // FetchPassphrase downloads the ajax-secure.js script from Euronext and extracts
 // the default passphrase. It returns the extracted passphrase or an error.
 func FetchPassphrase() (string, error) {
-	client := &http.Client{Timeout: 15 * time.Second}
-	resp, err := client.Get(ajaxSecureURL)
+	resp, err := fetch.Default.Fetch(&fetch.Request{Method: "GET", URL: ajaxSecureURL, Timeout: 15 * time.Second})
 	if err != nil {
 		return "", fmt.Errorf("cannot fetch ajax-secure.js: %w", err)
 	}
-	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		return "", fmt.Errorf("ajax-secure.js: unexpected status %s", resp.Status)
 	}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("cannot read ajax-secure.js body: %w", err)
-	}
-
+	body := resp.Body
 	matches := kyeRegexp.FindAllStringSubmatch(string(body), -1)
 	if len(matches) == 0 {
 		return "", fmt.Errorf("cannot find passphrase (kye) in ajax-secure.js")
@@ -1,27 +1,25 @@
 package intraday
 
 import (
-	"bytes"
 	"fmt"
-	"io"
 	"log"
-	"net/http"
 	"net/url"
 	"strings"
 	"time"
-)
 
-func getLastWorkingDay(startDateDaysBack int) string {
-	date := time.Now()
+	"calendar"
+	"euronext/euronext/fetch"
+)
 
-	// Go back to the most recent weekday (Monday-Friday)
-	for date.Weekday() == time.Saturday || date.Weekday() == time.Sunday {
-		date = date.AddDate(0, 0, -1)
+// getLastWorkingDay returns the session date of the mic calendar startDateDaysBack sessions
+// before the latest one, unknown mics use the Euronext calendar.
+func getLastWorkingDay(mic string, startDateDaysBack int) string {
+	cal, err := calendar.ForMic(mic)
+	if err != nil {
+		cal = calendar.Euronext
 	}
 
-	// Go back additional days as specified
-	date = date.AddDate(0, 0, -startDateDaysBack)
-
+	date := cal.AddSessions(cal.LastSession(time.Now()), -startDateDaysBack)
 	return date.Format("2006-01-02")
 }
 
@@ -30,7 +28,7 @@ func post(
 	timeout time.Duration,
 	referer string,
 	userAgent string,
-	startDateDaysBack int,
+	date string,
 	verbose bool,
 ) ([]byte, error) {
 	bodyMap := map[string]string{
@@ -38,7 +36,7 @@ func post(
 		// "endTime":   "20:00",
 		"nbitems":  "900000",
 		"timezone": "CET",
-		"date":     getLastWorkingDay(startDateDaysBack),
+		"date":     date,
 	}
 
 	// Prepare POST data
@@ -51,37 +49,22 @@ func post(
 	if verbose {
 		log.Printf("%s POST body: %s\n", uri, pd)
 	}
-	req, err := http.NewRequest("POST", uri, bytes.NewBufferString(pd))
-	if err != nil {
-		return nil, fmt.Errorf("cannot create request: %w", err)
-	}
-
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	req.Header.Set("User-Agent", userAgent)
-	req.Header.Set("Referer", referer)
-	req.Header.Set("Accept-Language", "en-us,en;q=0.5")
-	req.Header.Set("Accept-Charset", "ISO-8859-1,utf-8;q=0.7,*;q=0.7")
-	req.Header.Set("X-Requested-With", "XMLHttpRequest")
-	req.Header.Set("Accept", "application/json, text/javascript, */*")
-
-	// Create HTTP client with timeout and proxy settings
-	transport := &http.Transport{
-		Proxy: http.ProxyFromEnvironment, // Uses system proxy settings
+	header := fetch.Ajax(referer, userAgent)
+	header["Content-Type"] = "application/x-www-form-urlencoded"
+	req := &fetch.Request{
+		Method:  "POST",
+		URL:     uri,
+		Header:  header,
+		Body:    pd,
+		Timeout: timeout,
 	}
-	httpClient := http.Client{Timeout: timeout, Transport: transport}
 
-	resp, err := httpClient.Do(req)
+	resp, err := fetch.Default.Fetch(req)
 	if err != nil {
 		return nil, fmt.Errorf("download failed %s: %w", uri, err)
 	}
-	defer resp.Body.Close()
 
-	contents, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("cannot read response body %s: %w", uri, err)
-	}
-
-	return contents, nil
+	return resp.Body, nil
 }
 
 func postWithRetries(
@@ -91,7 +74,7 @@ func postWithRetries(
 	pauseBeforeRetry []time.Duration,
 	referer string,
 	userAgent string,
-	startDateDaysBack int,
+	date string,
 	verbose bool,
 ) ([]byte, error) {
 	var contents []byte
@@ -99,7 +82,7 @@ func postWithRetries(
 	retriesMax := len(pauseBeforeRetry)
 	retries := retriesMax
 	for retries > 0 {
-		contents, err = post(uri, timeout, referer, userAgent, startDateDaysBack, verbose)
+		contents, err = post(uri, timeout, referer, userAgent, date, verbose)
 		if err != nil {
 			if retries > 1 {
 				log.Printf("%s: download failed, retrying (%d of %d left): %v\n", label, retries, retriesMax, err)
@@ -169,8 +152,9 @@ func FetchIntradayData(
 	uri := getURI(isin, mic)
 	ref := getReferer(isin, mic, typ)
 	label := fmt.Sprintf("%s-%s-%s-%s", mic, typ, mnemonic, isin)
+	date := getLastWorkingDay(mic, startDateDaysBack)
 	if bs, err := postWithRetries(uri, label, timeout, pauseBeforeRetry, ref, userAgent,
-		startDateDaysBack, verbose); err != nil {
+		date, verbose); err != nil {
 		return nil, err
 	} else {
 		return bs, nil
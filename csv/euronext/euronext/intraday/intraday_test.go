@@ -0,0 +1,42 @@
+package intraday
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"euronext/euronext/fetch"
+)
+
+func TestFetchIntradayDataReplay(t *testing.T) {
+	rep, err := fetch.NewReplayer("testdata/fixtures")
+	if err != nil {
+		t.Fatalf("cannot load fixtures: %v", err)
+	}
+	defer fetch.Replace(rep)()
+
+	// The request body has the session date, the fixture matches any date.
+	pauses := []time.Duration{0}
+	bs, err := FetchIntradayData("it0005353880", "mtaa", "wnet23", "etv", time.Second, pauses, "agent", 3, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	jsonInd := JsonIntraday{}
+	if err := json.Unmarshal(bs, &jsonInd); err != nil {
+		t.Fatalf("cannot unmarshal json data: %v", err)
+	}
+
+	if len(jsonInd.Rows) != 1 || jsonInd.Count != 1 || jsonInd.TimeZone != "CET" {
+		t.Fatalf("unexpected intraday data %+v", jsonInd)
+	}
+
+	trade := jsonInd.Rows[0]
+	if trade.TradeID != "1OQ6DAH0G" || trade.Time != "15:10:26" || trade.Price != "4.773" || trade.Volume != "42" || trade.Type != "Auction" {
+		t.Errorf("unexpected trade %+v", trade)
+	}
+
+	if _, err := FetchIntradayData("gb0008847096", "xmsm", "tco", "stock", time.Second, pauses, "agent", 0, false); err == nil {
+		t.Errorf("expected an error for an instrument without fixtures")
+	}
+}
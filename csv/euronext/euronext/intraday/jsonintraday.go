@@ -33,7 +33,8 @@ type TradeType struct {
 	Label string `json:"label"`
 }
 
-type Trade struct {
+// JsonTrade is a trade row of the intraday json, see Trade for the typed trade.
+type JsonTrade struct {
 	TradeID StringOrInt `json:"tradeId"`
 	Time    string      `json:"time"`
 	Price   string      `json:"price"`
@@ -42,7 +43,7 @@ type Trade struct {
 }
 
 type JsonIntraday struct {
-	Rows             []Trade     `json:"rows"`
+	Rows             []JsonTrade `json:"rows"`
 	Count            int         `json:"count"`
 	Date             string      `json:"date"`
 	CountFiltered    int         `json:"countFiltered"`
@@ -0,0 +1,197 @@
+package intraday
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	jsonDateFormat = "02/01/2006"
+	jsonTimeFormat = "15:04:05"
+)
+
+// Trade is a typed intraday trade.
+type Trade struct {
+	ID string
+
+	// Time is the trade time in the time zone of the exchange.
+	Time time.Time
+
+	// Price is the exact price as quoted, the bars and the vwap are built from it.
+	Price  Decimal
+	Volume int64
+
+	// Type is the trade type of the trade label from the trade type list,
+	// a label missing from the list has only the Label field.
+	Type TradeType
+
+	// Code is the three-letter code of the trade label, UNK if the label is unknown.
+	Code string
+}
+
+// IsOffBook reports if the trade is reported off the order book.
+func (t *Trade) IsOffBook() bool {
+	switch t.Code {
+	case "OBM", "OBF", "OBE":
+		return true
+	default:
+		return false
+	}
+}
+
+// IsAuction reports if the trade is an auction or opening trade.
+func (t *Trade) IsAuction() bool {
+	switch t.Code {
+	case "AUC", "OPN":
+		return true
+	default:
+		return false
+	}
+}
+
+// Decimal is an exact decimal number, the Units scaled by 10 to the minus Scale, e.g. 1010.50 is 101050 and 2.
+type Decimal struct {
+	Units int64
+	Scale int
+}
+
+// ParseDecimal parses a decimal number with the optional thousands separators.
+func ParseDecimal(s string) (Decimal, error) {
+	t := strings.ReplaceAll(strings.TrimSpace(s), ",", "")
+	sign := ""
+	if strings.HasPrefix(t, "-") || strings.HasPrefix(t, "+") {
+		sign, t = t[:1], t[1:]
+	}
+
+	digits, fraction, _ := strings.Cut(t, ".")
+	if digits+fraction == "" || strings.Trim(digits+fraction, "0123456789") != "" {
+		return Decimal{}, fmt.Errorf("invalid decimal '%s'", s)
+	}
+
+	units, err := strconv.ParseInt(sign+digits+fraction, 10, 64)
+	if err != nil {
+		return Decimal{}, fmt.Errorf("invalid decimal '%s': %w", s, err)
+	}
+
+	return Decimal{Units: units, Scale: len(fraction)}, nil
+}
+
+// String returns the decimal with the Scale fraction digits.
+func (d Decimal) String() string {
+	s := strconv.FormatInt(d.Units, 10)
+	if d.Scale <= 0 {
+		return s
+	}
+
+	sign := ""
+	if d.Units < 0 {
+		sign, s = "-", s[1:]
+	}
+
+	if len(s) <= d.Scale {
+		s = strings.Repeat("0", d.Scale-len(s)+1) + s
+	}
+
+	return sign + s[:len(s)-d.Scale] + "." + s[len(s)-d.Scale:]
+}
+
+// Rat returns the exact value of the decimal.
+func (d Decimal) Rat() *big.Rat {
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(d.Scale)), nil)
+	return new(big.Rat).SetFrac(big.NewInt(d.Units), scale)
+}
+
+// Cmp compares the values of the decimals, returning -1, 0 or +1.
+func (d Decimal) Cmp(o Decimal) int {
+	if d.Scale == o.Scale {
+		switch {
+		case d.Units < o.Units:
+			return -1
+		case d.Units > o.Units:
+			return 1
+		default:
+			return 0
+		}
+	}
+
+	return d.Rat().Cmp(o.Rat())
+}
+
+// Float64 returns the float64 value nearest to the decimal.
+func (d Decimal) Float64() float64 {
+	f, _ := strconv.ParseFloat(d.String(), 64)
+	return f
+}
+
+// Trades converts the rows to typed trades sorted by time.
+// The trade times are on the json date in the json time zone, CET if the time zone is empty.
+func (j *JsonIntraday) Trades() ([]Trade, error) {
+	zone := j.TimeZone
+	if zone == "" {
+		zone = "CET"
+	}
+
+	loc, err := time.LoadLocation(zone)
+	if err != nil {
+		return nil, fmt.Errorf("cannot load time zone '%s': %w", zone, err)
+	}
+
+	date, err := time.ParseInLocation(jsonDateFormat, j.Date, loc)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse date '%s': %w", j.Date, err)
+	}
+
+	types := map[string]TradeType{}
+	for _, t := range j.TradeTypeList {
+		types[strings.ToLower(t.Label)] = t
+	}
+
+	codes := NewTradeLabelMap()
+	y, m, d := date.Date()
+	trades := make([]Trade, 0, len(j.Rows))
+	// The rows are the latest first, the ties keep the reversed row order.
+	for i := len(j.Rows) - 1; i >= 0; i-- {
+		r := &j.Rows[i]
+		tm, err := time.Parse(jsonTimeFormat, strings.TrimSpace(r.Time))
+		if err != nil {
+			return nil, fmt.Errorf("trade %s: cannot parse time '%s': %w", r.TradeID, r.Time, err)
+		}
+
+		price, err := ParseDecimal(r.Price)
+		if err != nil {
+			return nil, fmt.Errorf("trade %s: cannot parse price '%s': %w", r.TradeID, r.Price, err)
+		}
+
+		volume, err := strconv.ParseInt(strings.ReplaceAll(strings.TrimSpace(r.Volume), ",", ""), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("trade %s: cannot parse volume '%s': %w", r.TradeID, r.Volume, err)
+		}
+
+		label := strings.ToLower(strings.TrimSpace(r.Type))
+		typ, ok := types[label]
+		if !ok {
+			typ = TradeType{Label: r.Type}
+		}
+
+		code, ok := codes[label]
+		if !ok {
+			code = codes[""]
+		}
+
+		trades = append(trades, Trade{
+			ID:     string(r.TradeID),
+			Time:   time.Date(y, m, d, tm.Hour(), tm.Minute(), tm.Second(), 0, loc),
+			Price:  price,
+			Volume: volume,
+			Type:   typ,
+			Code:   code,
+		})
+	}
+
+	sort.SliceStable(trades, func(i, k int) bool { return trades[i].Time.Before(trades[k].Time) })
+	return trades, nil
+}
@@ -0,0 +1,220 @@
+package intraday
+
+import (
+	"compress/gzip"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"compressed/writer"
+)
+
+func testIntraday() *JsonIntraday {
+	return &JsonIntraday{
+		Date:     "29/03/2024",
+		TimeZone: "CET",
+		Rows: []JsonTrade{
+			{TradeID: "7", Time: "17:35:00", Price: "1,010.5", Volume: "1,000", Type: "Auction"},
+			{TradeID: "6", Time: "10:01:30", Price: "1000", Volume: "50", Type: "OffBook Out of market"},
+			{TradeID: "5", Time: "10:01:10", Price: "1003", Volume: "10", Type: "Exchange Continuous"},
+			{TradeID: "4", Time: "10:00:59", Price: "1001", Volume: "30", Type: "Exchange Continuous"},
+			{TradeID: "3", Time: "10:00:20", Price: "1004", Volume: "10", Type: "Exchange Continuous"},
+			{TradeID: "2", Time: "10:00:20", Price: "999", Volume: "20", Type: "Exchange Continuous"},
+			{TradeID: "1", Time: "09:00:00", Price: "1000", Volume: "100", Type: "Opening"},
+		},
+		TradeTypeList: []TradeType{{Code: "00H", IDNXT: "00H", Label: "Auction"}},
+	}
+}
+
+func TestTrades(t *testing.T) {
+	t.Parallel()
+
+	js, err := ReadJsonIntradayFile("testdata/fixtures/intraday_wnet23.body")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	trades, err := js.Trades()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(trades) != 1 {
+		t.Fatalf("expected 1 trade, got %d", len(trades))
+	}
+
+	// The 5th of April 2023 is in the summer time.
+	tr := trades[0]
+	if !tr.Time.Equal(time.Date(2023, 4, 5, 13, 10, 26, 0, time.UTC)) || tr.Price.String() != "4.773" || tr.Volume != 42 ||
+		tr.Code != "AUC" || tr.Type.Code != "00H" || tr.ID != "1OQ6DAH0G" || !tr.IsAuction() {
+		t.Errorf("unexpected trade %+v", tr)
+	}
+
+	trades, err = testIntraday().Trades()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ids := []string{}
+	for _, tr := range trades {
+		ids = append(ids, tr.ID)
+	}
+
+	if s := strings.Join(ids, ","); s != "1,2,3,4,5,6,7" {
+		t.Errorf("unexpected trade order %s", s)
+	}
+
+	if tr := trades[6]; tr.Price != (Decimal{Units: 10105, Scale: 1}) || tr.Volume != 1000 || tr.Type.Label != "Auction" {
+		t.Errorf("unexpected trade %+v", tr)
+	}
+
+	if tr := trades[5]; !tr.IsOffBook() || tr.Code != "OBM" || tr.Type.Code != "" {
+		t.Errorf("unexpected off-book trade %+v", tr)
+	}
+
+	bad := testIntraday()
+	bad.Rows[0].Volume = "x"
+	if _, err := bad.Trades(); err == nil {
+		t.Error("expected an error for a bad volume")
+	}
+}
+
+func TestAggregate(t *testing.T) {
+	t.Parallel()
+
+	trades, err := testIntraday().Trades()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	bars, err := Aggregate(trades, AggregateOptions{Interval: time.Minute, ExcludeOffBook: true, ExcludeAuction: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(bars) != 2 {
+		t.Fatalf("expected 2 bars, got %+v", bars)
+	}
+
+	b := bars[0]
+	if b.Time.Format("15:04:05") != "10:00:00" || b.Open != 999 || b.High != 1004 || b.Low != 999 || b.Close != 1001 ||
+		b.Volume != 60 || b.Trades != 3 || math.Abs(b.Vwap-(999*20+1004*10+1001*30)/60.) > 1e-9 {
+		t.Errorf("unexpected bar %+v", b)
+	}
+
+	bars, err = Aggregate(trades, AggregateOptions{Interval: 24 * time.Hour})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(bars) != 1 || bars[0].Time.Format("2006-01-02 15:04") != "2024-03-29 00:00" || bars[0].Trades != 7 ||
+		bars[0].Open != 1000 || bars[0].Close != 1010.5 || bars[0].Volume != 1220 {
+		t.Errorf("unexpected daily bars %+v", bars)
+	}
+
+	if _, err := Aggregate(trades, AggregateOptions{Interval: time.Millisecond}); err == nil {
+		t.Error("expected an error for a sub-second interval")
+	}
+}
+
+func TestAggregateExactPrices(t *testing.T) {
+	t.Parallel()
+
+	js := testIntraday()
+	js.Rows = []JsonTrade{
+		{TradeID: "3", Time: "10:00:30", Price: "0.3", Volume: "1", Type: "Exchange Continuous"},
+		{TradeID: "2", Time: "10:00:20", Price: "0.2", Volume: "1", Type: "Exchange Continuous"},
+		{TradeID: "1", Time: "10:00:10", Price: "0.10", Volume: "1", Type: "Exchange Continuous"},
+	}
+
+	trades, err := js.Trades()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if d := trades[0].Price; d != (Decimal{Units: 10, Scale: 2}) || d.String() != "0.10" || d.Float64() != 0.1 {
+		t.Errorf("unexpected price %+v", trades[0])
+	}
+
+	// The float64 sum 0.1+0.2+0.3 is 0.6000000000000001.
+	bars, err := Aggregate(trades, AggregateOptions{Interval: time.Minute})
+	if err != nil || len(bars) != 1 || bars[0].Vwap != 0.2 || bars[0].Open != 0.1 || bars[0].High != 0.3 {
+		t.Errorf("unexpected bars %+v, error %v", bars, err)
+	}
+}
+
+func TestParseDecimal(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]string{"1,010.5": "1010.5", "-0.05": "-0.05", ".5": "0.5", "7.": "7", "+12": "12", " 4.7730 ": "4.7730"}
+	for s, expected := range tests {
+		if d, err := ParseDecimal(s); err != nil || d.String() != expected {
+			t.Errorf("%s: expected %s, got %s: %v", s, expected, d, err)
+		}
+	}
+
+	for _, s := range []string{"", "-", ".", "1.2.3", "1e5", "x", "1-2"} {
+		if _, err := ParseDecimal(s); err == nil {
+			t.Errorf("%s: expected an error", s)
+		}
+	}
+
+	a, _ := ParseDecimal("1.50")
+	b, _ := ParseDecimal("1.5")
+	if a.Cmp(b) != 0 || b.Cmp(Decimal{Units: 151, Scale: 2}) >= 0 {
+		t.Errorf("unexpected comparison")
+	}
+}
+
+func TestParseInterval(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]time.Duration{"1s": time.Second, "5m": 5 * time.Minute, "1h": time.Hour, "1d": 24 * time.Hour}
+	for s, d := range tests {
+		if p, err := ParseInterval(s); err != nil || p != d {
+			t.Errorf("%s: expected %v, got %v: %v", s, d, p, err)
+		}
+	}
+
+	for _, s := range []string{"0s", "2d", "x", "500ms"} {
+		if _, err := ParseInterval(s); err == nil {
+			t.Errorf("%s: expected an error", s)
+		}
+	}
+}
+
+func TestWriteBarsCsv(t *testing.T) {
+	t.Parallel()
+
+	trades, _ := testIntraday().Trades()
+	bars, _ := Aggregate(trades, AggregateOptions{Interval: time.Hour})
+	fileName := filepath.Join(t.TempDir(), "bars.1h.csv.gz")
+	if err := WriteBarsCsv(fileName, bars, writer.Options{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	f, err := os.Open(fileName)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer f.Close()
+
+	r, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatalf("expected a gzip file: %v", err)
+	}
+
+	bs, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(bs)), "\n")
+	if len(lines) != 4 || lines[0] != BarsCsvHeader || lines[1] != "2024-03-29 09:00:00;1000;1000;1000;1000;100;1000;1" {
+		t.Errorf("unexpected csv %q", lines)
+	}
+}
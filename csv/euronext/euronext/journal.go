@@ -0,0 +1,273 @@
+package euronext
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// JournalMode selects the instruments processed by a run with a journal.
+type JournalMode int
+
+const (
+	JournalRestart     JournalMode = iota // JournalRestart clears the journal and processes all instruments.
+	JournalResume                         // JournalResume skips the instruments completed by the previous runs.
+	JournalRetryFailed                    // JournalRetryFailed processes only the instruments with download errors.
+)
+
+// JournalEntry is a line of the run journal, recording a finished stage of an instrument.
+type JournalEntry struct {
+	Time     string `json:"time"`
+	Mic      string `json:"mic"`
+	Type     string `json:"type"`
+	Mnemonic string `json:"mnemonic"`
+	Isin     string `json:"isin"`
+	Stage    string `json:"stage"`
+	Error    string `json:"error,omitempty"`
+}
+
+// journalState is the state of an instrument replayed from the journal entries.
+type journalState struct {
+	last   JournalEntry
+	failed bool
+}
+
+// Journal is an append-only json lines file recording the stages of the instruments of a run.
+// Every entry is written with a single write and synced, a partial last line
+// left by an interrupted run is ignored.
+type Journal struct {
+	mode   JournalMode
+	mu     sync.Mutex
+	file   *os.File
+	states map[string]*journalState
+}
+
+// JournalFileName returns the journal file name of the command and the session,
+// located next to the downloads folder.
+func JournalFileName(downloads, command string, sessionDate time.Time) string {
+	dir := filepath.Dir(filepath.Clean(downloads))
+	return filepath.Join(dir, fmt.Sprintf("%s_%s.journal", command, sessionDate.Format("20060102")))
+}
+
+func journalKey(mic, typ, mnemonic, isin string) string {
+	return mic + ";" + typ + ";" + mnemonic + ";" + isin
+}
+
+// OpenJournal opens the journal file for appending, the restart mode clears the existing entries.
+func OpenJournal(fileName string, mode JournalMode) (*Journal, error) {
+	j := &Journal{mode: mode, states: map[string]*journalState{}}
+
+	flags := os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	valid := int64(0)
+	if mode == JournalRestart {
+		flags |= os.O_TRUNC
+	} else {
+		var err error
+		if valid, err = j.read(fileName); err != nil {
+			return nil, err
+		}
+	}
+
+	f, err := os.OpenFile(fileName, flags, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open journal file '%s': %w", fileName, err)
+	}
+
+	j.file = f
+	if err := j.repair(valid); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return j, nil
+}
+
+// read replays the journal entries and returns the length of the valid lines.
+func (j *Journal) read(fileName string) (int64, error) {
+	f, err := os.Open(fileName)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return 0, nil
+		}
+
+		return 0, fmt.Errorf("cannot open journal file '%s': %w", fileName, err)
+	}
+	defer f.Close()
+
+	valid := int64(0)
+	var pending error
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		if pending != nil {
+			return 0, pending
+		}
+
+		e := JournalEntry{}
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			// Only the last line may be broken by an interrupted run.
+			pending = fmt.Errorf("cannot decode line %d of journal file '%s': %w", n, fileName, err)
+			continue
+		}
+
+		j.replay(e)
+		valid += int64(len(scanner.Bytes())) + 1
+	}
+
+	if err := scanner.Err(); err != nil {
+		return 0, fmt.Errorf("cannot read journal file '%s': %w", fileName, err)
+	}
+
+	return valid, nil
+}
+
+// repair cuts the broken last line or terminates the unterminated one, so the appended entries start on a new line.
+func (j *Journal) repair(valid int64) error {
+	fi, err := j.file.Stat()
+	if err != nil {
+		return fmt.Errorf("cannot stat journal file '%s': %w", j.file.Name(), err)
+	}
+
+	if valid < fi.Size() {
+		if err := j.file.Truncate(valid); err != nil {
+			return fmt.Errorf("cannot truncate journal file '%s': %w", j.file.Name(), err)
+		}
+	} else if valid > fi.Size() {
+		if _, err := j.file.Write([]byte{'\n'}); err != nil {
+			return fmt.Errorf("cannot write journal file '%s': %w", j.file.Name(), err)
+		}
+	}
+
+	return nil
+}
+
+func (j *Journal) replay(e JournalEntry) {
+	key := journalKey(e.Mic, e.Type, e.Mnemonic, e.Isin)
+	s, ok := j.states[key]
+	if !ok {
+		s = &journalState{}
+		j.states[key] = s
+	}
+
+	s.last = e
+	switch e.Stage {
+	case StageDownload.String():
+		s.failed = e.Error != ""
+	case StageArchive.String():
+		s.failed = s.failed || e.Error != ""
+	}
+}
+
+// Record appends the finished stage of the instrument to the journal.
+func (j *Journal) Record(ins *Instrument, stage Stage, stageErr string) error {
+	e := JournalEntry{
+		Time:     time.Now().Format("2006-01-02 15:04:05"),
+		Mic:      ins.Mic,
+		Type:     ins.Type,
+		Mnemonic: ins.Mnemonic,
+		Isin:     ins.Isin,
+		Stage:    stage.String(),
+		Error:    stageErr,
+	}
+
+	bs, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("cannot encode journal entry: %w", err)
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if _, err := j.file.Write(append(bs, '\n')); err != nil {
+		return fmt.Errorf("cannot write journal file '%s': %w", j.file.Name(), err)
+	}
+
+	if err := j.file.Sync(); err != nil {
+		return fmt.Errorf("cannot sync journal file '%s': %w", j.file.Name(), err)
+	}
+
+	j.replay(e)
+	return nil
+}
+
+// Completed reports if the last recorded stage of the instrument is the final one without an error.
+func (j *Journal) Completed(ins *Instrument, final Stage) bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	s, ok := j.states[journalKey(ins.Mic, ins.Type, ins.Mnemonic, ins.Isin)]
+	return ok && !s.failed && s.last.Stage == final.String() && s.last.Error == ""
+}
+
+// Failed reports if the last download of the instrument has a download or archive error.
+func (j *Journal) Failed(ins *Instrument) bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	s, ok := j.states[journalKey(ins.Mic, ins.Type, ins.Mnemonic, ins.Isin)]
+	return ok && s.failed
+}
+
+// JournalModeOf returns the journal mode of the resume and retry-failed command flags.
+func JournalModeOf(resume, retryFailed bool) (JournalMode, error) {
+	switch {
+	case resume && retryFailed:
+		return JournalRestart, errors.New("resume and retry-failed cannot be combined")
+	case resume:
+		return JournalResume, nil
+	case retryFailed:
+		return JournalRetryFailed, nil
+	default:
+		return JournalRestart, nil
+	}
+}
+
+// Close closes the journal file.
+func (j *Journal) Close() error {
+	if err := j.file.Close(); err != nil {
+		return fmt.Errorf("cannot close journal file '%s': %w", j.file.Name(), err)
+	}
+
+	return nil
+}
+
+// Hooks returns the pipeline hooks selecting the instruments of the journal mode
+// and recording the stages, final is the last stage of a completed instrument.
+// The logf reports the selection and the journal write errors.
+func (j *Journal) Hooks(final Stage, logf func(format string, args ...any)) Hooks {
+	return Hooks{
+		Loaded: func(instruments []Instrument) []Instrument {
+			if j.mode == JournalRestart {
+				return instruments
+			}
+
+			selected := []Instrument{}
+			for i := range instruments {
+				ins := &instruments[i]
+				if (j.mode == JournalResume && !j.Completed(ins, final)) ||
+					(j.mode == JournalRetryFailed && j.Failed(ins)) {
+					selected = append(selected, *ins)
+				}
+			}
+
+			logf("%d of %d instruments selected from the journal\n", len(selected), len(instruments))
+			return selected
+		},
+		After: func(stage Stage, c *Combi, err error) {
+			es := ""
+			if err != nil {
+				es = err.Error()
+			} else if stage != StageMerge {
+				es = c.DownloadError
+			}
+
+			if err := j.Record(&c.Instrument, stage, es); err != nil {
+				logf("%s\n", err)
+			}
+		},
+	}
+}
@@ -0,0 +1,120 @@
+package euronext
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+)
+
+// runJournaled runs a pipeline over the test instruments with the journal hooks
+// and returns the downloaded instruments, the failing ones have download errors.
+func runJournaled(t *testing.T, xmlFile, journalFile string, mode JournalMode, failing ...string) []string {
+	t.Helper()
+
+	j, err := OpenJournal(journalFile, mode)
+	if err != nil {
+		t.Fatalf("cannot open journal: %v", err)
+	}
+	defer j.Close()
+
+	downloaded := []string{}
+	p := Pipeline{
+		SessionDate:    time.Date(2024, 6, 4, 0, 0, 0, 0, time.UTC),
+		XmlInstruments: xmlFile,
+		Logf:           func(string, ...any) {},
+		Download: func(c *Combi) error {
+			downloaded = append(downloaded, c.Instrument.Mnemonic)
+			for _, f := range failing {
+				if c.Instrument.Mnemonic == f {
+					return errors.New("boom")
+				}
+			}
+
+			c.Raw = []byte(testRaw)
+			c.Adj = []byte(testAdj)
+			return nil
+		},
+		Merge: func(c *Combi, stati *Statistics) error {
+			return nil
+		},
+		Hooks: j.Hooks(StageMerge, func(string, ...any) {}),
+	}
+
+	if _, err := p.Run(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	sort.Strings(downloaded)
+	return downloaded
+}
+
+func TestJournal(t *testing.T) {
+	t.Parallel()
+
+	xmlFile := writeTestInstruments(t)
+	journalFile := JournalFileName(filepath.Join(t.TempDir(), "downloads")+"/", "enxhist", time.Date(2024, 6, 4, 0, 0, 0, 0, time.UTC))
+	if filepath.Base(journalFile) != "enxhist_20240604.journal" {
+		t.Errorf("unexpected journal file name %s", journalFile)
+	}
+
+	check := func(step string, got []string, expected ...string) {
+		t.Helper()
+		if len(got) != len(expected) {
+			t.Fatalf("%s: expected %v, got %v", step, expected, got)
+		}
+
+		for i := range got {
+			if got[i] != expected[i] {
+				t.Fatalf("%s: expected %v, got %v", step, expected, got)
+			}
+		}
+	}
+
+	check("restart", runJournaled(t, xmlFile, journalFile, JournalRestart, "kpn", "solb"), "ai", "kpn", "solb")
+	check("retry failed", runJournaled(t, xmlFile, journalFile, JournalRetryFailed, "solb"), "kpn", "solb")
+
+	// An interrupted write leaves a partial last line which is dropped.
+	f, err := os.OpenFile(journalFile, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatalf("cannot open journal: %v", err)
+	}
+	f.WriteString(`{"time":"2024-06-04 18:00:00","mic":"xpar","type":"st`)
+	f.Close()
+
+	check("resume", runJournaled(t, xmlFile, journalFile, JournalResume), "solb")
+	check("resume completed", runJournaled(t, xmlFile, journalFile, JournalResume))
+	check("restart", runJournaled(t, xmlFile, journalFile, JournalRestart), "ai", "kpn", "solb")
+
+	j, err := OpenJournal(journalFile, JournalResume)
+	if err != nil {
+		t.Fatalf("cannot reopen journal: %v", err)
+	}
+	defer j.Close()
+
+	if len(j.states) != 3 {
+		t.Errorf("expected 3 journaled instruments, got %d", len(j.states))
+	}
+}
+
+func TestJournalModeOf(t *testing.T) {
+	t.Parallel()
+
+	if m, err := JournalModeOf(false, false); err != nil || m != JournalRestart {
+		t.Errorf("unexpected mode %v: %v", m, err)
+	}
+
+	if m, err := JournalModeOf(true, false); err != nil || m != JournalResume {
+		t.Errorf("unexpected mode %v: %v", m, err)
+	}
+
+	if m, err := JournalModeOf(false, true); err != nil || m != JournalRetryFailed {
+		t.Errorf("unexpected mode %v: %v", m, err)
+	}
+
+	if _, err := JournalModeOf(true, true); err == nil {
+		t.Error("expected an error for combined modes")
+	}
+}
@@ -0,0 +1,173 @@
+package euronext
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"euronext/euronext/atomicfile"
+)
+
+// Lifecycle statuses of the instruments, stored in the status attribute of the xml instruments file.
+const (
+	StatusActive    = "active"    // StatusActive is the default status of an instrument without the status attribute.
+	StatusSuspended = "suspended" // StatusSuspended means the instrument disappeared from the discovery.
+	StatusDelisted  = "delisted"  // StatusDelisted means the instrument is missing from the discovery for a while.
+	StatusMigrated  = "migrated"  // StatusMigrated means the ISIN is discovered on another MIC, e.g. XPAR to ALXP.
+)
+
+// IsRetiredStatus tells if the status is delisted or migrated.
+// The suspended instruments are not retired, they may resume trading.
+func IsRetiredStatus(status string) bool {
+	return status == StatusDelisted || status == StatusMigrated
+}
+
+// LifecycleStatus returns the status of the instrument, the missing status attribute means active.
+func (ins *XmlInstrument) LifecycleStatus() string {
+	if ins.Status == nil || *ins.Status == "" {
+		return StatusActive
+	}
+
+	return strings.ToLower(*ins.Status)
+}
+
+// IsRetired tells if the instrument is delisted or migrated.
+func (s *Instrument) IsRetired() bool {
+	return IsRetiredStatus(s.Status)
+}
+
+// ActiveInstruments returns the instruments which are not retired.
+func ActiveInstruments(instruments []Instrument) []Instrument {
+	active := make([]Instrument, 0, len(instruments))
+	for _, ins := range instruments {
+		if !ins.IsRetired() {
+			active = append(active, ins)
+		}
+	}
+
+	return active
+}
+
+// LifecycleUpdate is a change of the lifecycle status of an instrument.
+type LifecycleUpdate struct {
+	Mic    string
+	Isin   string
+	Symbol string
+
+	// Old and New are the previous and the new statuses.
+	Old string
+	New string
+
+	// Date is the "2006-01-02" date the instrument was first missing from the discovery,
+	// it is empty for the active status.
+	Date string
+
+	// MigratedTo is the new MIC of a migrated instrument.
+	MigratedTo string
+}
+
+// String implements the fmt.Stringer interface.
+func (u LifecycleUpdate) String() string {
+	s := fmt.Sprintf("%s %s %s: %s -> %s", u.Mic, u.Symbol, u.Isin, u.Old, u.New)
+	if u.MigratedTo != "" {
+		s += " " + u.MigratedTo
+	}
+
+	if u.Date != "" {
+		s += " since " + u.Date
+	}
+
+	return s
+}
+
+var (
+	xmlInstrumentTagRegexp  = regexp.MustCompile(`<instrument\s[^>]*>`)
+	xmlAttributeRegexp      = regexp.MustCompile(`\s+([A-Za-z]+)="([^"]*)"`)
+	xmlStatusAttributeNames = map[string]bool{"status": true, "statusDate": true, "migratedTo": true}
+)
+
+// UpdateXmlInstrumentsFileStatus sets the lifecycle attributes of the updated instruments in place,
+// keeping the rest of the xml instruments file as is. The instruments are matched by MIC and ISIN.
+// The file is replaced by a renamed temporary file, so a failed write keeps the previous file.
+// It returns the number of the changed instrument elements.
+func UpdateXmlInstrumentsFileStatus(fileName string, updates []LifecycleUpdate) (int, error) {
+	if len(updates) == 0 {
+		return 0, nil
+	}
+
+	content, err := os.ReadFile(fileName)
+	if err != nil {
+		return 0, fmt.Errorf("cannot read file '%s': %w", fileName, err)
+	}
+
+	byKey := map[string]*LifecycleUpdate{}
+	for i := range updates {
+		byKey[masterKey(updates[i].Isin, updates[i].Mic)] = &updates[i]
+	}
+
+	changed := 0
+	content = xmlInstrumentTagRegexp.ReplaceAllFunc(content, func(tag []byte) []byte {
+		var mic, isin string
+		for _, m := range xmlAttributeRegexp.FindAllSubmatch(tag, -1) {
+			switch string(m[1]) {
+			case "mic":
+				mic = string(m[2])
+			case "isin":
+				isin = string(m[2])
+			}
+		}
+
+		u, ok := byKey[masterKey(isin, mic)]
+		if !ok {
+			return tag
+		}
+
+		changed++
+		return setXmlStatusAttributes(tag, u)
+	})
+
+	if changed == 0 {
+		return 0, nil
+	}
+
+	if err := atomicfile.WriteFile(fileName, content); err != nil {
+		return 0, fmt.Errorf("cannot write file '%s': %w", fileName, err)
+	}
+
+	return changed, nil
+}
+
+func setXmlStatusAttributes(tag []byte, u *LifecycleUpdate) []byte {
+	tag = xmlAttributeRegexp.ReplaceAllFunc(tag, func(attr []byte) []byte {
+		if xmlStatusAttributeNames[string(xmlAttributeRegexp.FindSubmatch(attr)[1])] {
+			return nil
+		}
+
+		return attr
+	})
+
+	if u.New == StatusActive {
+		return tag
+	}
+
+	attrs := fmt.Sprintf(" status=\"%s\"", u.New)
+	if u.Date != "" {
+		attrs += fmt.Sprintf(" statusDate=\"%s\"", u.Date)
+	}
+
+	if u.MigratedTo != "" {
+		attrs += fmt.Sprintf(" migratedTo=\"%s\"", u.MigratedTo)
+	}
+
+	end := len(tag) - 1
+	if bytes.HasSuffix(tag, []byte("/>")) {
+		end--
+		for end > 0 && tag[end-1] == ' ' {
+			end--
+		}
+	}
+
+	return append(append(append([]byte{}, tag[:end]...), attrs...), tag[end:]...)
+}
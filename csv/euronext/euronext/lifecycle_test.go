@@ -0,0 +1,126 @@
+package euronext
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"euronext/euronext/atomicfile"
+)
+
+func TestUpdateXmlInstrumentsFileStatus(t *testing.T) {
+	t.Parallel()
+
+	fileName := writeTestInstruments(t)
+	updates := []LifecycleUpdate{
+		{Mic: "xpar", Isin: "fr0000120073", Old: StatusActive, New: StatusMigrated, Date: "2024-06-04", MigratedTo: "ALXP"},
+		{Mic: "XAMS", Isin: "NL0000009082", Old: StatusActive, New: StatusSuspended, Date: "2024-06-04"},
+		{Mic: "XLIS", Isin: "PTEDP0AM0009", Old: StatusActive, New: StatusSuspended, Date: "2024-06-04"},
+	}
+
+	n, err := UpdateXmlInstrumentsFileStatus(fileName, updates)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if n != 2 {
+		t.Errorf("expected 2 changed instruments, got %d", n)
+	}
+
+	content, _ := os.ReadFile(fileName)
+	expected := `  <instrument mic="XPAR" isin="FR0000120073" symbol="AI" name="AIR LIQUIDE" type="stock" mep="PAR" vendor="Euronext" status="migrated" statusDate="2024-06-04" migratedTo="ALXP" />`
+	if lines := strings.Split(string(content), "\n"); len(lines) != 7 || lines[2] != expected {
+		t.Fatalf("unexpected content:\n%s", content)
+	}
+
+	instruments, err := ReadInstruments(fileName)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if instruments[0].Status != StatusMigrated || !instruments[0].IsRetired() ||
+		instruments[1].Status != StatusSuspended || instruments[1].IsRetired() || instruments[2].Status != StatusActive {
+		t.Errorf("unexpected statuses %+v", instruments)
+	}
+
+	if active := ActiveInstruments(instruments); len(active) != 2 || active[0].Mnemonic != "kpn" {
+		t.Errorf("unexpected active instruments %+v", active)
+	}
+
+	for _, includeRetired := range []bool{false, true} {
+		downloaded := 0
+		p := Pipeline{
+			XmlInstruments: fileName,
+			IncludeRetired: includeRetired,
+			Logf:           func(string, ...any) {},
+			Download: func(c *Combi) error {
+				downloaded++
+				return nil
+			},
+		}
+		if _, err := p.Run(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if expected := map[bool]int{false: 2, true: 3}[includeRetired]; downloaded != expected {
+			t.Errorf("include retired %v: expected %d downloads, got %d", includeRetired, expected, downloaded)
+		}
+	}
+
+	// Becoming active removes the attributes.
+	updates = []LifecycleUpdate{{Mic: "XPAR", Isin: "FR0000120073", Old: StatusMigrated, New: StatusActive}}
+	if _, err := UpdateXmlInstrumentsFileStatus(fileName, updates); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	content, _ = os.ReadFile(fileName)
+	expected = `  <instrument mic="XPAR" isin="FR0000120073" symbol="AI" name="AIR LIQUIDE" type="stock" mep="PAR" vendor="Euronext" />`
+	if lines := strings.Split(string(content), "\n"); len(lines) != 7 || lines[2] != expected {
+		t.Errorf("unexpected content:\n%s", content)
+	}
+
+	if _, err := UpdateXmlInstrumentsFileStatus(filepath.Join(t.TempDir(), "missing.xml"), updates); err == nil {
+		t.Errorf("expected an error for a missing file")
+	}
+}
+
+func TestUpdateXmlInstrumentsFileStatusFailedRename(t *testing.T) {
+	// Not parallel, the rename function is replaced.
+	atomicfile.Rename = func(string, string) error { return errors.New("disk full") }
+	defer func() { atomicfile.Rename = os.Rename }()
+
+	fileName := writeTestInstruments(t)
+	if err := os.Chmod(fileName, 0640); err != nil {
+		t.Fatal(err)
+	}
+
+	before, _ := os.ReadFile(fileName)
+	updates := []LifecycleUpdate{{Mic: "XPAR", Isin: "FR0000120073", Old: StatusActive, New: StatusDelisted, Date: "2024-06-04"}}
+	if _, err := UpdateXmlInstrumentsFileStatus(fileName, updates); err == nil {
+		t.Fatalf("expected an error for a failed rename")
+	}
+
+	if after, _ := os.ReadFile(fileName); string(after) != string(before) {
+		t.Errorf("expected the previous file kept, got\n%s", after)
+	}
+
+	if entries, _ := os.ReadDir(filepath.Dir(fileName)); len(entries) != 1 {
+		t.Errorf("expected the temporary file removed, got %v", entries)
+	}
+
+	atomicfile.Rename = os.Rename
+	if _, err := UpdateXmlInstrumentsFileStatus(fileName, updates); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	fi, err := os.Stat(fileName)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if fi.Mode().Perm() != 0640 {
+		t.Errorf("expected the file mode kept, got %v", fi.Mode())
+	}
+}
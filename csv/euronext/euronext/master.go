@@ -0,0 +1,372 @@
+package euronext
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"euronext/euronext/atomicfile"
+)
+
+// MasterDateFormat is the format of the valid-from and valid-to dates of the instrument master.
+const MasterDateFormat = "2006-01-02"
+
+// MasterVersion is a version of an instrument valid from the ValidFrom date inclusive
+// to the ValidTo date exclusive, an empty ValidTo means the version is current.
+type MasterVersion struct {
+	ValidFrom  string        `json:"validFrom"`
+	ValidTo    string        `json:"validTo,omitempty"`
+	Instrument XmlInstrument `json:"instrument"`
+}
+
+// MasterEntry is the history of the versions of an instrument keyed by ISIN and MIC, the oldest first.
+type MasterEntry struct {
+	Isin     string          `json:"isin"`
+	Mic      string          `json:"mic"`
+	Versions []MasterVersion `json:"versions"`
+}
+
+// Current returns the current version, nil if the instrument is retired.
+func (e *MasterEntry) Current() *MasterVersion {
+	if len(e.Versions) == 0 {
+		return nil
+	}
+
+	v := &e.Versions[len(e.Versions)-1]
+	if v.ValidTo != "" {
+		return nil
+	}
+
+	return v
+}
+
+// AsOf returns the version valid on the date, nil if none.
+func (e *MasterEntry) AsOf(date string) *MasterVersion {
+	for i := range e.Versions {
+		v := &e.Versions[i]
+		if v.ValidFrom <= date && (v.ValidTo == "" || date < v.ValidTo) {
+			return v
+		}
+	}
+
+	return nil
+}
+
+// Master is the versioned instrument master, a json file with the history of every instrument.
+type Master struct {
+	Entries []MasterEntry `json:"instruments"`
+	index   map[string]int
+}
+
+func masterKey(isin, mic string) string {
+	return strings.ToUpper(isin) + "-" + strings.ToUpper(mic)
+}
+
+// Entry returns the entry of the ISIN and MIC, nil if there is none.
+func (m *Master) Entry(isin, mic string) *MasterEntry {
+	m.reindex()
+	if i, ok := m.index[masterKey(isin, mic)]; ok {
+		return &m.Entries[i]
+	}
+
+	return nil
+}
+
+func (m *Master) reindex() {
+	if m.index != nil && len(m.index) == len(m.Entries) {
+		return
+	}
+
+	m.index = make(map[string]int, len(m.Entries))
+	for i, e := range m.Entries {
+		m.index[masterKey(e.Isin, e.Mic)] = i
+	}
+}
+
+// ReadMasterFile reads the instrument master, a missing file is an empty master.
+func ReadMasterFile(fileName string) (*Master, error) {
+	m := &Master{}
+	f, err := os.Open(fileName)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return m, nil
+		}
+
+		return nil, fmt.Errorf("cannot open master file '%s': %w", fileName, err)
+	}
+	defer f.Close()
+
+	if err := json.NewDecoder(f).Decode(m); err != nil {
+		return nil, fmt.Errorf("cannot decode master file '%s': %w", fileName, err)
+	}
+
+	return m, nil
+}
+
+// WriteMasterFile writes the instrument master sorted by MIC and ISIN.
+// The file is written to a temporary file first and renamed, so a failed write keeps the previous master.
+func WriteMasterFile(fileName string, m *Master) error {
+	sort.SliceStable(m.Entries, func(i, j int) bool {
+		if m.Entries[i].Mic != m.Entries[j].Mic {
+			return m.Entries[i].Mic < m.Entries[j].Mic
+		}
+
+		return m.Entries[i].Isin < m.Entries[j].Isin
+	})
+	m.index = nil
+
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("cannot marshal master: %w", err)
+	}
+
+	if err := atomicfile.WriteFile(fileName, data); err != nil {
+		return fmt.Errorf("cannot write master file: %w", err)
+	}
+
+	return nil
+}
+
+// Latest returns the latest valid-from or valid-to date of the versions, empty if there are none.
+func (m *Master) Latest() string {
+	latest := ""
+	for i := range m.Entries {
+		for _, v := range m.Entries[i].Versions {
+			if v.ValidFrom > latest {
+				latest = v.ValidFrom
+			}
+
+			if v.ValidTo > latest {
+				latest = v.ValidTo
+			}
+		}
+	}
+
+	return latest
+}
+
+// Import adds the instruments as of the date. An instrument differing from its current version
+// closes the current version and starts a new one, an unknown or retired instrument starts a new entry
+// or version. With retireMissing, the current instruments missing from the import are retired.
+// The date cannot be before the latest date of the master, so the versions stay in date order.
+// The returned changes are sorted by MIC and ISIN.
+func (m *Master) Import(instruments *XmlInstruments, date time.Time, retireMissing bool) ([]InstrumentChange, error) {
+	m.reindex()
+	d := date.Format(MasterDateFormat)
+	if latest := m.Latest(); d < latest {
+		return nil, fmt.Errorf("cannot import as of %s before the latest master date %s", d, latest)
+	}
+
+	changes := []InstrumentChange{}
+	seen := map[string]bool{}
+
+	for _, ins := range instruments.Instrument {
+		key := masterKey(ins.Isin, ins.Mic)
+		if seen[key] {
+			changes = append(changes, InstrumentChange{Isin: ins.Isin, Mic: ins.Mic, Kind: InstrumentDuplicate,
+				New: ins.Symbol})
+			continue
+		}
+		seen[key] = true
+
+		e := m.Entry(ins.Isin, ins.Mic)
+		if e == nil {
+			m.Entries = append(m.Entries, MasterEntry{Isin: ins.Isin, Mic: ins.Mic})
+			m.index[key] = len(m.Entries) - 1
+			e = &m.Entries[len(m.Entries)-1]
+		}
+
+		cur := e.Current()
+		if cur == nil {
+			e.Versions = append(e.Versions, MasterVersion{ValidFrom: d, Instrument: ins})
+			changes = append(changes, InstrumentChange{Isin: ins.Isin, Mic: ins.Mic, Kind: InstrumentAdded, New: ins.Symbol})
+			continue
+		}
+
+		diff := DiffXmlInstrument(&cur.Instrument, &ins)
+		if len(diff) == 0 {
+			continue
+		}
+
+		changes = append(changes, diff...)
+		if cur.ValidFrom == d {
+			// A change on the same date replaces the version.
+			cur.Instrument = ins
+			continue
+		}
+
+		cur.ValidTo = d
+		e.Versions = append(e.Versions, MasterVersion{ValidFrom: d, Instrument: ins})
+	}
+
+	if retireMissing {
+		for i := range m.Entries {
+			e := &m.Entries[i]
+			if cur := e.Current(); cur != nil && !seen[masterKey(e.Isin, e.Mic)] {
+				cur.ValidTo = d
+				changes = append(changes, InstrumentChange{Isin: e.Isin, Mic: e.Mic, Kind: InstrumentRemoved,
+					Old: cur.Instrument.Symbol})
+			}
+		}
+	}
+
+	SortInstrumentChanges(changes)
+	return changes, nil
+}
+
+// AsOf returns the instruments valid on the date sorted by MIC and ISIN, a zero date means the current ones.
+func (m *Master) AsOf(date time.Time) *XmlInstruments {
+	instruments := &XmlInstruments{Instrument: []XmlInstrument{}}
+	for i := range m.Entries {
+		e := &m.Entries[i]
+		var v *MasterVersion
+		if date.IsZero() {
+			v = e.Current()
+		} else {
+			v = e.AsOf(date.Format(MasterDateFormat))
+		}
+
+		if v != nil {
+			instruments.Instrument = append(instruments.Instrument, v.Instrument)
+		}
+	}
+
+	sort.SliceStable(instruments.Instrument, func(i, j int) bool {
+		a, b := &instruments.Instrument[i], &instruments.Instrument[j]
+		if a.Mic != b.Mic {
+			return a.Mic < b.Mic
+		}
+
+		return a.Isin < b.Isin
+	})
+
+	return instruments
+}
+
+// Kinds of the instrument changes.
+const (
+	InstrumentAdded     = "added"
+	InstrumentRemoved   = "removed"
+	InstrumentChanged   = "changed"
+	InstrumentDuplicate = "duplicate"
+)
+
+// InstrumentChange is a change of an instrument, the changed kind has the dotted json path
+// of the changed field, e.g. "stock.icb.icb1", the other kinds have the symbol as the old or new value.
+type InstrumentChange struct {
+	Isin  string `json:"isin"`
+	Mic   string `json:"mic"`
+	Kind  string `json:"kind"`
+	Field string `json:"field,omitempty"`
+	Old   string `json:"old,omitempty"`
+	New   string `json:"new,omitempty"`
+}
+
+// String implements the fmt.Stringer interface.
+func (c InstrumentChange) String() string {
+	s := fmt.Sprintf("%s %s %s", strings.ToUpper(c.Mic), strings.ToUpper(c.Isin), c.Kind)
+	switch c.Kind {
+	case InstrumentChanged:
+		return fmt.Sprintf("%s %s: '%s' -> '%s'", s, c.Field, c.Old, c.New)
+	case InstrumentRemoved:
+		return fmt.Sprintf("%s %s", s, c.Old)
+	default:
+		return fmt.Sprintf("%s %s", s, c.New)
+	}
+}
+
+// SortInstrumentChanges sorts the changes by MIC and ISIN in place, keeping the order of the fields.
+func SortInstrumentChanges(changes []InstrumentChange) {
+	sort.SliceStable(changes, func(i, j int) bool {
+		a, b := strings.ToUpper(changes[i].Mic), strings.ToUpper(changes[j].Mic)
+		if a != b {
+			return a < b
+		}
+
+		return strings.ToUpper(changes[i].Isin) < strings.ToUpper(changes[j].Isin)
+	})
+}
+
+// flattenXmlInstrument returns the values of the instrument fields keyed by the dotted json paths.
+func flattenXmlInstrument(ins *XmlInstrument) map[string]string {
+	fields := map[string]string{}
+	bs, err := json.Marshal(ins)
+	if err != nil {
+		return fields
+	}
+
+	var v any
+	if err := json.Unmarshal(bs, &v); err != nil {
+		return fields
+	}
+
+	var flatten func(prefix string, v any)
+	flatten = func(prefix string, v any) {
+		switch t := v.(type) {
+		case map[string]any:
+			for k, c := range t {
+				if prefix != "" {
+					k = prefix + "." + k
+				}
+				flatten(k, c)
+			}
+		case []any:
+			for i, c := range t {
+				flatten(fmt.Sprintf("%s.%d", prefix, i), c)
+			}
+		case nil:
+		default:
+			fields[prefix] = fmt.Sprint(t)
+		}
+	}
+
+	flatten("", v)
+	return fields
+}
+
+// DiffXmlInstrument returns the changed fields of the instrument sorted by the field path.
+// Missing and empty fields are equal.
+func DiffXmlInstrument(old, new *XmlInstrument) []InstrumentChange {
+	o, n := flattenXmlInstrument(old), flattenXmlInstrument(new)
+	keys := []string{}
+	for k := range o {
+		keys = append(keys, k)
+	}
+	for k := range n {
+		if _, ok := o[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	changes := []InstrumentChange{}
+	for _, k := range keys {
+		if o[k] != n[k] {
+			changes = append(changes, InstrumentChange{Isin: new.Isin, Mic: new.Mic, Kind: InstrumentChanged,
+				Field: k, Old: o[k], New: n[k]})
+		}
+	}
+
+	return changes
+}
+
+// DiffXmlInstruments returns the changes from the old to the new instruments, e.g. of two discovery runs,
+// matching the instruments by ISIN and MIC.
+func DiffXmlInstruments(old, new *XmlInstruments) []InstrumentChange {
+	m := &Master{}
+	// The dates of a fresh master are in order, so the imports cannot fail.
+	m.Import(old, time.Date(1, 1, 1, 0, 0, 0, 0, time.UTC), false)
+	imported, _ := m.Import(new, time.Date(1, 1, 2, 0, 0, 0, 0, time.UTC), true)
+	changes := []InstrumentChange{}
+	for _, c := range imported {
+		if c.Kind != InstrumentDuplicate {
+			changes = append(changes, c)
+		}
+	}
+
+	return changes
+}
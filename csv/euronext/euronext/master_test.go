@@ -0,0 +1,142 @@
+package euronext
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func testMasterInstrument(mic, isin, symbol, name, shares string) XmlInstrument {
+	return XmlInstrument{Mic: mic, Isin: isin, Symbol: symbol, Name: name, Type: "stock",
+		Stock: &XmlStock{Currency: "EUR", Shares: shares}}
+}
+
+func TestMasterImport(t *testing.T) {
+	t.Parallel()
+
+	d1 := time.Date(2024, 6, 3, 0, 0, 0, 0, time.UTC)
+	d2 := d1.AddDate(0, 0, 1)
+	d3 := d1.AddDate(0, 0, 2)
+	m := &Master{}
+
+	changes, _ := m.Import(&XmlInstruments{Instrument: []XmlInstrument{
+		testMasterInstrument("XPAR", "FR0000120073", "AI", "AIR LIQUIDE", "100"),
+		testMasterInstrument("XAMS", "NL0000009082", "KPN", "KPN KON", "200"),
+		testMasterInstrument("XAMS", "NL0000009082", "KPN", "KPN KON", "200"),
+	}}, d1, false)
+	if len(changes) != 3 || changes[0].Kind != InstrumentAdded || changes[0].Mic != "XAMS" ||
+		changes[1].Kind != InstrumentDuplicate || changes[2].Kind != InstrumentAdded {
+		t.Fatalf("unexpected changes %v", changes)
+	}
+
+	changes, _ = m.Import(&XmlInstruments{Instrument: []XmlInstrument{
+		testMasterInstrument("XPAR", "FR0000120073", "AI", "AIR LIQUIDE SA", "110"),
+	}}, d2, true)
+	expected := []string{
+		"XAMS NL0000009082 removed KPN",
+		"XPAR FR0000120073 changed name: 'AIR LIQUIDE' -> 'AIR LIQUIDE SA'",
+		"XPAR FR0000120073 changed stock.shares: '100' -> '110'",
+	}
+	if len(changes) != len(expected) {
+		t.Fatalf("expected changes %q, got %v", expected, changes)
+	}
+	for i, s := range expected {
+		if changes[i].String() != s {
+			t.Errorf("change %d: expected %q, got %q", i, s, changes[i].String())
+		}
+	}
+
+	// A second change on the same date replaces the version, a retired instrument is re-added.
+	changes, _ = m.Import(&XmlInstruments{Instrument: []XmlInstrument{
+		testMasterInstrument("XPAR", "FR0000120073", "AI", "AIR LIQUIDE SA", "120"),
+		testMasterInstrument("XAMS", "NL0000009082", "KPN", "KPN KON", "200"),
+	}}, d2, false)
+	if len(changes) != 2 || changes[0].Kind != InstrumentAdded || changes[1].Field != "stock.shares" {
+		t.Errorf("unexpected changes %v", changes)
+	}
+
+	e := m.Entry("fr0000120073", "xpar")
+	if e == nil || len(e.Versions) != 2 || e.Versions[0].ValidTo != "2024-06-04" || e.Versions[1].Instrument.Stock.Shares != "120" {
+		t.Fatalf("unexpected entry %+v", e)
+	}
+
+	if v := e.AsOf("2024-06-03"); v == nil || v.Instrument.Name != "AIR LIQUIDE" {
+		t.Errorf("unexpected version as of 2024-06-03: %+v", v)
+	}
+
+	if v := e.AsOf("2024-06-02"); v != nil {
+		t.Errorf("expected no version as of 2024-06-02, got %+v", v)
+	}
+
+	kpn := m.Entry("NL0000009082", "XAMS")
+	if kpn == nil || len(kpn.Versions) != 2 || kpn.Versions[0].ValidTo != "2024-06-04" || kpn.Versions[1].ValidFrom != "2024-06-04" {
+		t.Errorf("unexpected entry %+v", kpn)
+	}
+
+	if changes, err := m.Import(m.AsOf(time.Time{}), d3, true); err != nil || len(changes) != 0 {
+		t.Errorf("expected no changes importing the current instruments, got %v, error %v", changes, err)
+	}
+
+	// An older snapshot would invert the validity of the current versions.
+	if _, err := m.Import(&XmlInstruments{Instrument: []XmlInstrument{
+		testMasterInstrument("XPAR", "FR0000120073", "AI", "AIR LIQUIDE", "100"),
+	}}, d1, false); err == nil {
+		t.Errorf("expected an error importing before the latest date")
+	}
+
+	if v := m.Entry("FR0000120073", "XPAR").Current(); v == nil || v.ValidFrom != "2024-06-04" || v.Instrument.Stock.Shares != "120" {
+		t.Errorf("expected the current version unchanged, got %+v", v)
+	}
+
+	ins := m.AsOf(d1)
+	if len(ins.Instrument) != 2 || ins.Instrument[0].Mic != "XAMS" || ins.Instrument[1].Stock.Shares != "100" {
+		t.Errorf("unexpected instruments as of %v: %+v", d1, ins.Instrument)
+	}
+
+	fileName := filepath.Join(t.TempDir(), "master.json")
+	if err := WriteMasterFile(fileName, m); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	r, err := ReadMasterFile(fileName)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if e := r.Entry("FR0000120073", "XPAR"); e == nil || len(e.Versions) != 2 || e.Current().Instrument.Name != "AIR LIQUIDE SA" {
+		t.Errorf("unexpected entry after reading: %+v", e)
+	}
+
+	if r, err := ReadMasterFile(filepath.Join(t.TempDir(), "missing.json")); err != nil || len(r.Entries) != 0 {
+		t.Errorf("expected an empty master for a missing file, got %+v, %v", r, err)
+	}
+}
+
+func TestDiffXmlInstruments(t *testing.T) {
+	t.Parallel()
+
+	old := &XmlInstruments{Instrument: []XmlInstrument{
+		testMasterInstrument("XPAR", "FR0000120073", "AI", "AIR LIQUIDE", "100"),
+		testMasterInstrument("XBRU", "BE0003470755", "SOLB", "SOLVAY", "300"),
+	}}
+	new := &XmlInstruments{Instrument: []XmlInstrument{
+		testMasterInstrument("XPAR", "FR0000120073", "AI", "AIR LIQUIDE", "100"),
+		testMasterInstrument("XAMS", "NL0000009082", "KPN", "KPN KON", "200"),
+	}}
+	new.Instrument[0].Stock.Icb = &XmlIcb{Icb1: "1000"}
+
+	changes := DiffXmlInstruments(old, new)
+	expected := []string{
+		"XAMS NL0000009082 added KPN",
+		"XBRU BE0003470755 removed SOLB",
+		"XPAR FR0000120073 changed stock.icb.icb1: '' -> '1000'",
+	}
+	if len(changes) != len(expected) {
+		t.Fatalf("expected changes %q, got %v", expected, changes)
+	}
+	for i, s := range expected {
+		if changes[i].String() != s {
+			t.Errorf("change %d: expected %q, got %q", i, s, changes[i].String())
+		}
+	}
+}
@@ -0,0 +1,274 @@
+package euronext
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// MergeStatistics counts the dates of the merged daily histories.
+type MergeStatistics struct {
+	// New is the number of dates only in the new history.
+	New int
+
+	// Old is the number of dates only in the old history.
+	Old int
+
+	// Same is the number of dates in both histories without differences.
+	Same int
+
+	// Diff is the number of dates having differences or malformed price bars.
+	Diff int
+}
+
+// mergeField is a view of a single value of a daily bar.
+// The diff name is used in the difference messages, the replace name in the replacement ones.
+type mergeField struct {
+	diff    string
+	replace string
+	value   *float64
+}
+
+// The replacement messages have the close before the last.
+var replaceOrder = [...]int{0, 1, 2, 4, 3, 5, 6, 7, 8}
+
+func unadjustedFields(h *CombinedDailyHistory) []mergeField {
+	return []mergeField{
+		{"open", "open", &h.Open},
+		{"high", "high", &h.High},
+		{"low", "low", &h.Low},
+		{"last", "last", &h.Last},
+		{"close", "close", &h.Close},
+		{"num shares", "shares", &h.NumberOfShares},
+		{"num trades", "trades", &h.NumberOfTrades},
+		{"turnover", "turnover", &h.Turnover},
+		{"vwap", "vwap", &h.Vwap},
+	}
+}
+
+func adjustedFields(h *CombinedDailyHistory) []mergeField {
+	return []mergeField{
+		{"open", "open", &h.OpenAdjusted},
+		{"high", "high", &h.HighAdjusted},
+		{"low", "low", &h.LowAdjusted},
+		{"last", "last", &h.LastAdjusted},
+		{"close", "close", &h.CloseAdjusted},
+		{"num shares", "shares", &h.NumberOfSharesAdjusted},
+		{"num trades", "trades", &h.NumberOfTradesAdjusted},
+		{"turnover", "turnover", &h.TurnoverAdjusted},
+		{"vwap", "vwap", &h.VwapAdjusted},
+	}
+}
+
+func malformedBar(open, high, low, close, last float64) bool {
+	return high < low || high < open || high < close || high < last ||
+		low > open || low > close || open > high || low > last
+}
+
+func malformedUnadjusted(h *CombinedDailyHistory) bool {
+	return malformedBar(h.Open, h.High, h.Low, h.Close, h.Last)
+}
+
+func malformedAdjusted(h *CombinedDailyHistory) bool {
+	return malformedBar(h.OpenAdjusted, h.HighAdjusted, h.LowAdjusted, h.CloseAdjusted, h.LastAdjusted)
+}
+
+// mergeDates maps the histories by date and returns all dates in the descending order.
+func mergeDates(histOld, histNew []CombinedDailyHistory) (map[time.Time]CombinedDailyHistory, map[time.Time]CombinedDailyHistory, []time.Time) {
+	histMapOld := make(map[time.Time]CombinedDailyHistory)
+	histMapNew := make(map[time.Time]CombinedDailyHistory)
+	dateSet := make(map[time.Time]struct{})
+
+	for _, entry := range histOld {
+		histMapOld[entry.Date] = entry
+		dateSet[entry.Date] = struct{}{}
+	}
+	for _, entry := range histNew {
+		histMapNew[entry.Date] = entry
+		dateSet[entry.Date] = struct{}{}
+	}
+
+	dates := make([]time.Time, 0, len(dateSet))
+	for date := range dateSet {
+		dates = append(dates, date)
+	}
+
+	sort.Slice(dates, func(i, j int) bool {
+		return dates[i].After(dates[j])
+	})
+
+	return histMapOld, histMapNew, dates
+}
+
+// MergeCombinedDailyHistoryKeep merges the histories having the same prices
+// used as both unadjusted and adjusted.
+// A new entry replaces the old one unless it has a zero value which is not zero in the old entry.
+// The statistics are updated if not nil.
+func MergeCombinedDailyHistoryKeep(histOld, histNew []CombinedDailyHistory, sta *MergeStatistics) ([]CombinedDailyHistory, []string) {
+	if sta == nil {
+		sta = &MergeStatistics{}
+	}
+
+	messages := []string{}
+	histMapOld, histMapNew, dates := mergeDates(histOld, histNew)
+
+	var mergedHistory []CombinedDailyHistory
+	for _, date := range dates {
+		entryOld, existsOld := histMapOld[date]
+		entryNew, existsNew := histMapNew[date]
+		notEqual := []string{}
+
+		if existsNew && !existsOld {
+			mergedHistory = append(mergedHistory, entryNew)
+			sta.New += 1
+			if malformedUnadjusted(&entryNew) {
+				sta.Diff += 1
+				messages = append(messages,
+					fmt.Sprintf("Date %s:  (new) malformed price bar: %g, %g, %g, %g, %g",
+						date.Format("2006-01-02"), entryNew.Open, entryNew.High, entryNew.Low, entryNew.Close, entryNew.Last))
+			}
+		} else if existsNew && existsOld {
+			fieldsOld := unadjustedFields(&entryOld)
+			fieldsNew := unadjustedFields(&entryNew)
+			for i, f := range fieldsOld {
+				if *f.value != *fieldsNew[i].value {
+					notEqual = append(notEqual, fmt.Sprintf("diff %s: %g -> %g", f.diff, *f.value, *fieldsNew[i].value))
+				}
+			}
+
+			useOld := false
+			for _, i := range replaceOrder {
+				o, n := *fieldsOld[i].value, *fieldsNew[i].value
+				if n == 0 && o != 0 {
+					notEqual = append(notEqual, fmt.Sprintf("wont replace %s: %g -> %g", fieldsOld[i].replace, o, n))
+					useOld = true
+				}
+			}
+
+			entryNew.HasMarking = entryOld.HasMarking || entryNew.HasMarking
+			entryNew.HasMarkingAdjusted = entryOld.HasMarkingAdjusted || entryNew.HasMarkingAdjusted
+			if !useOld && malformedUnadjusted(&entryNew) {
+				notEqual = append(notEqual, fmt.Sprintf("(repl) malformed price bar: %g, %g, %g, %g, %g",
+					entryNew.Open, entryNew.High, entryNew.Low, entryNew.Close, entryNew.Last))
+			}
+
+			if len(notEqual) > 0 {
+				sta.Diff += 1
+				messages = append(messages,
+					fmt.Sprintf("Date %s: %s", date.Format("2006-01-02"), strings.Join(notEqual, ", ")))
+			} else {
+				sta.Same += 1
+			}
+
+			if useOld {
+				mergedHistory = append(mergedHistory, entryOld)
+			} else {
+				mergedHistory = append(mergedHistory, entryNew)
+			}
+		} else { // if !existsNew && existsOld
+			sta.Old += 1
+			mergedHistory = append(mergedHistory, entryOld)
+		}
+	}
+
+	return SortCombinedDailyHistory(mergedHistory), messages
+}
+
+// MergeCombinedDailyHistorySplit merges either the unadjusted or the adjusted half of the new entries
+// into the old ones. The other half of the new entries is missing and has -1 values.
+// A new value replaces the old one unless it is not positive while the old one is positive.
+// The statistics are updated if not nil.
+func MergeCombinedDailyHistorySplit(histOld, histNew []CombinedDailyHistory, unadjusted bool, sta *MergeStatistics) ([]CombinedDailyHistory, []string) {
+	if sta == nil {
+		sta = &MergeStatistics{}
+	}
+
+	kind := "adjusted"
+	fields, malformed := adjustedFields, malformedAdjusted
+	if unadjusted {
+		kind = "unadjusted"
+		fields, malformed = unadjustedFields, malformedUnadjusted
+	}
+
+	messages := []string{}
+	histMapOld, histMapNew, dates := mergeDates(histOld, histNew)
+
+	var mergedHistory []CombinedDailyHistory
+	for _, date := range dates {
+		entryOld, existsOld := histMapOld[date]
+		entryNew, existsNew := histMapNew[date]
+		notEqual := []string{}
+
+		if existsNew && !existsOld {
+			mergedHistory = append(mergedHistory, entryNew)
+			sta.New += 1
+			if malformed(&entryNew) {
+				f := fields(&entryNew)
+				sta.Diff += 1
+				messages = append(messages,
+					fmt.Sprintf("Date %s:  (new) malformed %s price bar: %g, %g, %g, %g, %g",
+						date.Format("2006-01-02"), kind, *f[0].value, *f[1].value, *f[2].value, *f[4].value, *f[3].value))
+			}
+		} else if existsNew && existsOld {
+			fieldsOld := fields(&entryOld)
+			fieldsNew := fields(&entryNew)
+			for i, f := range fieldsOld {
+				o, n := *f.value, *fieldsNew[i].value
+				if o != n && o != -1 && n != -1 {
+					notEqual = append(notEqual, fmt.Sprintf("diff %s %s: %g -> %g", kind, f.diff, o, n))
+				}
+			}
+
+			for _, i := range replaceOrder {
+				o, n := *fieldsOld[i].value, *fieldsNew[i].value
+				if n <= 0 && o > 0 {
+					notEqual = append(notEqual, fmt.Sprintf("wont replace %s %s: %g -> %g", kind, fieldsOld[i].replace, o, n))
+					*fieldsNew[i].value = o
+				}
+			}
+
+			if unadjusted {
+				entryNew.HasMarking = entryOld.HasMarking || entryNew.HasMarking
+			} else {
+				entryNew.HasMarkingAdjusted = entryOld.HasMarkingAdjusted || entryNew.HasMarkingAdjusted
+			}
+
+			if malformed(&entryNew) {
+				notEqual = append(notEqual, fmt.Sprintf("(repl) malformed %s price bar: %g, %g, %g, %g, %g", kind,
+					*fieldsNew[0].value, *fieldsNew[1].value, *fieldsNew[2].value, *fieldsNew[4].value, *fieldsNew[3].value))
+			}
+
+			// The other half is taken from the old entry.
+			if unadjusted {
+				for i, f := range adjustedFields(&entryOld) {
+					*adjustedFields(&entryNew)[i].value = *f.value
+				}
+
+				entryNew.AdjustmentFactor = entryOld.AdjustmentFactor
+				entryNew.HasMarkingAdjusted = entryOld.HasMarkingAdjusted
+			} else {
+				for i, f := range unadjustedFields(&entryOld) {
+					*unadjustedFields(&entryNew)[i].value = *f.value
+				}
+
+				entryNew.HasMarking = entryOld.HasMarking
+			}
+
+			if len(notEqual) > 0 {
+				sta.Diff += 1
+				messages = append(messages,
+					fmt.Sprintf("Date %s: %s", date.Format("2006-01-02"), strings.Join(notEqual, ", ")))
+			} else {
+				sta.Same += 1
+			}
+
+			mergedHistory = append(mergedHistory, entryNew)
+		} else { // if !existsNew && existsOld
+			sta.Old += 1
+			mergedHistory = append(mergedHistory, entryOld)
+		}
+	}
+
+	return SortCombinedDailyHistory(mergedHistory), messages
+}
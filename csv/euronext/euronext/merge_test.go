@@ -0,0 +1,81 @@
+package euronext
+
+import (
+	"testing"
+	"time"
+)
+
+func testMergeEntry(day int, open, high, low, close, shares float64) CombinedDailyHistory {
+	return CombinedDailyHistory{
+		Date: time.Date(2014, 12, day, 0, 0, 0, 0, time.UTC),
+		Open: open, High: high, Low: low, Last: close, Close: close, NumberOfShares: shares,
+		OpenAdjusted: open, HighAdjusted: high, LowAdjusted: low, LastAdjusted: close, CloseAdjusted: close,
+		NumberOfSharesAdjusted: shares, AdjustmentFactor: 1,
+	}
+}
+
+func TestMergeCombinedDailyHistoryKeep(t *testing.T) {
+	t.Parallel()
+
+	histOld := []CombinedDailyHistory{testMergeEntry(29, 10, 11, 9, 10.5, 100), testMergeEntry(30, 10, 11, 9, 10.5, 100)}
+	histNew := []CombinedDailyHistory{testMergeEntry(30, 10, 11, 9, 10.6, 0), testMergeEntry(31, 10, 11, 9, 10.5, 100)}
+	sta := MergeStatistics{}
+
+	merged, messages := MergeCombinedDailyHistoryKeep(histOld, histNew, &sta)
+	if len(merged) != 3 {
+		t.Fatalf("expected 3 entries, got %d", len(merged))
+	}
+
+	if merged[1].Close != 10.5 || merged[1].NumberOfShares != 100 {
+		t.Errorf("expected the old entry with a zero new value kept, got %+v", merged[1])
+	}
+
+	expected := "Date 2014-12-30: diff last: 10.5 -> 10.6, diff close: 10.5 -> 10.6, diff num shares: 100 -> 0, wont replace shares: 100 -> 0"
+	if len(messages) != 1 || messages[0] != expected {
+		t.Errorf("unexpected messages %q", messages)
+	}
+
+	if sta != (MergeStatistics{New: 1, Old: 1, Same: 0, Diff: 1}) {
+		t.Errorf("unexpected statistics %+v", sta)
+	}
+}
+
+func TestMergeCombinedDailyHistorySplit(t *testing.T) {
+	t.Parallel()
+
+	unadj := testMergeEntry(30, 10, 11, 9, 10.5, 100)
+	unadj.OpenAdjusted, unadj.HighAdjusted, unadj.LowAdjusted, unadj.LastAdjusted, unadj.CloseAdjusted = -1, -1, -1, -1, -1
+	unadj.NumberOfSharesAdjusted, unadj.NumberOfTradesAdjusted, unadj.TurnoverAdjusted, unadj.VwapAdjusted = -1, -1, -1, -1
+
+	adj := testMergeEntry(30, 5, 5.5, 4.5, 5.25, 0)
+	adj.Open, adj.High, adj.Low, adj.Last, adj.Close = -1, -1, -1, -1, -1
+	adj.NumberOfShares, adj.NumberOfTrades, adj.Turnover, adj.Vwap = -1, -1, -1, -1
+	adj.HasMarkingAdjusted = true
+
+	merged, messages := MergeCombinedDailyHistorySplit(nil, []CombinedDailyHistory{unadj}, true, nil)
+	if len(messages) != 0 {
+		t.Errorf("unexpected messages %q", messages)
+	}
+
+	adjOld := adj
+	adjOld.NumberOfSharesAdjusted = 200
+	merged, _ = MergeCombinedDailyHistorySplit(merged, []CombinedDailyHistory{adjOld}, false, nil)
+	merged, messages = MergeCombinedDailyHistorySplit(merged, []CombinedDailyHistory{adj}, false, nil)
+	if len(merged) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(merged))
+	}
+
+	h := merged[0]
+	if h.Close != 10.5 || h.NumberOfShares != 100 || h.CloseAdjusted != 5.25 || h.NumberOfSharesAdjusted != 200 {
+		t.Errorf("unexpected merged entry %+v", h)
+	}
+
+	if h.HasMarking || !h.HasMarkingAdjusted {
+		t.Errorf("unexpected markings %v, %v", h.HasMarking, h.HasMarkingAdjusted)
+	}
+
+	expected := "Date 2014-12-30: diff adjusted num shares: 200 -> 0, wont replace adjusted shares: 200 -> 0"
+	if len(messages) != 1 || messages[0] != expected {
+		t.Errorf("unexpected messages %q", messages)
+	}
+}
@@ -0,0 +1,304 @@
+package euronext
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Stage is a stage of a Pipeline.
+type Stage int
+
+const (
+	StageLoad     Stage = iota // StageLoad reads the instruments.
+	StageDownload              // StageDownload downloads the data of an instrument.
+	StageArchive               // StageArchive stores the downloaded data of an instrument.
+	StageMerge                 // StageMerge merges the downloaded data of an instrument into the repository.
+	StageReport                // StageReport prints the statistics.
+)
+
+// String implements the fmt.Stringer interface.
+func (s Stage) String() string {
+	switch s {
+	case StageLoad:
+		return "load"
+	case StageDownload:
+		return "download"
+	case StageArchive:
+		return "archive"
+	case StageMerge:
+		return "merge"
+	case StageReport:
+		return "report"
+	default:
+		return fmt.Sprintf("Stage(%d)", int(s))
+	}
+}
+
+// Combi carries the downloaded data of an instrument through the pipeline stages.
+type Combi struct {
+	DownloadError string
+	Index         int
+	Length        int
+	Instrument    Instrument
+	Raw           []byte
+	Adj           []byte
+}
+
+// Statistics collects the semicolon-separated report lines, the first line of each list is a header.
+type Statistics struct {
+	SessionDate       string
+	DownloadErrors    []string
+	MergeErrors       []string
+	MergeMessages     []string
+	ZeroLines         []string
+	UnequalLines      []string
+	LessThanFiveLines []string
+	NoHistoryLines    []string
+}
+
+// NewStatistics creates the statistics with the list headers.
+func NewStatistics(sessionDate time.Time) *Statistics {
+	return &Statistics{
+		SessionDate:       sessionDate.Format("2006-01-02"),
+		DownloadErrors:    []string{"date;mep;mic;type;mnemonic;isin;error"},
+		MergeErrors:       []string{"date;mep;mic;type;mnemonic;isin;error"},
+		MergeMessages:     []string{"date;mep;mic;type;mnemonic;isin;message"},
+		ZeroLines:         []string{"date;mep;mic;type;mnemonic;isin;lines raw;lines adjusted"},
+		UnequalLines:      []string{"date;mep;mic;type;mnemonic;isin;lines raw;lines adjusted"},
+		LessThanFiveLines: []string{"date;mep;mic;type;mnemonic;isin;lines raw;lines adjusted"},
+		NoHistoryLines:    []string{"date;mep;mic;type;mnemonic;isin;lines raw;lines adjusted"},
+	}
+}
+
+// Line returns a report line of the instrument.
+func (s *Statistics) Line(ins *Instrument, values ...any) string {
+	return ins.StatisticsLine(s.SessionDate, values...)
+}
+
+// Report prints the lists, total is the number of instruments.
+func (s *Statistics) Report(logf func(format string, args ...any), total int) {
+	lists := []struct {
+		title string
+		lines []string
+	}{
+		{"instruments with download errors", s.DownloadErrors},
+		{"instruments with merge errors", s.MergeErrors},
+		{"instruments with merge messages", s.MergeMessages},
+		{"instruments with zero lines", s.ZeroLines},
+		{"instruments with unequal raw and adjusted histories", s.UnequalLines},
+		{"instruments with less than 5 history lines", s.LessThanFiveLines},
+		{"instruments with valid header but no history", s.NoHistoryLines},
+	}
+
+	for _, l := range lists {
+		logf("\n\n%s: %d from %d\n", l.title, max(len(l.lines)-1, 0), total)
+		for _, z := range l.lines {
+			logf("%s\n", z)
+		}
+	}
+}
+
+// Hooks are optional callbacks around the pipeline stages.
+//
+// Before and After are called from the download workers for the download and archive stages,
+// so they should be safe for concurrent use when the Concurrency is above one.
+type Hooks struct {
+	// Loaded may filter or reorder the loaded instruments.
+	Loaded func(instruments []Instrument) []Instrument
+
+	// Before is called before a per-instrument stage, returning false skips this
+	// and the following stages of the instrument.
+	Before func(stage Stage, c *Combi) bool
+
+	// After is called after a per-instrument stage with the stage error.
+	After func(stage Stage, c *Combi, err error)
+
+	// Reported is called after the statistics are printed.
+	Reported func(stati *Statistics)
+}
+
+// Pipeline loads the instruments, then downloads, archives and merges them and reports the statistics.
+//
+// The download and archive stages of the instruments run concurrently,
+// the merge stage runs sequentially in a single goroutine.
+type Pipeline struct {
+	// SessionDate is the trading session date of the run.
+	SessionDate time.Time
+
+	// XmlInstruments is the xml instruments file name.
+	XmlInstruments string
+
+	// IncludeRetired downloads the delisted and migrated instruments too, they are skipped by default.
+	IncludeRetired bool
+
+	// Concurrency is the number of instruments downloaded at once, less than two means sequential.
+	Concurrency int
+
+	// Logf prints the progress, nil means fmt.Printf.
+	Logf func(format string, args ...any)
+
+	// Download downloads the data of an instrument into the Raw and Adj fields, it is required.
+	Download func(c *Combi) error
+
+	// Archive stores the downloaded data, nil skips the stage.
+	Archive func(c *Combi) error
+
+	// Merge merges the downloaded data into the repository, nil skips the stage.
+	// A returned error is added to the merge errors.
+	Merge func(c *Combi, stati *Statistics) error
+
+	// Hooks are the optional per-stage callbacks.
+	Hooks Hooks
+}
+
+// Run runs the pipeline and returns the statistics.
+func (p *Pipeline) Run() (*Statistics, error) {
+	if p.Download == nil {
+		return nil, errors.New("pipeline has no download stage")
+	}
+
+	if p.Logf == nil {
+		p.Logf = func(format string, args ...any) { fmt.Printf(format, args...) }
+	}
+
+	p.Logf("xml file: %s\n", p.XmlInstruments)
+	instruments, err := ReadInstruments(p.XmlInstruments)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read instruments: %w", err)
+	}
+	p.Logf("%d instruments read from %s\n", len(instruments), p.XmlInstruments)
+
+	if !p.IncludeRetired {
+		active := ActiveInstruments(instruments)
+		if n := len(instruments) - len(active); n > 0 {
+			p.Logf("skipping %d retired instruments\n", n)
+		}
+		instruments = active
+	}
+
+	if p.Hooks.Loaded != nil {
+		instruments = p.Hooks.Loaded(instruments)
+	}
+
+	stati := NewStatistics(p.SessionDate)
+	l := len(instruments)
+	if p.Concurrency < 2 {
+		for i, ins := range instruments {
+			c := p.download(ins, i, l)
+			p.merge(c, stati)
+		}
+	} else {
+		var wg sync.WaitGroup
+		sem := make(chan struct{}, p.Concurrency)
+
+		// Channel to pass data to the second (merging) pipeline
+		combiChan := make(chan *Combi, l)
+
+		// WaitGroup for the second pipeline
+		var mergeWg sync.WaitGroup
+
+		// Start the second pipeline to merge data from the channel
+		mergeWg.Add(1)
+		go func() {
+			defer mergeWg.Done()
+			for c := range combiChan {
+				p.merge(c, stati)
+			}
+		}()
+
+		for i, ins := range instruments {
+			wg.Add(1)
+			sem <- struct{}{} // Acquire a slot
+
+			go func(i, l int, ins Instrument) {
+				defer wg.Done()
+				defer func() { <-sem }() // Release the slot
+
+				combiChan <- p.download(ins, i, l)
+			}(i, l, ins)
+		}
+
+		// Wait for the first pipeline to finish processing
+		wg.Wait()
+
+		// Close the channel after all goroutines have finished
+		close(combiChan)
+
+		// Wait for the second pipeline to finish processing
+		mergeWg.Wait()
+	}
+
+	p.Logf("\nprocessed %s\n", time.Now().Format("2006-01-02 15-04-05"))
+	stati.Report(p.Logf, l)
+	if p.Hooks.Reported != nil {
+		p.Hooks.Reported(stati)
+	}
+
+	return stati, nil
+}
+
+// download runs the download and archive stages, a nil result means the instrument is skipped.
+func (p *Pipeline) download(ins Instrument, i, l int) *Combi {
+	c := &Combi{Index: i, Length: l, Instrument: ins}
+
+	if !p.before(StageDownload, c) {
+		return nil
+	}
+
+	err := p.Download(c)
+	if err != nil && c.DownloadError == "" {
+		c.DownloadError = err.Error()
+	}
+	p.after(StageDownload, c, err)
+
+	if p.Archive == nil || c.Raw == nil || !p.before(StageArchive, c) {
+		return c
+	}
+
+	err = p.Archive(c)
+	if err != nil && c.DownloadError == "" {
+		c.DownloadError = err.Error()
+	}
+	p.after(StageArchive, c, err)
+
+	return c
+}
+
+func (p *Pipeline) merge(c *Combi, stati *Statistics) {
+	if c == nil {
+		return
+	}
+
+	ins := &c.Instrument
+	prefix := fmt.Sprintf("[%d of %d] %s ... ", c.Index+1, c.Length, ins.FileFolder())
+	if len(c.DownloadError) > 0 {
+		stati.DownloadErrors = append(stati.DownloadErrors, stati.Line(ins, c.DownloadError))
+		if c.Raw == nil {
+			p.Logf("%snot merged due to download error\n", prefix)
+			return
+		}
+	}
+
+	if p.Merge == nil || !p.before(StageMerge, c) {
+		return
+	}
+
+	err := p.Merge(c, stati)
+	if err != nil {
+		stati.MergeErrors = append(stati.MergeErrors, stati.Line(ins, err.Error()))
+		p.Logf("%s%s\n", prefix, err)
+	}
+	p.after(StageMerge, c, err)
+}
+
+func (p *Pipeline) before(stage Stage, c *Combi) bool {
+	return p.Hooks.Before == nil || p.Hooks.Before(stage, c)
+}
+
+func (p *Pipeline) after(stage Stage, c *Combi, err error) {
+	if p.Hooks.After != nil {
+		p.Hooks.After(stage, c, err)
+	}
+}
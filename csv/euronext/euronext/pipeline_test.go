@@ -0,0 +1,212 @@
+package euronext
+
+import (
+	"archive/zip"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testInstrumentsXml = `<?xml version="1.0" encoding="utf-8" standalone="no"?>
+<instruments>
+  <instrument mic="XPAR" isin="FR0000120073" symbol="AI" name="AIR LIQUIDE" type="stock" mep="PAR" vendor="Euronext" />
+  <instrument mic="XAMS" isin="NL0000009082" symbol="KPN" name="KPN KON" type="stock" mep="AMS" vendor="Euronext" />
+  <instrument mic="XBRU" isin="BE0003470755" symbol="SOLB" name="SOLVAY" type="stock" mep="BRU" vendor="Euronext" />
+</instruments>
+`
+
+func writeTestInstruments(t *testing.T) string {
+	t.Helper()
+
+	fileName := filepath.Join(t.TempDir(), "instruments.xml")
+	if err := os.WriteFile(fileName, []byte(testInstrumentsXml), 0666); err != nil {
+		t.Fatalf("cannot write instruments: %v", err)
+	}
+
+	return fileName
+}
+
+func TestReadInstruments(t *testing.T) {
+	t.Parallel()
+
+	instruments, err := ReadInstruments(writeTestInstruments(t))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(instruments) != 3 {
+		t.Fatalf("expected 3 instruments, got %d", len(instruments))
+	}
+
+	ins := instruments[0]
+	if ins.Mnemonic != "ai" || ins.Mic != "xpar" || ins.Mep != "par" || ins.Isin != "fr0000120073" || ins.Type != "stock" {
+		t.Errorf("unexpected instrument %+v", ins)
+	}
+
+	if ins.FileFolder() != "xpar/stock/ai/" {
+		t.Errorf("unexpected folder %s", ins.FileFolder())
+	}
+
+	if line := ins.StatisticsLine("2024-06-04", 1, "x"); line != "2024-06-04;par;xpar;stock;ai;fr0000120073;1;x" {
+		t.Errorf("unexpected statistics line %s", line)
+	}
+
+	if _, err := ReadInstruments(filepath.Join(t.TempDir(), "missing.xml")); err == nil {
+		t.Errorf("expected an error for a missing file")
+	}
+}
+
+func TestPipeline(t *testing.T) {
+	t.Parallel()
+
+	xmlFile := writeTestInstruments(t)
+	for _, concurrency := range []int{0, 3} {
+		var mu sync.Mutex
+		stages := map[string][]Stage{}
+		merged := []string{}
+		reported := false
+
+		p := Pipeline{
+			SessionDate:    time.Date(2024, 6, 4, 0, 0, 0, 0, time.UTC),
+			XmlInstruments: xmlFile,
+			Concurrency:    concurrency,
+			Logf:           func(string, ...any) {},
+			Download: func(c *Combi) error {
+				if c.Instrument.Mnemonic == "kpn" {
+					return errors.New("boom")
+				}
+
+				c.Raw = []byte(testRaw)
+				c.Adj = []byte(testAdj)
+				return nil
+			},
+			Archive: func(c *Combi) error {
+				return nil
+			},
+			Merge: func(c *Combi, stati *Statistics) error {
+				merged = append(merged, c.Instrument.Mnemonic)
+				if c.Instrument.Mnemonic == "ai" {
+					return errors.New("cannot merge")
+				}
+
+				return nil
+			},
+			Hooks: Hooks{
+				Loaded: func(instruments []Instrument) []Instrument {
+					return instruments
+				},
+				Before: func(stage Stage, c *Combi) bool {
+					// Skip merging the third instrument.
+					return !(stage == StageMerge && c.Instrument.Mnemonic == "solb")
+				},
+				After: func(stage Stage, c *Combi, err error) {
+					mu.Lock()
+					defer mu.Unlock()
+					stages[c.Instrument.Mnemonic] = append(stages[c.Instrument.Mnemonic], stage)
+				},
+				Reported: func(stati *Statistics) {
+					reported = true
+				},
+			},
+		}
+
+		stati, err := p.Run()
+		if err != nil {
+			t.Fatalf("concurrency %d: unexpected error: %v", concurrency, err)
+		}
+
+		if !reported {
+			t.Errorf("concurrency %d: reported hook was not called", concurrency)
+		}
+
+		sort.Strings(merged)
+		if len(merged) != 1 || merged[0] != "ai" {
+			t.Errorf("concurrency %d: unexpected merged instruments %v", concurrency, merged)
+		}
+
+		expectedStages := map[string][]Stage{
+			"ai":   {StageDownload, StageArchive, StageMerge},
+			"kpn":  {StageDownload},
+			"solb": {StageDownload, StageArchive},
+		}
+
+		for name, expected := range expectedStages {
+			if len(stages[name]) != len(expected) {
+				t.Errorf("concurrency %d: %s: expected stages %v, got %v", concurrency, name, expected, stages[name])
+				continue
+			}
+
+			for i, s := range expected {
+				if stages[name][i] != s {
+					t.Errorf("concurrency %d: %s: expected stages %v, got %v", concurrency, name, expected, stages[name])
+				}
+			}
+		}
+
+		if len(stati.DownloadErrors) != 2 || stati.DownloadErrors[1] != "2024-06-04;ams;xams;stock;kpn;nl0000009082;boom" {
+			t.Errorf("concurrency %d: unexpected download errors %q", concurrency, stati.DownloadErrors)
+		}
+
+		if len(stati.MergeErrors) != 2 || stati.MergeErrors[1] != "2024-06-04;par;xpar;stock;ai;fr0000120073;cannot merge" {
+			t.Errorf("concurrency %d: unexpected merge errors %q", concurrency, stati.MergeErrors)
+		}
+	}
+}
+
+func TestPipelineErrors(t *testing.T) {
+	t.Parallel()
+
+	p := Pipeline{XmlInstruments: writeTestInstruments(t)}
+	if _, err := p.Run(); err == nil {
+		t.Errorf("expected an error without a download stage")
+	}
+
+	p = Pipeline{
+		XmlInstruments: filepath.Join(t.TempDir(), "missing.xml"),
+		Logf:           func(string, ...any) {},
+		Download:       func(*Combi) error { return nil },
+	}
+	if _, err := p.Run(); err == nil {
+		t.Errorf("expected an error for a missing instruments file")
+	}
+}
+
+func TestZipFolder(t *testing.T) {
+	t.Parallel()
+
+	dir := filepath.Join(t.TempDir(), "20240604")
+	if err := os.MkdirAll(filepath.Join(dir, "sub"), 0777); err != nil {
+		t.Fatalf("cannot create folder: %v", err)
+	}
+
+	for _, name := range []string{"a.json", "sub/b.json"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(name), 0666); err != nil {
+			t.Fatalf("cannot write file: %v", err)
+		}
+	}
+
+	// The zip file inside the zipped folder is not added to itself.
+	fz := filepath.Join(dir, "enx.zip")
+	if err := ZipFolder(dir+"/", fz); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	r, err := zip.OpenReader(fz)
+	if err != nil {
+		t.Fatalf("cannot open zip: %v", err)
+	}
+	defer r.Close()
+
+	names := []string{}
+	for _, f := range r.File {
+		names = append(names, f.Name)
+	}
+
+	if len(names) != 2 || names[0] != "20240604/a.json" || names[1] != "20240604/sub/b.json" {
+		t.Errorf("unexpected zip entries %v", names)
+	}
+}
@@ -122,8 +122,11 @@ type XmlInstrument struct {
 	Isin          string    `xml:"isin,attr" json:"isin"`
 	Mep           string    `xml:"mep,attr" json:"mep"`
 	Mic           string    `xml:"mic,attr" json:"mic"`
+	MigratedTo    *string   `xml:"migratedTo,attr" json:"migratedTo,omitempty"`
 	Name          string    `xml:"name,attr" json:"name"`
 	Notes         *string   `xml:"notes,attr" json:"notes,omitempty"`
+	Status        *string   `xml:"status,attr" json:"status,omitempty"`
+	StatusDate    *string   `xml:"statusDate,attr" json:"statusDate,omitempty"`
 	Symbol        string    `xml:"symbol,attr" json:"symbol"`
 	Tradingmode   *string   `xml:"tradingmode,attr" json:"tradingmode,omitempty"`
 	Type          string    `xml:"type,attr" json:"type"`
@@ -140,6 +143,11 @@ type XmlInstruments struct {
 	Instrument []XmlInstrument `xml:"instrument" json:"instrument"`
 }
 
+// H5Path returns the "xams/index/ASCX.h5:/XAMS_ASCX_NL0000249142" file path of an instrument.
+func H5Path(mic, isin, mnemonic, typ string) string {
+	return h5(mic, isin, mnemonic, typ)
+}
+
 func h5(mic, isin, mnemonic, typ string) string {
 	// xams/index/ASCX.h5:/XAMS_ASCX_NL0000249142
 	return fmt.Sprintf("%s/%s/%s.h5:/%s_%s_%s",
@@ -0,0 +1,47 @@
+// Package atomicfile replaces files so that an interrupted write keeps the previous content.
+package atomicfile
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// WriteFile writes the file to a temporary file in the same folder and renames it,
+// so an interrupted run keeps the previous file. The mode of an existing file is kept,
+// a new file has the 0644 mode.
+func WriteFile(fileName string, b []byte) error {
+	perm := os.FileMode(0644)
+	if fi, err := os.Stat(fileName); err == nil {
+		perm = fi.Mode().Perm()
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(fileName), filepath.Base(fileName)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("cannot create temporary file of '%s': %w", fileName, err)
+	}
+
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("cannot write '%s': %w", tmp.Name(), err)
+	}
+
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("cannot change mode of '%s': %w", tmp.Name(), err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("cannot close '%s': %w", tmp.Name(), err)
+	}
+
+	if err := os.Rename(tmp.Name(), fileName); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("cannot rename '%s' to '%s': %w", tmp.Name(), fileName, err)
+	}
+
+	return nil
+}
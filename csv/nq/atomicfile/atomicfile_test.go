@@ -0,0 +1,56 @@
+package atomicfile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteFile(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	name := filepath.Join(dir, "master.json")
+	for _, content := range []string{"first", "second"} {
+		if err := WriteFile(name, []byte(content)); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+
+		if b, err := os.ReadFile(name); err != nil || string(b) != content {
+			t.Errorf("expected %s, got %s, error %v", content, b, err)
+		}
+	}
+
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("expected no temporary files left, got %d files", len(entries))
+	}
+
+	if err := WriteFile(filepath.Join(dir, "missing", "master.json"), []byte("x")); err == nil {
+		t.Errorf("expected an error for a missing folder")
+	}
+}
+
+func TestWriteFileMode(t *testing.T) {
+	t.Parallel()
+
+	name := filepath.Join(t.TempDir(), "quarters.csv")
+	if err := WriteFile(name, []byte("first")); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if fi, err := os.Stat(name); err != nil || fi.Mode().Perm() != 0644 {
+		t.Errorf("expected the 0644 mode of a new file, got %v, error %v", fi, err)
+	}
+
+	if err := os.Chmod(name, 0640); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := WriteFile(name, []byte("second")); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if fi, err := os.Stat(name); err != nil || fi.Mode().Perm() != 0640 {
+		t.Errorf("expected the 0640 mode of the existing file kept, got %v, error %v", fi, err)
+	}
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"nq/atomicfile"
+	"nq/nasdaq"
+	"nq/nyse"
+)
+
+type symbols struct {
+	Updated string                `json:"updated"`
+	Symbols []nasdaq.NasdaqSymbol `json:"symbols"`
+}
+
+func main() {
+	t := time.Now().Format("2006-01-02_15-04-05")
+	fmt.Println("=======================================")
+	fmt.Println(t)
+	fmt.Println("=======================================")
+
+	categoryPtr := flag.String("category", "stock", "category: [stock, stock-nasdaq, stock-nyse, stock-amex, etf]")
+	repositoryPtr := flag.String("repository", "fundamentals", "repository folder of the accumulated fundamentals")
+	flag.Parse()
+
+	symbolsFileName := "nasdaq-stock.json"
+	switch *categoryPtr {
+	case "stock-nasdaq":
+		symbolsFileName = "nasdaq-stock-nasdaq.json"
+	case "stock-nyse":
+		symbolsFileName = "nasdaq-stock-nyse.json"
+	case "stock-amex":
+		symbolsFileName = "nasdaq-stock-amex.json"
+	case "etf":
+		symbolsFileName = "nasdaq-etf.json"
+	}
+
+	fmt.Println("reading " + symbolsFileName)
+
+	syms, err := readSymbols(symbolsFileName)
+	if err != nil {
+		panic(fmt.Sprintf("cannot read '%s' symbols: %s", symbolsFileName, err))
+	}
+	fmt.Printf("%d symbols read\n", len(syms.Symbols))
+
+	if err := os.MkdirAll(*repositoryPtr, os.ModePerm); err != nil {
+		panic(fmt.Sprintf("cannot create directory '%s': %s", *repositoryPtr, err))
+	}
+
+	added, failed := 0, 0
+	l := len(syms.Symbols)
+	for i, s := range syms.Symbols {
+		log := fmt.Sprintf("(%d of %d) %s ... ", i+1, l, s.Mnemonic)
+		ok, err := accumulate(s.Mnemonic, *repositoryPtr)
+		switch {
+		case err != nil:
+			failed++
+			fmt.Println(log + err.Error())
+		case ok:
+			added++
+			fmt.Println(log + "snapshot added")
+		default:
+			fmt.Println(log + "unchanged")
+		}
+	}
+
+	fmt.Printf("%d snapshots added, %d failed\n", added, failed)
+	fmt.Println("finished: " + time.Now().Format("2006-01-02_15-04-05"))
+}
+
+func readSymbols(fileName string) (*symbols, error) {
+	var s symbols
+
+	f, err := os.Open(fileName)
+	if err != nil {
+		return &s, fmt.Errorf("cannot open '%s' file: %w", fileName, err)
+	}
+	defer f.Close()
+
+	decoder := json.NewDecoder(f)
+
+	err = decoder.Decode(&s)
+	if err != nil {
+		return &s, fmt.Errorf("cannot decode '%s' file: %w", fileName, err)
+	}
+
+	return &s, nil
+}
+
+// accumulate adds the current fundamentals snapshot of the symbol to the "MNEMONIC.fundamentals.json" history
+// and rewrites the merged "MNEMONIC.quarters.csv" series. It tells if the snapshot is added.
+func accumulate(mnemonic, repository string) (bool, error) {
+	f, _, err := nyse.GetFundamentals(mnemonic)
+	if err != nil {
+		return false, err
+	}
+
+	if f.Retrieved.IsZero() {
+		f.Retrieved = time.Now().UTC().Truncate(time.Second)
+	}
+
+	// BRK/A
+	name := filepath.Join(repository, strings.ReplaceAll(strings.ToUpper(mnemonic), "/", "-"))
+	fileName := name + ".fundamentals.json"
+	h, err := readHistory(fileName)
+	if err != nil {
+		return false, err
+	}
+
+	if !h.Add(f) {
+		return false, nil
+	}
+
+	b, err := json.MarshalIndent(h, "", "  ")
+	if err != nil {
+		return false, fmt.Errorf("cannot encode '%s': %w", fileName, err)
+	}
+
+	if err := atomicfile.WriteFile(fileName, b); err != nil {
+		return false, err
+	}
+
+	csv := strings.Join(nyse.QuartersCSV(h.Quarters()), "")
+	return true, atomicfile.WriteFile(name+".quarters.csv", []byte(csv))
+}
+
+// readHistory reads the fundamentals history, an absent file is an empty history.
+func readHistory(fileName string) (*nyse.FundamentalsHistory, error) {
+	h := &nyse.FundamentalsHistory{}
+	b, err := os.ReadFile(fileName)
+	if os.IsNotExist(err) {
+		return h, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("cannot read '%s': %w", fileName, err)
+	}
+
+	if err := json.Unmarshal(b, h); err != nil {
+		return nil, fmt.Errorf("cannot decode '%s': %w", fileName, err)
+	}
+
+	return h, nil
+}
@@ -36,8 +36,14 @@ func main() {
 	fmt.Println("=======================================")
 
 	symbolsPtr := flag.String("symbols", "nasdaq.json", "symbols json file name")
+	sessionsPtr := flag.String("sessions", "regular", "comma-separated sessions: [pre, regular, post]")
 	flag.Parse()
 
+	sessions, err := parseSessions(*sessionsPtr)
+	if err != nil {
+		panic(fmt.Sprintf("cannot parse sessions: %s", err))
+	}
+
 	sym, err := readSymbols(*symbolsPtr)
 	if err != nil {
 		panic(fmt.Sprintf("cannot read symbols: %s", err))
@@ -65,7 +71,7 @@ func main() {
 		}
 
 		p := fmt.Sprintf("(%d of %d)", i+1, l)
-		if err = s.archive(cfg.Repository, p, cfg.RetryDelayMinutes); err != nil {
+		if err = s.archive(cfg.Repository, p, cfg.RetryDelayMinutes, sessions); err != nil {
 			fmt.Printf("%s: %s\n", s.Mnemonic, err)
 		}
 	}
@@ -73,6 +79,28 @@ func main() {
 	fmt.Println("finished " + time.Now().Format("2006-01-02 15-04-05"))
 }
 
+func parseSessions(names string) ([]nasdaq.Session, error) {
+	sessions := make([]nasdaq.Session, 0)
+	for _, n := range strings.Split(names, ",") {
+		if strings.TrimSpace(n) == "" {
+			continue
+		}
+
+		s, err := nasdaq.ParseSession(n)
+		if err != nil {
+			return nil, err
+		}
+
+		sessions = append(sessions, s)
+	}
+
+	if len(sessions) < 1 {
+		return nil, fmt.Errorf("no sessions in '%s'", names)
+	}
+
+	return sessions, nil
+}
+
 func readConfig(fileName string) (*config, error) {
 	var conf config
 
@@ -125,39 +153,36 @@ func ensureDirectoryExists(directory string) error {
 	return nil
 }
 
-func (s *symbol) archive(repository, prefix string, retryDelayMins []int) error {
-	path := repository + s.Mic + "/" + s.AssetClass + "/" + s.Mnemonic
-	if s.Mnemonic == "prn" {
-		path += "_"
-	}
-	if s.Mnemonic == "com" {
-		path += "_"
-	}
-
-	fmt.Printf("%s '%s' to '%s' ... ", prefix, s.Mnemonic, path)
-
-	err := ensureDirectoryExists(path)
-	if err != nil {
-		return fmt.Errorf("cannot create symbol repository directory '%s': %s", path, err)
-	}
-
+// retrieve retrieves the session trades, retrying after the delays.
+func (s *symbol) retrieve(session nasdaq.Session, retryDelayMins []int) (time.Time, []string, []nasdaq.NasdaqRealtimeJSON, *nasdaq.StitchReport, error) {
 	var t time.Time
 	var csv []string
 	var json []nasdaq.NasdaqRealtimeJSON
+	var report *nasdaq.StitchReport
+	var err error
 
-	path += "/"
 	retriesMax := len(retryDelayMins)
+	if retriesMax < 1 {
+		retriesMax = 1
+	}
 	retries := 0
 	for retries < retriesMax {
-		t, csv, json, err = nasdaq.RetrieveSession(s.Mnemonic)
+		if session == nasdaq.SessionRegular {
+			t, csv, json, report, err = nasdaq.RetrieveSession(s.Mnemonic)
+		} else {
+			var trades []nasdaq.NasdaqTrade
+			t, trades, json, report, err = nasdaq.RetrieveExtendedSession(s.Mnemonic, s.AssetClass, session)
+			csv = nasdaq.ConvertToCSV(trades)
+		}
+
 		if err != nil {
 			retries += 1
-			err := fmt.Errorf("failed to retrieve trades, retries (%d of %d): %w", retries, retriesMax, err)
+			err := fmt.Errorf("failed to retrieve %s trades, retries (%d of %d): %w", session, retries, retriesMax, err)
 			fmt.Println(err)
 			nasdaq.ResetCookie()
 			if retries >= retriesMax {
 				fmt.Printf("giving up after %d retries\n", retriesMax)
-				return err
+				return t, csv, json, report, err
 			} else {
 				mins := retryDelayMins[retries]
 				fmt.Printf("waiting %d minutes before %d retry ...\n", mins, retries+1)
@@ -168,6 +193,59 @@ func (s *symbol) archive(repository, prefix string, retryDelayMins []int) error
 		}
 	}
 
+	return t, csv, json, report, nil
+}
+
+// entryPrefix returns the zip entry prefix of the session,
+// the regular session entries are "2006-01-02_trade.csv", the others "2006-01-02_pre_trade.csv".
+func entryPrefix(t time.Time, session nasdaq.Session) string {
+	td := t.Format("2006-01-02") + "_"
+	if session != nasdaq.SessionRegular {
+		td += session.String() + "_"
+	}
+
+	return td
+}
+
+func (s *symbol) archive(repository, prefix string, retryDelayMins []int, sessions []nasdaq.Session) error {
+	path := repository + nasdaq.SymbolFolder(s.Mic, s.AssetClass, s.Mnemonic)
+
+	fmt.Printf("%s '%s' to '%s' ... ", prefix, s.Mnemonic, path)
+
+	err := ensureDirectoryExists(path)
+	if err != nil {
+		return fmt.Errorf("cannot create symbol repository directory '%s': %s", path, err)
+	}
+
+	// A failed session does not drop the retrieved ones, they are written and the failed ones are reported.
+	var t time.Time
+	retrieved := make([]nasdaq.Session, 0, len(sessions))
+	failed := make([]nasdaq.Session, 0)
+	csvs := make(map[nasdaq.Session][]string)
+	jsons := make(map[nasdaq.Session][]nasdaq.NasdaqRealtimeJSON)
+	gaps := make(map[nasdaq.Session][]string)
+	for _, session := range sessions {
+		st, csv, json, report, err := s.retrieve(session, retryDelayMins)
+		if err != nil {
+			failed = append(failed, session)
+			continue
+		}
+
+		t = st
+		retrieved = append(retrieved, session)
+		csvs[session] = csv
+		jsons[session] = json
+		gaps[session] = report.GapsCSV()
+		if report.Refetched > 0 || report.Duplicates > 0 || len(report.Gaps) > 0 {
+			fmt.Printf("%s session: %s\n", session, report)
+		}
+	}
+
+	if len(retrieved) == 0 {
+		return fmt.Errorf("cannot retrieve %s sessions", sessionNames(failed))
+	}
+
+	path += "/"
 	file := path + t.Format("2006-01-02") // _15-04-05
 	fz := file + "_trade.zip"
 
@@ -189,32 +267,63 @@ again:
 	w := zip.NewWriter(z)
 	defer w.Close()
 
-	td := t.Format("2006-01-02") + "_"
-	nam := td + "trade.csv"
-	f, err := w.Create(nam)
-	if err != nil {
-		return fmt.Errorf("cannot create zip entry '%s': %w", nam, err)
-	}
-
-	joined := strings.Join(csv, "")
-	_, err = f.Write([]byte(joined))
-	if err != nil {
-		return fmt.Errorf("cannot write zip entry '%s': %w", nam, err)
-	}
-
-	for _, j := range json {
-		nam = td + j.Period + "_trade.json"
-		f, err = w.Create(nam)
+	for _, session := range retrieved {
+		td := entryPrefix(t, session)
+		nam := td + "trade.csv"
+		f, err := w.Create(nam)
 		if err != nil {
 			return fmt.Errorf("cannot create zip entry '%s': %w", nam, err)
 		}
 
-		_, err = f.Write(j.JSON)
+		joined := strings.Join(csvs[session], "")
+		_, err = f.Write([]byte(joined))
 		if err != nil {
 			return fmt.Errorf("cannot write zip entry '%s': %w", nam, err)
 		}
+
+		if len(gaps[session]) > 0 {
+			nam = td + "gaps.csv"
+			f, err = w.Create(nam)
+			if err != nil {
+				return fmt.Errorf("cannot create zip entry '%s': %w", nam, err)
+			}
+
+			_, err = f.Write([]byte(strings.Join(gaps[session], "")))
+			if err != nil {
+				return fmt.Errorf("cannot write zip entry '%s': %w", nam, err)
+			}
+		}
+
+		for _, j := range jsons[session] {
+			nam = td + j.Period + "_trade.json"
+			f, err = w.Create(nam)
+			if err != nil {
+				return fmt.Errorf("cannot create zip entry '%s': %w", nam, err)
+			}
+
+			_, err = f.Write(j.JSON)
+			if err != nil {
+				return fmt.Errorf("cannot write zip entry '%s': %w", nam, err)
+			}
+		}
+	}
+
+	if len(failed) > 0 {
+		fmt.Printf("done without %s sessions\n", sessionNames(failed))
+		return fmt.Errorf("cannot retrieve %s sessions, %s written to '%s'", sessionNames(failed),
+			sessionNames(retrieved), fz)
 	}
 
 	fmt.Println("done")
 	return nil
 }
+
+// sessionNames returns the comma-separated session names.
+func sessionNames(sessions []nasdaq.Session) string {
+	names := make([]string, len(sessions))
+	for i, s := range sessions {
+		names[i] = s.String()
+	}
+
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"nq/atomicfile"
+	"nq/nasdaq"
+)
+
+type symbols struct {
+	Updated string                `json:"updated"`
+	Symbols []nasdaq.NasdaqSymbol `json:"symbols"`
+}
+
+type IsinSedolSymbol struct {
+	Mnemonic string `json:"mnemonic"`
+	ISIN     string `json:"ISIN"`
+	SEDOL    string `json:"SEDOL"`
+}
+
+func main() {
+	t := time.Now().Format("2006-01-02_15-04-05")
+	fmt.Println("=======================================")
+	fmt.Println(t)
+	fmt.Println("=======================================")
+
+	categoryPtr := flag.String("category", "stock", "category: [stock, stock-nasdaq, stock-nyse, stock-amex, etf]")
+	symbolsPtr := flag.String("symbols", "", "symbols json file name, default is the category file")
+	isinPtr := flag.String("isin", "", "isin-sedol json file name, default is the category file")
+	datePtr := flag.String("date", "", "snapshot date 2006-01-02, default is the updated date of the symbols")
+	migratePtr := flag.String("migrate", "", "migrate the repository files of the changed symbols: [rename, link]")
+	repositoryPtr := flag.String("repository", "./nasdaq-downloads/", "nqrt repository folder")
+	dryRunPtr := flag.Bool("dry-run", false, "list the changes and the migration actions without updating the master and the repository")
+	changesPtr := flag.String("changes", "", "print the changes between the dates 'from,to' and exit")
+	historyPtr := flag.String("history", "", "print the links of the symbol or the ISIN and exit")
+	atPtr := flag.String("at", "", "print the links valid at the date and exit")
+	flag.Parse()
+
+	category := strings.TrimPrefix(*categoryPtr, "stock-")
+	symbolsFileName := "nasdaq-stock.json"
+	switch *categoryPtr {
+	case "stock-nasdaq", "stock-nyse", "stock-amex":
+		symbolsFileName = "nasdaq-stock-" + category + ".json"
+	case "etf":
+		symbolsFileName = "nasdaq-etf.json"
+	}
+
+	base := strings.TrimSuffix(symbolsFileName, ".json")
+	masterFileName := base + ".master.json"
+	isinFileName := base + ".isin-sedol.json"
+	if *symbolsPtr != "" {
+		symbolsFileName = *symbolsPtr
+	}
+
+	if *isinPtr != "" {
+		isinFileName = *isinPtr
+	}
+
+	m, err := readMaster(masterFileName)
+	if err != nil {
+		panic(fmt.Sprintf("cannot read symbol master: %s", err))
+	}
+
+	if query(m, *changesPtr, *historyPtr, *atPtr) {
+		return
+	}
+
+	fmt.Println("reading " + symbolsFileName)
+	syms, err := readSymbols(symbolsFileName)
+	if err != nil {
+		panic(fmt.Sprintf("cannot read '%s' symbols: %s", symbolsFileName, err))
+	}
+	fmt.Printf("%d symbols read\n", len(syms.Symbols))
+
+	isins, err := readIsins(isinFileName)
+	if err != nil {
+		panic(fmt.Sprintf("cannot read '%s' isins: %s", isinFileName, err))
+	}
+	fmt.Printf("%d isins read from %s\n", len(isins), isinFileName)
+
+	date := *datePtr
+	if date == "" && len(syms.Updated) >= 10 {
+		date = syms.Updated[:10]
+	}
+
+	if _, err := time.Parse("2006-01-02", date); err != nil {
+		panic(fmt.Sprintf("invalid snapshot date '%s', use -date", date))
+	}
+
+	changes, err := m.Apply(date, syms.Symbols, isins)
+	if err != nil {
+		panic(fmt.Sprintf("cannot apply '%s': %s", symbolsFileName, err))
+	}
+
+	for _, c := range changes {
+		fmt.Println(c)
+	}
+	fmt.Printf("%d changes on %s\n", len(changes), date)
+
+	if *migratePtr != "" {
+		mode := nasdaq.MigrateRename
+		switch *migratePtr {
+		case "rename":
+		case "link":
+			mode = nasdaq.MigrateLink
+		default:
+			panic(fmt.Sprintf("unknown migration '%s', expected rename or link", *migratePtr))
+		}
+
+		actions, err := nasdaq.Migrate(*repositoryPtr, changes, mode, *dryRunPtr)
+		for _, a := range actions {
+			fmt.Println(a)
+		}
+
+		if err != nil {
+			panic(fmt.Sprintf("cannot migrate repository: %s", err))
+		}
+	}
+
+	if *dryRunPtr {
+		fmt.Println("dry run, " + masterFileName + " is not updated")
+		return
+	}
+
+	if err := writeMaster(masterFileName, m); err != nil {
+		panic(fmt.Sprintf("cannot write symbol master: %s", err))
+	}
+
+	fmt.Println("finished: " + time.Now().Format("2006-01-02_15-04-05"))
+}
+
+// query prints the changes between the dates, the links of the symbol or ISIN, or the links valid at the date.
+// It tells if a query is given.
+func query(m *nasdaq.SymbolMaster, changes, history, at string) bool {
+	if changes != "" {
+		from, to, _ := strings.Cut(changes, ",")
+		if to == "" {
+			to = m.Updated
+		}
+
+		for _, c := range m.ChangesBetween(from, to) {
+			fmt.Println(c)
+		}
+	}
+
+	links := []nasdaq.Link{}
+	if history != "" {
+		links = append(links, m.History(history)...)
+	}
+
+	if at != "" {
+		links = append(links, m.At(at)...)
+	}
+
+	for _, l := range links {
+		fmt.Printf("%s %s %s %s %s %s..%s\n", l.Mnemonic, l.ISIN, l.Mic, l.Exchange, l.AssetClass, l.From, l.To)
+	}
+
+	return changes != "" || history != "" || at != ""
+}
+
+func readSymbols(fileName string) (*symbols, error) {
+	var s symbols
+
+	f, err := os.Open(fileName)
+	if err != nil {
+		return &s, fmt.Errorf("cannot open '%s' file: %w", fileName, err)
+	}
+	defer f.Close()
+
+	decoder := json.NewDecoder(f)
+
+	err = decoder.Decode(&s)
+	if err != nil {
+		return &s, fmt.Errorf("cannot decode '%s' file: %w", fileName, err)
+	}
+
+	return &s, nil
+}
+
+// readIsins reads the nqsymisin ISINs by mnemonic, an absent file has no ISINs.
+func readIsins(fileName string) (map[string]string, error) {
+	isins := map[string]string{}
+	b, err := os.ReadFile(fileName)
+	if os.IsNotExist(err) {
+		return isins, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("cannot read '%s' file: %w", fileName, err)
+	}
+
+	var s []IsinSedolSymbol
+	if err := json.Unmarshal(b, &s); err != nil {
+		return nil, fmt.Errorf("cannot decode '%s' file: %w", fileName, err)
+	}
+
+	for _, v := range s {
+		if v.ISIN != "" {
+			isins[v.Mnemonic] = v.ISIN
+		}
+	}
+
+	return isins, nil
+}
+
+// readMaster reads the symbol master, an absent file is an empty master.
+func readMaster(fileName string) (*nasdaq.SymbolMaster, error) {
+	m := &nasdaq.SymbolMaster{}
+	b, err := os.ReadFile(fileName)
+	if os.IsNotExist(err) {
+		return m, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("cannot read '%s' file: %w", fileName, err)
+	}
+
+	if err := json.Unmarshal(b, m); err != nil {
+		return nil, fmt.Errorf("cannot decode '%s' file: %w", fileName, err)
+	}
+
+	return m, nil
+}
+
+// writeMaster writes the symbol master, an interrupted run keeps the previous master.
+func writeMaster(fileName string, m *nasdaq.SymbolMaster) error {
+	b, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("cannot encode '%s': %w", fileName, err)
+	}
+
+	return atomicfile.WriteFile(fileName, b)
+}
@@ -1,3 +1,7 @@
 module nq
 
 go 1.26.2
+
+require calendar v0.0.0
+
+replace calendar => ../calendar
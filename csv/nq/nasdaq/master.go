@@ -0,0 +1,463 @@
+package nasdaq
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// SymbolFolder returns the "xngs/stocks/aapl" folder of the symbol archives in the nqrt repository.
+// The empty mic is "other", the "prn" and "com" folders have a trailing underscore.
+func SymbolFolder(mic, assetClass, mnemonic string) string {
+	mic = strings.ToLower(mic)
+	if mic == empty {
+		mic = "other"
+	}
+
+	// PRN and COM are reserved file names on Windows.
+	mnemonic = strings.ToLower(mnemonic)
+	if mnemonic == "prn" || mnemonic == "com" {
+		mnemonic += "_"
+	}
+
+	return mic + "/" + strings.ToLower(assetClass) + "/" + mnemonic
+}
+
+// SymbolRef identifies a listing of an instrument.
+type SymbolRef struct {
+	Mnemonic   string `json:"mnemonic"`
+	ISIN       string `json:"isin,omitempty"`
+	Mic        string `json:"mic"`
+	AssetClass string `json:"assetClass"`
+}
+
+// Folder returns the folder of the archives in the nqrt repository.
+func (r *SymbolRef) Folder() string {
+	return SymbolFolder(r.Mic, r.AssetClass, r.Mnemonic)
+}
+
+// Link is a dated symbol↔ISIN↔exchange link, valid from the first to the last snapshot date it is seen.
+type Link struct {
+	SymbolRef
+	Exchange string `json:"exchange"`
+	Name     string `json:"name"`
+	From     string `json:"from"` // 2006-01-02
+	To       string `json:"to"`   // 2006-01-02
+}
+
+// ChangeKind is the kind of a symbol change.
+type ChangeKind string
+
+const (
+	// ChangeAdded is a new symbol.
+	ChangeAdded ChangeKind = "added"
+
+	// ChangeRemoved is a symbol not listed anymore.
+	ChangeRemoved ChangeKind = "removed"
+
+	// ChangeRenamed is the same ISIN under a new symbol.
+	ChangeRenamed ChangeKind = "renamed"
+
+	// ChangeExchange is the same symbol listed on another exchange.
+	ChangeExchange ChangeKind = "exchange"
+
+	// ChangeReused is the symbol of a different ISIN.
+	ChangeReused ChangeKind = "reused"
+
+	// ChangeAssetClass is the same symbol listed under another asset class.
+	ChangeAssetClass ChangeKind = "assetClass"
+)
+
+// Change is a symbol change detected between two snapshots.
+type Change struct {
+	Date     string     `json:"date"` // 2006-01-02
+	Kind     ChangeKind `json:"kind"`
+	Previous *SymbolRef `json:"previous,omitempty"`
+	Current  *SymbolRef `json:"current,omitempty"`
+}
+
+// String implements the fmt.Stringer interface.
+func (c Change) String() string {
+	ref := func(r *SymbolRef) string {
+		if r == nil {
+			return "-"
+		}
+		return fmt.Sprintf("%s %s %s", r.Mnemonic, r.Mic, r.ISIN)
+	}
+
+	return fmt.Sprintf("%s %s: %s -> %s", c.Date, c.Kind, ref(c.Previous), ref(c.Current))
+}
+
+// SymbolMaster keeps the dated links of the applied symbol snapshots and the changes between them.
+type SymbolMaster struct {
+	// Updated is the date of the latest applied snapshot.
+	Updated string   `json:"updated"`
+	Links   []Link   `json:"links"`
+	Changes []Change `json:"changes"`
+}
+
+// active returns the indices of the links seen in the latest snapshot.
+func (m *SymbolMaster) active() []int {
+	idx := make([]int, 0)
+	for i := range m.Links {
+		if m.Links[i].To == m.Updated {
+			idx = append(idx, i)
+		}
+	}
+
+	return idx
+}
+
+// Apply applies the symbols snapshot of the date with the ISINs by mnemonic, which may be incomplete,
+// and returns the detected changes.
+//
+// A symbol seen under the same mic and a compatible ISIN extends its link. An ISIN listed under a new symbol
+// which is gone from the snapshot is a rename, a symbol with a different ISIN is a reuse,
+// a symbol on another mic is an exchange change and a symbol under another asset class is an asset class change,
+// both start a new link. The first snapshot adds every symbol without changes.
+func (m *SymbolMaster) Apply(date string, syms []NasdaqSymbol, isins map[string]string) ([]Change, error) {
+	if date <= m.Updated {
+		return nil, fmt.Errorf("snapshot date %s is not after the last update %s", date, m.Updated)
+	}
+
+	first := len(m.Links) == 0
+	active := m.active()
+	byMnemonic := map[string]int{}
+	byISIN := map[string]int{}
+	for _, i := range active {
+		byMnemonic[m.Links[i].Mnemonic] = i
+		if m.Links[i].ISIN != empty {
+			byISIN[m.Links[i].ISIN] = i
+		}
+	}
+
+	listed := map[string]bool{}
+	for _, s := range syms {
+		listed[strings.ToUpper(s.Mnemonic)] = true
+	}
+
+	sorted := append([]NasdaqSymbol(nil), syms...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Mnemonic < sorted[j].Mnemonic })
+
+	changes := make([]Change, 0)
+	consumed := map[int]bool{}
+	opened := make([]Link, 0)
+	for _, s := range sorted {
+		link := Link{
+			SymbolRef: SymbolRef{
+				Mnemonic:   strings.ToUpper(s.Mnemonic),
+				ISIN:       strings.ToUpper(strings.TrimSpace(isins[s.Mnemonic])),
+				Mic:        s.Mic,
+				AssetClass: s.AssetClass,
+			},
+			Exchange: s.Exchange,
+			Name:     s.Name,
+			From:     date,
+			To:       date,
+		}
+
+		i, ok := byMnemonic[link.Mnemonic]
+		if ok && (link.ISIN == empty || m.Links[i].ISIN == empty || link.ISIN == m.Links[i].ISIN) {
+			consumed[i] = true
+			prev := &m.Links[i]
+			if !strings.EqualFold(prev.Mic, link.Mic) {
+				changes = append(changes, Change{Date: date, Kind: ChangeExchange, Previous: ref(prev), Current: ref(&link)})
+				opened = append(opened, link)
+				continue
+			}
+
+			if !strings.EqualFold(prev.AssetClass, link.AssetClass) {
+				changes = append(changes, Change{Date: date, Kind: ChangeAssetClass, Previous: ref(prev), Current: ref(&link)})
+				opened = append(opened, link)
+				continue
+			}
+
+			prev.To = date
+			prev.Exchange, prev.Name = link.Exchange, link.Name
+			if prev.ISIN == empty {
+				prev.ISIN = link.ISIN
+			}
+			continue
+		}
+
+		opened = append(opened, link)
+		if ok {
+			// The previous instrument of the symbol is gone.
+			consumed[i] = true
+			changes = append(changes, Change{Date: date, Kind: ChangeReused, Previous: ref(&m.Links[i]), Current: ref(&link)})
+			continue
+		}
+
+		if j, ok := byISIN[link.ISIN]; ok && link.ISIN != empty && !consumed[j] && !listed[m.Links[j].Mnemonic] {
+			consumed[j] = true
+			changes = append(changes, Change{Date: date, Kind: ChangeRenamed, Previous: ref(&m.Links[j]), Current: ref(&link)})
+			continue
+		}
+
+		if !first {
+			changes = append(changes, Change{Date: date, Kind: ChangeAdded, Current: ref(&link)})
+		}
+	}
+
+	for _, i := range active {
+		if !consumed[i] && !listed[m.Links[i].Mnemonic] {
+			changes = append(changes, Change{Date: date, Kind: ChangeRemoved, Previous: ref(&m.Links[i])})
+		}
+	}
+
+	m.Links = append(m.Links, opened...)
+	m.Changes = append(m.Changes, changes...)
+	m.Updated = date
+	return changes, nil
+}
+
+func ref(l *Link) *SymbolRef {
+	r := l.SymbolRef
+	return &r
+}
+
+// ChangesBetween returns the changes after the from date up to and including the to date.
+func (m *SymbolMaster) ChangesBetween(from, to string) []Change {
+	changes := make([]Change, 0)
+	for _, c := range m.Changes {
+		if c.Date > from && c.Date <= to {
+			changes = append(changes, c)
+		}
+	}
+
+	return changes
+}
+
+// At returns the links valid at the date.
+func (m *SymbolMaster) At(date string) []Link {
+	links := make([]Link, 0)
+	for _, l := range m.Links {
+		if l.From <= date && date <= l.To {
+			links = append(links, l)
+		}
+	}
+
+	return links
+}
+
+// History returns the links of the symbol or the ISIN ordered by date.
+func (m *SymbolMaster) History(symbolOrISIN string) []Link {
+	key := strings.ToUpper(symbolOrISIN)
+	links := make([]Link, 0)
+	for _, l := range m.Links {
+		if l.Mnemonic == key || l.ISIN == key {
+			links = append(links, l)
+		}
+	}
+
+	sort.SliceStable(links, func(i, j int) bool { return links[i].From < links[j].From })
+	return links
+}
+
+// MigrateMode tells how the repository files of a renamed symbol are migrated.
+type MigrateMode int
+
+const (
+	// MigrateRename moves the files to the new symbol folder.
+	MigrateRename MigrateMode = iota
+
+	// MigrateLink links the new symbol folder or its files to the previous ones.
+	MigrateLink
+)
+
+// Migrate migrates the nqrt repository folders of the renamed, exchange-changed and asset-class-changed symbols
+// to the current folders, and moves the folder of a reused symbol aside to "mnemonic.isin",
+// so the new instrument starts a clean folder.
+// The existing files are never overwritten. It returns the performed actions, only lists them in a dry run.
+func Migrate(repository string, changes []Change, mode MigrateMode, dryRun bool) ([]string, error) {
+	actions := make([]string, 0)
+	for _, c := range changes {
+		if c.Previous == nil || c.Current == nil {
+			continue
+		}
+
+		src := filepath.Join(repository, c.Previous.Folder())
+		if fi, err := os.Stat(src); err != nil || !fi.IsDir() {
+			continue
+		}
+
+		var a []string
+		var err error
+		switch c.Kind {
+		case ChangeRenamed, ChangeExchange, ChangeAssetClass:
+			dst := filepath.Join(repository, c.Current.Folder())
+			if src == dst {
+				continue
+			}
+
+			if mode == MigrateLink {
+				a, err = linkFolder(src, dst, dryRun)
+			} else {
+				a, err = moveFolder(src, dst, dryRun)
+			}
+		case ChangeReused:
+			if c.Previous.ISIN == empty {
+				continue
+			}
+
+			a, err = moveFolder(src, src+"."+strings.ToLower(c.Previous.ISIN), dryRun)
+		}
+
+		actions = append(actions, a...)
+		if err != nil {
+			return actions, fmt.Errorf("cannot migrate %s: %w", c, err)
+		}
+	}
+
+	return actions, nil
+}
+
+// moveFolder renames the folder, or moves its files into the existing destination without overwriting.
+func moveFolder(src, dst string, dryRun bool) ([]string, error) {
+	if _, err := os.Stat(dst); os.IsNotExist(err) {
+		a := []string{fmt.Sprintf("rename '%s' to '%s'", src, dst)}
+		if dryRun {
+			return a, nil
+		}
+
+		if err := os.MkdirAll(filepath.Dir(dst), os.ModePerm); err != nil {
+			return nil, fmt.Errorf("cannot create directory '%s': %w", filepath.Dir(dst), err)
+		}
+
+		if err := os.Rename(src, dst); err != nil {
+			return nil, fmt.Errorf("cannot rename '%s': %w", src, err)
+		}
+
+		return a, nil
+	}
+
+	return eachFile(src, dst, "move", dryRun, os.Rename)
+}
+
+// symlink creates the symbolic links, replaced in the tests.
+var symlink = os.Symlink
+
+// linkFolder symlinks the destination folder to the folder, or its files into the existing destination.
+// When a symlink cannot be created, e.g. without the privilege on Windows, the files are hard linked or copied.
+func linkFolder(src, dst string, dryRun bool) ([]string, error) {
+	link := func(oldName, newName string) error {
+		target, err := filepath.Rel(filepath.Dir(newName), oldName)
+		if err != nil {
+			target = oldName
+		}
+
+		if err := symlink(target, newName); err != nil {
+			if e := copyPath(oldName, newName); e != nil {
+				return errors.Join(err, e)
+			}
+		}
+
+		return nil
+	}
+
+	if _, err := os.Stat(dst); os.IsNotExist(err) {
+		a := []string{fmt.Sprintf("link '%s' to '%s'", src, dst)}
+		if dryRun {
+			return a, nil
+		}
+
+		if err := os.MkdirAll(filepath.Dir(dst), os.ModePerm); err != nil {
+			return nil, fmt.Errorf("cannot create directory '%s': %w", filepath.Dir(dst), err)
+		}
+
+		if err := link(src, dst); err != nil {
+			return nil, fmt.Errorf("cannot link '%s': %w", dst, err)
+		}
+
+		return a, nil
+	}
+
+	return eachFile(src, dst, "link", dryRun, link)
+}
+
+// copyPath hard links or copies the file, or the files of the folder recursively.
+func copyPath(oldName, newName string) error {
+	fi, err := os.Stat(oldName)
+	if err != nil {
+		return err
+	}
+
+	if !fi.IsDir() {
+		if err := os.Link(oldName, newName); err == nil {
+			return nil
+		}
+
+		return copyFile(oldName, newName, fi.Mode().Perm())
+	}
+
+	if err := os.Mkdir(newName, fi.Mode().Perm()); err != nil {
+		return err
+	}
+
+	entries, err := os.ReadDir(oldName)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		if err := copyPath(filepath.Join(oldName, e.Name()), filepath.Join(newName, e.Name())); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// copyFile copies the file without overwriting an existing one.
+func copyFile(oldName, newName string, perm os.FileMode) error {
+	in, err := os.Open(oldName)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(newName, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(newName)
+		return err
+	}
+
+	return out.Close()
+}
+
+// eachFile applies the action to the files of the folder absent in the destination.
+func eachFile(src, dst, verb string, dryRun bool, action func(oldName, newName string) error) ([]string, error) {
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read directory '%s': %w", src, err)
+	}
+
+	actions := make([]string, 0)
+	for _, e := range entries {
+		from, to := filepath.Join(src, e.Name()), filepath.Join(dst, e.Name())
+		if _, err := os.Lstat(to); err == nil {
+			actions = append(actions, fmt.Sprintf("skip '%s', '%s' exists", from, to))
+			continue
+		}
+
+		actions = append(actions, fmt.Sprintf("%s '%s' to '%s'", verb, from, to))
+		if dryRun {
+			continue
+		}
+
+		if err := action(from, to); err != nil {
+			return actions, fmt.Errorf("cannot %s '%s': %w", verb, from, err)
+		}
+	}
+
+	return actions, nil
+}
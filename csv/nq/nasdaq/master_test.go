@@ -0,0 +1,216 @@
+package nasdaq
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func testMaster(t *testing.T) (*SymbolMaster, []Change) {
+	m := &SymbolMaster{}
+	day1 := []NasdaqSymbol{
+		{Mnemonic: "AAA", Mic: "XNGS", AssetClass: "Stocks"},
+		{Mnemonic: "BBB", Mic: "XNYS", AssetClass: "Stocks"},
+		{Mnemonic: "CCC", Mic: "XNGS", AssetClass: "Stocks"},
+		{Mnemonic: "DDD", Mic: "XNYS", AssetClass: "Stocks"},
+	}
+	isins := map[string]string{"AAA": "US1", "BBB": "US2", "CCC": "US3", "DDD": "US4"}
+	if changes, err := m.Apply("2026-01-09", day1, isins); err != nil || len(changes) != 0 {
+		t.Fatalf("expected no changes for the first snapshot, got %v, error %v", changes, err)
+	}
+
+	day2 := []NasdaqSymbol{
+		{Mnemonic: "EEE", Mic: "XNGS", AssetClass: "Stocks"},
+		{Mnemonic: "AAB", Mic: "XNGS", AssetClass: "Stocks"},
+		{Mnemonic: "BBB", Mic: "XNGS", AssetClass: "Stocks"},
+		{Mnemonic: "CCC", Mic: "XNGS", AssetClass: "Stocks"},
+	}
+	isins = map[string]string{"AAB": "US1", "BBB": "US2", "CCC": "US9"}
+	changes, err := m.Apply("2026-02-02", day2, isins)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	return m, changes
+}
+
+func TestSymbolMasterApply(t *testing.T) {
+	t.Parallel()
+
+	m, changes := testMaster(t)
+	expected := []string{
+		"2026-02-02 renamed: AAA XNGS US1 -> AAB XNGS US1",
+		"2026-02-02 exchange: BBB XNYS US2 -> BBB XNGS US2",
+		"2026-02-02 reused: CCC XNGS US3 -> CCC XNGS US9",
+		"2026-02-02 added: - -> EEE XNGS ",
+		"2026-02-02 removed: DDD XNYS US4 -> -",
+	}
+	if fmt.Sprint(changes) != fmt.Sprint(expected) {
+		t.Errorf("expected %v, got %v", expected, changes)
+	}
+
+	if _, err := m.Apply("2026-02-02", nil, nil); err == nil {
+		t.Errorf("expected an error for a snapshot not after the last update")
+	}
+
+	if len(m.At("2026-01-09")) != 4 || len(m.At("2026-02-02")) != 4 || len(m.At("2026-01-20")) != 0 {
+		t.Errorf("unexpected links by date %v", m.Links)
+	}
+
+	h := m.History("us1")
+	if len(h) != 2 || h[0].Mnemonic != "AAA" || h[0].To != "2026-01-09" || h[1].Mnemonic != "AAB" || h[1].From != "2026-02-02" {
+		t.Errorf("unexpected history %v", h)
+	}
+
+	if len(m.ChangesBetween("2026-01-09", "2026-02-02")) != 5 || len(m.ChangesBetween("2026-02-02", "2026-12-31")) != 0 {
+		t.Errorf("unexpected changes by date %v", m.Changes)
+	}
+}
+
+func TestSymbolMasterApplyAssetClass(t *testing.T) {
+	t.Parallel()
+
+	m := &SymbolMaster{}
+	if _, err := m.Apply("2026-01-09", []NasdaqSymbol{{Mnemonic: "AAA", Mic: "XNGS", AssetClass: "Stocks"}}, nil); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	changes, err := m.Apply("2026-02-02", []NasdaqSymbol{{Mnemonic: "AAA", Mic: "XNGS", AssetClass: "ETF"}}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if len(changes) != 1 || changes[0].Kind != ChangeAssetClass || changes[0].Previous.Folder() != "xngs/stocks/aaa" ||
+		changes[0].Current.Folder() != "xngs/etf/aaa" {
+		t.Errorf("unexpected changes %v", changes)
+	}
+
+	h := m.History("AAA")
+	if len(h) != 2 || h[0].AssetClass != "Stocks" || h[0].To != "2026-01-09" || h[1].AssetClass != "ETF" || h[1].From != "2026-02-02" {
+		t.Errorf("unexpected history %v", h)
+	}
+
+	if changes, _ := m.Apply("2026-03-04", []NasdaqSymbol{{Mnemonic: "AAA", Mic: "XNGS", AssetClass: "etf"}}, nil); len(changes) != 0 {
+		t.Errorf("expected no changes for the same asset class, got %v", changes)
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	t.Parallel()
+
+	_, changes := testMaster(t)
+	dir := t.TempDir()
+	for _, f := range []string{"xngs/stocks/aaa/a.zip", "xnys/stocks/bbb/b1.zip", "xnys/stocks/bbb/b2.zip", "xngs/stocks/bbb/b1.zip", "xngs/stocks/ccc/c.zip"} {
+		name := filepath.Join(dir, f)
+		os.MkdirAll(filepath.Dir(name), os.ModePerm)
+		if err := os.WriteFile(name, []byte(f), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	dry, err := Migrate(dir, changes, MigrateRename, true)
+	if err != nil || len(dry) != 4 {
+		t.Fatalf("unexpected dry run %v, error %v", dry, err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "xngs/stocks/aaa")); err != nil {
+		t.Errorf("expected nothing moved in a dry run")
+	}
+
+	if _, err := Migrate(dir, changes, MigrateRename, false); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	exists := map[string]bool{
+		"xngs/stocks/aab/a.zip":     true,
+		"xngs/stocks/aaa":           false,
+		"xngs/stocks/bbb/b2.zip":    true,
+		"xnys/stocks/bbb/b1.zip":    true,
+		"xngs/stocks/ccc.us3/c.zip": true,
+		"xngs/stocks/ccc":           false,
+	}
+	for f, e := range exists {
+		if _, err := os.Stat(filepath.Join(dir, f)); (err == nil) != e {
+			t.Errorf("%s: expected exists %v", f, e)
+		}
+	}
+
+	if b, _ := os.ReadFile(filepath.Join(dir, "xngs/stocks/bbb/b1.zip")); string(b) != "xngs/stocks/bbb/b1.zip" {
+		t.Errorf("expected the existing file not overwritten, got %s", b)
+	}
+}
+
+func TestMigrateLink(t *testing.T) {
+	t.Parallel()
+
+	_, changes := testMaster(t)
+	dir := t.TempDir()
+	name := filepath.Join(dir, "xngs/stocks/aaa/a.zip")
+	os.MkdirAll(filepath.Dir(name), os.ModePerm)
+	if err := os.WriteFile(name, []byte("a"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Migrate(dir, changes, MigrateLink, false); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if b, err := os.ReadFile(filepath.Join(dir, "xngs/stocks/aab/a.zip")); err != nil || string(b) != "a" {
+		t.Errorf("expected the linked file, got %s, error %v", b, err)
+	}
+
+	if _, err := os.Stat(name); err != nil {
+		t.Errorf("expected the original file kept")
+	}
+}
+
+func TestMigrateLinkWithoutSymlinks(t *testing.T) {
+	// Not parallel, the symlink function is replaced.
+	symlink = func(string, string) error { return errors.New("a required privilege is not held by the client") }
+	defer func() { symlink = os.Symlink }()
+
+	_, changes := testMaster(t)
+	dir := t.TempDir()
+	for _, f := range []string{"xngs/stocks/aaa/a.zip", "xngs/stocks/aaa/2026/b.zip", "xnys/stocks/bbb/b.zip"} {
+		name := filepath.Join(dir, f)
+		os.MkdirAll(filepath.Dir(name), os.ModePerm)
+		if err := os.WriteFile(name, []byte(f), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The bbb destination exists, its files are linked one by one.
+	os.MkdirAll(filepath.Join(dir, "xngs/stocks/bbb"), os.ModePerm)
+	if _, err := Migrate(dir, changes, MigrateLink, false); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	for _, f := range []string{"xngs/stocks/aab/a.zip", "xngs/stocks/aab/2026/b.zip", "xngs/stocks/bbb/b.zip"} {
+		fi, err := os.Lstat(filepath.Join(dir, f))
+		if err != nil || !fi.Mode().IsRegular() {
+			t.Errorf("%s: expected a regular file, error %v", f, err)
+		}
+	}
+
+	if b, _ := os.ReadFile(filepath.Join(dir, "xngs/stocks/aab/2026/b.zip")); string(b) != "xngs/stocks/aaa/2026/b.zip" {
+		t.Errorf("unexpected copied content %s", b)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "xngs/stocks/aaa/a.zip")); err != nil {
+		t.Errorf("expected the original file kept")
+	}
+}
+
+func TestSymbolFolder(t *testing.T) {
+	t.Parallel()
+
+	if f := SymbolFolder("XNGM", "ETF", "PRN"); f != "xngm/etf/prn_" {
+		t.Errorf("unexpected folder %s", f)
+	}
+
+	if f := SymbolFolder("", "Stocks", "AAPL"); f != "other/stocks/aapl" {
+		t.Errorf("unexpected folder %s", f)
+	}
+}
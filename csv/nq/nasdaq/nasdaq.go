@@ -8,6 +8,8 @@ import (
 	"strconv"
 	"strings"
 	"time"
+
+	"calendar"
 )
 
 /*
@@ -86,14 +88,98 @@ type NasdaqRealtime struct {
 }
 
 type NasdaqRealtimeJSON struct {
-	Period string
-	JSON   []byte
+	Session Session
+	Period  string
+	JSON    []byte
+}
+
+/*
+{
+	data: {
+		symbol:"TSLA",
+		tradeDetailTable: {
+			headers: {
+				time:"Trade Time (ET)",
+				price:"Trade Price",
+				shareVolume:"Share Volume"
+			},
+			rows: [
+				{ time:"07:59:59", price:"$ 200.46", shareVolume:"10" },
+				{ time:"07:59:58", price:"$ 200.45", shareVolume:"100" }
+			]
+		}
+	},
+	message:null,
+	status:{ rCode:200, bCodeMessage:null, developerMessage:null }
+}
+*/
+
+type NasdaqExtendedTrade struct {
+	Time   string `json:"time"`
+	Price  string `json:"price"`
+	Volume string `json:"shareVolume"`
+}
+
+type NasdaqExtendedTable struct {
+	Rows []NasdaqExtendedTrade `json:"rows"`
+}
+
+type NasdaqExtendedData struct {
+	Symbol           string              `json:"symbol"`
+	TradeDetailTable NasdaqExtendedTable `json:"tradeDetailTable"`
+}
+
+type NasdaqExtended struct {
+	Data    NasdaqExtendedData   `json:"data"`
+	Message string               `json:"message"`
+	Status  NasdaqRealtimeStatus `json:"status"`
 }
 
 type NasdaqTrade struct {
-	Time   time.Time
-	Price  float64
-	Volume float64
+	Time    time.Time
+	Price   float64
+	Volume  float64
+	Session Session
+}
+
+// Session is the trading session of a trade.
+type Session int
+
+const (
+	// SessionPre is the pre-market session from 04:00 to 09:30 ET.
+	SessionPre Session = iota
+
+	// SessionRegular is the regular session from 09:30 to 16:00 ET.
+	SessionRegular
+
+	// SessionPost is the after-hours session from 16:00 to 20:00 ET.
+	SessionPost
+)
+
+// Sessions are the trading sessions in time order.
+var Sessions = []Session{SessionPre, SessionRegular, SessionPost}
+
+// String implements the fmt.Stringer interface.
+func (s Session) String() string {
+	switch s {
+	case SessionPre:
+		return "pre"
+	case SessionPost:
+		return "post"
+	default:
+		return "regular"
+	}
+}
+
+// ParseSession parses the "pre", "regular" or "post" session name.
+func ParseSession(name string) (Session, error) {
+	for _, s := range Sessions {
+		if strings.EqualFold(strings.TrimSpace(name), s.String()) {
+			return s, nil
+		}
+	}
+
+	return SessionRegular, fmt.Errorf("unknown session '%s', expected pre, regular or post", name)
 }
 
 const userAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/108.0.0.0 Safari/537.36"
@@ -102,7 +188,7 @@ const empty = ""
 
 var cookieValue = empty
 
-func (nrls *NasdaqRealtimeLastSale) convertToNasdaqTrade(dateEst time.Time) (*NasdaqTrade, error) {
+func (nrls *NasdaqRealtimeLastSale) convertToNasdaqTrade(dateEst time.Time, session Session) (*NasdaqTrade, error) {
 
 	s := strings.TrimSpace(nrls.Time)
 	t, err := time.Parse("15:04:05", s)
@@ -126,9 +212,10 @@ func (nrls *NasdaqRealtimeLastSale) convertToNasdaqTrade(dateEst time.Time) (*Na
 	}
 
 	return &NasdaqTrade{
-		Time:   t.AddDate(dateEst.Year(), int(dateEst.Month())-1, dateEst.Day()-1),
-		Price:  p,
-		Volume: v,
+		Time:    t.AddDate(dateEst.Year(), int(dateEst.Month())-1, dateEst.Day()-1),
+		Price:   p,
+		Volume:  v,
+		Session: session,
 	}, nil
 }
 
@@ -141,6 +228,21 @@ func unmarshalRealtime(jsn []byte) (*NasdaqRealtime, error) {
 	return &rt, nil
 }
 
+// unmarshalExtended unmarshals the extended trading json as the realtime rows.
+func unmarshalExtended(jsn []byte) ([]NasdaqRealtimeLastSale, error) {
+	ext := NasdaqExtended{}
+	if err := json.Unmarshal(jsn, &ext); err != nil {
+		return nil, fmt.Errorf("cannot unmarshal NasdaqExtended: %w", err)
+	}
+
+	rows := make([]NasdaqRealtimeLastSale, 0, len(ext.Data.TradeDetailTable.Rows))
+	for _, r := range ext.Data.TradeDetailTable.Rows {
+		rows = append(rows, NasdaqRealtimeLastSale{Time: r.Time, Price: r.Price, Volume: r.Volume})
+	}
+
+	return rows, nil
+}
+
 func ResetCookie() {
 	cookieValue = empty
 }
@@ -230,36 +332,14 @@ repeat:
 	return contents, nil
 }
 
+// sessionDate returns the latest Nasdaq session date on or before today in New York.
 func sessionDate() (time.Time, error) {
-	today := time.Now().Add(time.Hour * -6)
-	/*loc, err := time.LoadLocation("EST")
+	cal, err := calendar.ForMic("XNAS")
 	if err != nil {
-		return today, fmt.Errorf("cannot load EST timezone: %w", err)
+		return time.Now(), fmt.Errorf("cannot get Nasdaq calendar: %w", err)
 	}
 
-	today = today.In(loc)*/
-	dow := today.Weekday()
-	/*if dow == time.Tuesday {
-		return today.AddDate(0, 0, -1), nil
-	} else if dow == time.Wednesday {
-		return today.AddDate(0, 0, -2), nil
-	} else if dow == time.Thursday {
-		return today.AddDate(0, 0, -3), nil
-	} else*/ if dow == time.Saturday {
-		return today.AddDate(0, 0, -1), nil
-	} else if dow == time.Sunday {
-		return today.AddDate(0, 0, -2), nil
-	} else {
-		/*if today.Hour() < 9 || (today.Hour() == 9 && today.Minute() <= 25) {
-			return today.AddDate(0, 0, -1), nil
-		}
-
-		if today.Hour() < 15 || (today.Hour() == 15 && today.Minute() <= 35) {
-			return today, fmt.Errorf("time now should be outside 09:25 till 15:35 EST: %v", today)
-		}*/
-
-		return today, nil
-	}
+	return cal.LastSession(time.Now()), nil
 }
 
 func convertToCSV(series []NasdaqTrade) []string {
@@ -274,61 +354,109 @@ func convertToCSV(series []NasdaqTrade) []string {
 	return csv
 }
 
-func RetrieveSession(mnemonic string) (time.Time, []string, []NasdaqRealtimeJSON, error) {
-	periods := []string{
-		"09:30",
-		"10:00",
-		"10:30",
-		"11:00",
-		"11:30",
-		"12:00",
-		"12:30",
-		"13:00",
-		"13:30",
-		"14:00",
-		"14:30",
-		"15:00",
-		"15:30"}
+// slot is a half-hour request of a session.
+type slot struct {
+	// period is the "09-30" start time of the slot.
+	period string
 
-	csv := make([]string, 0)
-	series := make([]NasdaqTrade, 0)
-	jsons := make([]NasdaqRealtimeJSON, 0)
-	today, err := sessionDate()
-	if err != nil {
-		return today, csv, jsons, err
+	// url is the request url of the slot.
+	url string
+
+	// start and end are the requested time of day.
+	start, end time.Duration
+
+	// from and to are the time of day window of the slot trades,
+	// the first and the last slot of a session are open-ended.
+	from, to time.Duration
+}
+
+const day = 24 * time.Hour
+
+// realtimeURL returns the regular session url from the time of day.
+func realtimeURL(mnemonic string, fromTime time.Duration) string {
+	return "https://api.nasdaq.com/api/quote/" + mnemonic + "/realtime-trades?limit=99999999&fromTime=" + clock(fromTime, ":")
+}
+
+// clock formats the time of day as "09:30" with the separator.
+func clock(d time.Duration, separator string) string {
+	return fmt.Sprintf("%02d%s%02d", int(d/time.Hour), separator, int(d%time.Hour/time.Minute))
+}
+
+// sessionSlots returns the half-hour slots of the session.
+//
+// The regular session is requested by the start time, the extended sessions by the slot number:
+// the pre-market slots 1 to 11 start at 04:00, 04:30, ... 09:00, the after-hours slots 1 to 8 start at 16:00, 16:30, ... 19:30.
+func sessionSlots(mnemonic, assetClass string, session Session) []slot {
+	if assetClass == empty {
+		assetClass = "stocks"
 	}
 
-	url := "https://api.nasdaq.com/api/quote/" + mnemonic + "/realtime-trades?limit=99999999&fromTime="
+	var start time.Duration
+	var count int
+	switch session {
+	case SessionPre:
+		start, count = 4*time.Hour, 11
+	case SessionPost:
+		start, count = 16*time.Hour, 8
+	default:
+		start, count = 9*time.Hour+30*time.Minute, 13
+	}
 
-	for _, p := range periods {
-		b, err := get(url + p)
-		if err != nil {
-			return today, csv, jsons, fmt.Errorf("cannot get url '%s': %w", url+p, err)
+	slots := make([]slot, 0, count)
+	for i := 0; i < count; i++ {
+		t := start + time.Duration(i)*30*time.Minute
+		sl := slot{period: clock(t, "-"), url: realtimeURL(mnemonic, t), start: t, end: t + 30*time.Minute, from: t, to: t + 30*time.Minute}
+		if session != SessionRegular {
+			sl.url = fmt.Sprintf("https://api.nasdaq.com/api/quote/%s/extended-trading?markettype=%s&assetclass=%s&limit=99999999&time=%d",
+				mnemonic, session, assetClass, i+1)
 		}
 
-		jsons = append(jsons, NasdaqRealtimeJSON{
-			Period: strings.ReplaceAll(p, ":", "-"),
-			JSON:   b,
-		})
+		if i == 0 {
+			sl.from = 0
+		}
 
-		rt, err := unmarshalRealtime(b)
-		if err != nil {
-			return today, csv, jsons, fmt.Errorf("cannot unmarshal url '%s': %w", url+p, err)
+		if i == count-1 {
+			sl.to = day
 		}
 
-		for i := len(rt.Data.Rows) - 1; i >= 0; i-- {
-			el := rt.Data.Rows[i]
-			cv, err := el.convertToNasdaqTrade(today)
-			if err != nil {
-				return today, csv, jsons, fmt.Errorf("cannot convert url '%s': %w", url+p, err)
-			}
+		slots = append(slots, sl)
+	}
 
-			series = append(series, *cv)
-		}
+	return slots
+}
+
+func RetrieveSession(mnemonic string) (time.Time, []string, []NasdaqRealtimeJSON, *StitchReport, error) {
+	today, err := sessionDate()
+	if err != nil {
+		return today, make([]string, 0), make([]NasdaqRealtimeJSON, 0), &StitchReport{}, err
+	}
+
+	st := newStitcher(mnemonic, SessionRegular, today)
+	series, err := st.retrieve(sessionSlots(mnemonic, empty, SessionRegular))
+	if err != nil {
+		return today, make([]string, 0), st.jsons, st.report, err
+	}
+
+	return today, convertToCSV(series), st.jsons, st.report, nil
+}
+
+// RetrieveExtendedSession retrieves the trades of the pre-market or after-hours session
+// from the extended trading endpoint, the regular session is retrieved as RetrieveSession does.
+// The asset class is "stocks" or "etf", empty means "stocks".
+func RetrieveExtendedSession(mnemonic, assetClass string, session Session) (time.Time, []NasdaqTrade, []NasdaqRealtimeJSON, *StitchReport, error) {
+	today, err := sessionDate()
+	if err != nil {
+		return today, make([]NasdaqTrade, 0), make([]NasdaqRealtimeJSON, 0), &StitchReport{}, err
 	}
 
-	csv = convertToCSV(series)
-	return today, csv, jsons, nil
+	st := newStitcher(mnemonic, session, today)
+	series, err := st.retrieve(sessionSlots(mnemonic, assetClass, session))
+	return today, series, st.jsons, st.report, err
+}
+
+// ConvertToCSV converts the trades to the "2006-01-02 15:04:05;price;volume" lines.
+func ConvertToCSV(series []NasdaqTrade) []string {
+	return convertToCSV(series)
 }
 
 type NasdaqSymbol struct {
@@ -0,0 +1,70 @@
+package nasdaq
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSessionSlots(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		session Session
+		count   int
+		period  string
+		url     string
+		last    string
+	}{
+		{SessionPre, 11, "04-00", "https://api.nasdaq.com/api/quote/tsla/extended-trading?markettype=pre&assetclass=stocks&limit=99999999&time=1", "09-00"},
+		{SessionRegular, 13, "09-30", "https://api.nasdaq.com/api/quote/tsla/realtime-trades?limit=99999999&fromTime=09:30", "15-30"},
+		{SessionPost, 8, "16-00", "https://api.nasdaq.com/api/quote/tsla/extended-trading?markettype=post&assetclass=stocks&limit=99999999&time=1", "19-30"},
+	}
+
+	for _, tt := range tests {
+		slots := sessionSlots("tsla", "", tt.session)
+		if len(slots) != tt.count || slots[0].period != tt.period || slots[0].url != tt.url || slots[len(slots)-1].period != tt.last {
+			t.Errorf("%s: unexpected slots %v", tt.session, slots)
+		}
+
+		if slots[0].from != 0 || slots[len(slots)-1].to != day {
+			t.Errorf("%s: expected open-ended first and last slots", tt.session)
+		}
+	}
+}
+
+func TestParseSession(t *testing.T) {
+	t.Parallel()
+
+	for _, s := range Sessions {
+		if p, err := ParseSession(" " + s.String() + " "); err != nil || p != s {
+			t.Errorf("%s: got %s, error %v", s, p, err)
+		}
+	}
+
+	if _, err := ParseSession("night"); err == nil {
+		t.Errorf("expected an error for an unknown session")
+	}
+}
+
+func TestUnmarshalExtended(t *testing.T) {
+	t.Parallel()
+
+	jsn := `{"data":{"symbol":"TSLA","tradeDetailTable":{"headers":{"time":"Trade Time (ET)"},"rows":[
+		{"time":"07:59:59","price":"$ 1,200.46","shareVolume":"10"},
+		{"time":"07:59:58","price":"$ 200.45","shareVolume":"1,100"}]}},"message":null,"status":{"rCode":200}}`
+	rows, err := unmarshalExtended([]byte(jsn))
+	if err != nil || len(rows) != 2 {
+		t.Fatalf("unexpected rows %v, error %v", rows, err)
+	}
+
+	date := time.Date(2023, 2, 24, 0, 0, 0, 0, time.UTC)
+	trade, err := rows[0].convertToNasdaqTrade(date, SessionPre)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	expected := NasdaqTrade{Time: time.Date(2023, 2, 24, 7, 59, 59, 0, time.UTC), Price: 1200.46, Volume: 10, Session: SessionPre}
+	if *trade != expected {
+		t.Errorf("expected %v, got %v", expected, *trade)
+	}
+}
@@ -0,0 +1,228 @@
+package nasdaq
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// refineSteps are the finer slot durations used to re-fetch an incomplete regular session slot.
+var refineSteps = []time.Duration{5 * time.Minute, time.Minute}
+
+// Gap is a slot window with less trades than the response total records, which cannot be re-fetched finer.
+type Gap struct {
+	Session  Session
+	From     time.Duration
+	To       time.Duration
+	Expected int
+	Received int
+}
+
+// String implements the fmt.Stringer interface.
+func (g Gap) String() string {
+	return fmt.Sprintf("%s %s-%s: %d of %d trades received", g.Session, clock(g.From, ":"), clock(g.To, ":"),
+		g.Received, g.Expected)
+}
+
+// StitchReport is the result of stitching the slots of a session.
+type StitchReport struct {
+	// Slots is the number of the fetched slots including the re-fetched finer ones.
+	Slots int
+
+	// Verified is the number of the slots with all total records received.
+	Verified int
+
+	// Refetched is the number of the incomplete slots re-fetched finer.
+	Refetched int
+
+	// Duplicates is the number of the dropped prints repeated across the slots.
+	Duplicates int
+
+	Gaps []Gap
+}
+
+// String implements the fmt.Stringer interface.
+func (r *StitchReport) String() string {
+	s := fmt.Sprintf("%d slots, %d verified, %d refetched, %d duplicates, %d gaps", r.Slots, r.Verified, r.Refetched, r.Duplicates, len(r.Gaps))
+	for _, g := range r.Gaps {
+		s += "\n" + g.String()
+	}
+
+	return s
+}
+
+// GapsCSV returns the "session;from;to;expected;received" lines of the gaps.
+func (r *StitchReport) GapsCSV() []string {
+	csv := make([]string, 0, len(r.Gaps))
+	for _, g := range r.Gaps {
+		csv = append(csv, fmt.Sprintf("%s;%s;%s;%d;%d\n", g.Session, clock(g.From, ":"), clock(g.To, ":"),
+			g.Expected, g.Received))
+	}
+
+	return csv
+}
+
+// tradeKey identifies a print, the position tells apart the identical prints in the same second.
+type tradeKey struct {
+	time     time.Time
+	price    float64
+	volume   float64
+	position int
+}
+
+// Stitch merges the time-ordered trades of the slot responses into one time-ordered series.
+//
+// A print is identified by its time, price, volume and position among the identical prints of a response,
+// so a print repeated in overlapping responses is kept once and the identical prints of a response are all kept.
+// It returns the merged trades and the number of the dropped duplicates.
+func Stitch(responses [][]NasdaqTrade) ([]NasdaqTrade, int) {
+	merged := make([]NasdaqTrade, 0)
+	seen := make(map[tradeKey]bool)
+	duplicates := 0
+	for _, trades := range responses {
+		positions := make(map[tradeKey]int)
+		for _, t := range trades {
+			k := tradeKey{time: t.Time, price: t.Price, volume: t.Volume}
+			k.position = positions[k]
+			positions[tradeKey{time: t.Time, price: t.Price, volume: t.Volume}]++
+
+			if seen[k] {
+				duplicates++
+				continue
+			}
+
+			seen[k] = true
+			merged = append(merged, t)
+		}
+	}
+
+	sort.SliceStable(merged, func(i, j int) bool { return merged[i].Time.Before(merged[j].Time) })
+	return merged, duplicates
+}
+
+// stitcher fetches the slots of a session, re-fetching the incomplete regular session slots finer.
+type stitcher struct {
+	mnemonic  string
+	session   Session
+	today     time.Time
+	fetch     func(url string) ([]byte, error)
+	responses [][]NasdaqTrade
+	jsons     []NasdaqRealtimeJSON
+	report    *StitchReport
+}
+
+func newStitcher(mnemonic string, session Session, today time.Time) *stitcher {
+	return &stitcher{
+		mnemonic: mnemonic,
+		session:  session,
+		today:    today,
+		fetch:    get,
+		jsons:    make([]NasdaqRealtimeJSON, 0),
+		report:   &StitchReport{},
+	}
+}
+
+// retrieve fetches the slots and returns the stitched trades.
+func (st *stitcher) retrieve(slots []slot) ([]NasdaqTrade, error) {
+	for _, sl := range slots {
+		if err := st.fetchSlot(sl, refineSteps); err != nil {
+			return make([]NasdaqTrade, 0), err
+		}
+	}
+
+	series, duplicates := Stitch(st.responses)
+	st.report.Duplicates = duplicates
+	return series, nil
+}
+
+// fetchSlot fetches a slot, an incomplete regular session slot is re-fetched with the first step
+// and the remaining steps, an incomplete slot without steps is a gap.
+//
+// The first finer slot has the url of the slot, it is fetched again rather than reusing the incomplete
+// response, so the re-fetched response covers the finer window.
+func (st *stitcher) fetchSlot(sl slot, steps []time.Duration) error {
+	b, err := st.fetch(sl.url)
+	if err != nil {
+		return fmt.Errorf("cannot get url '%s': %w", sl.url, err)
+	}
+
+	st.jsons = append(st.jsons, NasdaqRealtimeJSON{
+		Session: st.session,
+		Period:  sl.period,
+		JSON:    b,
+	})
+
+	// The extended trading json has no total records.
+	var rows []NasdaqRealtimeLastSale
+	total := 0
+	if st.session == SessionRegular {
+		rt, err := unmarshalRealtime(b)
+		if err != nil {
+			return fmt.Errorf("cannot unmarshal url '%s': %w", sl.url, err)
+		}
+		rows, total = rt.Data.Rows, rt.Data.TotalRecords
+	} else if rows, err = unmarshalExtended(b); err != nil {
+		return fmt.Errorf("cannot unmarshal url '%s': %w", sl.url, err)
+	}
+
+	// The rows are the latest first.
+	trades := make([]NasdaqTrade, 0, len(rows))
+	for i := len(rows) - 1; i >= 0; i-- {
+		cv, err := rows[i].convertToNasdaqTrade(st.today, st.session)
+		if err != nil {
+			return fmt.Errorf("cannot convert url '%s': %w", sl.url, err)
+		}
+
+		if d := timeOfDay(cv.Time); d >= sl.from && d < sl.to {
+			trades = append(trades, *cv)
+		}
+	}
+
+	st.responses = append(st.responses, trades)
+	st.report.Slots++
+
+	if total <= len(rows) {
+		if total > 0 {
+			st.report.Verified++
+		}
+		return nil
+	}
+
+	// The received trades are the ones of the slot window, not the whole response.
+	if st.session != SessionRegular || len(steps) == 0 || steps[0] >= sl.end-sl.start {
+		st.report.Gaps = append(st.report.Gaps, Gap{Session: st.session, From: sl.start, To: sl.end, Expected: total, Received: len(trades)})
+		return nil
+	}
+
+	st.report.Refetched++
+	step := steps[0]
+	for t := sl.start; t < sl.end; t += step {
+		sub := slot{
+			period: fmt.Sprintf("%s_%dm", clock(t, "-"), int(step/time.Minute)),
+			url:    realtimeURL(st.mnemonic, t),
+			start:  t,
+			end:    min(t+step, sl.end),
+			from:   t,
+			to:     min(t+step, sl.end),
+		}
+
+		if t == sl.start {
+			sub.from = sl.from
+		}
+
+		if sub.end == sl.end {
+			sub.to = sl.to
+		}
+
+		if err := st.fetchSlot(sub, steps[1:]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// timeOfDay returns the duration since the midnight of the time.
+func timeOfDay(t time.Time) time.Duration {
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second
+}
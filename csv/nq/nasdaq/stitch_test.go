@@ -0,0 +1,106 @@
+package nasdaq
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// realtimeJSON returns the realtime trades json of the "15:04:05 price volume" rows, the latest first.
+func realtimeJSON(total int, rows ...string) []byte {
+	rt := NasdaqRealtime{Data: NasdaqRealtimeData{TotalRecords: total, Rows: []NasdaqRealtimeLastSale{}}}
+	for _, r := range rows {
+		var tm, price, volume string
+		fmt.Sscan(r, &tm, &price, &volume)
+		rt.Data.Rows = append(rt.Data.Rows, NasdaqRealtimeLastSale{Time: tm, Price: "$ " + price, Volume: volume})
+	}
+
+	b, _ := json.Marshal(rt)
+	return b
+}
+
+func TestStitch(t *testing.T) {
+	t.Parallel()
+
+	t1 := time.Date(2023, 2, 24, 10, 0, 0, 0, time.UTC)
+	t2 := t1.Add(time.Second)
+	responses := [][]NasdaqTrade{
+		{{Time: t1, Price: 1, Volume: 100}, {Time: t1, Price: 1, Volume: 100}, {Time: t2, Price: 2, Volume: 10}},
+		{{Time: t1, Price: 1, Volume: 100}, {Time: t2, Price: 2, Volume: 10}, {Time: t2, Price: 3, Volume: 10}},
+	}
+
+	merged, duplicates := Stitch(responses)
+	if duplicates != 2 || len(merged) != 4 {
+		t.Fatalf("expected 4 trades and 2 duplicates, got %v and %d", merged, duplicates)
+	}
+
+	if !merged[1].Time.Equal(t1) || merged[2].Price != 2 || merged[3].Price != 3 {
+		t.Errorf("unexpected order %v", merged)
+	}
+}
+
+func TestStitcherRefetch(t *testing.T) {
+	t.Parallel()
+
+	// The responses of a url in the fetch order, the last one is repeated.
+	responses := map[string][][]byte{}
+	for m := 9*time.Hour + 30*time.Minute; m < 16*time.Hour; m += time.Minute {
+		responses[realtimeURL("tsla", m)] = [][]byte{realtimeJSON(0)}
+	}
+
+	// 09:30 is complete and overlaps 10:00, 10:00 misses one of the oldest rows.
+	responses[realtimeURL("tsla", 9*time.Hour+30*time.Minute)] = [][]byte{realtimeJSON(2, "10:00:00 5 1", "09:59:59 4 1")}
+	responses[realtimeURL("tsla", 10*time.Hour)] = [][]byte{
+		realtimeJSON(4, "10:29:00 9 1", "10:06:00 7 1", "10:00:00 5 1"),
+		// The re-fetched 10:00-10:05 slot receives a new print and is still incomplete.
+		realtimeJSON(5, "10:06:00 7 1", "10:01:00 8 1", "10:00:00 5 1"),
+		// The re-fetched 10:00-10:01 slot has one of its rows outside of the slot.
+		realtimeJSON(3, "10:01:00 8 1", "10:00:00 5 1"),
+	}
+
+	// The 5 minute slots after 10:00 are complete.
+	responses[realtimeURL("tsla", 10*time.Hour+5*time.Minute)] = [][]byte{realtimeJSON(1, "10:06:00 7 1")}
+	responses[realtimeURL("tsla", 10*time.Hour+25*time.Minute)] = [][]byte{realtimeJSON(1, "10:29:00 9 1")}
+
+	fetched := map[string]int{}
+	st := newStitcher("tsla", SessionRegular, time.Date(2023, 2, 24, 0, 0, 0, 0, time.UTC))
+	st.fetch = func(url string) ([]byte, error) {
+		r := responses[url]
+		b := r[min(fetched[url], len(r)-1)]
+		fetched[url]++
+		return b, nil
+	}
+
+	series, err := st.retrieve(sessionSlots("tsla", "", SessionRegular))
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	csv := convertToCSV(series)
+	expected := []string{
+		"2023-02-24 09:59:59;4;1\n",
+		"2023-02-24 10:00:00;5;1\n",
+		"2023-02-24 10:01:00;8;1\n",
+		"2023-02-24 10:06:00;7;1\n",
+		"2023-02-24 10:29:00;9;1\n",
+	}
+	if fmt.Sprint(csv) != fmt.Sprint(expected) {
+		t.Errorf("expected %v, got %v", expected, csv)
+	}
+
+	// 13 half-hour, 6 five-minute and 5 one-minute slots, 10:00 is fetched for each of them.
+	r := st.report
+	if r.Slots != 24 || r.Verified != 3 || r.Refetched != 2 || r.Duplicates != 4 || len(r.Gaps) != 1 {
+		t.Errorf("unexpected report %s", r)
+	}
+
+	if len(r.Gaps) == 1 && r.Gaps[0].String() != "regular 10:00-10:01: 1 of 3 trades received" {
+		t.Errorf("unexpected gap %s", r.Gaps[0])
+	}
+
+	if fetched[realtimeURL("tsla", 10*time.Hour)] != 3 || len(st.jsons) != 24 ||
+		st.jsons[2].Period != "10-00_5m" || st.jsons[3].Period != "10-00_1m" {
+		t.Errorf("unexpected fetches %v, jsons %d", fetched, len(st.jsons))
+	}
+}
@@ -0,0 +1,336 @@
+package nyse
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Quarter is a reported quarter, the date is the earnings report date.
+type Quarter struct {
+	Date    time.Time `json:"date"`
+	Revenue *float64  `json:"revenue,omitempty"`
+	EPS     *float64  `json:"eps,omitempty"`
+
+	// RevenueTTM and EPSTTM are the sums of the quarter and the three previous quarters.
+	RevenueTTM *float64 `json:"revenueTTM,omitempty"`
+	EPSTTM     *float64 `json:"epsTTM,omitempty"`
+}
+
+// Ratios are the trailing twelve months ratios.
+type Ratios struct {
+	TotalDebt2Equity *float64 `json:"totalDebt2Equity,omitempty"`
+	CurrentRatio     *float64 `json:"currentRatio,omitempty"`
+	QuickRatio       *float64 `json:"quickRatio,omitempty"`
+	Price2Sales      *float64 `json:"price2Sales,omitempty"`
+	Price2Book       *float64 `json:"price2Book,omitempty"`
+	ReturnOnAssets   *float64 `json:"returnOnAssets,omitempty"`
+	ReturnOnEquity   *float64 `json:"returnOnEquity,omitempty"`
+	PayoutRatio      *float64 `json:"payoutRatio,omitempty"`
+	EBITDA           *float64 `json:"ebitda,omitempty"`
+}
+
+// Fundamentals is a typed snapshot of the MQ_Fundamentals dataset.
+type Fundamentals struct {
+	Symbol          string    `json:"symbol"`
+	ISIN            string    `json:"isin"`
+	SEDOL           string    `json:"sedol"`
+	Retrieved       time.Time `json:"retrieved,omitzero"`
+	LastUpdate      time.Time `json:"lastUpdate,omitzero"`
+	TotalRevenueFY  *float64  `json:"totalRevenueFY,omitempty"`
+	ShortVolume     *float64  `json:"shortVolume,omitempty"`
+	ShortVolumeDate time.Time `json:"shortVolumeDate,omitzero"`
+	NextEPSEstimate *float64  `json:"nextEPSEstimate,omitempty"`
+	NextReportDate  time.Time `json:"nextReportDate,omitzero"`
+	TTM             Ratios    `json:"ttm"`
+
+	// Quarters are ordered by date, the oldest first.
+	Quarters []Quarter `json:"quarters"`
+}
+
+// ttmSpan is the longest span between the report dates of the first and the last of four consecutive quarters.
+const ttmSpan = 330 * 24 * time.Hour
+
+// quarterRegexp matches the "REVQ", "EPSQ_1" and "EPSQDate_15" keys.
+var quarterRegexp = regexp.MustCompile(`^(REVQ|EPSQ|EPSQDate)(_([0-9]+))?$`)
+
+// fields are the raw values of the MQ_Fundamentals keys, a value is a number or a string.
+type fields map[string]json.RawMessage
+
+// text returns the string or the number text of the key, or an empty string.
+func (f fields) text(key string) string {
+	raw := bytes.TrimSpace(f[key])
+	if len(raw) == 0 || string(raw) == "null" {
+		return empty
+	}
+
+	if raw[0] == '"' {
+		var s string
+		if err := json.Unmarshal(raw, &s); err == nil {
+			return strings.TrimSpace(s)
+		}
+	}
+
+	return string(raw)
+}
+
+// number returns the number of the key, nil if the key is absent or empty.
+// The numbers may come as strings like ".952300" or "1,200".
+func (f fields) number(key string) (*float64, error) {
+	s := strings.ReplaceAll(f.text(key), ",", empty)
+	if s == empty {
+		return nil, nil
+	}
+
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse %s '%s': %w", key, s, err)
+	}
+
+	return &v, nil
+}
+
+// date returns the "20221116" or "2023-02-27 00:00:00" date of the key, zero if the key is absent or empty.
+func (f fields) date(key string) (time.Time, error) {
+	s := f.text(key)
+	if s == empty {
+		return time.Time{}, nil
+	}
+
+	for _, layout := range []string{"20060102", "2006-01-02 15:04:05", "2006-01-02", time.RFC3339} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("cannot parse %s date '%s'", key, s)
+}
+
+// ParseFundamentals parses the MQ_Fundamentals content, optionally wrapped in a callback.
+//
+// The quarter n is made of the REVQ_n revenue, the EPSQ_n earnings per share and the EPSQDate_n report date,
+// the quarter without a suffix is the latest one. The quarters without a report date are dropped.
+func ParseFundamentals(body []byte) (*Fundamentals, error) {
+	// callback(JSON)
+	i, j := bytes.IndexByte(body, '{'), bytes.LastIndexByte(body, '}')
+	if i < 0 || j < i {
+		return nil, fmt.Errorf("no json in '%s'", string(body))
+	}
+
+	var ct struct {
+		Content map[string]json.RawMessage `json:"content"`
+	}
+	if err := json.Unmarshal(body[i:j+1], &ct); err != nil {
+		return nil, fmt.Errorf("cannot unmarshal fundamentals: %w", err)
+	}
+
+	raw, ok := ct.Content["MQ_Fundamentals"]
+	if !ok {
+		return nil, fmt.Errorf("no MQ_Fundamentals in '%s'", string(body))
+	}
+
+	var f fields
+	if err := json.Unmarshal(raw, &f); err != nil {
+		return nil, fmt.Errorf("cannot unmarshal MQ_Fundamentals: %w", err)
+	}
+
+	if s := f.text("status"); s != empty && s != "ok" {
+		return nil, fmt.Errorf("MQ_Fundamentals status '%s'", s)
+	}
+
+	fu := &Fundamentals{
+		Symbol: f.text("Symbol"),
+		ISIN:   f.text("ISIN"),
+		SEDOL:  f.text("SEDOL"),
+	}
+
+	if fu.Symbol == empty {
+		fu.Symbol = f.text("key")
+	}
+
+	var err error
+	dates := []struct {
+		key string
+		t   *time.Time
+	}{
+		{"LASTUPDATE", &fu.LastUpdate},
+		{"ShortVolDate", &fu.ShortVolumeDate},
+		{"QR1ReportDate", &fu.NextReportDate},
+	}
+	for _, d := range dates {
+		if *d.t, err = f.date(d.key); err != nil {
+			return nil, err
+		}
+	}
+
+	if fu.Retrieved, err = fields(ct.Content).date("retrieved"); err != nil {
+		return nil, err
+	}
+
+	numbers := []struct {
+		key string
+		v   **float64
+	}{
+		{"TotalRevenueFY", &fu.TotalRevenueFY},
+		{"ShortVol", &fu.ShortVolume},
+		{"QR1EPSEstimate", &fu.NextEPSEstimate},
+		{"TotalDebt2EquityTTM", &fu.TTM.TotalDebt2Equity},
+		{"CurrentRatioTTM", &fu.TTM.CurrentRatio},
+		{"QuickRatioTTM", &fu.TTM.QuickRatio},
+		{"Price2SalesTTM", &fu.TTM.Price2Sales},
+		{"Price2BookTTM", &fu.TTM.Price2Book},
+		{"ReturnOnAssetsTTM", &fu.TTM.ReturnOnAssets},
+		{"ReturnOnEquityTTM", &fu.TTM.ReturnOnEquity},
+		{"PayoutRatioTTM", &fu.TTM.PayoutRatio},
+		{"EBITDATTM", &fu.TTM.EBITDA},
+	}
+	for _, n := range numbers {
+		if *n.v, err = f.number(n.key); err != nil {
+			return nil, err
+		}
+	}
+
+	suffixes := map[string]bool{}
+	for k := range f {
+		if m := quarterRegexp.FindStringSubmatch(k); m != nil {
+			suffixes[m[2]] = true
+		}
+	}
+
+	fu.Quarters = make([]Quarter, 0, len(suffixes))
+	for s := range suffixes {
+		q := Quarter{}
+		if q.Date, err = f.date("EPSQDate" + s); err != nil {
+			return nil, err
+		}
+
+		if q.Revenue, err = f.number("REVQ" + s); err != nil {
+			return nil, err
+		}
+
+		if q.EPS, err = f.number("EPSQ" + s); err != nil {
+			return nil, err
+		}
+
+		if !q.Date.IsZero() {
+			fu.Quarters = append(fu.Quarters, q)
+		}
+	}
+
+	sort.Slice(fu.Quarters, func(i, j int) bool { return fu.Quarters[i].Date.Before(fu.Quarters[j].Date) })
+	trailing(fu.Quarters)
+	return fu, nil
+}
+
+// trailing sets the trailing twelve months sums of the date-ordered quarters,
+// when the quarter and the three previous quarters have the values and span less than ttmSpan.
+func trailing(quarters []Quarter) {
+	sum := func(qs []Quarter, v func(*Quarter) *float64) *float64 {
+		s := 0.0
+		for i := range qs {
+			p := v(&qs[i])
+			if p == nil {
+				return nil
+			}
+			s += *p
+		}
+		return &s
+	}
+
+	for i := range quarters {
+		quarters[i].RevenueTTM, quarters[i].EPSTTM = nil, nil
+		if i < 3 || quarters[i].Date.Sub(quarters[i-3].Date) > ttmSpan {
+			continue
+		}
+
+		qs := quarters[i-3 : i+1]
+		quarters[i].RevenueTTM = sum(qs, func(q *Quarter) *float64 { return q.Revenue })
+		quarters[i].EPSTTM = sum(qs, func(q *Quarter) *float64 { return q.EPS })
+	}
+}
+
+// FundamentalsHistory accumulates the fundamentals snapshots of a symbol.
+type FundamentalsHistory struct {
+	Symbol    string         `json:"symbol"`
+	ISIN      string         `json:"isin"`
+	Snapshots []Fundamentals `json:"snapshots"`
+}
+
+// Add appends the snapshot unless it equals the latest snapshot apart from the retrieval time,
+// the earlier snapshots are kept as is. It tells if the snapshot is appended.
+func (h *FundamentalsHistory) Add(f *Fundamentals) bool {
+	if n := len(h.Snapshots); n > 0 {
+		last, cur := h.Snapshots[n-1], *f
+		last.Retrieved, cur.Retrieved = time.Time{}, time.Time{}
+		if reflect.DeepEqual(last, cur) {
+			return false
+		}
+	}
+
+	if f.Symbol != empty {
+		h.Symbol = f.Symbol
+	}
+
+	if f.ISIN != empty {
+		h.ISIN = f.ISIN
+	}
+
+	h.Snapshots = append(h.Snapshots, *f)
+	return true
+}
+
+// Quarters merges the quarters of the snapshots by date, a later snapshot revises the values of an earlier one.
+func (h *FundamentalsHistory) Quarters() []Quarter {
+	byDate := map[time.Time]*Quarter{}
+	for _, s := range h.Snapshots {
+		for _, q := range s.Quarters {
+			m, ok := byDate[q.Date]
+			if !ok {
+				m = &Quarter{Date: q.Date}
+				byDate[q.Date] = m
+			}
+
+			if q.Revenue != nil {
+				m.Revenue = q.Revenue
+			}
+
+			if q.EPS != nil {
+				m.EPS = q.EPS
+			}
+		}
+	}
+
+	quarters := make([]Quarter, 0, len(byDate))
+	for _, q := range byDate {
+		quarters = append(quarters, *q)
+	}
+
+	sort.Slice(quarters, func(i, j int) bool { return quarters[i].Date.Before(quarters[j].Date) })
+	trailing(quarters)
+	return quarters
+}
+
+// QuartersCSV converts the quarters to the "2006-01-02;revenue;eps;revenue ttm;eps ttm" lines,
+// a missing value is empty.
+func QuartersCSV(quarters []Quarter) []string {
+	value := func(v *float64) string {
+		if v == nil {
+			return empty
+		}
+		return strconv.FormatFloat(*v, 'f', -1, 64)
+	}
+
+	csv := make([]string, 0, len(quarters))
+	for _, q := range quarters {
+		csv = append(csv, fmt.Sprintf("%s;%s;%s;%s;%s\n", q.Date.Format("2006-01-02"), value(q.Revenue), value(q.EPS),
+			value(q.RevenueTTM), value(q.EPSTTM)))
+	}
+
+	return csv
+}
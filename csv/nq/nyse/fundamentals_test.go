@@ -0,0 +1,123 @@
+package nyse
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+const fundamentalsJSON = `callback({"success":"true","content":{"retrieved":"2023-02-28T15:22:14Z","MQ_Fundamentals":{
+	"REVQ_3":8288,"REVQ_4":7643,"EPSQDate_10":"","REVQ_1":5931,"REVQ_2":6704,"REVQ_7":5661,"REVQ_8":5003,"REVQ_5":7103,
+	"REVQ_6":6507,"REVQ":6051,"TotalDebt2EquityTTM":54.436451,"REVQ_9":4726,"REVQ_15":"","EPSQDate_15":"",
+	"ShortVolDate":"2023-02-27 00:00:00","EPSQ_6":".952300","EPSQ_7":".769700","EPSQ_8":".588400","EPSQDate_9":20201118,
+	"REVQ_10":"","EPSQ_9":".540500","EPSQDate_8":20210224,"CurrentRatioTTM":3.515618,"EPSQ_14":"","EPSQDate_7":20210526,
+	"EPSQDate_6":20210818,"Price2SalesTTM":21.6423,"EPSQDate_5":20211117,"LASTUPDATE":"2023-02-28 00:42:29",
+	"EPSQDate_4":20220216,"TotalRevenueFY":26974,"EPSQDate_3":20220525,"Price2BookTTM":25.9822,"EPSQDate_2":20220824,
+	"EPSQDate_1":20221116,"expiry":3600,"ShortVol":2717259,"ReturnOnAssetsTTM":10.233223,"PayoutRatioTTM":9.18801,
+	"SEDOL":2379504,"status":"ok","ReturnOnEquityTTM":17.933611,"Symbol":"NVDA","EPSQDate":20230222,
+	"QR1EPSEstimate":7.034131,"QR1ReportDate":"2023-07-31 00:00:00","key":"NVDA","fromcache":1,"EPSQ":".573900",
+	"ISIN":"US67066G1040","EPSQ_2":".262900","EPSQ_3":".645600","QuickRatioTTM":2.729544,"EPSQ_4":1.1993,
+	"EBITDATTM":7794,"EPSQ_5":".986000","EPSQ_1":".273900"}}})`
+
+func near(v *float64, expected float64) bool {
+	return v != nil && math.Abs(*v-expected) < 1e-9
+}
+
+func TestParseFundamentals(t *testing.T) {
+	t.Parallel()
+
+	f, err := ParseFundamentals([]byte(fundamentalsJSON))
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if f.Symbol != "NVDA" || f.ISIN != "US67066G1040" || f.SEDOL != "2379504" {
+		t.Errorf("unexpected symbol %s %s %s", f.Symbol, f.ISIN, f.SEDOL)
+	}
+
+	if !f.LastUpdate.Equal(time.Date(2023, 2, 28, 0, 42, 29, 0, time.UTC)) || !f.Retrieved.Equal(time.Date(2023, 2, 28, 15, 22, 14, 0, time.UTC)) {
+		t.Errorf("unexpected last update %v, retrieved %v", f.LastUpdate, f.Retrieved)
+	}
+
+	if !near(f.TTM.Price2Book, 25.9822) || !near(f.TTM.EBITDA, 7794) || !near(f.NextEPSEstimate, 7.034131) {
+		t.Errorf("unexpected ratios %+v", f.TTM)
+	}
+
+	if len(f.Quarters) != 10 {
+		t.Fatalf("expected 10 quarters, got %d", len(f.Quarters))
+	}
+
+	first, last := f.Quarters[0], f.Quarters[9]
+	if !first.Date.Equal(time.Date(2020, 11, 18, 0, 0, 0, 0, time.UTC)) || !near(first.EPS, 0.5405) || first.RevenueTTM != nil {
+		t.Errorf("unexpected first quarter %+v", first)
+	}
+
+	if !last.Date.Equal(time.Date(2023, 2, 22, 0, 0, 0, 0, time.UTC)) || !near(last.Revenue, 6051) || !near(last.EPS, 0.5739) {
+		t.Errorf("unexpected last quarter %+v", last)
+	}
+
+	if !near(last.RevenueTTM, *f.TotalRevenueFY) || !near(last.EPSTTM, 1.7563) {
+		t.Errorf("unexpected ttm %v %v", *last.RevenueTTM, *last.EPSTTM)
+	}
+
+	if _, err := ParseFundamentals([]byte(`{"content":{"MQ_Fundamentals":{"status":"ok","EPSQ":"n/a","EPSQDate":20230222}}}`)); err == nil {
+		t.Errorf("expected an error for an invalid number")
+	}
+}
+
+func TestFundamentalsHistory(t *testing.T) {
+	t.Parallel()
+
+	f, err := ParseFundamentals([]byte(fundamentalsJSON))
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	h := &FundamentalsHistory{}
+	if !h.Add(f) {
+		t.Fatalf("expected the first snapshot added")
+	}
+
+	// The same content retrieved later is not a new snapshot.
+	again, _ := ParseFundamentals([]byte(strings.Replace(fundamentalsJSON, "15:22:14", "16:00:00", 1)))
+	if h.Add(again) {
+		t.Errorf("expected the same snapshot not added")
+	}
+
+	// The history read back is unchanged by the same content.
+	b, _ := json.Marshal(h)
+	read := &FundamentalsHistory{}
+	if err := json.Unmarshal(b, read); err != nil || read.Add(again) {
+		t.Errorf("expected the same snapshot not added to the history read back, error %v", err)
+	}
+
+	// The next quarter is reported and the latest EPS is revised.
+	next, _ := ParseFundamentals([]byte(strings.NewReplacer(
+		`"REVQ":6051`, `"REVQ":7192`, `"EPSQ":".573900"`, `"EPSQ":".880000"`, `"EPSQDate":20230222`, `"EPSQDate":20230524`,
+		`"REVQ_1":5931`, `"REVQ_1":6051`, `"EPSQ_1":".273900"`, `"EPSQ_1":".600000"`, `"EPSQDate_1":20221116`, `"EPSQDate_1":20230222`,
+		`"LASTUPDATE":"2023-02-28 00:42:29"`, `"LASTUPDATE":"2023-05-25 00:42:29"`).Replace(fundamentalsJSON)))
+	if !h.Add(next) || len(h.Snapshots) != 2 || h.Symbol != "NVDA" {
+		t.Fatalf("expected the next snapshot added, got %d snapshots", len(h.Snapshots))
+	}
+
+	// The earlier snapshot is kept.
+	if !near(h.Snapshots[0].Quarters[9].EPS, 0.5739) {
+		t.Errorf("expected the earlier snapshot unchanged")
+	}
+
+	quarters := h.Quarters()
+	if len(quarters) != 11 {
+		t.Fatalf("expected 11 quarters, got %d", len(quarters))
+	}
+
+	if !near(quarters[9].EPS, 0.6) || !near(quarters[10].Revenue, 7192) || !near(quarters[10].RevenueTTM, 7192+6051+5931+6704) {
+		t.Errorf("unexpected merged quarters %+v %+v", quarters[9], quarters[10])
+	}
+
+	csv := QuartersCSV(quarters[10:])
+	if csv[0] != "2023-05-24;7192;0.88;25878;2.0168\n" {
+		t.Errorf("unexpected csv %q", csv[0])
+	}
+}
@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"strings"
 	"time"
 )
 
@@ -171,7 +170,9 @@ func unmarshalContent(jsn []byte) (*NyseContent, error) {
 		return fmt.Errorf("err is: %w, body is: %s", err, string(body))
 	}
 */
-func GetSymbol(symbol string) (*NyseSymbol, error) /*(*NyseContent, error)*/ {
+
+// getFundamentals downloads the MQ_Fundamentals content of the symbol.
+func getFundamentals(symbol string) ([]byte, error) {
 	const urlPrefix = "https://data2-widgets.dataservices.theice.com/fsml?requestType=content&username=nysecomwebsite&key=oHhwWp17SzK9d77UJcnVMG6YGEAxxpjGr7K6x5VF48gmm8VMhYItfTYw%2FjtC1pWsKWOhDAZdafL%2FVTfPQ5yx5rkEJHxLla2TYEUDVYWpRCU%3D&cbid=7010&dataset=MQ_Fundamentals&fsmlParams=key%3D"
 	const urlSuffix = "&json=true"
 	url := urlPrefix + symbol + urlSuffix
@@ -214,26 +215,37 @@ func GetSymbol(symbol string) (*NyseSymbol, error) /*(*NyseContent, error)*/ {
 		return nil, fmt.Errorf("cannot read body: %w", err)
 	}
 
-	str := string(body)
-	sp1 := strings.Split(str, "\"ISIN\":")
-	if len(sp1) < 2 {
-		return nil, fmt.Errorf("cannot find ISIN in body %s : %w", str, err)
+	return body, nil
+}
+
+// GetFundamentals downloads and parses the MQ_Fundamentals of the symbol, returning the downloaded content too.
+func GetFundamentals(symbol string) (*Fundamentals, []byte, error) {
+	body, err := getFundamentals(symbol)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	f, err := ParseFundamentals(body)
+	if err != nil {
+		return nil, body, err
 	}
 
-	sp2 := strings.Split(sp1[1], ",")
-	isin := strings.Trim(sp2[0], "\"")
+	return f, body, nil
+}
 
-	sp1 = strings.Split(str, "\"SEDOL\":")
-	if len(sp1) < 2 {
-		return nil, fmt.Errorf("cannot find SEDOL in body %s : %w", str, err)
+func GetSymbol(symbol string) (*NyseSymbol, error) {
+	f, _, err := GetFundamentals(symbol)
+	if err != nil {
+		return nil, err
 	}
 
-	sp2 = strings.Split(sp1[1], ",")
-	sedol := strings.Trim(sp2[0], "\"")
+	if f.ISIN == empty {
+		return nil, fmt.Errorf("cannot find ISIN of %s", symbol)
+	}
 
 	nc := &NyseSymbol{
-		ISIN:  isin,
-		SEDOL: sedol,
+		ISIN:  f.ISIN,
+		SEDOL: f.SEDOL,
 	}
 
 	return nc, nil
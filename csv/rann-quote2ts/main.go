@@ -1,14 +1,12 @@
 package main
 
 import (
-	"encoding/csv"
 	"flag"
 	"fmt"
-	"io"
 	"os"
-	"strconv"
-	"strings"
 	"time"
+
+	"compressed/dialect"
 )
 
 const header = `import { TimeGranularity } from 'projects/mb/src/public-api';
@@ -33,24 +31,31 @@ func main() {
 
 	flag.Parse()
 
-	var fin *os.File
+	columns := []dialect.Column{
+		{Name: "time", Type: dialect.Time, Layout: *tformatPtr},
+		{Name: "bid", Type: dialect.Float},
+		{Name: "ask", Type: dialect.Float},
+	}
+
+	var csvReader *dialect.Reader
 	var fout *os.File
 	var err error
 
 	if filename := flag.Arg(0); filename == "" {
 		fail("expecting CSV file name as the positional argument")
 	} else {
-		if !strings.HasSuffix(filename, ".csv") {
-			fail(fmt.Sprintf("expecting CSV file name to end with '.csv': %s", filename))
+		if !dialect.HasExt(filename, ".csv") {
+			fail(fmt.Sprintf("expecting CSV file name to end with '.csv', optionally compressed: %s", filename))
 		}
 
-		fin, err = os.Open(filename)
+		d := dialect.Dialect{Comma: '\t', Comment: '#', Header: *headerPtr, Quote: '"'}
+		csvReader, err = dialect.Open(filename, d, columns)
 		if err != nil {
 			fail(fmt.Sprintf("error opening file: %s", err))
 		}
-		defer fin.Close()
+		defer csvReader.Close()
 
-		fout, err = os.Create(filename + ".ts")
+		fout, err = os.Create(dialect.TrimExt(filename) + ".ts")
 		if err != nil {
 			fail(fmt.Sprintf("error creating file: %s", err))
 		}
@@ -59,64 +64,35 @@ func main() {
 
 	writeString(fout, header)
 
-	csvReader := csv.NewReader(fin)
-	csvReader.Comment = '#'
-	csvReader.Comma = '\t'
-
 	t0 := time.Date(0, 0, 0, 0, 0, 0, 0, time.Local)
-	lineNo := 0
-
-	for {
-		rec, err := csvReader.Read()
-		if err != nil {
-			if err == io.EOF {
-				break
-			}
 
-			fail(fmt.Sprintf("error reading line %d: %s", lineNo, err))
-		}
-
-		if lineNo == 0 && *headerPtr {
-			lineNo++
-			continue
-		}
-
-		if len(rec) < 3 {
-			fail(fmt.Sprintf("line %d: expected at least 3 parts, got %d", lineNo, len(rec)))
-		}
-
-		t, err := time.Parse(*tformatPtr, rec[0])
-		if err != nil {
-			fail(fmt.Sprintf("line %d: failed to parse time part '%s' using format '%s': %s", lineNo, rec[0], *tformatPtr, err))
-		}
+	for csvReader.Next() {
+		row := csvReader.Row()
+		lineNo := row.Line()
 
+		t := row.Time(0)
 		if t0.After(t) {
-			fail(fmt.Sprintf("line %d: time part '%s' time '%v' is before previous line time '%v'", lineNo, rec[0], t, t0))
+			fail(fmt.Sprintf("line %d: time part '%s' time '%v' is before previous line time '%v'", lineNo, row.Bytes(0), t, t0))
 		}
 
 		t0 = t
 
-		b, err := strconv.ParseFloat(rec[1], 64)
-		if err != nil {
-			fail(fmt.Sprintf("line %d: failed to parse bid part '%s': %s", lineNo, rec[1], err))
-		}
-
-		a, err := strconv.ParseFloat(rec[2], 64)
-		if err != nil {
-			fail(fmt.Sprintf("line %d: failed to parse ask part '%s': %s", lineNo, rec[2], err))
-		}
+		b, a := row.Float(1), row.Float(2)
 
 		m := t.Nanosecond() / 1000000
 		if m > 999 {
 			// m = 999
-			fail(fmt.Sprintf("line %d: too many millisecond ticks in fractional time part: '%s', JavaScript does not support this", lineNo, rec[0]))
+			fail(fmt.Sprintf("line %d: too many millisecond ticks in fractional time part: '%s', JavaScript does not support this", lineNo, row.Bytes(0)))
 		}
 
 		writeString(fout, fmt.Sprintf(
 			"    { time: new Date(%d, %d, %d, %d, %d, %d, %d), askPrice: %v, bidPrice: %v, askSize: %v, bidSize: %v },\n",
 			t.Year(), t.Month()-1, t.Day(), t.Hour(), t.Minute(), t.Second(), m,
 			a, b, *volumePtr, *volumePtr))
-		lineNo++
+	}
+
+	if err := csvReader.Err(); err != nil {
+		fail(fmt.Sprintf("error reading file: %s", err))
 	}
 
 	writeString(fout, footer)
@@ -1,15 +1,13 @@
 package main
 
 import (
-	"encoding/csv"
 	"flag"
 	"fmt"
-	"io"
 	"math"
 	"os"
-	"strconv"
-	"strings"
 	"time"
+
+	"compressed/dialect"
 )
 
 func main() {
@@ -18,77 +16,52 @@ func main() {
 
 	flag.Parse()
 
-	var fin *os.File
+	columns := []dialect.Column{
+		{Name: "time", Type: dialect.Time, Layout: *tformatPtr},
+		{Name: "price", Type: dialect.Float},
+		{Name: "volume", Type: dialect.Float},
+	}
+
+	var csvReader *dialect.Reader
 	var fout *os.File
 	var err error
 
 	if filename := flag.Arg(0); filename == "" {
 		fail("expecting CSV file name as the positional argument")
 	} else {
-		if !strings.HasSuffix(filename, ".csv") {
-			fail(fmt.Sprintf("expecting CSV file name to end with '.csv': %s", filename))
+		if !dialect.HasExt(filename, ".csv") {
+			fail(fmt.Sprintf("expecting CSV file name to end with '.csv', optionally compressed: %s", filename))
 		}
 
-		fin, err = os.Open(filename)
+		d := dialect.Dialect{Comma: ';', Comment: '#', Header: *headerPtr, Quote: '"'}
+		csvReader, err = dialect.Open(filename, d, columns)
 		if err != nil {
 			fail(fmt.Sprintf("error opening file: %s", err))
 		}
-		defer fin.Close()
+		defer csvReader.Close()
 
-		fout, err = os.Create(filename + ".m1.csv")
+		fout, err = os.Create(dialect.TrimExt(filename) + ".m1.csv")
 		if err != nil {
 			fail(fmt.Sprintf("error creating file: %s", err))
 		}
 		defer fout.Close()
 	}
 
-	csvReader := csv.NewReader(fin)
-	csvReader.Comment = '#'
-	csvReader.Comma = ';'
-
 	t0 := time.Date(0, 0, 0, 0, 0, 0, 0, time.Local)
-	lineNo, m := 0, -1
+	m := -1
 	co, ch, cl, cc, cv := 0., 0., 0., 0., 0.
 
-	for {
-		rec, err := csvReader.Read()
-		if err != nil {
-			if err == io.EOF {
-				break
-			}
-
-			fail(fmt.Sprintf("error reading line %d: %s", lineNo, err))
-		}
-
-		if lineNo == 0 && *headerPtr {
-			lineNo++
-			continue
-		}
-
-		if len(rec) < 3 {
-			fail(fmt.Sprintf("line %d: expected at least 3 parts, got %d", lineNo, len(rec)))
-		}
-
-		t, err := time.Parse(*tformatPtr, rec[0])
-		if err != nil {
-			fail(fmt.Sprintf("line %d: failed to parse time part '%s' using format '%s': %s", lineNo, rec[0], *tformatPtr, err))
-		}
+	for csvReader.Next() {
+		row := csvReader.Row()
 
+		t := row.Time(0)
 		if t0.After(t) {
-			fail(fmt.Sprintf("line %d: time part '%s' time '%v' is before previous line time '%v'", lineNo, rec[0], t, t0))
+			fail(fmt.Sprintf("line %d: time part '%s' time '%v' is before previous line time '%v'", row.Line(), row.Bytes(0), t, t0))
 		}
 
 		t0 = t
 
-		p, err := strconv.ParseFloat(rec[1], 64)
-		if err != nil {
-			fail(fmt.Sprintf("line %d: failed to parse price part '%s': %s", lineNo, rec[1], err))
-		}
-
-		v, err := strconv.ParseFloat(rec[2], 64)
-		if err != nil {
-			fail(fmt.Sprintf("line %d: failed to parse volume part '%s': %s", lineNo, rec[2], err))
-		}
+		p, v := row.Float(1), row.Float(2)
 
 		if m < 0 { // initial assignment
 			m = t.Minute()
@@ -107,8 +80,10 @@ func main() {
 				cv = v
 			}
 		}
+	}
 
-		lineNo++
+	if err := csvReader.Err(); err != nil {
+		fail(fmt.Sprintf("error reading file: %s", err))
 	}
 
 	writeString(fout, fmt.Sprintf("%s;%v;%v;%v;%v;%v\n", t0.Format("2006/01/02 15:04"), co, ch, cl, cc, cv))